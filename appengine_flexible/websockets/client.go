@@ -0,0 +1,103 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// writeWait is the time allowed to write a message to the client.
+	writeWait = 10 * time.Second
+	// maxMessageSize is the largest message accepted from a client.
+	maxMessageSize = 4096
+	// sendQueueSize is the number of messages queued for a client before
+	// it is considered too slow and disconnected.
+	sendQueueSize = 16
+)
+
+// client is a websocket connection in a room. Its read loop broadcasts the
+// messages it receives, and its write loop sends queued messages and pings.
+type client struct {
+	hub  *hub
+	conn *websocket.Conn
+	room string
+	send chan []byte
+	// closeMsg is the close frame to send once send is closed. It is set
+	// by the hub before closing send.
+	closeMsg []byte
+
+	pingPeriod time.Duration
+	pongWait   time.Duration
+}
+
+// readLoop broadcasts each message from the client to its room until the
+// connection fails, the client closes it, or no pong arrives in time.
+func (c *client) readLoop(ctx context.Context) {
+	defer c.hub.leave(c)
+
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(c.pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(c.pongWait))
+	})
+	for {
+		_, p, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("conn.ReadMessage: %v", err)
+			}
+			return
+		}
+		if err := c.hub.broadcast(ctx, c.room, p); err != nil {
+			log.Printf("broadcast: %v", err)
+			return
+		}
+	}
+}
+
+// writeLoop sends queued messages and pings to the client. It owns the
+// connection, and closes it once the hub closes the send queue.
+func (c *client) writeLoop() {
+	ticker := time.NewTicker(c.pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+		c.hub.writers.Done()
+	}()
+
+	for {
+		select {
+		case msg, ok := <-c.send:
+			if !ok {
+				c.conn.WriteControl(websocket.CloseMessage, c.closeMsg, time.Now().Add(writeWait))
+				return
+			}
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				log.Printf("conn.WriteMessage: %v", err)
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				return
+			}
+		}
+	}
+}
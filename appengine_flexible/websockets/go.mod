@@ -2,4 +2,13 @@ module github.com/GoogleCloudPlatform/golang-samples/appengine_flexible/websocke
 
 go 1.21.13
 
-require github.com/gorilla/websocket v1.5.3
+require (
+	github.com/alicebob/miniredis/v2 v2.33.0
+	github.com/gomodule/redigo v2.0.0+incompatible
+	github.com/gorilla/websocket v1.5.3
+)
+
+require (
+	github.com/alicebob/gopher-json v0.0.0-20200520072559-a9ecdc9d1d3a // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
+)
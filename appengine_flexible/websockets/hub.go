@@ -0,0 +1,144 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// broker fans messages out to every instance of the app. Each instance
+// subscribes to the broker and delivers the messages it receives to its
+// own clients.
+type broker interface {
+	Publish(ctx context.Context, room string, data []byte) error
+}
+
+// hub tracks the clients in each room and delivers messages to them.
+type hub struct {
+	// broker, if set, carries messages between instances. Otherwise
+	// messages are only delivered to clients of this instance.
+	broker broker
+
+	mu     sync.Mutex
+	rooms  map[string]map[*client]bool
+	closed bool
+
+	// writers counts the running client write loops, which close the
+	// connections.
+	writers sync.WaitGroup
+}
+
+func newHub() *hub {
+	return &hub{rooms: make(map[string]map[*client]bool)}
+}
+
+// Close messages sent to clients as they are removed from the hub.
+var (
+	closeNormal   = websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	closeSlow     = websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "client too slow")
+	closeDraining = websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+)
+
+// join adds c to its room. It reports false if the hub is shutting down.
+func (h *hub) join(c *client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return false
+	}
+	room := h.rooms[c.room]
+	if room == nil {
+		room = make(map[*client]bool)
+		h.rooms[c.room] = room
+	}
+	room[c] = true
+	h.writers.Add(1)
+	return true
+}
+
+// leave removes c from its room, if it is still there.
+func (h *hub) leave(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(c, closeNormal)
+}
+
+// remove deletes c from its room and closes its send queue, which makes
+// its write loop send msg as a close frame. h.mu must be held.
+func (h *hub) remove(c *client, msg []byte) {
+	room := h.rooms[c.room]
+	if !room[c] {
+		return
+	}
+	delete(room, c)
+	if len(room) == 0 {
+		delete(h.rooms, c.room)
+	}
+	c.closeMsg = msg
+	close(c.send)
+}
+
+// broadcast sends data to every client in room, on every instance when the
+// hub has a broker.
+func (h *hub) broadcast(ctx context.Context, room string, data []byte) error {
+	if h.broker != nil {
+		return h.broker.Publish(ctx, room, data)
+	}
+	h.deliver(room, data)
+	return nil
+}
+
+// deliver queues data for each client of this instance in room. A client
+// whose queue is full is not keeping up, so it is disconnected rather than
+// letting it hold back the room or use unbounded memory.
+func (h *hub) deliver(room string, data []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.rooms[room] {
+		select {
+		case c.send <- data:
+		default:
+			h.remove(c, closeSlow)
+		}
+	}
+}
+
+// shutdown stops accepting clients, asks every client to reconnect
+// elsewhere, and waits until their connections are closed or ctx is done.
+func (h *hub) shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	h.closed = true
+	for _, room := range h.rooms {
+		for c := range room {
+			h.remove(c, closeDraining)
+		}
+	}
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.writers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -15,57 +15,168 @@
 // [START gae_flex_websockets_app]
 
 // Sample websockets demonstrates an App Engine Flexible app.
+//
+// Clients connect to /ws?room=name and every message sent to a room is
+// broadcast to all of its clients. Set REDISHOST (and optionally REDISPORT)
+// to a Memorystore for Redis instance to share rooms between instances, and
+// ALLOWED_ORIGINS to a comma separated list of origins, such as
+// https://example.com, allowed to connect in addition to the app's own.
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+// drainTimeout is how long clients have to disconnect on shutdown. App
+// Engine sends SIGTERM and then waits about 30 seconds before stopping an
+// instance.
+const drainTimeout = 25 * time.Second
+
 func main() {
-	http.Handle("/", http.FileServer(http.Dir("static")))
-	http.HandleFunc("/ws", socketHandler)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	h := newHub()
+	if host := os.Getenv("REDISHOST"); host != "" {
+		port := os.Getenv("REDISPORT")
+		if port == "" {
+			port = "6379"
+		}
+		b := newRedisBroker(net.JoinHostPort(host, port))
+		h.broker = b
+		go b.subscribe(ctx, h.deliver)
+	}
+	a := newApp(h, strings.Split(os.Getenv("ALLOWED_ORIGINS"), ","))
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.Dir("static")))
+	mux.HandleFunc("/ws", a.socketHandler)
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
-	log.Printf("Listening on port %s", port)
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
-		log.Fatal(err)
+	srv := &http.Server{Addr: ":" + port, Handler: mux}
+	go func() {
+		log.Printf("Listening on port %s", port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Print("Shutting down")
+	ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+	// Shutdown does not wait for hijacked websocket connections, so the
+	// hub closes those itself.
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("srv.Shutdown: %v", err)
+	}
+	if err := h.shutdown(ctx); err != nil {
+		log.Printf("hub.shutdown: %v", err)
 	}
 }
 
-// upgrader holds the websocket connection.
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
+// defaultRoom is the room of clients that don't ask for one.
+const defaultRoom = "lobby"
+
+var validRoom = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// app serves websocket connections.
+type app struct {
+	hub      *hub
+	upgrader websocket.Upgrader
+
+	// pingPeriod is how often clients are pinged. A client that has not
+	// answered with a pong within pongWait is disconnected.
+	pingPeriod time.Duration
+	pongWait   time.Duration
 }
 
-// socketHandler echos websocket messages back to the client.
-func socketHandler(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
-	defer conn.Close()
+func newApp(h *hub, allowedOrigins []string) *app {
+	return &app{
+		hub: h,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     checkOrigin(allowedOrigins),
+		},
+		pingPeriod: 50 * time.Second,
+		pongWait:   60 * time.Second,
+	}
+}
 
+// checkOrigin returns a function that accepts requests from the app's own
+// origin and from allowed. Browsers always send an Origin header, so
+// requests without one don't come from a page on another site.
+func checkOrigin(allowed []string) func(r *http.Request) bool {
+	ok := make(map[string]bool)
+	for _, o := range allowed {
+		if o = strings.TrimSpace(o); o != "" {
+			ok[strings.ToLower(o)] = true
+		}
+	}
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		if ok[strings.ToLower(origin)] {
+			return true
+		}
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+}
+
+// socketHandler adds the websocket client to the room named by the room
+// query parameter.
+func (a *app) socketHandler(w http.ResponseWriter, r *http.Request) {
+	room := r.URL.Query().Get("room")
+	if room == "" {
+		room = defaultRoom
+	}
+	if !validRoom.MatchString(room) {
+		http.Error(w, "invalid room name", http.StatusBadRequest)
+		return
+	}
+
+	conn, err := a.upgrader.Upgrade(w, r, nil)
 	if err != nil {
+		// Upgrade has already replied to the client.
 		log.Printf("upgrader.Upgrade: %v", err)
 		return
 	}
 
-	for {
-		messageType, p, err := conn.ReadMessage()
-		if err != nil {
-			log.Printf("conn.ReadMessage: %v", err)
-			return
-		}
-		if err := conn.WriteMessage(messageType, p); err != nil {
-			log.Printf("conn.WriteMessage: %v", err)
-			return
-		}
+	c := &client{
+		hub:        a.hub,
+		conn:       conn,
+		room:       room,
+		send:       make(chan []byte, sendQueueSize),
+		pingPeriod: a.pingPeriod,
+		pongWait:   a.pongWait,
+	}
+	if !a.hub.join(c) {
+		conn.WriteControl(websocket.CloseMessage, closeDraining, time.Now().Add(writeWait))
+		conn.Close()
+		return
 	}
+	go c.writeLoop()
+	c.readLoop(r.Context())
 }
 
 // [END gae_flex_websockets_app]
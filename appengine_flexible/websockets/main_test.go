@@ -16,38 +16,289 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	miniredis "github.com/alicebob/miniredis/v2"
 	"github.com/gorilla/websocket"
 )
 
+// dial connects to the websocket handler of server, in room if it is set.
+func dial(t *testing.T, server *httptest.Server, room string) *websocket.Conn {
+	t.Helper()
+	u := "ws://" + server.Listener.Addr().String() + "/ws"
+	if room != "" {
+		u += "?room=" + room
+	}
+	conn, resp, err := websocket.DefaultDialer.Dial(u, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := resp.StatusCode, http.StatusSwitchingProtocols; got != want {
+		t.Errorf("resp.StatusCode = %d, want %d", got, want)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func read(t *testing.T, conn *websocket.Conn) []byte {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, p, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// waitForClients waits until room has n clients, as handlers join the hub
+// after the dial returns.
+func waitForClients(t *testing.T, h *hub, room string, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		h.mu.Lock()
+		got := len(h.rooms[room])
+		h.mu.Unlock()
+		if got == n {
+			return
+		}
+	}
+	t.Fatalf("room %q never had %d clients", room, n)
+}
+
 func TestSocketHandler(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(socketHandler))
+	a := newApp(newHub(), nil)
+	server := httptest.NewServer(http.HandlerFunc(a.socketHandler))
 	defer server.Close()
-	dialer := websocket.Dialer{}
 
-	conn, resp, err := dialer.Dial("ws://"+server.Listener.Addr().String()+"/ws", nil)
+	conn := dial(t, server, "")
+	message := []byte("echo test")
+	if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(t, conn); !bytes.Equal(got, message) {
+		t.Errorf("got %q, want %q", got, message)
+	}
+}
+
+func TestRooms(t *testing.T) {
+	h := newHub()
+	server := httptest.NewServer(http.HandlerFunc(newApp(h, nil).socketHandler))
+	defer server.Close()
+
+	alice := dial(t, server, "gophers")
+	bob := dial(t, server, "gophers")
+	carol := dial(t, server, "other")
+	waitForClients(t, h, "gophers", 2)
+	waitForClients(t, h, "other", 1)
+
+	if err := alice.WriteMessage(websocket.TextMessage, []byte("hi gophers")); err != nil {
+		t.Fatal(err)
+	}
+	for _, conn := range []*websocket.Conn{alice, bob} {
+		if got := read(t, conn); string(got) != "hi gophers" {
+			t.Errorf("got %q, want %q", got, "hi gophers")
+		}
+	}
+	if err := carol.WriteMessage(websocket.TextMessage, []byte("hi other")); err != nil {
+		t.Fatal(err)
+	}
+	// carol must not have received the message to the gophers room.
+	if got := read(t, carol); string(got) != "hi other" {
+		t.Errorf("got %q, want %q", got, "hi other")
+	}
+
+	resp, err := http.Get(server.URL + "/ws?room=" + strings.Repeat("x", 65))
 	if err != nil {
 		t.Fatal(err)
 	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid room: got status %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
 
-	want := http.StatusSwitchingProtocols
-	if got := resp.StatusCode; got != want {
-		t.Errorf("resp.StatusCode = %q, want %q", got, want)
+func TestSlowClientDropped(t *testing.T) {
+	h := newHub()
+	c := &client{room: "r", send: make(chan []byte, 1)}
+	if !h.join(c) {
+		t.Fatal("join failed")
 	}
+	h.deliver("r", []byte("1"))
+	h.deliver("r", []byte("2"))
 
-	message := []byte("echo test")
-	if err = conn.WriteMessage(websocket.TextMessage, message); err != nil {
+	if got := <-c.send; string(got) != "1" {
+		t.Errorf("got %q, want %q", got, "1")
+	}
+	if _, ok := <-c.send; ok {
+		t.Fatal("send queue still open after overflowing")
+	}
+	if !bytes.Equal(c.closeMsg, closeSlow) {
+		t.Errorf("closeMsg = %q, want %q", c.closeMsg, closeSlow)
+	}
+	if len(h.rooms) != 0 {
+		t.Errorf("rooms = %v, want none", h.rooms)
+	}
+	// Leaving after being dropped must not close the queue again.
+	h.leave(c)
+}
+
+func TestHeartbeat(t *testing.T) {
+	h := newHub()
+	a := newApp(h, nil)
+	a.pingPeriod = 20 * time.Millisecond
+	a.pongWait = 100 * time.Millisecond
+	server := httptest.NewServer(http.HandlerFunc(a.socketHandler))
+	defer server.Close()
+
+	// A client that answers pings, which gorilla/websocket does while
+	// reading, stays connected past pongWait.
+	live := dial(t, server, "")
+	msgs := make(chan []byte)
+	go func() {
+		for {
+			_, p, err := live.ReadMessage()
+			if err != nil {
+				close(msgs)
+				return
+			}
+			msgs <- p
+		}
+	}()
+
+	// A client that ignores pings is disconnected.
+	dead := dial(t, server, "")
+	dead.SetPingHandler(func(string) error { return nil })
+	dead.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if _, _, err := dead.ReadMessage(); err != nil {
+			var netErr interface{ Timeout() bool }
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				t.Fatal("client without pongs was not disconnected")
+			}
+			break
+		}
+	}
+
+	if err := live.WriteMessage(websocket.TextMessage, []byte("still here")); err != nil {
 		t.Fatal(err)
 	}
+	select {
+	case got, ok := <-msgs:
+		if !ok || string(got) != "still here" {
+			t.Errorf("got %q, %v, want %q", got, ok, "still here")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	check := checkOrigin([]string{"https://example.com", " "})
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"", true},
+		{"https://app.example.net", true},
+		{"https://EXAMPLE.com", true},
+		{"https://evil.example", false},
+		{"::", false},
+	}
+	for _, tc := range tests {
+		r := httptest.NewRequest("GET", "https://app.example.net/ws", nil)
+		if tc.origin != "" {
+			r.Header.Set("Origin", tc.origin)
+		}
+		if got := check(r); got != tc.want {
+			t.Errorf("checkOrigin(%q) = %v, want %v", tc.origin, got, tc.want)
+		}
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	h := newHub()
+	server := httptest.NewServer(http.HandlerFunc(newApp(h, nil).socketHandler))
+	defer server.Close()
+
+	conn := dial(t, server, "")
+	waitForClients(t, h, defaultRoom, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := h.shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("ReadMessage = %v, want going away close", err)
+	}
 
-	_, got, err := conn.ReadMessage()
+	// New clients are turned away.
+	conn = dial(t, server, "")
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("ReadMessage after shutdown = %v, want going away close", err)
+	}
+}
+
+func TestRedisFanOut(t *testing.T) {
+	s, err := miniredis.Run()
 	if err != nil {
+		t.Fatalf("miniredis.Run: %v", err)
+	}
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Two hubs stand in for two instances of the app.
+	var servers []*httptest.Server
+	for i := 0; i < 2; i++ {
+		h := newHub()
+		b := newRedisBroker(s.Addr())
+		h.broker = b
+		go b.subscribe(ctx, h.deliver)
+		server := httptest.NewServer(http.HandlerFunc(newApp(h, nil).socketHandler))
+		defer server.Close()
+		servers = append(servers, server)
+	}
+	for deadline := time.Now().Add(5 * time.Second); s.PubSubNumPat() < 2; time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("hubs never subscribed")
+		}
+	}
+
+	b := dial(t, servers[1], "shared")
+	other := dial(t, servers[1], "other")
+	// Make sure b and other have joined before publishing. A client joins
+	// before its first message is read, so a needs no such check.
+	for _, conn := range []*websocket.Conn{b, other} {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte("ready")); err != nil {
+			t.Fatal(err)
+		}
+		read(t, conn)
+	}
+
+	a := dial(t, servers[0], "shared")
+	if err := a.WriteMessage(websocket.TextMessage, []byte("across instances")); err != nil {
 		t.Fatal(err)
 	}
-	if !bytes.Equal(got, message) {
-		t.Errorf("got %q, want %q", got, message)
+	for _, conn := range []*websocket.Conn{a, b} {
+		if got := read(t, conn); string(got) != "across instances" {
+			t.Errorf("got %q, want %q", got, "across instances")
+		}
+	}
+	if err := other.WriteMessage(websocket.TextMessage, []byte("only other")); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(t, other); string(got) != "only other" {
+		t.Errorf("other got %q, want %q", got, "only other")
 	}
 }
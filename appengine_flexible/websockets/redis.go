@@ -0,0 +1,102 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// redisChannelPrefix is prepended to room names to form Redis channels.
+const redisChannelPrefix = "chat:"
+
+// redisBroker fans messages out through Redis pub/sub, such as a
+// Memorystore for Redis instance, so clients of every instance of the app
+// share the same rooms.
+type redisBroker struct {
+	addr string
+	pool *redis.Pool
+}
+
+func newRedisBroker(addr string) *redisBroker {
+	return &redisBroker{
+		addr: addr,
+		pool: &redis.Pool{
+			MaxIdle: 3,
+			Dial: func() (redis.Conn, error) {
+				return redis.Dial("tcp", addr)
+			},
+		},
+	}
+}
+
+// Publish publishes data to the channel of room.
+func (b *redisBroker) Publish(ctx context.Context, room string, data []byte) error {
+	conn, err := b.pool.GetContext(ctx)
+	if err != nil {
+		return fmt.Errorf("pool.GetContext: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Do("PUBLISH", redisChannelPrefix+room, data); err != nil {
+		return fmt.Errorf("PUBLISH: %w", err)
+	}
+	return nil
+}
+
+// subscribe calls deliver with each message published to any room until
+// ctx is done, resubscribing if the connection to Redis is lost.
+func (b *redisBroker) subscribe(ctx context.Context, deliver func(room string, data []byte)) {
+	for {
+		err := b.receive(ctx, deliver)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Redis subscription lost, retrying: %v", err)
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (b *redisBroker) receive(ctx context.Context, deliver func(room string, data []byte)) error {
+	conn, err := redis.Dial("tcp", b.addr)
+	if err != nil {
+		return fmt.Errorf("redis.Dial: %w", err)
+	}
+	psc := redis.PubSubConn{Conn: conn}
+	defer psc.Close()
+	if err := psc.PSubscribe(redisChannelPrefix + "*"); err != nil {
+		return fmt.Errorf("PSUBSCRIBE: %w", err)
+	}
+
+	// Closing the connection unblocks Receive when ctx is done.
+	stop := context.AfterFunc(ctx, func() { psc.Close() })
+	defer stop()
+	for {
+		switch v := psc.Receive().(type) {
+		case redis.Message:
+			deliver(strings.TrimPrefix(v.Channel, redisChannelPrefix), v.Data)
+		case error:
+			return v
+		}
+	}
+}
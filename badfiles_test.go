@@ -132,6 +132,13 @@ var allowList = []string{
 
 	// Speech-to-Text audio/video files
 	"speech/resources/commercial_mono.wav",
+	// Speech-to-Text recorded responses and golden caption files
+	"speech/captions/testdata/*.json",
+	"speech/captions/testdata/*.srt",
+	"speech/captions/testdata/*.vtt",
+
+	// Route Optimization tour inputs and golden routes
+	"routeoptimization/tours/testdata/**/*.geojson",
 
 	// deprecated tests (introduced for IoT samples)
 	"**/*_test.go.deprecated",
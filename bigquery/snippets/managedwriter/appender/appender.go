@@ -0,0 +1,416 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package appender writes Go structs to BigQuery with the Storage Write API.
+//
+// The proto descriptor the API needs is derived either from the struct type,
+// using bigquery.InferSchema, or from the destination table's schema. Values
+// are converted to the encoding each column type expects: civil.Date and
+// time.Time to DATE, civil.DateTime to DATETIME, *big.Rat to NUMERIC and
+// BIGNUMERIC, strings to GEOGRAPHY and JSON, and so on. Pointers and the
+// bigquery.NullXXX types represent NULL.
+//
+// Rows are buffered and sent in batches that fit within the AppendRows
+// request size limit.
+//
+// By default rows go to the table's default stream, which gives
+// at-least-once delivery. WithCommittedStream instead writes to a committed
+// stream with explicit offsets: if the writer checkpoints StreamName and
+// Offset after each Flush, a restarted writer can resume from the
+// checkpoint and replay its input without creating duplicate rows.
+package appender
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"cloud.google.com/go/bigquery/storage/managedwriter"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// DefaultMaxBatchBytes is the default limit on the encoded size of a batch.
+// AppendRows requests are limited to 10MB, which must also hold the
+// descriptor and stream metadata.
+const DefaultMaxBatchBytes = 8 << 20
+
+// Option configures an Appender.
+type Option func(*config)
+
+type config struct {
+	schema        bigquery.Schema
+	committed     bool
+	streamName    string
+	offset        int64
+	maxBatchBytes int
+	maxBatchRows  int
+}
+
+// WithSchema uses the given table schema, typically the destination
+// table's TableMetadata.Schema, instead of inferring one from the struct
+// type. This is required for GEOGRAPHY, JSON and BIGNUMERIC columns that
+// are represented by plain Go strings or *big.Rat values.
+func WithSchema(s bigquery.Schema) Option {
+	return func(c *config) { c.schema = s }
+}
+
+// WithCommittedStream writes to a committed stream using explicit offsets.
+// If streamName is empty a new stream is created, starting at offset 0.
+// Otherwise writing resumes on streamName at offset, which should both come
+// from a checkpoint taken after Flush.
+func WithCommittedStream(streamName string, offset int64) Option {
+	return func(c *config) {
+		c.committed = true
+		c.streamName = streamName
+		c.offset = offset
+	}
+}
+
+// WithMaxBatchBytes limits the encoded size of each AppendRows request.
+func WithMaxBatchBytes(n int) Option {
+	return func(c *config) { c.maxBatchBytes = n }
+}
+
+// WithMaxBatchRows limits the number of rows in each AppendRows request. By
+// default only the size is limited.
+func WithMaxBatchRows(n int) Option {
+	return func(c *config) { c.maxBatchRows = n }
+}
+
+// result is the subset of *managedwriter.AppendResult used by Appender.
+type result interface {
+	Ready() <-chan struct{}
+	GetResult(ctx context.Context) (int64, error)
+}
+
+// stream is the subset of *managedwriter.ManagedStream used by Appender. A
+// negative offset appends without an offset.
+type stream interface {
+	appendRows(ctx context.Context, rows [][]byte, offset int64) (result, error)
+	name() string
+	close() error
+}
+
+type managedStream struct {
+	ms *managedwriter.ManagedStream
+}
+
+func (s managedStream) appendRows(ctx context.Context, rows [][]byte, offset int64) (result, error) {
+	var opts []managedwriter.AppendOption
+	if offset >= 0 {
+		opts = append(opts, managedwriter.WithOffset(offset))
+	}
+	r, err := s.ms.AppendRows(ctx, rows, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (s managedStream) name() string { return s.ms.StreamName() }
+func (s managedStream) close() error { return s.ms.Close() }
+
+// inflight is an append whose result has not been checked yet.
+type inflight struct {
+	res    result
+	offset int64
+	rows   int
+}
+
+// Appender writes values of type T, which must be a struct or a pointer to
+// a struct, to a BigQuery table. It is safe for concurrent use, but rows
+// appended concurrently are written in an unspecified order.
+type Appender[T any] struct {
+	enc           *recordEncoder
+	stream        stream
+	committed     bool
+	maxBatchBytes int
+	maxBatchRows  int
+
+	mu         sync.Mutex
+	batch      [][]byte
+	batchBytes int
+	pending    []inflight
+	// next is the offset of the first row of batch. The default stream
+	// ignores offsets, but they are still counted.
+	next int64
+	// acked is the offset after the last row the service acknowledged.
+	acked int64
+	// synced is false after resuming a committed stream until an append
+	// has succeeded, because rows after the checkpoint may already have
+	// been written.
+	synced bool
+	// err is the first append error. The Appender cannot be used after it.
+	err error
+}
+
+// New returns an Appender that writes to the table
+// projectID.datasetID.tableID.
+func New[T any](ctx context.Context, client *managedwriter.Client, projectID, datasetID, tableID string, opts ...Option) (*Appender[T], error) {
+	cfg := config{maxBatchBytes: DefaultMaxBatchBytes}
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	schema := cfg.schema
+	if schema == nil {
+		var zero T
+		s, err := bigquery.InferSchema(zero)
+		if err != nil {
+			return nil, fmt.Errorf("bigquery.InferSchema: %w", err)
+		}
+		schema = s
+	}
+	enc, wopts, err := encoderFor[T](schema)
+	if err != nil {
+		return nil, err
+	}
+
+	table := managedwriter.TableParentFromParts(projectID, datasetID, tableID)
+	if cfg.committed {
+		name := cfg.streamName
+		if name == "" {
+			ws, err := client.CreateWriteStream(ctx, &storagepb.CreateWriteStreamRequest{
+				Parent: table,
+				WriteStream: &storagepb.WriteStream{
+					Type: storagepb.WriteStream_COMMITTED,
+				},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("CreateWriteStream: %w", err)
+			}
+			name = ws.GetName()
+		}
+		wopts = append(wopts, managedwriter.WithStreamName(name))
+	} else {
+		wopts = append(wopts,
+			managedwriter.WithType(managedwriter.DefaultStream),
+			managedwriter.WithDestinationTable(table))
+	}
+	ms, err := client.NewManagedStream(ctx, wopts...)
+	if err != nil {
+		return nil, fmt.Errorf("NewManagedStream: %w", err)
+	}
+	return newAppender[T](managedStream{ms}, enc, cfg), nil
+}
+
+// encoderFor builds the encoder for T and the writer option that sends its
+// descriptor.
+func encoderFor[T any](schema bigquery.Schema) (*recordEncoder, []managedwriter.WriterOption, error) {
+	md, dp, err := descriptors(schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	enc, err := newRecordEncoder(reflect.TypeOf((*T)(nil)).Elem(), schema, md)
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, []managedwriter.WriterOption{managedwriter.WithSchemaDescriptor(dp)}, nil
+}
+
+func newAppender[T any](s stream, enc *recordEncoder, cfg config) *Appender[T] {
+	a := &Appender[T]{
+		enc:           enc,
+		stream:        s,
+		committed:     cfg.committed,
+		maxBatchBytes: cfg.maxBatchBytes,
+		maxBatchRows:  cfg.maxBatchRows,
+		next:          cfg.offset,
+		acked:         cfg.offset,
+		synced:        cfg.streamName == "",
+	}
+	return a
+}
+
+// StreamName returns the name of the stream rows are written to.
+func (a *Appender[T]) StreamName() string {
+	return a.stream.name()
+}
+
+// Offset returns the offset after the last row the service has
+// acknowledged. On a committed stream, Offset and StreamName form the
+// checkpoint to resume from with WithCommittedStream; take it after Flush.
+func (a *Appender[T]) Offset() int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.acked
+}
+
+// Append encodes rows and adds them to the current batch, sending batches
+// as they fill. It does not wait for the service; use Flush for that. An
+// error from an earlier batch is returned by the next call.
+func (a *Appender[T]) Append(ctx context.Context, rows ...T) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.reap(); err != nil {
+		return err
+	}
+	for i, row := range rows {
+		b, err := a.enc.marshal(reflect.ValueOf(row))
+		if err != nil {
+			return fmt.Errorf("row %d: %w", i, err)
+		}
+		size := protowire.SizeTag(1) + protowire.SizeBytes(len(b))
+		if size > a.maxBatchBytes {
+			return fmt.Errorf("row %d: encoded size %d exceeds the batch limit of %d bytes", i, size, a.maxBatchBytes)
+		}
+		if len(a.batch) > 0 && (a.batchBytes+size > a.maxBatchBytes || a.maxBatchRows > 0 && len(a.batch) >= a.maxBatchRows) {
+			if err := a.send(ctx); err != nil {
+				return err
+			}
+		}
+		a.batch = append(a.batch, b)
+		a.batchBytes += size
+	}
+	return nil
+}
+
+// Flush sends the current batch and waits until every batch has been
+// acknowledged.
+func (a *Appender[T]) Flush(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.err != nil {
+		return a.err
+	}
+	if len(a.batch) > 0 {
+		if err := a.send(ctx); err != nil {
+			return err
+		}
+	}
+	for len(a.pending) > 0 {
+		p := a.pending[0]
+		if _, err := p.res.GetResult(ctx); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			a.err = fmt.Errorf("append at offset %d: %w", p.offset, err)
+			return a.err
+		}
+		a.pending = a.pending[1:]
+		a.acked = p.offset + int64(p.rows)
+	}
+	return nil
+}
+
+// Close flushes the Appender and closes its stream.
+func (a *Appender[T]) Close(ctx context.Context) error {
+	err := a.Flush(ctx)
+	if cerr := a.stream.close(); err == nil && cerr != nil {
+		err = fmt.Errorf("close: %w", cerr)
+	}
+	return err
+}
+
+// reap records the results of completed appends without blocking.
+func (a *Appender[T]) reap() error {
+	for a.err == nil && len(a.pending) > 0 {
+		p := a.pending[0]
+		select {
+		case <-p.res.Ready():
+		default:
+			return nil
+		}
+		if _, err := p.res.GetResult(context.Background()); err != nil {
+			a.err = fmt.Errorf("append at offset %d: %w", p.offset, err)
+			break
+		}
+		a.pending = a.pending[1:]
+		a.acked = p.offset + int64(p.rows)
+	}
+	return a.err
+}
+
+// send appends the current batch.
+func (a *Appender[T]) send(ctx context.Context) error {
+	rows := a.batch
+	a.batch, a.batchBytes = nil, 0
+	if !a.committed {
+		r, err := a.stream.appendRows(ctx, rows, -1)
+		if err != nil {
+			a.err = fmt.Errorf("AppendRows: %w", err)
+			return a.err
+		}
+		a.pending = append(a.pending, inflight{res: r, offset: a.next, rows: len(rows)})
+		a.next += int64(len(rows))
+		return nil
+	}
+
+	if !a.synced {
+		// The previous run may have written some of these rows after its
+		// last checkpoint, so append synchronously until the stream and
+		// a.next agree.
+		if err := a.resume(ctx, rows); err != nil {
+			a.err = err
+			return err
+		}
+		return nil
+	}
+	r, err := a.stream.appendRows(ctx, rows, a.next)
+	if err != nil {
+		a.err = fmt.Errorf("AppendRows: %w", err)
+		return a.err
+	}
+	a.pending = append(a.pending, inflight{res: r, offset: a.next, rows: len(rows)})
+	a.next += int64(len(rows))
+	return nil
+}
+
+// resume appends the rows that are not already on the stream. The stream
+// rejects an append with ALREADY_EXISTS if its offset is before the end of
+// the stream, and with OUT_OF_RANGE if it is after it, so the first missing
+// row can be found by binary search. This relies on the rows being replayed
+// in the same order as before.
+func (a *Appender[T]) resume(ctx context.Context, rows [][]byte) error {
+	lo, hi := 0, len(rows)-1
+	for i := 0; lo <= hi; i = lo + (hi-lo)/2 {
+		err := a.appendSync(ctx, rows[i:], a.next+int64(i))
+		switch status.Code(err) {
+		case codes.OK:
+			a.next += int64(len(rows))
+			a.acked = a.next
+			a.synced = true
+			return nil
+		case codes.AlreadyExists:
+			lo = i + 1
+		case codes.OutOfRange:
+			hi = i - 1
+		default:
+			return err
+		}
+	}
+	if lo < len(rows) {
+		return fmt.Errorf("offset %d is past the end of stream %s", a.next+int64(lo), a.stream.name())
+	}
+	// Every row was already written.
+	a.next += int64(len(rows))
+	a.acked = a.next
+	return nil
+}
+
+func (a *Appender[T]) appendSync(ctx context.Context, rows [][]byte, offset int64) error {
+	r, err := a.stream.appendRows(ctx, rows, offset)
+	if err != nil {
+		return fmt.Errorf("AppendRows: %w", err)
+	}
+	if _, err := r.GetResult(ctx); err != nil {
+		return fmt.Errorf("append at offset %d: %w", offset, err)
+	}
+	return nil
+}
@@ -0,0 +1,420 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appender
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+type item struct {
+	SKU      string `bigquery:"sku"`
+	Quantity int64  `bigquery:"quantity"`
+}
+
+type order struct {
+	ID       int64          `bigquery:"id"`
+	Placed   civil.Date     `bigquery:"placed"`
+	Local    civil.DateTime `bigquery:"local_time"`
+	Opens    civil.Time     `bigquery:"opens"`
+	Received time.Time      `bigquery:"received"`
+	Total    *big.Rat       `bigquery:"total,nullable"`
+	Store    bigquery.NullGeography
+	Note     bigquery.NullString `bigquery:"note"`
+	Tags     []string            `bigquery:"tags"`
+	Items    []item              `bigquery:"items"`
+	Shipping *item               `bigquery:"shipping,nullable"`
+	internal string
+	Ignored  string `bigquery:"-"`
+}
+
+// fakeStream is an in-memory committed stream that enforces offsets the
+// way the service does.
+type fakeStream struct {
+	mu      sync.Mutex
+	rows    [][]byte
+	appends []int
+	// fail, if set, is returned for the next append.
+	fail error
+}
+
+type fakeResult struct {
+	offset int64
+	err    error
+}
+
+func (r fakeResult) Ready() <-chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}
+
+func (r fakeResult) GetResult(context.Context) (int64, error) { return r.offset, r.err }
+
+func (s *fakeStream) appendRows(ctx context.Context, rows [][]byte, offset int64) (result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.appends = append(s.appends, len(rows))
+	if err := s.fail; err != nil {
+		s.fail = nil
+		return fakeResult{err: err}, nil
+	}
+	end := int64(len(s.rows))
+	switch {
+	case offset < 0:
+		offset = end
+	case offset < end:
+		return fakeResult{err: status.Errorf(codes.AlreadyExists, "offset %d already exists", offset)}, nil
+	case offset > end:
+		return fakeResult{err: status.Errorf(codes.OutOfRange, "offset %d is beyond %d", offset, end)}, nil
+	}
+	s.rows = append(s.rows, rows...)
+	return fakeResult{offset: offset}, nil
+}
+
+func (s *fakeStream) name() string { return "projects/p/datasets/d/tables/t/streams/s" }
+func (s *fakeStream) close() error { return nil }
+
+func decode(t *testing.T, md protoreflect.MessageDescriptor, b []byte) *dynamicpb.Message {
+	t.Helper()
+	m := dynamicpb.NewMessage(md)
+	if err := proto.Unmarshal(b, m); err != nil {
+		t.Fatalf("proto.Unmarshal: %v", err)
+	}
+	return m
+}
+
+func TestInferredSchemaEncoding(t *testing.T) {
+	schema, err := bigquery.InferSchema(order{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	md, dp, err := descriptors(schema)
+	if err != nil {
+		t.Fatalf("descriptors: %v", err)
+	}
+	for _, f := range dp.GetField() {
+		switch f.GetName() {
+		case "local_time", "opens", "total":
+			if f.GetType() != descriptorpb.FieldDescriptorProto_TYPE_STRING {
+				t.Errorf("field %s has type %s, want STRING", f.GetName(), f.GetType())
+			}
+		case "placed":
+			if f.GetType() != descriptorpb.FieldDescriptorProto_TYPE_INT32 {
+				t.Errorf("field placed has type %s, want INT32", f.GetType())
+			}
+		}
+	}
+	if len(dp.GetNestedType()) == 0 {
+		t.Errorf("normalized descriptor has no nested types")
+	}
+
+	enc, err := newRecordEncoder(reflect.TypeOf(order{}), schema, md)
+	if err != nil {
+		t.Fatalf("newRecordEncoder: %v", err)
+	}
+	received := time.Date(2024, 3, 1, 10, 30, 0, 500000, time.UTC)
+	o := order{
+		ID:       7,
+		Placed:   civil.Date{Year: 2024, Month: 3, Day: 1},
+		Local:    civil.DateTime{Date: civil.Date{Year: 2024, Month: 3, Day: 1}, Time: civil.Time{Hour: 12, Minute: 13, Second: 14}},
+		Opens:    civil.Time{Hour: 9},
+		Received: received,
+		Total:    big.NewRat(1999, 100),
+		Store:    bigquery.NullGeography{GeographyVal: "POINT(-122.35 47.65)", Valid: true},
+		Tags:     []string{"a", "b"},
+		Items:    []item{{"x", 1}, {"y", 2}},
+	}
+	b, err := enc.marshal(reflect.ValueOf(o))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	m := decode(t, md, b)
+	get := func(name string) protoreflect.Value {
+		fd := md.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			t.Fatalf("no field %s", name)
+		}
+		return m.Get(fd)
+	}
+	has := func(name string) bool {
+		return m.Has(md.Fields().ByName(protoreflect.Name(name)))
+	}
+
+	if got := get("placed").Int(); got != 19783 {
+		t.Errorf("placed = %d, want 19783 days", got)
+	}
+	if got := get("local_time").String(); got != "2024-03-01 12:13:14" {
+		t.Errorf("local_time = %q", got)
+	}
+	if got := get("opens").String(); got != "09:00:00" {
+		t.Errorf("opens = %q", got)
+	}
+	if got := get("received").Int(); got != received.UnixMicro() {
+		t.Errorf("received = %d, want %d", got, received.UnixMicro())
+	}
+	if got := get("total").String(); got != "19.990000000" {
+		t.Errorf("total = %q", got)
+	}
+	if got := get("Store").String(); got != "POINT(-122.35 47.65)" {
+		t.Errorf("Store = %q", got)
+	}
+	if has("note") || has("shipping") {
+		t.Errorf("NULL columns were set")
+	}
+	if l := get("tags").List(); l.Len() != 2 || l.Get(1).String() != "b" {
+		t.Errorf("tags = %v", l)
+	}
+	items := get("items").List()
+	if items.Len() != 2 {
+		t.Fatalf("items has %d elements, want 2", items.Len())
+	}
+	second := items.Get(1).Message()
+	if got := second.Get(second.Descriptor().Fields().ByName("sku")).String(); got != "y" {
+		t.Errorf("items[1].sku = %q", got)
+	}
+}
+
+func TestTableSchemaEncoding(t *testing.T) {
+	schema := bigquery.Schema{
+		{Name: "ID", Type: bigquery.IntegerFieldType, Required: true},
+		{Name: "amount", Type: bigquery.BigNumericFieldType},
+		{Name: "area", Type: bigquery.GeographyFieldType},
+		{Name: "attrs", Type: bigquery.JSONFieldType},
+		{Name: "unused", Type: bigquery.StringFieldType},
+	}
+	type row struct {
+		ID     int
+		Amount *big.Rat `bigquery:"amount"`
+		Area   string   `bigquery:"area"`
+		Attrs  []byte   `bigquery:"attrs"`
+	}
+	md, _, err := descriptors(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := newRecordEncoder(reflect.TypeOf(row{}), schema, md)
+	if err != nil {
+		t.Fatalf("newRecordEncoder: %v", err)
+	}
+	b, err := enc.marshal(reflect.ValueOf(&row{ID: 1, Amount: big.NewRat(1, 3), Area: "POINT(1 2)", Attrs: []byte(`{"a":1}`)}))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	m := decode(t, md, b)
+	fields := md.Fields()
+	if got := m.Get(fields.ByName("amount")).String(); !strings.HasPrefix(got, "0.33333333333333333333333333333333333333") {
+		t.Errorf("amount = %q, want BIGNUMERIC precision", got)
+	}
+	if got := m.Get(fields.ByName("attrs")).String(); got != `{"a":1}` {
+		t.Errorf("attrs = %q", got)
+	}
+
+	type extra struct {
+		ID    int
+		Color string
+	}
+	if _, err := newRecordEncoder(reflect.TypeOf(extra{}), schema, md); err == nil || !strings.Contains(err.Error(), "Color") {
+		t.Errorf("newRecordEncoder with an unknown field got err %v", err)
+	}
+	type missing struct {
+		Area string `bigquery:"area"`
+	}
+	if _, err := newRecordEncoder(reflect.TypeOf(missing{}), schema, md); err == nil {
+		t.Errorf("newRecordEncoder without the REQUIRED column succeeded")
+	}
+	type wrongType struct {
+		ID   int
+		Area int `bigquery:"area"`
+	}
+	enc, err = newRecordEncoder(reflect.TypeOf(wrongType{}), schema, md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := enc.marshal(reflect.ValueOf(wrongType{})); err == nil {
+		t.Errorf("marshal of an int GEOGRAPHY succeeded")
+	}
+
+	if _, _, err := descriptors(bigquery.Schema{{Name: "r", Type: bigquery.RangeFieldType}}); err == nil {
+		t.Errorf("descriptors of a RANGE column succeeded")
+	}
+}
+
+func newTestAppender(t *testing.T, s stream, opts ...Option) *Appender[item] {
+	t.Helper()
+	cfg := config{maxBatchBytes: DefaultMaxBatchBytes}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	schema, err := bigquery.InferSchema(item{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, _, err := encoderFor[item](schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newAppender[item](s, enc, cfg)
+}
+
+func items(from, to int) []item {
+	var out []item
+	for i := from; i < to; i++ {
+		out = append(out, item{SKU: "sku", Quantity: int64(i)})
+	}
+	return out
+}
+
+func TestBatching(t *testing.T) {
+	ctx := context.Background()
+	s := &fakeStream{}
+	a := newTestAppender(t, s, WithCommittedStream("", 0), WithMaxBatchBytes(64))
+	if err := a.Append(ctx, items(0, 20)...); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := a.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if len(s.rows) != 20 || a.Offset() != 20 {
+		t.Fatalf("stream has %d rows, Offset() = %d; want 20", len(s.rows), a.Offset())
+	}
+	if len(s.appends) < 2 {
+		t.Errorf("rows were sent in %d appends, want several", len(s.appends))
+	}
+	for i, n := range s.appends {
+		var size int
+		for _, r := range s.rows[:n] {
+			size += len(r) + 2
+		}
+		if size > 64 {
+			t.Errorf("append %d is %d bytes, over the limit", i, size)
+		}
+	}
+
+	s = &fakeStream{}
+	a = newTestAppender(t, s, WithMaxBatchRows(3))
+	if err := a.Append(ctx, items(0, 10)...); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{3, 3, 3, 1}; !reflect.DeepEqual(s.appends, want) {
+		t.Errorf("appends = %v, want %v", s.appends, want)
+	}
+
+	a = newTestAppender(t, &fakeStream{}, WithMaxBatchBytes(4))
+	if err := a.Append(ctx, item{SKU: "too large"}); err == nil {
+		t.Errorf("Append of a row over the batch limit succeeded")
+	}
+}
+
+func TestAppendErrorIsSticky(t *testing.T) {
+	ctx := context.Background()
+	s := &fakeStream{fail: status.Error(codes.Internal, "boom")}
+	a := newTestAppender(t, s, WithCommittedStream("", 0), WithMaxBatchRows(1))
+	if err := a.Append(ctx, items(0, 2)...); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := a.Flush(ctx); status.Code(err) != codes.Internal {
+		t.Fatalf("Flush got err %v, want Internal", err)
+	}
+	if err := a.Append(ctx, items(2, 3)...); status.Code(err) != codes.Internal {
+		t.Errorf("Append after a failure got err %v", err)
+	}
+	if a.Offset() != 0 {
+		t.Errorf("Offset() = %d after a failed first append", a.Offset())
+	}
+}
+
+// TestResumeExactlyOnce simulates a writer that crashes after its
+// checkpoint, having written some more rows, and is restarted with
+// different batch boundaries.
+func TestResumeExactlyOnce(t *testing.T) {
+	ctx := context.Background()
+	input := items(0, 50)
+
+	for _, tc := range []struct {
+		name       string
+		checkpoint int
+		written    int
+		batchRows  int
+	}{
+		{"nothing after checkpoint", 20, 20, 7},
+		{"partial batch", 20, 24, 7},
+		{"whole batches", 20, 34, 7},
+		{"everything", 20, 50, 7},
+		{"single rows", 10, 13, 1},
+		{"one batch", 0, 37, 100},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &fakeStream{}
+			first := newTestAppender(t, s, WithCommittedStream("", 0))
+			if err := first.Append(ctx, input[:tc.written]...); err != nil {
+				t.Fatal(err)
+			}
+			if err := first.Flush(ctx); err != nil {
+				t.Fatal(err)
+			}
+			want := append([][]byte(nil), s.rows...)
+
+			a := newTestAppender(t, s, WithCommittedStream(s.name(), int64(tc.checkpoint)), WithMaxBatchRows(tc.batchRows))
+			if err := a.Append(ctx, input[tc.checkpoint:]...); err != nil {
+				t.Fatalf("Append: %v", err)
+			}
+			if err := a.Flush(ctx); err != nil {
+				t.Fatalf("Flush: %v", err)
+			}
+			if len(s.rows) != len(input) || a.Offset() != int64(len(input)) {
+				t.Fatalf("stream has %d rows, Offset() = %d; want %d", len(s.rows), a.Offset(), len(input))
+			}
+			for i := range want {
+				if string(s.rows[i]) != string(want[i]) {
+					t.Fatalf("row %d was rewritten", i)
+				}
+			}
+			enc := a.enc
+			for i, b := range s.rows {
+				m := decode(t, enc.md, b)
+				if q := m.Get(enc.md.Fields().ByName("quantity")).Int(); q != int64(i) {
+					t.Fatalf("row %d has quantity %d", i, q)
+				}
+			}
+		})
+	}
+
+	s := &fakeStream{}
+	a := newTestAppender(t, s, WithCommittedStream(s.name(), 5))
+	if err := a.Append(ctx, input...); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Flush(ctx); err == nil {
+		t.Errorf("resuming past the end of the stream got err %v", err)
+	}
+}
@@ -0,0 +1,373 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appender
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+var (
+	typeOfRat  = reflect.TypeOf((*big.Rat)(nil))
+	unixEpoch  = civil.Date{Year: 1970, Month: time.January, Day: 1}
+	errNoValue = errors.New("NULL value")
+)
+
+// recordEncoder converts Go structs into messages of a descriptor derived
+// from a table schema.
+type recordEncoder struct {
+	md     protoreflect.MessageDescriptor
+	fields []fieldEncoder
+}
+
+// fieldEncoder maps one column to one struct field.
+type fieldEncoder struct {
+	col   *bigquery.FieldSchema
+	fd    protoreflect.FieldDescriptor
+	index []int
+	// rec is set for RECORD columns.
+	rec *recordEncoder
+}
+
+// newRecordEncoder matches the columns of schema to the fields of struct
+// type t. Every struct field must have a column, and every REQUIRED column
+// must have a field. Column names are matched case-insensitively, as they
+// are in BigQuery.
+func newRecordEncoder(t reflect.Type, schema bigquery.Schema, md protoreflect.MessageDescriptor) (*recordEncoder, error) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s is not a struct type", t)
+	}
+
+	fields := structFields(t)
+	byName := make(map[string]int)
+	for i, sf := range fields {
+		byName[strings.ToLower(sf.name)] = i
+	}
+	matched := make([]bool, len(fields))
+
+	e := &recordEncoder{md: md}
+	for i, col := range schema {
+		// adapt numbers fields in schema order, starting at 1.
+		fd := md.Fields().ByNumber(protoreflect.FieldNumber(i + 1))
+		if fd == nil {
+			return nil, fmt.Errorf("no descriptor field for column %s", col.Name)
+		}
+		j, ok := byName[strings.ToLower(col.Name)]
+		if !ok {
+			if col.Required {
+				return nil, fmt.Errorf("%s has no field for required column %s", t, col.Name)
+			}
+			continue
+		}
+		sf := fields[j]
+		matched[j] = true
+
+		fe := fieldEncoder{col: col, fd: fd, index: sf.index}
+		if col.Type == bigquery.RecordFieldType {
+			rt, ok := recordType(sf.typ)
+			if !ok {
+				return nil, fmt.Errorf("field %s.%s is a %s, want a struct for RECORD column %s", t, sf.name, sf.typ, col.Name)
+			}
+			rec, err := newRecordEncoder(rt, col.Schema, fd.Message())
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", col.Name, err)
+			}
+			fe.rec = rec
+		}
+		e.fields = append(e.fields, fe)
+	}
+	for j, sf := range fields {
+		if !matched[j] {
+			return nil, fmt.Errorf("field %s.%s does not match any column", t, sf.name)
+		}
+	}
+	return e, nil
+}
+
+// marshal encodes v, a struct or pointer to struct, to the proto2 wire
+// format.
+func (e *recordEncoder) marshal(v reflect.Value) ([]byte, error) {
+	m, err := e.encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(m)
+}
+
+func (e *recordEncoder) encode(v reflect.Value) (*dynamicpb.Message, error) {
+	v = reflect.Indirect(v)
+	if !v.IsValid() {
+		return nil, errors.New("nil row")
+	}
+	m := dynamicpb.NewMessage(e.md)
+	for i := range e.fields {
+		f := &e.fields[i]
+		if err := f.set(m, v.FieldByIndex(f.index)); err != nil {
+			return nil, fmt.Errorf("column %s: %w", f.col.Name, err)
+		}
+	}
+	return m, nil
+}
+
+func (f *fieldEncoder) set(m *dynamicpb.Message, v reflect.Value) error {
+	if !f.col.Repeated {
+		pv, err := f.value(v)
+		if err == errNoValue {
+			if f.col.Required {
+				return errors.New("REQUIRED column is NULL")
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		m.Set(f.fd, pv)
+		return nil
+	}
+
+	v = deref(v)
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return fmt.Errorf("cannot use %s as REPEATED %s", v.Type(), f.col.Type)
+	}
+	l := m.Mutable(f.fd).List()
+	for i := 0; i < v.Len(); i++ {
+		pv, err := f.value(v.Index(i))
+		if err == errNoValue {
+			return fmt.Errorf("element %d: arrays cannot contain NULL", i)
+		}
+		if err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+		l.Append(pv)
+	}
+	return nil
+}
+
+// value converts a single (non-repeated) value. It returns errNoValue for
+// NULL.
+func (f *fieldEncoder) value(v reflect.Value) (protoreflect.Value, error) {
+	if f.rec != nil {
+		v = deref(v)
+		if !v.IsValid() {
+			return protoreflect.Value{}, errNoValue
+		}
+		if v.Kind() != reflect.Struct {
+			return protoreflect.Value{}, fmt.Errorf("cannot use %s as RECORD", v.Type())
+		}
+		m, err := f.rec.encode(v)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfMessage(m), nil
+	}
+
+	x, err := convert(f.col.Type, v)
+	if err != nil {
+		return protoreflect.Value{}, err
+	}
+	switch f.fd.Kind() {
+	case protoreflect.Int32Kind:
+		if n, ok := x.(int64); ok && n >= math.MinInt32 && n <= math.MaxInt32 {
+			return protoreflect.ValueOfInt32(int32(n)), nil
+		}
+	case protoreflect.Int64Kind:
+		if n, ok := x.(int64); ok {
+			return protoreflect.ValueOfInt64(n), nil
+		}
+	case protoreflect.DoubleKind:
+		if n, ok := x.(float64); ok {
+			return protoreflect.ValueOfFloat64(n), nil
+		}
+	case protoreflect.BoolKind:
+		if b, ok := x.(bool); ok {
+			return protoreflect.ValueOfBool(b), nil
+		}
+	case protoreflect.StringKind:
+		if s, ok := x.(string); ok {
+			return protoreflect.ValueOfString(s), nil
+		}
+	case protoreflect.BytesKind:
+		if b, ok := x.([]byte); ok {
+			return protoreflect.ValueOfBytes(b), nil
+		}
+	}
+	return protoreflect.Value{}, fmt.Errorf("cannot encode %T as proto %s", x, f.fd.Kind())
+}
+
+// deref follows pointers and interfaces, returning the zero Value for nil.
+func deref(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		if v.Type() == typeOfRat {
+			break
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// unwrapNull replaces the bigquery.NullXXX types with their value. ok is
+// false for an invalid (NULL) value.
+func unwrapNull(v reflect.Value) (reflect.Value, bool) {
+	if !v.CanInterface() {
+		return v, true
+	}
+	var x interface{}
+	var valid bool
+	switch n := v.Interface().(type) {
+	case bigquery.NullString:
+		x, valid = n.StringVal, n.Valid
+	case bigquery.NullInt64:
+		x, valid = n.Int64, n.Valid
+	case bigquery.NullFloat64:
+		x, valid = n.Float64, n.Valid
+	case bigquery.NullBool:
+		x, valid = n.Bool, n.Valid
+	case bigquery.NullTimestamp:
+		x, valid = n.Timestamp, n.Valid
+	case bigquery.NullDate:
+		x, valid = n.Date, n.Valid
+	case bigquery.NullTime:
+		x, valid = n.Time, n.Valid
+	case bigquery.NullDateTime:
+		x, valid = n.DateTime, n.Valid
+	case bigquery.NullGeography:
+		x, valid = n.GeographyVal, n.Valid
+	case bigquery.NullJSON:
+		x, valid = n.JSONVal, n.Valid
+	default:
+		return v, true
+	}
+	return reflect.ValueOf(x), valid
+}
+
+// convert converts v to the Go representation of a column of type typ in
+// the descriptor: int64, float64, bool, string or []byte.
+//
+// DATE becomes days since the Unix epoch and TIMESTAMP microseconds since
+// the Unix epoch. DATETIME, TIME, NUMERIC and BIGNUMERIC become their
+// canonical string forms. GEOGRAPHY values are WKT or GeoJSON strings and
+// are passed through.
+func convert(typ bigquery.FieldType, v reflect.Value) (interface{}, error) {
+	v = deref(v)
+	if !v.IsValid() {
+		return nil, errNoValue
+	}
+	v, ok := unwrapNull(v)
+	if !ok {
+		return nil, errNoValue
+	}
+	var x interface{}
+	if v.CanInterface() {
+		x = v.Interface()
+	}
+
+	switch typ {
+	case bigquery.StringFieldType, bigquery.GeographyFieldType:
+		if v.Kind() == reflect.String {
+			return v.String(), nil
+		}
+	case bigquery.JSONFieldType:
+		if v.Kind() == reflect.String {
+			return v.String(), nil
+		}
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes()), nil
+		}
+	case bigquery.BytesFieldType:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			if v.IsNil() {
+				return nil, errNoValue
+			}
+			return v.Bytes(), nil
+		}
+	case bigquery.IntegerFieldType:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return v.Int(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if n := v.Uint(); n <= math.MaxInt64 {
+				return int64(n), nil
+			}
+			return nil, fmt.Errorf("%d overflows INTEGER", v.Uint())
+		}
+	case bigquery.FloatFieldType:
+		switch v.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return v.Float(), nil
+		}
+	case bigquery.BooleanFieldType:
+		if v.Kind() == reflect.Bool {
+			return v.Bool(), nil
+		}
+	case bigquery.TimestampFieldType:
+		if t, ok := x.(time.Time); ok {
+			return t.UnixMicro(), nil
+		}
+	case bigquery.DateFieldType:
+		switch d := x.(type) {
+		case civil.Date:
+			return int64(d.DaysSince(unixEpoch)), nil
+		case time.Time:
+			return int64(civil.DateOf(d).DaysSince(unixEpoch)), nil
+		}
+	case bigquery.DateTimeFieldType:
+		switch d := x.(type) {
+		case civil.DateTime:
+			return bigquery.CivilDateTimeString(d), nil
+		case time.Time:
+			return bigquery.CivilDateTimeString(civil.DateTimeOf(d)), nil
+		case string:
+			return d, nil
+		}
+	case bigquery.TimeFieldType:
+		switch t := x.(type) {
+		case civil.Time:
+			return bigquery.CivilTimeString(t), nil
+		case string:
+			return t, nil
+		}
+	case bigquery.NumericFieldType, bigquery.BigNumericFieldType:
+		switch r := x.(type) {
+		case *big.Rat:
+			if typ == bigquery.BigNumericFieldType {
+				return bigquery.BigNumericString(r), nil
+			}
+			return bigquery.NumericString(r), nil
+		case string:
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot use %s as %s", v.Type(), typ)
+}
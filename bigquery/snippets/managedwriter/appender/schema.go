@@ -0,0 +1,135 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appender
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"cloud.google.com/go/bigquery/storage/managedwriter/adapt"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// descriptors builds the message descriptor used to encode rows for schema,
+// and the normalized form of it that is sent to the service.
+//
+// adapt maps DATETIME and TIME to packed int64 values and NUMERIC and
+// BIGNUMERIC to packed bytes. The service also accepts the canonical string
+// form of each of these types, which is much simpler to produce correctly,
+// so those columns are described as strings instead. The service decodes
+// each column by its table type, not by its proto type.
+func descriptors(schema bigquery.Schema) (protoreflect.MessageDescriptor, *descriptorpb.DescriptorProto, error) {
+	if err := checkSupported(schema, ""); err != nil {
+		return nil, nil, err
+	}
+	ts, err := adapt.BQSchemaToStorageTableSchema(schema)
+	if err != nil {
+		return nil, nil, fmt.Errorf("adapt.BQSchemaToStorageTableSchema: %w", err)
+	}
+	useStringEncodings(ts.GetFields())
+
+	d, err := adapt.StorageSchemaToProto2Descriptor(ts, "root")
+	if err != nil {
+		return nil, nil, fmt.Errorf("adapt.StorageSchemaToProto2Descriptor: %w", err)
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, nil, fmt.Errorf("adapted descriptor is a %T, not a message", d)
+	}
+	dp, err := adapt.NormalizeDescriptor(md)
+	if err != nil {
+		return nil, nil, fmt.Errorf("adapt.NormalizeDescriptor: %w", err)
+	}
+	return md, dp, nil
+}
+
+func checkSupported(schema bigquery.Schema, prefix string) error {
+	for _, f := range schema {
+		switch f.Type {
+		case bigquery.RangeFieldType, bigquery.IntervalFieldType:
+			return fmt.Errorf("column %s%s: type %s is not supported", prefix, f.Name, f.Type)
+		case bigquery.RecordFieldType:
+			if err := checkSupported(f.Schema, prefix+f.Name+"."); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func useStringEncodings(fields []*storagepb.TableFieldSchema) {
+	for _, f := range fields {
+		switch f.GetType() {
+		case storagepb.TableFieldSchema_DATETIME,
+			storagepb.TableFieldSchema_TIME,
+			storagepb.TableFieldSchema_NUMERIC,
+			storagepb.TableFieldSchema_BIGNUMERIC:
+			f.Type = storagepb.TableFieldSchema_STRING
+		case storagepb.TableFieldSchema_STRUCT:
+			useStringEncodings(f.GetFields())
+		}
+	}
+}
+
+// structField is an exported field of a Go struct, possibly promoted from
+// an embedded struct.
+type structField struct {
+	name  string
+	index []int
+	typ   reflect.Type
+}
+
+// structFields lists the fields of t that map to columns, using the same
+// naming rules as bigquery.InferSchema: a `bigquery:"name"` tag overrides
+// the Go field name, and `bigquery:"-"` skips the field.
+func structFields(t reflect.Type) []structField {
+	var out []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("bigquery")
+		if tag == "-" {
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			for _, sf := range structFields(f.Type) {
+				sf.index = append([]int{i}, sf.index...)
+				out = append(out, sf)
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		out = append(out, structField{name: name, index: []int{i}, typ: f.Type})
+	}
+	return out
+}
+
+// recordType returns the struct type that holds the value of a RECORD
+// column of Go type t, looking through pointers and slices.
+func recordType(t reflect.Type) (reflect.Type, bool) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	return t, t.Kind() == reflect.Struct
+}
@@ -96,4 +96,10 @@ func TestAppends(t *testing.T) {
 		}
 	})
 
+	t.Run("CommittedStructs", func(t *testing.T) {
+		if err := appendStructsToCommittedStream(ioutil.Discard, tc.ProjectID, testDatasetID, testTableID); err != nil {
+			t.Errorf("appendStructsToCommittedStream(%q %q): %v", testDatasetID, testTableID, err)
+		}
+	})
+
 }
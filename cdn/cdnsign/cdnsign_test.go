@@ -0,0 +1,345 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdnsign
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The keys and expected values are those of the cdn/signedurls,
+// cdn/signedcookies and mediacdn samples.
+var (
+	hmacTestKey = []byte{0x9d, 0x9b, 0x51, 0xa2, 0x17, 0x4d, 0x17, 0xd9,
+		0xb7, 0x70, 0xa3, 0x36, 0xe0, 0x87, 0x0a, 0xe3} // base64url: nZtRohdNF9m3cKM24IcK4w==
+
+	ed25519TestKey = ed25519.PrivateKey{34, 31, 185, 24, 168, 225, 242, 115, 112, 155, 38,
+		157, 183, 65, 104, 243, 85, 182, 188, 26, 176, 101, 247, 177,
+		243, 93, 114, 156, 94, 191, 219, 75, 183, 211, 110, 78, 223,
+		133, 62, 172, 159, 217, 158, 126, 34, 6, 254, 108, 57, 194,
+		141, 93, 219, 91, 8, 162, 88, 62, 52, 75, 42, 103, 202, 238,
+	}
+)
+
+func mustKeySet(t *testing.T, active Key, previous ...Key) *KeySet {
+	t.Helper()
+	ks, err := NewKeySet(active, previous...)
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+	return ks
+}
+
+func TestDecodeKey(t *testing.T) {
+	for _, s := range []string{"nZtRohdNF9m3cKM24IcK4w==", "nZtRohdNF9m3cKM24IcK4w\n"} {
+		b, err := DecodeKey(s)
+		if err != nil || string(b) != string(hmacTestKey) {
+			t.Errorf("DecodeKey(%q) = %v, %v", s, b, err)
+		}
+	}
+}
+
+func TestKnownSignatures(t *testing.T) {
+	cdn := mustKeySet(t, HMACKey("my-key", hmacTestKey))
+	media := mustKeySet(t, Ed25519Key("my-key", ed25519TestKey))
+
+	cases := []struct {
+		name string
+		sign func() (string, error)
+		want string
+	}{
+		{
+			name: "Cloud CDN URL",
+			sign: func() (string, error) {
+				return cdn.SignURL("https://www.example.com/some/path?some=query&another=param", time.Unix(1549751461, 0))
+			},
+			want: "https://www.example.com/some/path?some=query&another=param&Expires=1549751461&KeyName=my-key&Signature=sTqqGX5hUJmlRJ84koAIhWW_c3M=",
+		},
+		{
+			name: "Cloud CDN prefix",
+			sign: func() (string, error) {
+				return cdn.SignPrefix("https://media.example.com/segments/", time.Unix(1558131350, 0))
+			},
+			want: "URLPrefix=aHR0cHM6Ly9tZWRpYS5leGFtcGxlLmNvbS9zZWdtZW50cy8=&Expires=1558131350&KeyName=my-key&Signature=HWE5tBTZgnYVoZzVLG7BtRnOsgk=",
+		},
+		{
+			name: "Media CDN URL",
+			sign: func() (string, error) {
+				return media.SignURL("http://35.186.234.33/index.html", time.Unix(1558131350, 0))
+			},
+			want: "http://35.186.234.33/index.html?Expires=1558131350&KeyName=my-key&Signature=bwCkNAIuVneG0cRPwwPDk1vGmMfqR_TbFfLguwdsfF8Pdlk8INOKICYVOTHY5jHlGgwSF2jkRkm8bWZGwu-SAw",
+		},
+		{
+			name: "Media CDN prefix",
+			sign: func() (string, error) {
+				u := "https://www.example.com/some/path?some=query&another=param"
+				return media.SignURLWithPrefix(u, u, time.Unix(1549751461, 0))
+			},
+			want: "https://www.example.com/some/path?some=query&another=param&URLPrefix=aHR0cHM6Ly93d3cuZXhhbXBsZS5jb20vc29tZS9wYXRoP3NvbWU9cXVlcnkmYW5vdGhlcj1wYXJhbQ&Expires=1549751461&KeyName=my-key&Signature=zx7rPX3Zol2y3Uu9HuL_IQFe0LiOp556Z40rlAJjxBwiQ6vwbA2BvqvkrSNb3VWOWbxpnI4ssHxtMn7sQHh9CQ",
+		},
+		{
+			name: "Media CDN cookie",
+			sign: func() (string, error) {
+				return media.SignCookie("https://www.google.com/", time.Unix(1549751401, 0))
+			},
+			want: "URLPrefix=aHR0cHM6Ly93d3cuZ29vZ2xlLmNvbS8:Expires=1549751401:KeyName=my-key:Signature=O67Laog-pcQ2_RNOuVrgGiN5NS-16I0SOItQRnW0yDkbawgVgX9KfFCgdoqXpY0P3f8ZdMEM2tEVsU6-Saq9BA",
+		},
+	}
+	for _, c := range cases {
+		got, err := c.sign()
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s: got %s, want %s", c.name, got, c.want)
+		}
+	}
+
+	if _, err := cdn.SignPrefix("https://www.example.com/?a=b", time.Now()); err == nil {
+		t.Errorf("Cloud CDN SignPrefix with a query string succeeded")
+	}
+}
+
+func TestVerifyURL(t *testing.T) {
+	expires := time.Unix(1549751461, 0)
+	before, after := expires.Add(-time.Minute), expires.Add(time.Minute)
+	for _, ks := range []*KeySet{
+		mustKeySet(t, HMACKey("my-key", hmacTestKey)),
+		mustKeySet(t, Ed25519Key("my-key", ed25519TestKey)),
+	} {
+		alg := ks.Active().Algorithm
+		signed, err := ks.SignURL("https://www.example.com/a?x=1", expires)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ks.VerifyURL(signed, before); err != nil {
+			t.Errorf("%v: VerifyURL: %v", alg, err)
+		}
+		if err := ks.VerifyURL(signed, after); !errors.Is(err, ErrExpired) {
+			t.Errorf("%v: VerifyURL after expiry = %v, want ErrExpired", alg, err)
+		}
+		tampered := strings.Replace(signed, "x=1", "x=2", 1)
+		if err := ks.VerifyURL(tampered, before); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%v: VerifyURL(tampered) = %v, want ErrInvalidSignature", alg, err)
+		}
+		renamed := strings.Replace(signed, "KeyName=my-key", "KeyName=other", 1)
+		if err := ks.VerifyURL(renamed, before); !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("%v: VerifyURL(renamed) = %v, want ErrUnknownKey", alg, err)
+		}
+
+		prefixed, err := ks.SignURLWithPrefix("https://www.example.com/segments/1.ts", "https://www.example.com/segments/", expires)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ks.VerifyURL(prefixed, before); err != nil {
+			t.Errorf("%v: VerifyURL(prefixed): %v", alg, err)
+		}
+		moved := strings.Replace(prefixed, "/segments/1.ts", "/private/1.ts", 1)
+		if err := ks.VerifyURL(moved, before); !errors.Is(err, ErrNotAllowed) {
+			t.Errorf("%v: VerifyURL outside prefix = %v, want ErrNotAllowed", alg, err)
+		}
+
+		cookie, err := ks.SignCookie("https://www.example.com/segments/", expires)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ks.VerifyCookie(cookie, "https://www.example.com/segments/2.ts", before); err != nil {
+			t.Errorf("%v: VerifyCookie: %v", alg, err)
+		}
+		if err := ks.VerifyCookie(cookie, "https://www.example.com/other", before); !errors.Is(err, ErrNotAllowed) {
+			t.Errorf("%v: VerifyCookie outside prefix = %v, want ErrNotAllowed", alg, err)
+		}
+	}
+
+	if err := mustKeySet(t, HMACKey("my-key", hmacTestKey)).VerifyURL("https://www.example.com/", time.Now()); !errors.Is(err, ErrNoSignature) {
+		t.Errorf("VerifyURL(unsigned) = %v, want ErrNoSignature", err)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	_, next, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := mustKeySet(t, Ed25519Key("key-1", ed25519TestKey))
+	expires := time.Now().Add(time.Hour)
+	signedOld, err := old.SignURL("https://www.example.com/", expires)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := old.Rotate(Ed25519Key("key-2", next), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.Active().Name != "key-2" {
+		t.Errorf("active key = %q, want key-2", rotated.Active().Name)
+	}
+	signedNew, err := rotated.SignURL("https://www.example.com/", expires)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []string{signedOld, signedNew} {
+		if err := rotated.VerifyURL(u, time.Now()); err != nil {
+			t.Errorf("VerifyURL(%s): %v", u, err)
+		}
+	}
+
+	// Rotating again with keep 1 drops key-1.
+	_, third, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err = rotated.Rotate(Ed25519Key("key-3", third), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rotated.VerifyURL(signedOld, time.Now()); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("VerifyURL with a dropped key = %v, want ErrUnknownKey", err)
+	}
+
+	// An origin holding only public keys verifies but cannot sign.
+	verifyOnly, err := NewVerifyKeySet(Ed25519PublicKey("key-1", ed25519TestKey.Public().(ed25519.PublicKey)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyOnly.VerifyURL(signedOld, time.Now()); err != nil {
+		t.Errorf("VerifyURL with a public key: %v", err)
+	}
+	if _, err := verifyOnly.SignURL("https://www.example.com/", expires); err == nil {
+		t.Errorf("SignURL with a public key succeeded")
+	}
+	if _, err := NewKeySet(HMACKey("a", hmacTestKey), HMACKey("a", hmacTestKey)); err == nil {
+		t.Errorf("NewKeySet with duplicate names succeeded")
+	}
+}
+
+func TestTokens(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	v := &Verifier{BaseURL: "https://media.example.com", Now: func() time.Time { return now }}
+	request := func(target string) *http.Request {
+		r := httptest.NewRequest("GET", target, nil)
+		r.RemoteAddr = "203.0.113.7:4321"
+		r.Header.Set("User-Agent", "player/1.0")
+		return r
+	}
+
+	for _, k := range []Key{
+		Ed25519Key("ed", ed25519TestKey),
+		HMACSHA256Key("sha256", hmacTestKey),
+		HMACKey("sha1", hmacTestKey),
+	} {
+		v.Keys = mustKeySet(t, k)
+		cases := []struct {
+			token  Token
+			target string
+			want   error
+		}{
+			{Token{URLPrefix: "https://media.example.com/vod/", Expires: now.Add(time.Hour)}, "/vod/a.m3u8", nil},
+			{Token{URLPrefix: "https://media.example.com/vod/", Expires: now.Add(time.Hour)}, "/live/a.m3u8", ErrNotAllowed},
+			{Token{URLPrefix: "https://media.example.com/vod/", Expires: now.Add(-time.Second)}, "/vod/a.m3u8", ErrExpired},
+			{Token{FullPath: "/vod/a.m3u8", Expires: now.Add(time.Hour)}, "/vod/a.m3u8", nil},
+			{Token{FullPath: "/vod/a.m3u8", Expires: now.Add(time.Hour)}, "/vod/b.m3u8", ErrInvalidSignature},
+			{Token{PathGlobs: []string{"/vod/*.ts", "/live/*"}, Expires: now.Add(time.Hour)}, "/live/x/1.ts", nil},
+			{Token{PathGlobs: []string{"/vod/*.ts"}, Expires: now.Add(time.Hour)}, "/vod/a.m3u8", ErrNotAllowed},
+			{Token{PathGlobs: []string{"/*"}, Starts: now.Add(time.Minute), Expires: now.Add(time.Hour)}, "/a", ErrNotYetValid},
+			{Token{PathGlobs: []string{"/*"}, Expires: now.Add(time.Hour), SessionID: "s1", Data: "d",
+				Headers: []Header{{"User-Agent", "player/1.0"}}, IPRanges: []string{"203.0.113.0/24"}}, "/a", nil},
+			{Token{PathGlobs: []string{"/*"}, Expires: now.Add(time.Hour),
+				Headers: []Header{{"User-Agent", "other/2.0"}}}, "/a", ErrInvalidSignature},
+			{Token{PathGlobs: []string{"/*"}, Expires: now.Add(time.Hour), IPRanges: []string{"198.51.100.0/24"}}, "/a", ErrNotAllowed},
+		}
+		for i, c := range cases {
+			token, err := v.Keys.SignToken(c.token)
+			if err != nil {
+				t.Fatalf("%v case %d: SignToken: %v", k.Algorithm, i, err)
+			}
+			if err := v.VerifyToken(token, request(c.target)); !errors.Is(err, c.want) {
+				t.Errorf("%v case %d: VerifyToken(%s, %s) = %v, want %v", k.Algorithm, i, token, c.target, err, c.want)
+			}
+		}
+	}
+
+	if _, err := v.Keys.SignToken(Token{URLPrefix: "https://a/", FullPath: "/b", Expires: now}); err == nil {
+		t.Errorf("SignToken with two path restrictions succeeded")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	ks := mustKeySet(t, Ed25519Key("my-key", ed25519TestKey))
+	v := &Verifier{Keys: ks, BaseURL: "https://media.example.com"}
+	h := v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	expires := time.Now().Add(time.Hour)
+
+	signedURL, err := ks.SignURL("https://media.example.com/a.mp4", expires)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cookie, err := ks.Cookie("https://media.example.com/vod/", expires)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cookie.Name != MediaCDNCookie || cookie.Path != "/vod/" {
+		t.Errorf("Cookie = %v", cookie)
+	}
+	token, err := ks.SignToken(Token{PathGlobs: []string{"/live/*"}, Expires: expires})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		req    func() *http.Request
+		status int
+	}{
+		{"unsigned", func() *http.Request { return httptest.NewRequest("GET", "/a.mp4", nil) }, http.StatusForbidden},
+		{"signed URL", func() *http.Request {
+			return httptest.NewRequest("GET", strings.TrimPrefix(signedURL, "https://media.example.com"), nil)
+		}, http.StatusOK},
+		{"cookie", func() *http.Request {
+			r := httptest.NewRequest("GET", "/vod/1.ts", nil)
+			r.AddCookie(cookie)
+			return r
+		}, http.StatusOK},
+		{"token header", func() *http.Request {
+			r := httptest.NewRequest("GET", "/live/1.ts", nil)
+			r.Header.Set(TokenHeader, token)
+			return r
+		}, http.StatusOK},
+		{"token query", func() *http.Request {
+			return httptest.NewRequest("GET", "/live/1.ts?"+TokenQuery+"="+token, nil)
+		}, http.StatusOK},
+		{"token wrong path", func() *http.Request {
+			r := httptest.NewRequest("GET", "/vod/1.ts", nil)
+			r.Header.Set(TokenHeader, token)
+			return r
+		}, http.StatusForbidden},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, c.req())
+		if rec.Code != c.status {
+			t.Errorf("%s: status %d, want %d: %s", c.name, rec.Code, c.status, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdnsign
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"strings"
+)
+
+// Algorithm is a signing algorithm.
+type Algorithm int
+
+const (
+	// HMACSHA1 is used by Cloud CDN signed URLs and cookies, and by Media
+	// CDN tokens.
+	HMACSHA1 Algorithm = iota + 1
+	// Ed25519 is used by Media CDN signed URLs, cookies and tokens.
+	Ed25519
+	// HMACSHA256 is used by Media CDN tokens.
+	HMACSHA256
+)
+
+func (a Algorithm) String() string {
+	switch a {
+	case HMACSHA1:
+		return "HMAC-SHA1"
+	case Ed25519:
+		return "Ed25519"
+	case HMACSHA256:
+		return "HMAC-SHA256"
+	}
+	return fmt.Sprintf("Algorithm(%d)", int(a))
+}
+
+// Key is a named signing key. Keys made with Ed25519PublicKey can only
+// verify signatures.
+type Key struct {
+	Name      string
+	Algorithm Algorithm
+
+	secret  []byte
+	private ed25519.PrivateKey
+	public  ed25519.PublicKey
+}
+
+// HMACKey returns a Cloud CDN key. The secret is the raw 16 byte key, not
+// its base64url encoding.
+func HMACKey(name string, secret []byte) Key {
+	return Key{Name: name, Algorithm: HMACSHA1, secret: secret}
+}
+
+// HMACSHA256Key returns a Media CDN key for signing tokens with
+// HMAC-SHA256.
+func HMACSHA256Key(name string, secret []byte) Key {
+	return Key{Name: name, Algorithm: HMACSHA256, secret: secret}
+}
+
+// Ed25519Key returns a Media CDN key for signing and verifying.
+func Ed25519Key(name string, private ed25519.PrivateKey) Key {
+	return Key{Name: name, Algorithm: Ed25519, private: private, public: private.Public().(ed25519.PublicKey)}
+}
+
+// Ed25519PublicKey returns a Media CDN key that can only verify
+// signatures, such as a key from the keyset of an origin that does not
+// hold the private key.
+func Ed25519PublicKey(name string, public ed25519.PublicKey) Key {
+	return Key{Name: name, Algorithm: Ed25519, public: public}
+}
+
+// DecodeKey decodes a base64url-encoded key, with or without padding, as
+// used in Cloud CDN key files and Media CDN keysets.
+func DecodeKey(s string) ([]byte, error) {
+	s = strings.TrimRight(strings.TrimSpace(s), "=")
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("cdnsign: decoding key: %w", err)
+	}
+	return b, nil
+}
+
+// ReadKeyFile reads a file holding a base64url-encoded key.
+func ReadKeyFile(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+	return DecodeKey(string(b))
+}
+
+func (k Key) canSign() bool {
+	return len(k.secret) > 0 || len(k.private) > 0
+}
+
+func (k Key) hash() func() hash.Hash {
+	if k.Algorithm == HMACSHA256 {
+		return sha256.New
+	}
+	return sha1.New
+}
+
+func (k Key) sign(msg string) ([]byte, error) {
+	switch {
+	case !k.canSign():
+		return nil, fmt.Errorf("cdnsign: key %q cannot sign", k.Name)
+	case k.Algorithm == Ed25519:
+		return ed25519.Sign(k.private, []byte(msg)), nil
+	}
+	mac := hmac.New(k.hash(), k.secret)
+	mac.Write([]byte(msg))
+	return mac.Sum(nil), nil
+}
+
+func (k Key) verify(msg string, sig []byte) bool {
+	if k.Algorithm == Ed25519 {
+		return len(k.public) == ed25519.PublicKeySize && ed25519.Verify(k.public, []byte(msg), sig)
+	}
+	if len(k.secret) == 0 {
+		return false
+	}
+	mac := hmac.New(k.hash(), k.secret)
+	mac.Write([]byte(msg))
+	return hmac.Equal(mac.Sum(nil), sig)
+}
+
+// encoding returns the base64 encoding used for the URL prefix and
+// signature of signed URLs and cookies: Cloud CDN pads them, Media CDN
+// does not.
+func (k Key) encoding() *base64.Encoding {
+	if k.Algorithm == HMACSHA1 {
+		return base64.URLEncoding
+	}
+	return base64.RawURLEncoding
+}
+
+// KeySet holds the keys of a Cloud CDN backend or a Media CDN keyset.
+// Signing uses the active key, and verification accepts any key in the set,
+// so a key can be rotated by making a new key active while the previous
+// key remains valid until URLs signed with it expire.
+type KeySet struct {
+	active   Key
+	previous []Key
+}
+
+// NewKeySet returns a KeySet that signs with active.
+func NewKeySet(active Key, previous ...Key) (*KeySet, error) {
+	if !active.canSign() {
+		return nil, fmt.Errorf("cdnsign: active key %q cannot sign", active.Name)
+	}
+	ks := &KeySet{active: active, previous: previous}
+	seen := make(map[string]bool)
+	for _, k := range ks.Keys() {
+		if k.Name == "" {
+			return nil, errors.New("cdnsign: keys must have a name")
+		}
+		if seen[k.Name] {
+			return nil, fmt.Errorf("cdnsign: duplicate key name %q", k.Name)
+		}
+		seen[k.Name] = true
+	}
+	return ks, nil
+}
+
+// NewVerifyKeySet returns a KeySet that only verifies, for example at an
+// origin that holds the Ed25519 public keys of a Media CDN keyset.
+func NewVerifyKeySet(keys ...Key) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("cdnsign: no keys")
+	}
+	return &KeySet{active: keys[0], previous: keys[1:]}, nil
+}
+
+// Active returns the key used for signing.
+func (ks *KeySet) Active() Key {
+	return ks.active
+}
+
+// Keys returns all the keys, starting with the active key.
+func (ks *KeySet) Keys() []Key {
+	return append([]Key{ks.active}, ks.previous...)
+}
+
+// Rotate returns a KeySet that signs with next and keeps the current
+// active key for verification. At most keep previous keys are kept, the
+// most recent first.
+func (ks *KeySet) Rotate(next Key, keep int) (*KeySet, error) {
+	previous := ks.Keys()
+	if len(previous) > keep {
+		previous = previous[:keep]
+	}
+	return NewKeySet(next, previous...)
+}
+
+func (ks *KeySet) lookup(name string) (Key, bool) {
+	for _, k := range ks.Keys() {
+		if k.Name == name {
+			return k, true
+		}
+	}
+	return Key{}, false
+}
@@ -0,0 +1,249 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cdnsign signs and verifies Cloud CDN and Media CDN signed URLs,
+// signed URL prefixes, signed cookies and Media CDN tokens.
+//
+// The scheme follows from the algorithm of the signing key: HMAC-SHA1 keys
+// produce Cloud CDN signatures, and Ed25519 keys produce Media CDN
+// signatures. Media CDN tokens, used for dual-token authentication, can be
+// signed with Ed25519, HMAC-SHA256 or HMAC-SHA1 keys.
+//
+// A Verifier checks requests the way the CDN does, so an origin or a local
+// test server can enforce the same signatures with Verifier.Middleware.
+package cdnsign
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Cookie names used by each scheme.
+const (
+	CloudCDNCookie = "Cloud-CDN-Cookie"
+	MediaCDNCookie = "Edge-Cache-Cookie"
+)
+
+// Names of the header and query parameter that carry a Media CDN token.
+const (
+	TokenHeader = "Edge-Cache-Token"
+	TokenQuery  = "edge-cache-token"
+)
+
+// SignURL signs rawURL so that exactly that URL may be fetched until
+// expires. rawURL must not already have Expires, KeyName or Signature query
+// parameters.
+func (ks *KeySet) SignURL(rawURL string, expires time.Time) (string, error) {
+	k := ks.active
+	if err := checkURLKey(k); err != nil {
+		return "", err
+	}
+	toSign := fmt.Sprintf("%s%cExpires=%d&KeyName=%s", rawURL, querySep(rawURL), expires.Unix(), k.Name)
+	sig, err := k.sign(toSign)
+	if err != nil {
+		return "", err
+	}
+	return toSign + "&Signature=" + k.encoding().EncodeToString(sig), nil
+}
+
+// SignPrefix returns the query parameters that allow any URL starting with
+// urlPrefix to be fetched until expires. Append them to a URL under the
+// prefix, or use SignURLWithPrefix. Cloud CDN prefixes must not include a
+// query string.
+func (ks *KeySet) SignPrefix(urlPrefix string, expires time.Time) (string, error) {
+	k := ks.active
+	if err := checkURLKey(k); err != nil {
+		return "", err
+	}
+	if k.Algorithm == HMACSHA1 && strings.Contains(urlPrefix, "?") {
+		return "", fmt.Errorf("cdnsign: urlPrefix must not include query params: %s", urlPrefix)
+	}
+	return ks.signFields(k, urlPrefix, expires, "&")
+}
+
+// SignURLWithPrefix returns rawURL with the query parameters of
+// SignPrefix(urlPrefix, expires) appended.
+func (ks *KeySet) SignURLWithPrefix(rawURL, urlPrefix string, expires time.Time) (string, error) {
+	q, err := ks.SignPrefix(urlPrefix, expires)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%c%s", rawURL, querySep(rawURL), q), nil
+}
+
+// SignCookie returns the value of a signed cookie that allows any URL
+// starting with urlPrefix to be fetched until expires.
+func (ks *KeySet) SignCookie(urlPrefix string, expires time.Time) (string, error) {
+	k := ks.active
+	if err := checkURLKey(k); err != nil {
+		return "", err
+	}
+	return ks.signFields(k, urlPrefix, expires, ":")
+}
+
+// Cookie returns a signed cookie for urlPrefix, named for the scheme of the
+// active key and limited to the path of the prefix. Set its Domain to send
+// it to other hosts of the same site.
+func (ks *KeySet) Cookie(urlPrefix string, expires time.Time) (*http.Cookie, error) {
+	u, err := url.Parse(urlPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("url.Parse: %w", err)
+	}
+	value, err := ks.SignCookie(urlPrefix, expires)
+	if err != nil {
+		return nil, err
+	}
+	name := CloudCDNCookie
+	if ks.active.Algorithm == Ed25519 {
+		name = MediaCDNCookie
+	}
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     u.Path,
+		Expires:  expires,
+		Secure:   u.Scheme == "https",
+		HttpOnly: true,
+	}, nil
+}
+
+// signFields signs the URLPrefix, Expires and KeyName fields joined by sep.
+func (ks *KeySet) signFields(k Key, urlPrefix string, expires time.Time, sep string) (string, error) {
+	toSign := strings.Join([]string{
+		"URLPrefix=" + k.encoding().EncodeToString([]byte(urlPrefix)),
+		fmt.Sprintf("Expires=%d", expires.Unix()),
+		"KeyName=" + k.Name,
+	}, sep)
+	sig, err := k.sign(toSign)
+	if err != nil {
+		return "", err
+	}
+	return toSign + sep + "Signature=" + k.encoding().EncodeToString(sig), nil
+}
+
+func checkURLKey(k Key) error {
+	if k.Algorithm != HMACSHA1 && k.Algorithm != Ed25519 {
+		return fmt.Errorf("cdnsign: %v keys can only sign tokens", k.Algorithm)
+	}
+	return nil
+}
+
+func querySep(rawURL string) rune {
+	if strings.Contains(rawURL, "?") {
+		return '&'
+	}
+	return '?'
+}
+
+// Header is a request header whose value is covered by a token signature.
+type Header struct {
+	Name, Value string
+}
+
+// Token describes a Media CDN token. Exactly one of URLPrefix, FullPath and
+// PathGlobs limits the requests it allows.
+type Token struct {
+	// URLPrefix allows URLs that start with it.
+	URLPrefix string
+	// FullPath allows only this path, which is signed but not included in
+	// the token.
+	FullPath string
+	// PathGlobs allows paths that match any of the globs, where * matches
+	// any sequence of characters.
+	PathGlobs []string
+
+	// Starts is when the token becomes valid. The zero value means now.
+	Starts time.Time
+	// Expires is when the token stops being valid.
+	Expires time.Time
+
+	// SessionID and Data are opaque values carried in the token and covered
+	// by the signature.
+	SessionID string
+	Data      string
+	// Headers must be present in requests with the same values. Only their
+	// names are included in the token.
+	Headers []Header
+	// IPRanges limits the client addresses, in CIDR notation.
+	IPRanges []string
+}
+
+// SignToken returns t signed with the active key, in the format Media CDN
+// accepts in the Edge-Cache-Token header, the edge-cache-token query
+// parameter and the Edge-Cache-Cookie cookie.
+func (ks *KeySet) SignToken(t Token) (string, error) {
+	var fields, toSign []string
+	add := func(field, signed string) {
+		fields = append(fields, field)
+		toSign = append(toSign, signed)
+	}
+
+	switch {
+	case t.URLPrefix != "" && t.FullPath == "" && len(t.PathGlobs) == 0:
+		f := "URLPrefix=" + base64.RawURLEncoding.EncodeToString([]byte(t.URLPrefix))
+		add(f, f)
+	case t.URLPrefix == "" && t.FullPath != "" && len(t.PathGlobs) == 0:
+		add("FullPath", "FullPath="+t.FullPath)
+	case t.URLPrefix == "" && t.FullPath == "" && len(t.PathGlobs) > 0:
+		f := "PathGlobs=" + strings.Join(t.PathGlobs, ",")
+		add(f, f)
+	default:
+		return "", errors.New("cdnsign: a token needs exactly one of URLPrefix, FullPath and PathGlobs")
+	}
+	if t.Expires.IsZero() {
+		return "", errors.New("cdnsign: a token needs Expires")
+	}
+	if !t.Starts.IsZero() {
+		f := fmt.Sprintf("Starts=%d", t.Starts.Unix())
+		add(f, f)
+	}
+	f := fmt.Sprintf("Expires=%d", t.Expires.Unix())
+	add(f, f)
+	if t.SessionID != "" {
+		add("SessionID="+t.SessionID, "SessionID="+t.SessionID)
+	}
+	if t.Data != "" {
+		add("Data="+t.Data, "Data="+t.Data)
+	}
+	if len(t.Headers) > 0 {
+		var names, pairs []string
+		for _, h := range t.Headers {
+			names = append(names, h.Name)
+			pairs = append(pairs, h.Name+"="+h.Value)
+		}
+		add("Headers="+strings.Join(names, ","), "Headers="+strings.Join(pairs, ","))
+	}
+	if len(t.IPRanges) > 0 {
+		f := "IPRanges=" + base64.RawURLEncoding.EncodeToString([]byte(strings.Join(t.IPRanges, ",")))
+		add(f, f)
+	}
+
+	k := ks.active
+	sig, err := k.sign(strings.Join(toSign, "~"))
+	if err != nil {
+		return "", err
+	}
+	if k.Algorithm == Ed25519 {
+		fields = append(fields, "Signature="+base64.RawURLEncoding.EncodeToString(sig))
+	} else {
+		fields = append(fields, "hmac="+hex.EncodeToString(sig))
+	}
+	return strings.Join(fields, "~"), nil
+}
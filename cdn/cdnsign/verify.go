@@ -0,0 +1,377 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdnsign
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Verification errors. Errors returned by the Verify functions wrap one of
+// these.
+var (
+	ErrNoSignature      = errors.New("cdnsign: request is not signed")
+	ErrMalformed        = errors.New("cdnsign: malformed signature")
+	ErrUnknownKey       = errors.New("cdnsign: unknown key name")
+	ErrInvalidSignature = errors.New("cdnsign: invalid signature")
+	ErrExpired          = errors.New("cdnsign: signature expired")
+	ErrNotYetValid      = errors.New("cdnsign: token not yet valid")
+	ErrNotAllowed       = errors.New("cdnsign: signature does not allow this request")
+)
+
+// VerifyURL verifies a URL signed by SignURL or SignURLWithPrefix.
+func (ks *KeySet) VerifyURL(rawURL string, now time.Time) error {
+	i := strings.LastIndex(rawURL, "&Signature=")
+	if i < 0 {
+		return ErrNoSignature
+	}
+	signed, sig := rawURL[:i], rawURL[i+len("&Signature="):]
+	q := strings.IndexByte(signed, '?')
+	if q < 0 {
+		return fmt.Errorf("%w: no query string", ErrMalformed)
+	}
+
+	// A prefix signature is the last query parameters of the URL, starting
+	// with URLPrefix. Otherwise the whole URL is signed.
+	if j := strings.LastIndex(signed, "URLPrefix="); j > q && (signed[j-1] == '?' || signed[j-1] == '&') {
+		prefix, err := ks.verifyFields(signed[j:], sig, "&", now)
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(signed[:j-1], prefix) {
+			return fmt.Errorf("%w: URL does not start with %q", ErrNotAllowed, prefix)
+		}
+		return nil
+	}
+
+	params, err := url.ParseQuery(signed[q+1:])
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if err := ks.verifySignature(params.Get("KeyName"), signed, sig); err != nil {
+		return err
+	}
+	return checkExpires(params.Get("Expires"), now)
+}
+
+// VerifyCookie verifies the value of a cookie signed by SignCookie, and
+// that it allows rawURL.
+func (ks *KeySet) VerifyCookie(value, rawURL string, now time.Time) error {
+	i := strings.LastIndex(value, ":Signature=")
+	if i < 0 {
+		return ErrNoSignature
+	}
+	prefix, err := ks.verifyFields(value[:i], value[i+len(":Signature="):], ":", now)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(rawURL, prefix) {
+		return fmt.Errorf("%w: URL does not start with %q", ErrNotAllowed, prefix)
+	}
+	return nil
+}
+
+// verifyFields verifies the URLPrefix, Expires and KeyName fields joined by
+// sep, and returns the decoded prefix.
+func (ks *KeySet) verifyFields(signed, sig, sep string, now time.Time) (string, error) {
+	parts := strings.Split(signed, sep)
+	values := make(map[string]string)
+	for _, p := range parts {
+		name, value, ok := strings.Cut(p, "=")
+		if !ok {
+			return "", fmt.Errorf("%w: field %q", ErrMalformed, p)
+		}
+		values[name] = value
+	}
+	if len(parts) != 3 || len(values) != 3 {
+		return "", fmt.Errorf("%w: want URLPrefix, Expires and KeyName", ErrMalformed)
+	}
+	if err := ks.verifySignature(values["KeyName"], signed, sig); err != nil {
+		return "", err
+	}
+	if err := checkExpires(values["Expires"], now); err != nil {
+		return "", err
+	}
+	prefix, err := decodeBase64(values["URLPrefix"])
+	if err != nil {
+		return "", fmt.Errorf("%w: URLPrefix: %v", ErrMalformed, err)
+	}
+	return string(prefix), nil
+}
+
+// verifySignature checks the base64url signature sig of signed with the
+// named key.
+func (ks *KeySet) verifySignature(keyName, signed, sig string) error {
+	k, ok := ks.lookup(keyName)
+	if !ok || checkURLKey(k) != nil {
+		return fmt.Errorf("%w: %q", ErrUnknownKey, keyName)
+	}
+	b, err := decodeBase64(sig)
+	if err != nil {
+		return fmt.Errorf("%w: Signature: %v", ErrMalformed, err)
+	}
+	if !k.verify(signed, b) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// decodeBase64 decodes base64url with or without padding, as Cloud CDN
+// pads and Media CDN does not.
+func decodeBase64(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+func parseTime(s string) (time.Time, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: time %q", ErrMalformed, s)
+	}
+	return time.Unix(n, 0), nil
+}
+
+func checkExpires(s string, now time.Time) error {
+	expires, err := parseTime(s)
+	if err != nil {
+		return err
+	}
+	if now.After(expires) {
+		return fmt.Errorf("%w at %v", ErrExpired, expires.UTC())
+	}
+	return nil
+}
+
+// Verifier verifies requests signed with the keys of a KeySet.
+type Verifier struct {
+	Keys *KeySet
+	// BaseURL is the scheme and host that URLs were signed for, such as
+	// "https://media.example.com", when requests arrive at a different
+	// address, as at an origin or a local test server. By default the
+	// request's own scheme and host are used.
+	BaseURL string
+	// Now returns the current time. Default time.Now.
+	Now func() time.Time
+}
+
+func (v *Verifier) now() time.Time {
+	if v.Now != nil {
+		return v.Now()
+	}
+	return time.Now()
+}
+
+// requestURL returns the URL that r would have been signed for.
+func (v *Verifier) requestURL(r *http.Request) string {
+	if v.BaseURL != "" {
+		return strings.TrimSuffix(v.BaseURL, "/") + r.URL.RequestURI()
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
+
+// Verify checks r for a token in the Edge-Cache-Token header or the
+// edge-cache-token query parameter, a signed URL, or a signed cookie, in
+// that order, and verifies the first one found.
+func (v *Verifier) Verify(r *http.Request) error {
+	if t := r.Header.Get(TokenHeader); t != "" {
+		return v.VerifyToken(t, r)
+	}
+	if t := r.URL.Query().Get(TokenQuery); t != "" {
+		return v.VerifyToken(t, r)
+	}
+	u := v.requestURL(r)
+	if strings.Contains(u, "&Signature=") {
+		return v.Keys.VerifyURL(u, v.now())
+	}
+	for _, name := range []string{CloudCDNCookie, MediaCDNCookie} {
+		c, err := r.Cookie(name)
+		if err != nil {
+			continue
+		}
+		// Media CDN also accepts tokens in its cookie.
+		if strings.Contains(c.Value, "~") {
+			return v.VerifyToken(c.Value, r)
+		}
+		return v.Keys.VerifyCookie(c.Value, u, v.now())
+	}
+	return ErrNoSignature
+}
+
+// VerifyToken verifies a token signed by SignToken, and that it allows r.
+func (v *Verifier) VerifyToken(token string, r *http.Request) error {
+	parts := strings.Split(token, "~")
+	last := parts[len(parts)-1]
+	parts = parts[:len(parts)-1]
+
+	var toSign []string
+	values := make(map[string]string)
+	for _, p := range parts {
+		name, value, _ := strings.Cut(p, "=")
+		values[name] = value
+		switch name {
+		case "FullPath":
+			toSign = append(toSign, "FullPath="+r.URL.Path)
+		case "Headers":
+			var pairs []string
+			for _, h := range strings.Split(value, ",") {
+				pairs = append(pairs, h+"="+r.Header.Get(h))
+			}
+			toSign = append(toSign, "Headers="+strings.Join(pairs, ","))
+		default:
+			toSign = append(toSign, p)
+		}
+	}
+	if err := v.verifyTokenSignature(strings.Join(toSign, "~"), last); err != nil {
+		return err
+	}
+
+	now := v.now()
+	if s, ok := values["Starts"]; ok {
+		starts, err := parseTime(s)
+		if err != nil {
+			return err
+		}
+		if now.Before(starts) {
+			return fmt.Errorf("%w until %v", ErrNotYetValid, starts.UTC())
+		}
+	}
+	if err := checkExpires(values["Expires"], now); err != nil {
+		return err
+	}
+
+	switch {
+	case values["URLPrefix"] != "":
+		prefix, err := decodeBase64(values["URLPrefix"])
+		if err != nil {
+			return fmt.Errorf("%w: URLPrefix: %v", ErrMalformed, err)
+		}
+		if !strings.HasPrefix(v.requestURL(r), string(prefix)) {
+			return fmt.Errorf("%w: URL does not start with %q", ErrNotAllowed, prefix)
+		}
+	case values["PathGlobs"] != "":
+		if !matchAny(strings.Split(values["PathGlobs"], ","), r.URL.Path) {
+			return fmt.Errorf("%w: path %q does not match %s", ErrNotAllowed, r.URL.Path, values["PathGlobs"])
+		}
+	}
+	if s, ok := values["IPRanges"]; ok {
+		ranges, err := decodeBase64(s)
+		if err != nil {
+			return fmt.Errorf("%w: IPRanges: %v", ErrMalformed, err)
+		}
+		if !inRanges(strings.Split(string(ranges), ","), r.RemoteAddr) {
+			return fmt.Errorf("%w: client %s is not in %s", ErrNotAllowed, r.RemoteAddr, ranges)
+		}
+	}
+	return nil
+}
+
+// verifyTokenSignature checks the Signature or hmac field of a token
+// against every key of the right kind, as tokens do not name their key.
+func (v *Verifier) verifyTokenSignature(signed, field string) error {
+	name, value, _ := strings.Cut(field, "=")
+	var sig []byte
+	var err error
+	switch name {
+	case "Signature":
+		sig, err = decodeBase64(value)
+	case "hmac":
+		sig, err = hex.DecodeString(value)
+	default:
+		return fmt.Errorf("%w: token has no signature", ErrMalformed)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrMalformed, name, err)
+	}
+	for _, k := range v.Keys.Keys() {
+		if (k.Algorithm == Ed25519) != (name == "Signature") {
+			continue
+		}
+		if k.verify(signed, sig) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// matchAny reports whether path matches any of the globs, where * matches
+// any sequence of characters, including slashes.
+func matchAny(globs []string, path string) bool {
+	for _, g := range globs {
+		if matchGlob(g, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchGlob(glob, s string) bool {
+	parts := strings.Split(glob, "*")
+	if len(parts) == 1 {
+		return glob == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	for _, p := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, p)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(p):]
+	}
+	return strings.HasSuffix(s, parts[len(parts)-1])
+}
+
+func inRanges(ranges []string, remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, r := range ranges {
+		_, n, err := net.ParseCIDR(strings.TrimSpace(r))
+		if err == nil && n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware returns a handler that serves requests that pass Verify with
+// next, and rejects others with 403 Forbidden.
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := v.Verify(r); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
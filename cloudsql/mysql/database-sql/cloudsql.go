@@ -19,6 +19,7 @@
 package cloudsql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"html/template"
@@ -28,57 +29,24 @@ import (
 	"os"
 	"sync"
 	"time"
+
+	"github.com/GoogleCloudPlatform/golang-samples/cloudsql/mysql/database-sql/internal/votes"
 )
 
 var (
 	indexTmpl = template.Must(template.New("index").Parse(indexHTML))
-	db        *sql.DB
+	repo      votes.Repository
 	once      sync.Once
 )
 
-// getDB lazily instantiates a database connection pool. Users of Cloud Run or
-// Cloud Functions may wish to skip this lazy instantiation and connect as soon
-// as the function is loaded. This is primarily to help testing.
-func getDB() *sql.DB {
+// getRepo lazily instantiates a database connection pool. Users of Cloud Run
+// or Cloud Functions may wish to skip this lazy instantiation and connect as
+// soon as the function is loaded. This is primarily to help testing.
+func getRepo() votes.Repository {
 	once.Do(func() {
-		db = mustConnect()
+		repo = votes.New(mustConnect(), votes.MySQL)
 	})
-	return db
-}
-
-// migrateDB creates the votes table if it does not already exist.
-func migrateDB(db *sql.DB) error {
-	createVotes := `CREATE TABLE IF NOT EXISTS votes (
-		id SERIAL NOT NULL,
-		created_at datetime NOT NULL,
-		candidate VARCHAR(6) NOT NULL,
-		PRIMARY KEY (id)
-	);`
-	_, err := db.Exec(createVotes)
-	return err
-}
-
-// recentVotes returns the last five votes cast.
-func recentVotes(db *sql.DB) ([]vote, error) {
-	rows, err := db.Query("SELECT candidate, created_at FROM votes ORDER BY created_at DESC LIMIT 5")
-	if err != nil {
-		return nil, fmt.Errorf("DB.Query: %w", err)
-	}
-	defer rows.Close()
-
-	var votes []vote
-	for rows.Next() {
-		var (
-			candidate string
-			voteTime  time.Time
-		)
-		err := rows.Scan(&candidate, &voteTime)
-		if err != nil {
-			return nil, fmt.Errorf("Rows.Scan: %w", err)
-		}
-		votes = append(votes, vote{Candidate: candidate, VoteTime: voteTime})
-	}
-	return votes, nil
+	return repo
 }
 
 // formatMargin calculates the difference between votes and returns a human
@@ -98,34 +66,21 @@ type votingData struct {
 	TabsCount   int
 	SpacesCount int
 	VoteMargin  string
-	RecentVotes []vote
+	RecentVotes []votes.Vote
 }
 
-// currentTotals retrieves all voting data from the database.
-func currentTotals(db *sql.DB) (votingData, error) {
-	var (
-		tabs   int
-		spaces int
-	)
-	err := db.QueryRow("SELECT count(id) FROM votes WHERE candidate='TABS'").Scan(&tabs)
-	if err != nil {
-		return votingData{}, fmt.Errorf("DB.QueryRow: %w", err)
-	}
-	err = db.QueryRow("SELECT count(id) FROM votes WHERE candidate='SPACES'").Scan(&spaces)
-	if err != nil {
-		return votingData{}, fmt.Errorf("DB.QueryRow: %w", err)
-	}
-
-	recent, err := recentVotes(db)
+// currentTotals retrieves all voting data from the repository.
+func currentTotals(ctx context.Context, repo votes.Repository) (votingData, error) {
+	t, err := repo.Totals(ctx)
 	if err != nil {
-		return votingData{}, fmt.Errorf("recentVotes: %w", err)
+		return votingData{}, fmt.Errorf("Totals: %w", err)
 	}
 
 	return votingData{
-		TabsCount:   tabs,
-		SpacesCount: spaces,
-		VoteMargin:  formatMargin(tabs, spaces),
-		RecentVotes: recent,
+		TabsCount:   t.Tabs,
+		SpacesCount: t.Spaces,
+		VoteMargin:  formatMargin(t.Tabs, t.Spaces),
+		RecentVotes: t.Recent,
 	}, nil
 }
 
@@ -174,14 +129,16 @@ func mustConnect() *sql.DB {
 		log.Fatal("Missing database connection type. Please define one of INSTANCE_HOST, INSTANCE_UNIX_SOCKET, or INSTANCE_CONNECTION_NAME")
 	}
 
-	if err := migrateDB(db); err != nil {
-		log.Fatalf("unable to create table: %s", err)
+	if err := votes.New(db, votes.MySQL).Migrate(context.Background()); err != nil {
+		log.Fatalf("unable to migrate schema: %s", err)
 	}
 
 	return db
 }
 
-// configureConnectionPool sets database connection pool properties.
+// configureConnectionPool sets database connection pool properties. The
+// DB_MAX_IDLE_CONNS, DB_MAX_OPEN_CONNS, DB_CONN_MAX_LIFETIME and
+// DB_CONN_MAX_IDLE_TIME environment variables override them.
 // For more information, see https://golang.org/pkg/database/sql
 func configureConnectionPool(db *sql.DB) {
 	// [START cloud_sql_mysql_databasesql_limit]
@@ -204,32 +161,34 @@ func configureConnectionPool(db *sql.DB) {
 	// The database/sql package currently doesn't offer any functionality to
 	// configure connection timeout.
 	// [END cloud_sql_mysql_databasesql_timeout]
+
+	if err := votes.OverridePool(db); err != nil {
+		log.Fatalf("configureConnectionPool: %v", err)
+	}
 }
 
 // Votes handles HTTP requests to alternatively show the voting app or to save a
 // vote.
 func Votes(w http.ResponseWriter, r *http.Request) {
+	serveVotes(w, r, getRepo())
+}
+
+// serveVotes handles a request to the voting app using repo.
+func serveVotes(w http.ResponseWriter, r *http.Request, repo votes.Repository) {
 	switch r.Method {
 	case http.MethodGet:
-		renderIndex(w, r, getDB())
+		renderIndex(w, r, repo)
 	case http.MethodPost:
-		saveVote(w, r, getDB())
+		saveVote(w, r, repo.DB())
 	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
 	}
 }
 
-// vote contains a single row from the votes table in the database. Each vote
-// includes a candidate ("TABS" or "SPACES") and a timestamp.
-type vote struct {
-	Candidate string
-	VoteTime  time.Time
-}
-
 // renderIndex renders the HTML application with the voting form, current
 // totals, and recent votes.
-func renderIndex(w http.ResponseWriter, r *http.Request, db *sql.DB) {
-	t, err := currentTotals(db)
+func renderIndex(w http.ResponseWriter, r *http.Request, repo votes.Repository) {
+	t, err := currentTotals(r.Context(), repo)
 	if err != nil {
 		log.Printf("renderIndex: failed to read current totals: %v", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
@@ -271,6 +230,7 @@ func saveVote(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 	if err != nil {
 		log.Printf("saveVote: unable to save vote: %v", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
 	}
 	fmt.Fprintf(w, "Vote successfully cast for %s!", team)
 }
@@ -20,6 +20,9 @@ import (
 	"os"
 	"strings"
 	"testing"
+
+	"github.com/GoogleCloudPlatform/golang-samples/cloudsql/mysql/database-sql/internal/votes"
+	"github.com/GoogleCloudPlatform/golang-samples/cloudsql/mysql/database-sql/internal/votes/votestest"
 )
 
 // dbConfig holds database connection information derived from the environment.
@@ -171,7 +174,7 @@ func TestGetVotes(t *testing.T) {
 			defer cleanup()
 
 			// initialize database connection based on environment
-			db = mustConnect()
+			repo = votes.New(mustConnect(), votes.MySQL)
 
 			testGetVotes(t)
 		})
@@ -199,9 +202,50 @@ func TestCastVote(t *testing.T) {
 			defer cleanup()
 
 			// initialize database connection based on environment
-			db = mustConnect()
+			repo = votes.New(mustConnect(), votes.MySQL)
 
 			testCastVote(t)
 		})
 	}
 }
+
+// TestVotesOffline runs the handler against SQLite, without a Cloud SQL
+// instance.
+func TestVotesOffline(t *testing.T) {
+	repo := votestest.New(t)
+
+	for _, tc := range []struct {
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{body: "team=SPACES", wantStatus: 200, wantBody: "Vote successfully cast for SPACES"},
+		{body: "team=SPACES", wantStatus: 200, wantBody: "Vote successfully cast for SPACES"},
+		{body: "team=TABS", wantStatus: 200, wantBody: "Vote successfully cast for TABS"},
+		{body: "team=EMACS", wantStatus: 400},
+		{body: "", wantStatus: 400},
+	} {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		serveVotes(rr, req, repo)
+		if rr.Code != tc.wantStatus || !strings.Contains(rr.Body.String(), tc.wantBody) {
+			t.Errorf("POST %q: got %d %q, want %d %q", tc.body, rr.Code, rr.Body, tc.wantStatus, tc.wantBody)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	serveVotes(rr, httptest.NewRequest("GET", "/", nil), repo)
+	body := rr.Body.String()
+	for _, want := range []string{"SPACES are winning by 1 vote", "<h3>1 votes</h3>", "<h3>2 votes</h3>", "A vote for <b>TABS</b> was cast at"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("GET: failed to find %q in resp = %v", want, body)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	serveVotes(rr, httptest.NewRequest("DELETE", "/", nil), repo)
+	if rr.Code != 405 {
+		t.Errorf("DELETE: got status %d, want 405", rr.Code)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package votes
+
+// Dialect holds the schema, which differs between MySQL and SQLite.
+type Dialect struct {
+	createSchemaTable string
+
+	// migrations[i] upgrades the schema from version i to version i+1.
+	migrations []migration
+}
+
+// A migration upgrades the schema by one version. MySQL commits DDL
+// implicitly, so a migration can run without its version being recorded,
+// and run again on the next start: its statement must be idempotent.
+type migration struct {
+	stmt string
+	// applied, if set, counts the objects stmt creates, for DDL that can't
+	// be made idempotent: stmt is skipped if the count isn't 0.
+	applied string
+}
+
+var (
+	// MySQL is the dialect of Cloud SQL for MySQL.
+	MySQL = &Dialect{
+		createSchemaTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT NOT NULL,
+			applied_at DATETIME NOT NULL,
+			PRIMARY KEY (version)
+		)`,
+		migrations: []migration{
+			{stmt: `CREATE TABLE IF NOT EXISTS votes (
+				id SERIAL NOT NULL,
+				created_at datetime NOT NULL,
+				candidate VARCHAR(6) NOT NULL,
+				PRIMARY KEY (id)
+			)`},
+			{
+				// MySQL has no CREATE INDEX IF NOT EXISTS.
+				stmt: "CREATE INDEX votes_created_at ON votes (created_at)",
+				applied: `SELECT COUNT(*) FROM information_schema.statistics
+					WHERE table_schema = DATABASE() AND table_name = 'votes' AND index_name = 'votes_created_at'`,
+			},
+		},
+	}
+
+	// SQLite is used by tests, with the pure Go modernc.org/sqlite driver.
+	SQLite = &Dialect{
+		createSchemaTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER NOT NULL PRIMARY KEY,
+			applied_at TIMESTAMP NOT NULL
+		)`,
+		migrations: []migration{
+			{stmt: `CREATE TABLE IF NOT EXISTS votes (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				created_at TIMESTAMP NOT NULL,
+				candidate VARCHAR(6) NOT NULL
+			)`},
+			{stmt: "CREATE INDEX IF NOT EXISTS votes_created_at ON votes (created_at)"},
+		},
+	}
+)
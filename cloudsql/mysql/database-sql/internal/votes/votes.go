@@ -0,0 +1,135 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package votes stores the votes of the "Tabs vs Spaces" sample app in
+// Cloud SQL for MySQL, using database/sql.
+//
+// The schema is created and upgraded by versioned migrations, recorded in
+// a schema_migrations table. Tests run the same queries on SQLite, without
+// a Cloud SQL instance; see the votestest package.
+package votes
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Candidates that can be voted for.
+const (
+	Tabs   = "TABS"
+	Spaces = "SPACES"
+)
+
+// Vote is a single vote.
+type Vote struct {
+	Candidate string
+	VoteTime  time.Time
+}
+
+// Totals are the vote counts and the most recent votes.
+type Totals struct {
+	Tabs   int
+	Spaces int
+	Recent []Vote
+}
+
+// Repository holds the votes table.
+type Repository interface {
+	// Migrate brings the schema up to date.
+	Migrate(ctx context.Context) error
+	// DB returns the connection pool, which votes are inserted with.
+	DB() *sql.DB
+	// Totals returns the counts and the five most recent votes.
+	Totals(ctx context.Context) (Totals, error)
+}
+
+// The queries of Totals, which MySQL and SQLite share.
+const (
+	countVotes  = "SELECT candidate, COUNT(*) FROM votes GROUP BY candidate"
+	recentVotes = "SELECT candidate, created_at FROM votes ORDER BY created_at DESC LIMIT 5"
+)
+
+// Store is a Repository backed by a database/sql connection pool.
+type Store struct {
+	db      *sql.DB
+	dialect *Dialect
+}
+
+// New returns a Store that uses db with the SQL of dialect.
+func New(db *sql.DB, dialect *Dialect) *Store {
+	return &Store{db: db, dialect: dialect}
+}
+
+// DB returns the connection pool of s.
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// Totals returns the vote counts, from a single grouped query, and the most
+// recent votes.
+func (s *Store) Totals(ctx context.Context) (Totals, error) {
+	var t Totals
+	rows, err := s.db.QueryContext(ctx, countVotes)
+	if err != nil {
+		return Totals{}, fmt.Errorf("DB.Query: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			candidate string
+			n         int
+		)
+		if err := rows.Scan(&candidate, &n); err != nil {
+			return Totals{}, fmt.Errorf("Rows.Scan: %w", err)
+		}
+		switch candidate {
+		case Tabs:
+			t.Tabs = n
+		case Spaces:
+			t.Spaces = n
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return Totals{}, fmt.Errorf("Rows.Err: %w", err)
+	}
+
+	t.Recent, err = s.recent(ctx)
+	if err != nil {
+		return Totals{}, err
+	}
+	return t, nil
+}
+
+func (s *Store) recent(ctx context.Context) ([]Vote, error) {
+	rows, err := s.db.QueryContext(ctx, recentVotes)
+	if err != nil {
+		return nil, fmt.Errorf("DB.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var votes []Vote
+	for rows.Next() {
+		var v Vote
+		if err := rows.Scan(&v.Candidate, &v.VoteTime); err != nil {
+			return nil, fmt.Errorf("Rows.Scan: %w", err)
+		}
+		votes = append(votes, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Rows.Err: %w", err)
+	}
+	return votes, nil
+}
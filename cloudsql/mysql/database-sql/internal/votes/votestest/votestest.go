@@ -0,0 +1,54 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package votestest provides a votes.Store backed by SQLite, so that the
+// voting apps can be tested without a Cloud SQL instance.
+package votestest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/golang-samples/cloudsql/mysql/database-sql/internal/votes"
+	"modernc.org/sqlite"
+)
+
+// The app inserts votes with MySQL's NOW(), which SQLite lacks.
+func init() {
+	sqlite.MustRegisterScalarFunction("NOW", 0, func(*sqlite.FunctionContext, []driver.Value) (driver.Value, error) {
+		return time.Now().UTC().Format("2006-01-02 15:04:05.999999999"), nil
+	})
+}
+
+// New returns a Store backed by a new SQLite database in a temporary
+// directory, with the schema migrated. The database is closed when the
+// test ends.
+func New(t testing.TB) *votes.Store {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "votes.db"))
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	s := votes.New(db, votes.SQLite)
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	return s
+}
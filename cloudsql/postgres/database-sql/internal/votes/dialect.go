@@ -0,0 +1,58 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package votes
+
+// Dialect holds the schema, which differs between PostgreSQL and SQLite.
+type Dialect struct {
+	createSchemaTable string
+
+	// migrations[i] upgrades the schema from version i to version i+1.
+	migrations []string
+}
+
+var (
+	// Postgres is the dialect of Cloud SQL for PostgreSQL.
+	Postgres = &Dialect{
+		createSchemaTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT NOT NULL PRIMARY KEY,
+			applied_at timestamp NOT NULL
+		)`,
+		migrations: []string{
+			`CREATE TABLE IF NOT EXISTS votes (
+				id SERIAL NOT NULL,
+				created_at timestamp NOT NULL,
+				candidate VARCHAR(6) NOT NULL,
+				PRIMARY KEY (id)
+			)`,
+			"CREATE INDEX IF NOT EXISTS votes_created_at ON votes (created_at)",
+		},
+	}
+
+	// SQLite is used by tests, with the pure Go modernc.org/sqlite driver.
+	SQLite = &Dialect{
+		createSchemaTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER NOT NULL PRIMARY KEY,
+			applied_at TIMESTAMP NOT NULL
+		)`,
+		migrations: []string{
+			`CREATE TABLE IF NOT EXISTS votes (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				created_at TIMESTAMP NOT NULL,
+				candidate VARCHAR(6) NOT NULL
+			)`,
+			"CREATE INDEX IF NOT EXISTS votes_created_at ON votes (created_at)",
+		},
+	}
+)
@@ -0,0 +1,112 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package votes_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/golang-samples/cloudsql/postgres/database-sql/internal/votes"
+	"github.com/GoogleCloudPlatform/golang-samples/cloudsql/postgres/database-sql/internal/votes/votestest"
+)
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	s := votestest.New(t)
+
+	// Running again, as every instance does on start, changes nothing.
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("second Migrate: %v", err)
+	}
+	v, err := s.Version(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := votes.SQLite.SchemaVersion(); v != want {
+		t.Errorf("Version = %d, want %d", v, want)
+	}
+	var n int
+	if err := s.DB().QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != v {
+		t.Errorf("schema_migrations has %d rows, want %d", n, v)
+	}
+
+	if got, want := votes.Postgres.SchemaVersion(), votes.SQLite.SchemaVersion(); got != want {
+		t.Errorf("Postgres has %d migrations, want %d", got, want)
+	}
+}
+
+func TestTotals(t *testing.T) {
+	ctx := context.Background()
+	s := votestest.New(t)
+
+	got, err := s.Totals(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Tabs != 0 || got.Spaces != 0 || len(got.Recent) != 0 {
+		t.Errorf("empty Totals = %+v", got)
+	}
+
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	cast := []string{votes.Tabs, votes.Spaces, votes.Spaces, votes.Tabs, votes.Spaces, votes.Spaces, votes.Tabs}
+	for i, c := range cast {
+		at := start.Add(time.Duration(i) * time.Minute)
+		if _, err := s.DB().Exec("INSERT INTO votes(candidate, created_at) VALUES($1, $2)", c, at); err != nil {
+			t.Fatalf("DB.Exec: %v", err)
+		}
+	}
+
+	got, err = s.Totals(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Tabs != 3 || got.Spaces != 4 {
+		t.Errorf("Totals = %d tabs, %d spaces, want 3, 4", got.Tabs, got.Spaces)
+	}
+	var recent []string
+	for _, v := range got.Recent {
+		recent = append(recent, v.Candidate)
+	}
+	if want := []string{votes.Tabs, votes.Spaces, votes.Spaces, votes.Tabs, votes.Spaces}; !reflect.DeepEqual(recent, want) {
+		t.Errorf("recent = %v, want %v", recent, want)
+	}
+	if !got.Recent[0].VoteTime.Equal(start.Add(6 * time.Minute)) {
+		t.Errorf("latest vote at %v, want %v", got.Recent[0].VoteTime, start.Add(6*time.Minute))
+	}
+}
+
+func TestOverridePool(t *testing.T) {
+	db := votestest.New(t).DB()
+	db.SetMaxOpenConns(7)
+
+	t.Setenv("DB_MAX_OPEN_CONNS", "20")
+	t.Setenv("DB_CONN_MAX_LIFETIME", "5m")
+	if err := votes.OverridePool(db); err != nil {
+		t.Fatal(err)
+	}
+	if got := db.Stats().MaxOpenConnections; got != 20 {
+		t.Errorf("MaxOpenConnections = %d, want 20", got)
+	}
+
+	t.Setenv("DB_MAX_IDLE_CONNS", "many")
+	if err := votes.OverridePool(db); err == nil {
+		t.Errorf("OverridePool with DB_MAX_IDLE_CONNS=many succeeded")
+	}
+}
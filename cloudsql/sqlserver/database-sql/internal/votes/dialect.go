@@ -0,0 +1,66 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package votes
+
+// Dialect holds the SQL that differs between SQL Server and SQLite.
+type Dialect struct {
+	// recentVotes selects the five most recent votes.
+	recentVotes string
+
+	createSchemaTable string
+	// migrations[i] upgrades the schema from version i to version i+1.
+	migrations []string
+}
+
+var (
+	// SQLServer is the dialect of Cloud SQL for SQL Server.
+	SQLServer = &Dialect{
+		recentVotes: "SELECT TOP 5 RTRIM(candidate), created_at FROM votes ORDER BY created_at DESC",
+
+		createSchemaTable: `IF OBJECT_ID(N'schema_migrations', N'U') IS NULL
+			CREATE TABLE schema_migrations (
+				version INT NOT NULL PRIMARY KEY,
+				applied_at DATETIME NOT NULL
+			)`,
+		migrations: []string{
+			`IF OBJECT_ID(N'votes', N'U') IS NULL
+				CREATE TABLE votes (
+					id int IDENTITY(1,1) PRIMARY KEY,
+					created_at DATETIME NOT NULL,
+					candidate CHAR(6) NOT NULL
+				)`,
+			`IF NOT EXISTS (SELECT * FROM sys.indexes WHERE name = N'votes_created_at' AND object_id = OBJECT_ID(N'votes'))
+				CREATE INDEX votes_created_at ON votes (created_at)`,
+		},
+	}
+
+	// SQLite is used by tests, with the pure Go modernc.org/sqlite driver.
+	SQLite = &Dialect{
+		recentVotes: "SELECT RTRIM(candidate), created_at FROM votes ORDER BY created_at DESC LIMIT 5",
+
+		createSchemaTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER NOT NULL PRIMARY KEY,
+			applied_at TIMESTAMP NOT NULL
+		)`,
+		migrations: []string{
+			`CREATE TABLE IF NOT EXISTS votes (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				created_at TIMESTAMP NOT NULL,
+				candidate CHAR(6) NOT NULL
+			)`,
+			"CREATE INDEX IF NOT EXISTS votes_created_at ON votes (created_at)",
+		},
+	}
+)
@@ -0,0 +1,85 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package votes
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	currentVersion = "SELECT COALESCE(MAX(version), 0) FROM schema_migrations"
+	recordVersion  = "INSERT INTO schema_migrations (version, applied_at) VALUES (@VERSION, @APPLIED_AT)"
+)
+
+// SchemaVersion is the schema version that Migrate upgrades to.
+func (d *Dialect) SchemaVersion() int {
+	return len(d.migrations)
+}
+
+// Version returns the schema version recorded in the database, or 0 if no
+// migration has run.
+func (s *Store) Version(ctx context.Context) (int, error) {
+	if _, err := s.db.ExecContext(ctx, s.dialect.createSchemaTable); err != nil {
+		return 0, fmt.Errorf("creating schema_migrations: %w", err)
+	}
+	var v int
+	if err := s.db.QueryRowContext(ctx, currentVersion).Scan(&v); err != nil {
+		return 0, fmt.Errorf("DB.QueryRow: %w", err)
+	}
+	return v, nil
+}
+
+// Migrate applies the migrations that have not run yet, in order. Each
+// migration is committed together with its schema_migrations row, and its
+// statement checks for the objects it creates first.
+//
+// Several instances may start at once, as on Cloud Run. If a migration
+// fails because another instance applied it first, Migrate continues.
+func (s *Store) Migrate(ctx context.Context) error {
+	current, err := s.Version(ctx)
+	if err != nil {
+		return err
+	}
+	for v := current + 1; v <= s.dialect.SchemaVersion(); v++ {
+		if err := s.apply(ctx, v); err != nil {
+			now, verr := s.Version(ctx)
+			if verr != nil || now < v {
+				return fmt.Errorf("migration %d: %w", v, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Store) apply(ctx context.Context, version int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("DB.BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, s.dialect.migrations[version-1]); err != nil {
+		return fmt.Errorf("Tx.Exec: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, recordVersion, sql.Named("VERSION", version), sql.Named("APPLIED_AT", time.Now().UTC())); err != nil {
+		return fmt.Errorf("Tx.Exec: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Tx.Commit: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package votes
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// OverridePool changes the settings of db's connection pool that are set
+// by the environment variables DB_MAX_IDLE_CONNS, DB_MAX_OPEN_CONNS,
+// DB_CONN_MAX_LIFETIME and DB_CONN_MAX_IDLE_TIME. Durations are written as
+// "30m" or "1800s". Settings without a variable are left unchanged.
+func OverridePool(db *sql.DB) error {
+	for _, v := range []struct {
+		name string
+		n    func(int)
+		d    func(time.Duration)
+	}{
+		{name: "DB_MAX_IDLE_CONNS", n: db.SetMaxIdleConns},
+		{name: "DB_MAX_OPEN_CONNS", n: db.SetMaxOpenConns},
+		{name: "DB_CONN_MAX_LIFETIME", d: db.SetConnMaxLifetime},
+		{name: "DB_CONN_MAX_IDLE_TIME", d: db.SetConnMaxIdleTime},
+	} {
+		s := os.Getenv(v.name)
+		if s == "" {
+			continue
+		}
+		if v.n != nil {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return fmt.Errorf("%s: %w", v.name, err)
+			}
+			v.n(n)
+			continue
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("%s: %w", v.name, err)
+		}
+		v.d(d)
+	}
+	return nil
+}
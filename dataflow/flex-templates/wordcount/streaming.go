@@ -0,0 +1,224 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/apache/beam/sdks/v2/go/pkg/beam"
+	"github.com/apache/beam/sdks/v2/go/pkg/beam/core/graph/window"
+	"github.com/apache/beam/sdks/v2/go/pkg/beam/core/typex"
+	"github.com/apache/beam/sdks/v2/go/pkg/beam/io/bigqueryio"
+	"github.com/apache/beam/sdks/v2/go/pkg/beam/io/filesystem"
+	"github.com/apache/beam/sdks/v2/go/pkg/beam/io/pubsubio"
+	"github.com/apache/beam/sdks/v2/go/pkg/beam/options/gcpopts"
+	"github.com/apache/beam/sdks/v2/go/pkg/beam/register"
+	"github.com/apache/beam/sdks/v2/go/pkg/beam/transforms/stats"
+	"github.com/apache/beam/sdks/v2/go/pkg/beam/transforms/top"
+)
+
+var (
+	// Setting inputTopic runs the pipeline in streaming mode.
+	inputTopic        = flag.String("input_topic", "", "Pub/Sub topic to read lines from, in streaming mode.")
+	inputSubscription = flag.String("input_subscription", "", "Pub/Sub subscription of input_topic to read from. Default: a new subscription.")
+
+	windowSize      = flag.Duration("window_size", time.Minute, "Length of the windows words are counted in.")
+	windowPeriod    = flag.Duration("window_period", 0, "Period of sliding windows. Default: fixed windows.")
+	allowedLateness = flag.Duration("allowed_lateness", 0, "How long after the end of a window late lines are still counted.")
+	topN            = flag.Int("top", 10, "Number of words to output per window.")
+
+	// Streaming pipelines write to either output, as a file prefix, or
+	// outputTable.
+	outputTable = flag.String("output_table", "", "BigQuery table to write to, as project:dataset.table.")
+)
+
+func init() {
+	register.Function1x1(bytesToStringFn)
+	register.Function2x1(toWordCountFn)
+	register.Function2x1(lessWordCount)
+	register.DoFn4x0[typex.PaneInfo, beam.Window, []WordCount, func(WindowedCount)](&rankFn{})
+	register.DoFn4x1[context.Context, typex.PaneInfo, beam.Window, []WordCount, error](&writeWindowFn{})
+	register.Emitter1[WindowedCount]()
+}
+
+// WordCount is a word and the number of times it occurs.
+type WordCount struct {
+	Word  string
+	Count int
+}
+
+// WindowedCount is a row of the top words of a window.
+type WindowedCount struct {
+	WindowStart time.Time `bigquery:"window_start"`
+	WindowEnd   time.Time `bigquery:"window_end"`
+	// Pane is 0 for the results of a window when the watermark passes its
+	// end, and counts up for each update with late data.
+	Pane  int64  `bigquery:"pane"`
+	Rank  int    `bigquery:"rank"`
+	Word  string `bigquery:"word"`
+	Count int    `bigquery:"count"`
+}
+
+// Windowing describes how a streaming pipeline divides lines into windows.
+type Windowing struct {
+	// Size is the length of each window.
+	Size time.Duration
+	// Period is the time between the starts of sliding windows. If it is 0,
+	// windows are fixed and don't overlap.
+	Period time.Duration
+	// AllowedLateness is how long after the end of a window lines are
+	// still counted. Each late line updates the window's results.
+	AllowedLateness time.Duration
+}
+
+// Window divides a PCollection into windows. Late panes accumulate, so
+// each pane has the complete results of its window so far.
+func Window(s beam.Scope, w Windowing, col beam.PCollection) beam.PCollection {
+	ws := window.NewFixedWindows(w.Size)
+	if w.Period > 0 {
+		ws = window.NewSlidingWindows(w.Period, w.Size)
+	}
+	var opts []beam.WindowIntoOption
+	if w.AllowedLateness > 0 {
+		// Without allowed lateness there is one pane per window, and the
+		// accumulation mode makes no difference.
+		opts = append(opts, beam.AllowedLateness(w.AllowedLateness), beam.PanesAccumulate())
+	}
+	return beam.WindowInto(s, ws, col, opts...)
+}
+
+// TopWords is a composite transform that finds the n most common words of
+// each window of a PCollection of lines. Words shorter than
+// small_word_length are left out. It returns a PCollection of []WordCount,
+// one per window pane, most common first.
+func TopWords(s beam.Scope, lines beam.PCollection, n int) beam.PCollection {
+	s = s.Scope("TopWords")
+
+	words := beam.ParDo(s, &extractFn{SmallWordLength: *smallWordLength, SkipSmallWords: true}, lines)
+	counted := beam.ParDo(s, toWordCountFn, stats.Count(s, words))
+	return top.Largest(s, counted, n, lessWordCount)
+}
+
+func toWordCountFn(w string, c int) WordCount {
+	return WordCount{Word: w, Count: c}
+}
+
+// lessWordCount orders words by count, and then alphabetically, so that
+// ties are broken the same way in every run.
+func lessWordCount(a, b WordCount) bool {
+	if a.Count != b.Count {
+		return a.Count < b.Count
+	}
+	return a.Word > b.Word
+}
+
+// sortWordCounts sorts the result of top.Largest, most common first.
+func sortWordCounts(top []WordCount) {
+	sort.Slice(top, func(i, j int) bool { return lessWordCount(top[j], top[i]) })
+}
+
+// Rank converts the output of TopWords to a WindowedCount per word.
+func Rank(s beam.Scope, top beam.PCollection) beam.PCollection {
+	return beam.ParDo(s, &rankFn{}, top)
+}
+
+// rankFn is a DoFn that emits a WindowedCount for each top word of a window.
+type rankFn struct{}
+
+func (f *rankFn) ProcessElement(pane typex.PaneInfo, w beam.Window, top []WordCount, emit func(WindowedCount)) {
+	start, end := bounds(w)
+	sortWordCounts(top)
+	for i, wc := range top {
+		emit(WindowedCount{
+			WindowStart: start,
+			WindowEnd:   end,
+			Pane:        pane.Index,
+			Rank:        i + 1,
+			Word:        wc.Word,
+			Count:       wc.Count,
+		})
+	}
+}
+
+// bounds returns the start and end of an interval window.
+func bounds(w beam.Window) (start, end time.Time) {
+	iw := w.(window.IntervalWindow)
+	return iw.Start.ToTime().UTC(), iw.End.ToTime().UTC()
+}
+
+// WriteWindows writes the output of TopWords to a file per window pane,
+// named after prefix and the window's bounds and pane index, such as
+// gs://bucket/counts-20260102T150400Z-20260102T150500Z-0.txt.
+func WriteWindows(s beam.Scope, prefix string, top beam.PCollection) {
+	s = s.Scope("WriteWindows")
+	filesystem.ValidateScheme(prefix)
+	beam.ParDo0(s, &writeWindowFn{Prefix: prefix}, top)
+}
+
+// windowFileTime is the format of window bounds in file names.
+const windowFileTime = "20060102T150405Z"
+
+// writeWindowFn is a DoFn that writes the top words of a window pane to a
+// file. A retried bundle overwrites the same file.
+type writeWindowFn struct {
+	Prefix string `json:"prefix"`
+}
+
+func (f *writeWindowFn) ProcessElement(ctx context.Context, pane typex.PaneInfo, w beam.Window, top []WordCount) error {
+	start, end := bounds(w)
+	filename := fmt.Sprintf("%s-%s-%s-%d.txt", f.Prefix, start.Format(windowFileTime), end.Format(windowFileTime), pane.Index)
+
+	fs, err := filesystem.New(ctx, filename)
+	if err != nil {
+		return err
+	}
+	defer fs.Close()
+	fd, err := fs.OpenWrite(ctx, filename)
+	if err != nil {
+		return err
+	}
+	sortWordCounts(top)
+	for _, wc := range top {
+		if _, err := fmt.Fprintln(fd, formatFn(wc.Word, wc.Count)); err != nil {
+			fd.Close()
+			return err
+		}
+	}
+	return fd.Close()
+}
+
+func bytesToStringFn(b []byte) string {
+	return string(b)
+}
+
+// streamingPipeline reads lines from Pub/Sub and writes the top words of
+// each window to files or BigQuery.
+func streamingPipeline(ctx context.Context, s beam.Scope) {
+	project := gcpopts.GetProject(ctx)
+	msgs := pubsubio.Read(s, project, *inputTopic, &pubsubio.ReadOptions{Subscription: *inputSubscription})
+	lines := beam.ParDo(s, bytesToStringFn, msgs)
+
+	windowed := Window(s, Windowing{Size: *windowSize, Period: *windowPeriod, AllowedLateness: *allowedLateness}, lines)
+	top := TopWords(s, windowed, *topN)
+	if *outputTable != "" {
+		bigqueryio.Write(s, project, *outputTable, Rank(s, top))
+		return
+	}
+	WriteWindows(s, *output, top)
+}
@@ -0,0 +1,192 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apache/beam/sdks/v2/go/pkg/beam"
+	"github.com/apache/beam/sdks/v2/go/pkg/beam/core/graph/window"
+	"github.com/apache/beam/sdks/v2/go/pkg/beam/core/runtime/graphx"
+	_ "github.com/apache/beam/sdks/v2/go/pkg/beam/io/filesystem/local"
+	pipepb "github.com/apache/beam/sdks/v2/go/pkg/beam/model/pipeline_v1"
+	"github.com/apache/beam/sdks/v2/go/pkg/beam/testing/passert"
+	"github.com/apache/beam/sdks/v2/go/pkg/beam/testing/ptest"
+	"github.com/apache/beam/sdks/v2/go/pkg/beam/testing/teststream"
+)
+
+var update = flag.Bool("update_golden", false, "Update the golden files in testdata.")
+
+// t0 is the start of the first window in the tests.
+var t0 = time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC)
+
+// at returns a TestStream timestamp d after t0.
+func at(d time.Duration) int64 {
+	return t0.Add(d).UnixMilli()
+}
+
+func TestTopWordsGolden(t *testing.T) {
+	// Words shorter than small_word_length (6) are left out, so "the" and
+	// "count" are never in the output.
+	tests := []struct {
+		name      string
+		windowing Windowing
+		events    func(c *teststream.Config)
+	}{
+		{
+			name:      "fixed",
+			windowing: Windowing{Size: time.Minute},
+			events: func(c *teststream.Config) {
+				c.AddElements(at(10*time.Second), "gophers stream the gophers", "streams count gophers")
+				c.AddElements(at(50*time.Second), "streams and streams")
+				c.AddElements(at(70*time.Second), "windows windows pipeline")
+				c.AdvanceWatermarkToInfinity()
+			},
+		},
+		{
+			name:      "sliding",
+			windowing: Windowing{Size: 2 * time.Minute, Period: time.Minute},
+			events: func(c *teststream.Config) {
+				c.AddElements(at(10*time.Second), "gophers gophers streams")
+				c.AddElements(at(70*time.Second), "streams pipeline streams")
+				c.AdvanceWatermarkToInfinity()
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := teststream.NewConfig()
+			tc.events(&c)
+
+			dir := t.TempDir()
+			p, s := beam.NewPipelineWithRoot()
+			lines := teststream.Create(s, c)
+			WriteWindows(s, filepath.Join(dir, "counts"), TopWords(s, Window(s, tc.windowing, lines), 2))
+			if err := ptest.Run(p); err != nil {
+				t.Fatalf("pipeline failed: %v", err)
+			}
+
+			got := readDir(t, dir)
+			golden := filepath.Join("testdata", "streaming", tc.name)
+			if *update {
+				os.RemoveAll(golden)
+				if err := os.MkdirAll(golden, 0o755); err != nil {
+					t.Fatal(err)
+				}
+				for name, content := range got {
+					if err := os.WriteFile(filepath.Join(golden, name), []byte(content), 0o644); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			want := readDir(t, golden)
+			for name, content := range want {
+				if got[name] != content {
+					t.Errorf("%s: got\n%s\nwant\n%s", name, got[name], content)
+				}
+			}
+			for name := range got {
+				if _, ok := want[name]; !ok {
+					t.Errorf("unexpected output file %s:\n%s", name, got[name])
+				}
+			}
+		})
+	}
+}
+
+// readDir returns the contents of the files in dir by name.
+func readDir(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	files := make(map[string]string)
+	for _, e := range entries {
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		files[e.Name()] = string(b)
+	}
+	return files
+}
+
+func TestRank(t *testing.T) {
+	c := teststream.NewConfig()
+	c.AddElements(at(10*time.Second), "gophers streams gophers", "pipeline")
+	c.AdvanceWatermarkToInfinity()
+
+	p, s := beam.NewPipelineWithRoot()
+	lines := teststream.Create(s, c)
+	rows := Rank(s, TopWords(s, Window(s, Windowing{Size: time.Minute}, lines), 2))
+	end := t0.Add(time.Minute)
+	// passert compares in the global window.
+	passert.Equals(s, beam.WindowInto(s, window.NewGlobalWindows(), rows),
+		WindowedCount{WindowStart: t0, WindowEnd: end, Rank: 1, Word: "gophers", Count: 2},
+		WindowedCount{WindowStart: t0, WindowEnd: end, Rank: 2, Word: "pipeline", Count: 1},
+	)
+	if err := ptest.Run(p); err != nil {
+		t.Fatalf("pipeline failed: %v", err)
+	}
+}
+
+func TestSkipSmallWords(t *testing.T) {
+	p, s := beam.NewPipelineWithRoot()
+	lines := beam.Create(s, "a gopher and gophers", "streams")
+	words := beam.ParDo(s, &extractFn{SmallWordLength: 7, SkipSmallWords: true}, lines)
+	passert.Equals(s, words, "gophers", "streams")
+	if err := ptest.Run(p); err != nil {
+		t.Fatalf("pipeline failed: %v", err)
+	}
+}
+
+// TestWindowLateness checks the windowing strategy of late data. Prism, the
+// local runner, can't yet run pipelines with allowed lateness, so this
+// inspects the pipeline rather than running it.
+func TestWindowLateness(t *testing.T) {
+	p, s := beam.NewPipelineWithRoot()
+	lines := beam.Create(s, "gophers")
+	Window(s, Windowing{Size: time.Minute, AllowedLateness: 5 * time.Minute}, lines)
+
+	edges, _, err := p.Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	pipeline, err := graphx.Marshal(edges, &graphx.Options{})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var found bool
+	for _, ws := range pipeline.GetComponents().GetWindowingStrategies() {
+		if ws.GetAllowedLateness() == 0 {
+			continue
+		}
+		found = true
+		if got, want := ws.GetAllowedLateness(), (5 * time.Minute).Milliseconds(); got != want {
+			t.Errorf("allowed lateness: got %dms, want %dms", got, want)
+		}
+		if got, want := ws.GetAccumulationMode(), pipepb.AccumulationMode_ACCUMULATING; got != want {
+			t.Errorf("accumulation mode: got %v, want %v", got, want)
+		}
+	}
+	if !found {
+		t.Errorf("no windowing strategy with allowed lateness in %v", pipeline.GetComponents().GetWindowingStrategies())
+	}
+}
@@ -18,6 +18,10 @@
 // The input file defaults to a public data set containing the text of King
 // Lear, by William Shakespeare. You can override it and choose your own input
 // with --input.
+//
+// With --input_topic, the pipeline instead runs in streaming mode: it reads
+// lines from Pub/Sub and writes the most common words of each window, to
+// files or to BigQuery with --output_table.
 package main
 
 import (
@@ -41,8 +45,9 @@ var (
 	// King Lear. Set this option to choose a different input file or glob.
 	input = flag.String("input", "gs://apache-beam-samples/shakespeare/kinglear.txt", "File(s) to read.")
 
-	// Set this required option to specify where to write the output.
-	output = flag.String("output", "", "Output file (required).")
+	// Set this option to specify where to write the output. It's required,
+	// unless a streaming pipeline writes to --output_table.
+	output = flag.String("output", "", "Output file (required unless streaming to --output_table).")
 )
 
 func init() {
@@ -62,6 +67,8 @@ var (
 // extractFn is a DoFn that emits the words in a given line and keeps a count for small words.
 type extractFn struct {
 	SmallWordLength int `json:"smallWordLength"`
+	// SkipSmallWords leaves small words out of the output.
+	SkipSmallWords bool `json:"skipSmallWords"`
 }
 
 // ProcessElement for extractFn processes a line at a time, emitting each word in that line
@@ -76,6 +83,9 @@ func (f *extractFn) ProcessElement(ctx context.Context, line string, emit func(s
 		// less than small_word_length
 		if len(word) < f.SmallWordLength {
 			smallWords.Inc(ctx, 1)
+			if f.SkipSmallWords {
+				continue
+			}
 		}
 		emit(word)
 	}
@@ -115,19 +125,25 @@ func main() {
 	flag.Parse()
 	beam.Init()
 
-	if *output == "" {
+	// Batch mode only writes files; streaming mode writes files or BigQuery.
+	if *output == "" && (*inputTopic == "" || *outputTable == "") {
 		log.Fatal("No output provided")
 	}
 
+	ctx := context.Background()
 	p := beam.NewPipeline()
 	s := p.Root()
 
-	lines := textio.Read(s, *input)
-	counted := CountWords(s, lines)
-	formatted := beam.ParDo(s, formatFn, counted)
-	textio.Write(s, *output, formatted)
+	if *inputTopic != "" {
+		streamingPipeline(ctx, s)
+	} else {
+		lines := textio.Read(s, *input)
+		counted := CountWords(s, lines)
+		formatted := beam.ParDo(s, formatFn, counted)
+		textio.Write(s, *output, formatted)
+	}
 
-	if err := beamx.Run(context.Background(), p); err != nil {
+	if err := beamx.Run(ctx, p); err != nil {
 		log.Fatalf("Failed to execute job: %v", err)
 	}
 }
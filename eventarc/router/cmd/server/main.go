@@ -0,0 +1,78 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The server command handles Cloud Storage, IAM audit log and Pub/Sub
+// events from several Eventarc triggers on one Cloud Run service.
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/GoogleCloudPlatform/golang-samples/eventarc/router"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/googleapis/google-cloudevents-go/cloud/auditdata"
+	"github.com/googleapis/google-cloudevents-go/cloud/storagedata"
+)
+
+func main() {
+	// disable leading timestamp, since it is automatic with Cloud Logging.
+	log.SetFlags(0)
+
+	r := router.New()
+	router.Handle(r, router.StorageObjectFinalized, imageUploaded, router.Subject("objects/images/*"))
+	router.Handle(r, router.StorageObjectFinalized, objectFinalized)
+	router.Handle(r, router.AuditLogWritten, keyCreated,
+		router.Extension("methodname", "google.iam.admin.v1.CreateServiceAccountKey"))
+	router.Handle(r, router.PubSubMessagePublished, messagePublished)
+
+	// Determine port for HTTP service.
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	log.Printf("Listening on port %s", port)
+	if err := http.ListenAndServe(":"+port, r); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func imageUploaded(ctx context.Context, e cloudevents.Event, obj *storagedata.StorageObjectData) error {
+	if obj.GetContentType() != "image/jpeg" && obj.GetContentType() != "image/png" {
+		// Retrying won't change the content type.
+		return router.Permanent(errors.New("unsupported image type " + obj.GetContentType()))
+	}
+	log.Printf("New image: gs://%s/%s (%d bytes)", obj.GetBucket(), obj.GetName(), obj.GetSize())
+	return nil
+}
+
+func objectFinalized(ctx context.Context, e cloudevents.Event, obj *storagedata.StorageObjectData) error {
+	log.Printf("New object: gs://%s/%s", obj.GetBucket(), obj.GetName())
+	return nil
+}
+
+func keyCreated(ctx context.Context, e cloudevents.Event, entry *auditdata.LogEntryData) error {
+	actor := entry.GetProtoPayload().GetAuthenticationInfo().GetPrincipalEmail()
+	principal := entry.GetProtoPayload().GetRequest().AsMap()["name"]
+	log.Printf("New Service Account Key created for %v by %s", principal, actor)
+	return nil
+}
+
+func messagePublished(ctx context.Context, e cloudevents.Event, m *router.MessagePublishedData) error {
+	log.Printf("Message %s: %s", m.Message.MessageID, m.Message.Data)
+	return nil
+}
@@ -0,0 +1,194 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package eventarctest builds HTTP requests that look like Eventarc
+// deliveries, for testing CloudEvent handlers.
+package eventarctest
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/golang-samples/eventarc/router"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Target is the URL requests are addressed to.
+const Target = "http://localhost:8080/"
+
+// Option customizes a request built by NewRequest.
+type Option func(*options)
+
+type options struct {
+	id         string
+	source     string
+	subject    string
+	ext        map[string]any
+	structured bool
+}
+
+// ID sets the event ID. Default: a new random ID.
+func ID(id string) Option {
+	return func(o *options) { o.id = id }
+}
+
+// Source sets the event source. Default: a source typical of the event
+// type.
+func Source(source string) Option {
+	return func(o *options) { o.source = source }
+}
+
+// Subject sets the event subject.
+func Subject(subject string) Option {
+	return func(o *options) { o.subject = subject }
+}
+
+// Extension sets an extension attribute.
+func Extension(name string, value any) Option {
+	return func(o *options) { o.ext[name] = value }
+}
+
+// Structured sends the event in structured content mode, with the
+// attributes and data in a JSON body, instead of binary content mode, with
+// the attributes in ce- headers.
+func Structured() Option {
+	return func(o *options) { o.structured = true }
+}
+
+// defaultSources are the sources of the event types in the router package,
+// as Eventarc sends them.
+var defaultSources = map[string]string{
+	router.StorageObjectFinalized:       "//storage.googleapis.com/projects/_/buckets/test-bucket",
+	router.StorageObjectDeleted:         "//storage.googleapis.com/projects/_/buckets/test-bucket",
+	router.StorageObjectArchived:        "//storage.googleapis.com/projects/_/buckets/test-bucket",
+	router.StorageObjectMetadataUpdated: "//storage.googleapis.com/projects/_/buckets/test-bucket",
+	router.AuditLogWritten:              "//cloudaudit.googleapis.com/projects/test-project/logs/activity",
+	router.PubSubMessagePublished:       "//pubsub.googleapis.com/projects/test-project/topics/test-topic",
+}
+
+// NewRequest returns a request delivering an event of type eventType with
+// data, which is marshaled with protojson if it is a protocol buffer
+// message, sent as is if it is a []byte, and marshaled with encoding/json
+// otherwise.
+//
+// Like Eventarc's requests, it has an Authorization header with an OIDC
+// token. The token is not validly signed.
+func NewRequest(t testing.TB, eventType string, data any, opts ...Option) *http.Request {
+	t.Helper()
+	o := &options{id: randomID(t), ext: make(map[string]any)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.source == "" {
+		o.source = defaultSources[eventType]
+		if o.source == "" {
+			o.source = "//eventarc.googleapis.com/projects/test-project"
+		}
+	}
+
+	e := cloudevents.NewEvent()
+	e.SetID(o.id)
+	e.SetType(eventType)
+	e.SetSource(o.source)
+	e.SetSubject(o.subject)
+	e.SetTime(time.Now())
+	for name, v := range o.ext {
+		e.SetExtension(name, v)
+	}
+	body, err := marshal(data)
+	if err != nil {
+		t.Fatalf("eventarctest: marshaling %T: %v", data, err)
+	}
+	if err := e.SetData(cloudevents.ApplicationJSON, body); err != nil {
+		t.Fatalf("eventarctest: SetData: %v", err)
+	}
+	if err := e.Validate(); err != nil {
+		t.Fatalf("eventarctest: invalid event: %v", err)
+	}
+
+	var req *http.Request
+	if o.structured {
+		b, err := json.Marshal(e)
+		if err != nil {
+			t.Fatalf("eventarctest: marshaling event: %v", err)
+		}
+		req, err = http.NewRequest(http.MethodPost, Target, bytes.NewReader(b))
+		if err != nil {
+			t.Fatalf("eventarctest: http.NewRequest: %v", err)
+		}
+		req.Header.Set("Content-Type", cloudevents.ApplicationCloudEventsJSON)
+	} else {
+		req, err = cloudevents.NewHTTPRequestFromEvent(context.Background(), Target, e)
+		if err != nil {
+			t.Fatalf("eventarctest: NewHTTPRequestFromEvent: %v", err)
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+token(t))
+	req.Header.Set("User-Agent", "APIs-Google; (+https://developers.google.com/webmasters/APIs-Google.html)")
+	return req
+}
+
+func marshal(data any) ([]byte, error) {
+	switch d := data.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return d, nil
+	case proto.Message:
+		return protojson.Marshal(d)
+	default:
+		return json.Marshal(d)
+	}
+}
+
+// token returns a JWT shaped like a Google-signed OIDC token, with a
+// random signature.
+func token(t testing.TB) string {
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"alg":"RS256","kid":"eventarctest","typ":"JWT"}`))
+	now := time.Now()
+	claims, err := json.Marshal(map[string]any{
+		"aud":   Target,
+		"email": "eventarc-trigger@test-project.iam.gserviceaccount.com",
+		"exp":   now.Add(time.Hour).Unix(),
+		"iat":   now.Unix(),
+		"iss":   "https://accounts.google.com",
+		"sub":   "100000000000000000000",
+	})
+	if err != nil {
+		t.Fatalf("eventarctest: marshaling claims: %v", err)
+	}
+	sig := make([]byte, 256)
+	if _, err := rand.Read(sig); err != nil {
+		t.Fatalf("eventarctest: rand.Read: %v", err)
+	}
+	return header + "." + enc.EncodeToString(claims) + "." + enc.EncodeToString(sig)
+}
+
+func randomID(t testing.TB) string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("eventarctest: rand.Read: %v", err)
+	}
+	return fmt.Sprintf("%x", b)
+}
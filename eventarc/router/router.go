@@ -0,0 +1,274 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package router dispatches CloudEvents delivered by Eventarc to typed Go
+// handlers.
+//
+// Routes match the event type and, optionally, patterns on the subject,
+// source and extension attributes. The event data is decoded into the
+// handler's type: google-cloudevents-go types and other protocol buffer
+// messages are decoded with protojson, anything else with encoding/json.
+// Events may arrive in binary or structured content mode.
+//
+// A handler that fails with an error is retried by Eventarc, unless the
+// error is marked with Permanent.
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Event types of the sources used by the Eventarc samples.
+const (
+	StorageObjectFinalized       = "google.cloud.storage.object.v1.finalized"
+	StorageObjectDeleted         = "google.cloud.storage.object.v1.deleted"
+	StorageObjectArchived        = "google.cloud.storage.object.v1.archived"
+	StorageObjectMetadataUpdated = "google.cloud.storage.object.v1.metadataUpdated"
+	AuditLogWritten              = "google.cloud.audit.log.v1.written"
+	PubSubMessagePublished       = "google.cloud.pubsub.topic.v1.messagePublished"
+)
+
+// MessagePublishedData is the data of a PubSubMessagePublished event.
+// google-cloudevents-go v0.8.0, which these samples use, has no Pub/Sub
+// payload type, so it's declared here, decoded with encoding/json.
+type MessagePublishedData struct {
+	Message      PubSubMessage `json:"message"`
+	Subscription string        `json:"subscription"`
+}
+
+// PubSubMessage is a Pub/Sub message. See
+// https://cloud.google.com/pubsub/docs/reference/rest/v1/PubsubMessage.
+type PubSubMessage struct {
+	Data        []byte            `json:"data,omitempty"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	MessageID   string            `json:"messageId"`
+	PublishTime time.Time         `json:"publishTime"`
+	OrderingKey string            `json:"orderingKey,omitempty"`
+}
+
+// Router is an http.Handler that dispatches CloudEvents to the first
+// route that matches them, in the order the routes were added.
+type Router struct {
+	// PermanentStatus is the status returned for events that can never be
+	// handled: requests that aren't CloudEvents, events with data that
+	// can't be decoded, those no route matches, and those whose handler
+	// returns a Permanent error.
+	//
+	// Eventarc triggers retry every response outside the 2xx range until
+	// the event expires, so the default is http.StatusNoContent, which
+	// drops the event. Buses that don't retry 4xx responses may use
+	// http.StatusUnprocessableEntity instead, to show failures in the
+	// request logs.
+	PermanentStatus int
+	// Logger logs events that fail. Default: slog.Default().
+	Logger *slog.Logger
+
+	routes []*route
+}
+
+// New returns an empty Router.
+func New() *Router {
+	return &Router{}
+}
+
+type route struct {
+	eventType string
+	subject   string
+	source    string
+	ext       map[string]string
+	handle    func(ctx context.Context, e cloudevents.Event) error
+}
+
+// Option adds a condition to a route.
+type Option func(*route)
+
+// Subject only matches events whose subject matches pattern, in the syntax
+// of path.Match. For example, Cloud Storage subjects look like
+// "objects/images/cat.jpg".
+func Subject(pattern string) Option {
+	mustValidate(pattern)
+	return func(r *route) { r.subject = pattern }
+}
+
+// Source only matches events whose source matches pattern, in the syntax
+// of path.Match.
+func Source(pattern string) Option {
+	mustValidate(pattern)
+	return func(r *route) { r.source = pattern }
+}
+
+// Extension only matches events with an extension attribute name whose
+// value matches pattern, in the syntax of path.Match. Audit log events,
+// for example, have "methodname" and "servicename" extensions.
+func Extension(name, pattern string) Option {
+	mustValidate(pattern)
+	return func(r *route) {
+		if r.ext == nil {
+			r.ext = make(map[string]string)
+		}
+		r.ext[name] = pattern
+	}
+}
+
+func mustValidate(pattern string) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		panic(fmt.Sprintf("router: bad pattern %q: %v", pattern, err))
+	}
+}
+
+// HandleEvent adds a route for events whose type matches eventType, in the
+// syntax of path.Match, to h. The event data is left for h to decode.
+func (r *Router) HandleEvent(eventType string, h func(ctx context.Context, e cloudevents.Event) error, opts ...Option) {
+	mustValidate(eventType)
+	rt := &route{eventType: eventType, handle: h}
+	for _, opt := range opts {
+		opt(rt)
+	}
+	r.routes = append(r.routes, rt)
+}
+
+// Handle adds a route for events whose type matches eventType to h, with
+// the event data decoded into a T.
+func Handle[T any](r *Router, eventType string, h func(ctx context.Context, e cloudevents.Event, data *T) error, opts ...Option) {
+	r.HandleEvent(eventType, func(ctx context.Context, e cloudevents.Event) error {
+		data := new(T)
+		if err := decode(e.Data(), data); err != nil {
+			return Permanent(fmt.Errorf("decoding %s data: %w", e.Type(), err))
+		}
+		return h(ctx, e, data)
+	}, opts...)
+}
+
+// decode unmarshals JSON data into v, with protojson if v is a protocol
+// buffer message.
+func decode(data []byte, v any) error {
+	if m, ok := v.(proto.Message); ok {
+		// Events may gain fields, and audit logs include @type
+		// annotations, so unknown fields are ignored.
+		return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, m)
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (rt *route) matches(e cloudevents.Event) bool {
+	if !match(rt.eventType, e.Type()) {
+		return false
+	}
+	if rt.subject != "" && !match(rt.subject, e.Subject()) {
+		return false
+	}
+	if rt.source != "" && !match(rt.source, e.Source()) {
+		return false
+	}
+	for name, pattern := range rt.ext {
+		v, ok := e.Extensions()[name]
+		if !ok || !match(pattern, fmt.Sprint(v)) {
+			return false
+		}
+	}
+	return true
+}
+
+func match(pattern, s string) bool {
+	ok, _ := path.Match(pattern, s)
+	return ok
+}
+
+// permanentError is an error that retrying the event won't fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as an error that retrying the event won't fix, so
+// the event is dropped rather than redelivered.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// ServeHTTP decodes the CloudEvent in req and dispatches it. It replies
+// with:
+//
+//   - 200 OK if the handler succeeded;
+//   - PermanentStatus if req is not a CloudEvent, or the event can never
+//     be handled;
+//   - 500 Internal Server Error if the handler failed and the event
+//     should be retried.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	logger := r.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	permanent := r.PermanentStatus
+	if permanent == 0 {
+		permanent = http.StatusNoContent
+	}
+
+	e, err := cloudevents.NewEventFromHTTPRequest(req)
+	if err != nil {
+		// Retrying a malformed request won't fix it.
+		logger.Warn("dropping request that is not a CloudEvent", "error", err)
+		w.WriteHeader(permanent)
+		return
+	}
+	attrs := []any{"id", e.ID(), "type", e.Type(), "source", e.Source(), "subject", e.Subject()}
+
+	var rt *route
+	for _, candidate := range r.routes {
+		if candidate.matches(*e) {
+			rt = candidate
+			break
+		}
+	}
+	if rt == nil {
+		logger.Warn("no route for event", attrs...)
+		w.WriteHeader(permanent)
+		return
+	}
+
+	if err := rt.handle(req.Context(), *e); err != nil {
+		attrs = append(attrs, "error", err)
+		if IsPermanent(err) {
+			logger.Error("dropping event", attrs...)
+			w.WriteHeader(permanent)
+			return
+		}
+		logger.Error("event failed, will be retried", attrs...)
+		http.Error(w, "event failed", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
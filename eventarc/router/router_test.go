@@ -0,0 +1,195 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package router_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/golang-samples/eventarc/router"
+	"github.com/GoogleCloudPlatform/golang-samples/eventarc/router/eventarctest"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/googleapis/google-cloudevents-go/cloud/auditdata"
+	"github.com/googleapis/google-cloudevents-go/cloud/storagedata"
+)
+
+func newRouter() *router.Router {
+	r := router.New()
+	r.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	return r
+}
+
+func serve(r http.Handler, req *http.Request) int {
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+func TestTypedHandlers(t *testing.T) {
+	for _, mode := range []struct {
+		name string
+		opts []eventarctest.Option
+	}{
+		{"binary", nil},
+		{"structured", []eventarctest.Option{eventarctest.Structured()}},
+	} {
+		t.Run(mode.name, func(t *testing.T) {
+			r := newRouter()
+			var gotObject, gotActor, gotMessage string
+			router.Handle(r, router.StorageObjectFinalized, func(ctx context.Context, e cloudevents.Event, obj *storagedata.StorageObjectData) error {
+				gotObject = obj.GetBucket() + "/" + obj.GetName()
+				return nil
+			})
+			router.Handle(r, router.AuditLogWritten, func(ctx context.Context, e cloudevents.Event, entry *auditdata.LogEntryData) error {
+				gotActor = entry.GetProtoPayload().GetAuthenticationInfo().GetPrincipalEmail()
+				return nil
+			})
+			router.Handle(r, router.PubSubMessagePublished, func(ctx context.Context, e cloudevents.Event, m *router.MessagePublishedData) error {
+				gotMessage = string(m.Message.Data)
+				return nil
+			})
+
+			obj := &storagedata.StorageObjectData{Bucket: "b", Name: "cat.jpg", ContentType: "image/jpeg"}
+			if got := serve(r, eventarctest.NewRequest(t, router.StorageObjectFinalized, obj, mode.opts...)); got != http.StatusOK {
+				t.Errorf("storage event: got status %d, want %d", got, http.StatusOK)
+			}
+			if want := "b/cat.jpg"; gotObject != want {
+				t.Errorf("storage event: got object %q, want %q", gotObject, want)
+			}
+
+			// Audit logs have an @type annotation that must be ignored.
+			audit := []byte(`{"protoPayload": {"@type": "type.googleapis.com/google.cloud.audit.AuditLog", "authenticationInfo": {"principalEmail": "alice@example.com"}}}`)
+			if got := serve(r, eventarctest.NewRequest(t, router.AuditLogWritten, audit, mode.opts...)); got != http.StatusOK {
+				t.Errorf("audit event: got status %d, want %d", got, http.StatusOK)
+			}
+			if want := "alice@example.com"; gotActor != want {
+				t.Errorf("audit event: got actor %q, want %q", gotActor, want)
+			}
+
+			msg := router.MessagePublishedData{Message: router.PubSubMessage{Data: []byte("hello"), MessageID: "1"}}
+			if got := serve(r, eventarctest.NewRequest(t, router.PubSubMessagePublished, msg, mode.opts...)); got != http.StatusOK {
+				t.Errorf("pubsub event: got status %d, want %d", got, http.StatusOK)
+			}
+			if want := "hello"; gotMessage != want {
+				t.Errorf("pubsub event: got message %q, want %q", gotMessage, want)
+			}
+		})
+	}
+}
+
+func TestMatching(t *testing.T) {
+	r := newRouter()
+	var got string
+	handler := func(name string) func(context.Context, cloudevents.Event) error {
+		return func(context.Context, cloudevents.Event) error {
+			got = name
+			return nil
+		}
+	}
+	r.HandleEvent(router.StorageObjectFinalized, handler("images"), router.Subject("objects/images/*"))
+	r.HandleEvent(router.StorageObjectFinalized, handler("other-bucket"), router.Source("//storage.googleapis.com/projects/_/buckets/other"))
+	r.HandleEvent(router.AuditLogWritten, handler("keys"), router.Extension("methodname", "*.CreateServiceAccountKey"))
+	r.HandleEvent("google.cloud.storage.object.v1.*", handler("storage"))
+
+	tests := []struct {
+		eventType string
+		opts      []eventarctest.Option
+		want      string
+		status    int
+	}{
+		{router.StorageObjectFinalized, []eventarctest.Option{eventarctest.Subject("objects/images/cat.jpg")}, "images", http.StatusOK},
+		{router.StorageObjectFinalized, []eventarctest.Option{eventarctest.Subject("objects/images/2026/cat.jpg")}, "storage", http.StatusOK},
+		{router.StorageObjectFinalized, []eventarctest.Option{eventarctest.Source("//storage.googleapis.com/projects/_/buckets/other")}, "other-bucket", http.StatusOK},
+		{router.StorageObjectDeleted, []eventarctest.Option{eventarctest.Subject("objects/images/cat.jpg")}, "storage", http.StatusOK},
+		{router.AuditLogWritten, []eventarctest.Option{eventarctest.Extension("methodname", "google.iam.admin.v1.CreateServiceAccountKey")}, "keys", http.StatusOK},
+		{router.AuditLogWritten, []eventarctest.Option{eventarctest.Extension("methodname", "google.iam.admin.v1.DeleteServiceAccountKey")}, "", http.StatusNoContent},
+		{router.AuditLogWritten, nil, "", http.StatusNoContent},
+		{router.PubSubMessagePublished, nil, "", http.StatusNoContent},
+	}
+	for i, tc := range tests {
+		got = ""
+		status := serve(r, eventarctest.NewRequest(t, tc.eventType, []byte(`{}`), tc.opts...))
+		if got != tc.want || status != tc.status {
+			t.Errorf("#%d %s: got route %q, status %d; want %q, %d", i, tc.eventType, got, status, tc.want, tc.status)
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	r := newRouter()
+	retry := errors.New("backend unavailable")
+	var handlerErr error
+	router.Handle(r, router.StorageObjectFinalized, func(context.Context, cloudevents.Event, *storagedata.StorageObjectData) error {
+		return handlerErr
+	})
+
+	tests := []struct {
+		name string
+		err  error
+		data []byte
+		want int
+	}{
+		{"success", nil, []byte(`{"name": "a"}`), http.StatusOK},
+		{"retry", retry, []byte(`{"name": "a"}`), http.StatusInternalServerError},
+		{"wrapped retry", errors.Join(retry), []byte(`{"name": "a"}`), http.StatusInternalServerError},
+		{"permanent", router.Permanent(retry), []byte(`{"name": "a"}`), http.StatusNoContent},
+		{"bad data", nil, []byte(`{"name": 1}`), http.StatusNoContent},
+	}
+	for _, tc := range tests {
+		handlerErr = tc.err
+		if got := serve(r, eventarctest.NewRequest(t, router.StorageObjectFinalized, tc.data)); got != tc.want {
+			t.Errorf("%s: got status %d, want %d", tc.name, got, tc.want)
+		}
+	}
+
+	r.PermanentStatus = http.StatusUnprocessableEntity
+	handlerErr = router.Permanent(retry)
+	if got := serve(r, eventarctest.NewRequest(t, router.StorageObjectFinalized, []byte(`{}`))); got != http.StatusUnprocessableEntity {
+		t.Errorf("PermanentStatus: got status %d, want %d", got, http.StatusUnprocessableEntity)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("not an event"))
+	if got := serve(r, req); got != http.StatusUnprocessableEntity {
+		t.Errorf("not an event: got status %d, want %d", got, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestIsPermanent(t *testing.T) {
+	err := errors.New("oops")
+	if router.IsPermanent(err) {
+		t.Errorf("IsPermanent(%v) = true, want false", err)
+	}
+	if p := router.Permanent(err); !router.IsPermanent(p) || !errors.Is(p, err) {
+		t.Errorf("Permanent(%v): IsPermanent = %v, errors.Is = %v; want true, true", err, router.IsPermanent(p), errors.Is(p, err))
+	}
+	if router.Permanent(nil) != nil {
+		t.Errorf("Permanent(nil) != nil")
+	}
+}
+
+func TestBadPattern(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Subject(%q) did not panic", "[")
+		}
+	}()
+	router.Subject("[")
+}
@@ -0,0 +1,224 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package background
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/translate"
+	"golang.org/x/text/language"
+)
+
+// Translator detects languages and translates text. It's implemented by
+// *translate.Client.
+type Translator interface {
+	DetectLanguage(ctx context.Context, inputs []string) ([][]translate.Detection, error)
+	Translate(ctx context.Context, inputs []string, target language.Tag, opts *translate.Options) ([]translate.Translation, error)
+}
+
+// Defaults of a Batcher.
+const (
+	DefaultWindow   = 100 * time.Millisecond
+	DefaultMaxBatch = 100
+)
+
+// batchTimeout bounds the API calls of a batch, which doesn't belong to any
+// one request.
+const batchTimeout = time.Minute
+
+// A Result is a translated text.
+type Result struct {
+	Text string
+	// Source is the detected language of the original text.
+	Source language.Tag
+}
+
+// A Batcher translates the texts requested within a short window together:
+// it detects the languages of all of them with one call, and translates
+// them with one call per target language. Texts already in the target
+// language aren't translated.
+type Batcher struct {
+	Translator Translator
+	// Window is how long a batch waits for more texts. Default:
+	// DefaultWindow.
+	Window time.Duration
+	// MaxBatch is the most texts in a batch, which is sent as soon as it's
+	// full. Default: DefaultMaxBatch.
+	MaxBatch int
+
+	mu    sync.Mutex
+	queue []*batchRequest
+	timer *time.Timer
+}
+
+type batchRequest struct {
+	text   string
+	target language.Tag
+	done   chan batchResult // Buffered, so that the batch never blocks.
+}
+
+type batchResult struct {
+	Result
+	err error
+}
+
+// Translate translates text to target with the next batch.
+func (b *Batcher) Translate(ctx context.Context, text string, target language.Tag) (Result, error) {
+	req := &batchRequest{text: text, target: target, done: make(chan batchResult, 1)}
+
+	b.mu.Lock()
+	b.queue = append(b.queue, req)
+	maxBatch := b.MaxBatch
+	if maxBatch <= 0 {
+		maxBatch = DefaultMaxBatch
+	}
+	switch {
+	case len(b.queue) >= maxBatch:
+		if b.timer != nil {
+			b.timer.Stop()
+			b.timer = nil
+		}
+		go b.flush(b.take())
+	case b.timer == nil:
+		window := b.Window
+		if window <= 0 {
+			window = DefaultWindow
+		}
+		b.timer = time.AfterFunc(window, func() {
+			b.mu.Lock()
+			b.timer = nil
+			batch := b.take()
+			b.mu.Unlock()
+			b.flush(batch)
+		})
+	}
+	b.mu.Unlock()
+
+	select {
+	case res := <-req.done:
+		return res.Result, res.err
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+}
+
+// take returns the queued requests and empties the queue. b.mu must be
+// held.
+func (b *Batcher) take() []*batchRequest {
+	batch := b.queue
+	b.queue = nil
+	return batch
+}
+
+// flush translates a batch and sends the results to its requests.
+func (b *Batcher) flush(batch []*batchRequest) {
+	if len(batch) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), batchTimeout)
+	defer cancel()
+
+	fail := func(reqs []*batchRequest, err error) {
+		for _, r := range reqs {
+			r.done <- batchResult{err: err}
+		}
+	}
+
+	texts := uniqueTexts(batch)
+	detections, err := b.Translator.DetectLanguage(ctx, texts)
+	if err != nil {
+		fail(batch, fmt.Errorf("DetectLanguage: %w", err))
+		return
+	}
+	sources := map[string]language.Tag{}
+	for i, ds := range detections {
+		if i >= len(texts) {
+			break
+		}
+		best := -1.0
+		for _, d := range ds {
+			if d.Confidence > best {
+				sources[texts[i]], best = d.Language, d.Confidence
+			}
+		}
+	}
+
+	// Route each text: no translation if it's already in the target
+	// language, otherwise with the other texts to the same language.
+	byTarget := map[language.Tag][]*batchRequest{}
+	var targets []language.Tag
+	for _, r := range batch {
+		if src, ok := sources[r.text]; ok && sameLanguage(src, r.target) {
+			r.done <- batchResult{Result: Result{Text: r.text, Source: src}}
+			continue
+		}
+		if _, ok := byTarget[r.target]; !ok {
+			targets = append(targets, r.target)
+		}
+		byTarget[r.target] = append(byTarget[r.target], r)
+	}
+
+	for _, target := range targets {
+		reqs := byTarget[target]
+		inputs := uniqueTexts(reqs)
+		outs, err := b.Translator.Translate(ctx, inputs, target, nil)
+		if err != nil {
+			fail(reqs, fmt.Errorf("Translate: %w", err))
+			continue
+		}
+		if len(outs) != len(inputs) {
+			fail(reqs, fmt.Errorf("Translate got %d translations, want %d", len(outs), len(inputs)))
+			continue
+		}
+		results := map[string]Result{}
+		for i, out := range outs {
+			src := out.Source
+			if src == language.Und {
+				src = sources[inputs[i]]
+			}
+			results[inputs[i]] = Result{Text: out.Text, Source: src}
+		}
+		for _, r := range reqs {
+			r.done <- batchResult{Result: results[r.text]}
+		}
+	}
+}
+
+// uniqueTexts returns the texts of reqs, without duplicates.
+func uniqueTexts(reqs []*batchRequest) []string {
+	var texts []string
+	seen := map[string]bool{}
+	for _, r := range reqs {
+		if !seen[r.text] {
+			seen[r.text] = true
+			texts = append(texts, r.text)
+		}
+	}
+	return texts
+}
+
+// sameLanguage reports whether a and b are the same language in the same
+// script, ignoring regions: "en-US" is "en", but "zh-TW", written in
+// Traditional Chinese, isn't "zh-CN".
+func sameLanguage(a, b language.Tag) bool {
+	baseA, _ := a.Base()
+	baseB, _ := b.Base()
+	scriptA, _ := a.Script()
+	scriptB, _ := b.Script()
+	return baseA == baseB && scriptA == scriptB
+}
@@ -4,6 +4,7 @@ go 1.21.13
 
 require (
 	cloud.google.com/go/firestore v1.17.0
+	cloud.google.com/go/functions v1.19.1
 	cloud.google.com/go/pubsub v1.44.0
 	cloud.google.com/go/translate v1.12.1
 	golang.org/x/text v0.21.0
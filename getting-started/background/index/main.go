@@ -107,7 +107,8 @@ func newApp(projectID, templateDir string) (*app, error) {
 
 // [START getting_started_background_app_list]
 
-// index lists the current translations.
+// index lists the current translations, with the status of those that
+// aren't done.
 func (a *app) index(w http.ResponseWriter, r *http.Request) {
 	docs, err := a.firestoreClient.Collection("translations").Documents(r.Context()).GetAll()
 	if err != nil {
@@ -159,20 +160,27 @@ func (a *app) requestTranslation(w http.ResponseWriter, r *http.Request) {
 		"ja": true,
 		"sw": true,
 	}
-	lang := r.PostFormValue("lang")
-	if !acceptableLanguages[lang] {
-		log.Printf("Unsupported language: %v", lang)
-		http.Error(w, fmt.Sprintf("Unsupported language: %v", lang), http.StatusBadRequest)
+	langs := r.PostForm["lang"]
+	if len(langs) == 0 {
+		log.Printf("No language")
+		http.Error(w, "No language", http.StatusBadRequest)
 		return
 	}
+	for _, lang := range langs {
+		if !acceptableLanguages[lang] {
+			log.Printf("Unsupported language: %v", lang)
+			http.Error(w, fmt.Sprintf("Unsupported language: %v", lang), http.StatusBadRequest)
+			return
+		}
+	}
 
-	log.Printf("Translation requested: %q -> %s", v, lang)
+	log.Printf("Translation requested: %q -> %v", v, langs)
 
-	t := background.Translation{
-		Original: v,
-		Language: lang,
+	req := background.Request{
+		Original:  v,
+		Languages: langs,
 	}
-	msg, err := json.Marshal(t)
+	msg, err := json.Marshal(req)
 	if err != nil {
 		log.Printf("json.Marshal: %v", err)
 		http.Error(w, "Error requesting translation", http.StatusInternalServerError)
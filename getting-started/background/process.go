@@ -0,0 +1,118 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package background
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+// A Processor translates requests and stores the translations.
+type Processor struct {
+	Store   Store
+	Batcher *Batcher
+}
+
+// Process translates the text of a request to each of its languages. A
+// translation is done once, whichever message requests it first, and again
+// only if it failed or its message is redelivered before it's done.
+func (p *Processor) Process(ctx context.Context, messageID string, data []byte) error {
+	req := Request{}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	if req.Original == "" {
+		return errors.New("Process: empty text")
+	}
+	langs, err := targetLanguages(req)
+	if err != nil {
+		return err
+	}
+
+	// Translate to every language at once, so that they share a batch.
+	var wg sync.WaitGroup
+	errs := make([]error, len(langs))
+	for i, lang := range langs {
+		wg.Add(1)
+		go func(i int, lang language.Tag) {
+			defer wg.Done()
+			errs[i] = p.translate(ctx, messageID, req.Original, lang)
+		}(i, lang)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// targetLanguages returns the languages of req, without duplicates.
+func targetLanguages(req Request) ([]language.Tag, error) {
+	names := req.Languages
+	if req.Language != "" {
+		names = append([]string{req.Language}, names...)
+	}
+	var langs []language.Tag
+	seen := map[string]bool{}
+	for _, name := range names {
+		l, err := language.Parse(name)
+		if err != nil {
+			return nil, fmt.Errorf("language.Parse: %w", err)
+		}
+		if !seen[l.String()] {
+			seen[l.String()] = true
+			langs = append(langs, l)
+		}
+	}
+	if len(langs) == 0 {
+		return nil, errors.New("Process: no target language")
+	}
+	return langs, nil
+}
+
+// translate translates original to lang, unless it's already done, and
+// stores the translation with its status.
+func (p *Processor) translate(ctx context.Context, messageID, original string, lang language.Tag) error {
+	key := docName(lang.String(), original)
+	t := Translation{
+		Original:  original,
+		Language:  lang.String(),
+		Status:    StatusPending,
+		MessageID: messageID,
+	}
+	claimed, err := p.Store.Claim(ctx, key, t)
+	if err != nil {
+		return fmt.Errorf("Claim: %w", err)
+	}
+	if !claimed {
+		return nil
+	}
+
+	t.Translated, t.OriginalLanguage, err = p.translateString(ctx, original, lang)
+	if err != nil {
+		t.Status, t.Error = StatusFailed, err.Error()
+		if err := p.Store.Put(ctx, key, t); err != nil {
+			return fmt.Errorf("Put: %w", err)
+		}
+		return err
+	}
+	t.Status = StatusDone
+	if err := p.Store.Put(ctx, key, t); err != nil {
+		return fmt.Errorf("Put: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package background
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/translate"
+	"golang.org/x/text/language"
+)
+
+// fakeTranslator "translates" a text by upper-casing it, and detects English
+// unless a text starts with "fr:".
+type fakeTranslator struct {
+	mu         sync.Mutex
+	detects    int
+	translates map[string][][]string // Inputs of the calls, by target.
+	err        error
+}
+
+func (f *fakeTranslator) DetectLanguage(ctx context.Context, inputs []string) ([][]translate.Detection, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.detects++
+	var ds [][]translate.Detection
+	for _, in := range inputs {
+		lang := language.English
+		if strings.HasPrefix(in, "fr:") {
+			lang = language.French
+		}
+		ds = append(ds, []translate.Detection{{Language: lang, Confidence: 1}})
+	}
+	return ds, nil
+}
+
+func (f *fakeTranslator) Translate(ctx context.Context, inputs []string, target language.Tag, opts *translate.Options) ([]translate.Translation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.translates == nil {
+		f.translates = map[string][][]string{}
+	}
+	f.translates[target.String()] = append(f.translates[target.String()], inputs)
+	if f.err != nil {
+		return nil, f.err
+	}
+	var outs []translate.Translation
+	for _, in := range inputs {
+		outs = append(outs, translate.Translation{Text: strings.ToUpper(in), Source: language.English})
+	}
+	return outs, nil
+}
+
+func (f *fakeTranslator) calls(target string) [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.translates[target]
+}
+
+// memoryStore is a Store in memory.
+type memoryStore struct {
+	mu           sync.Mutex
+	translations map[string]Translation
+}
+
+func (s *memoryStore) Claim(ctx context.Context, key string, t Translation) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, ok := s.translations[key]; ok && !canClaim(old, t.MessageID) {
+		return false, nil
+	}
+	s.translations[key] = t
+	return true, nil
+}
+
+func (s *memoryStore) Put(ctx context.Context, key string, t Translation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.translations[key] = t
+	return nil
+}
+
+func (s *memoryStore) get(lang, original string) Translation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.translations[docName(lang, original)]
+}
+
+func newTestProcessor() (*Processor, *fakeTranslator, *memoryStore) {
+	tr := &fakeTranslator{}
+	store := &memoryStore{translations: map[string]Translation{}}
+	p := &Processor{
+		Store:   store,
+		Batcher: &Batcher{Translator: tr, Window: 100 * time.Millisecond},
+	}
+	return p, tr, store
+}
+
+func message(t *testing.T, req Request) []byte {
+	t.Helper()
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	return data
+}
+
+func TestProcessBatches(t *testing.T) {
+	ctx := context.Background()
+	p, tr, store := newTestProcessor()
+
+	// Messages processed together share a batch.
+	var wg sync.WaitGroup
+	for i, text := range []string{"me", "you", "fr:moi"} {
+		wg.Add(1)
+		go func(id, text string) {
+			defer wg.Done()
+			data := message(t, Request{Original: text, Languages: []string{"fr", "de"}})
+			if err := p.Process(ctx, id, data); err != nil {
+				t.Errorf("Process(%q): %v", text, err)
+			}
+		}(string(rune('1'+i)), text)
+	}
+	wg.Wait()
+
+	if tr.detects != 1 {
+		t.Errorf("got %d DetectLanguage calls, want 1", tr.detects)
+	}
+	if got := tr.calls("fr"); len(got) != 1 || len(got[0]) != 2 {
+		t.Errorf("got Translate calls to fr %q, want one with 2 texts", got)
+	}
+	if got := tr.calls("de"); len(got) != 1 || len(got[0]) != 3 {
+		t.Errorf("got Translate calls to de %q, want one with 3 texts", got)
+	}
+
+	for _, tc := range []struct {
+		lang, original string
+		want           Translation
+	}{
+		{"de", "me", Translation{Translated: "ME", OriginalLanguage: "en"}},
+		{"fr", "you", Translation{Translated: "YOU", OriginalLanguage: "en"}},
+		// Already in French: not translated.
+		{"fr", "fr:moi", Translation{Translated: "fr:moi", OriginalLanguage: "fr"}},
+	} {
+		got := store.get(tc.lang, tc.original)
+		if got.Status != StatusDone || got.Translated != tc.want.Translated || got.OriginalLanguage != tc.want.OriginalLanguage {
+			t.Errorf("got translation of %q to %s %+v, want %+v", tc.original, tc.lang, got, tc.want)
+		}
+	}
+}
+
+func TestProcessOnce(t *testing.T) {
+	ctx := context.Background()
+	p, tr, store := newTestProcessor()
+	data := message(t, Request{Original: "me", Language: "fr"})
+
+	if err := p.Process(ctx, "1", data); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	// A redelivery, and another message with the same request.
+	if err := p.Process(ctx, "1", data); err != nil {
+		t.Fatalf("Process again: %v", err)
+	}
+	if err := p.Process(ctx, "2", data); err != nil {
+		t.Fatalf("Process other message: %v", err)
+	}
+	if got := tr.calls("fr"); len(got) != 1 {
+		t.Errorf("got %d Translate calls, want 1", len(got))
+	}
+	if got := store.get("fr", "me"); got.Status != StatusDone || got.MessageID != "1" {
+		t.Errorf("got %+v, want done by message 1", got)
+	}
+}
+
+func TestProcessRedeliveredPending(t *testing.T) {
+	ctx := context.Background()
+	p, tr, store := newTestProcessor()
+	pending := Translation{Original: "me", Language: "fr", Status: StatusPending, MessageID: "1"}
+	store.translations[docName("fr", "me")] = pending
+	data := message(t, Request{Original: "me", Language: "fr"})
+
+	// Another message leaves it to message 1.
+	if err := p.Process(ctx, "2", data); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if got := tr.calls("fr"); len(got) != 0 {
+		t.Fatalf("got %d Translate calls, want 0", len(got))
+	}
+	// Message 1, delivered again, was interrupted: it's translated.
+	if err := p.Process(ctx, "1", data); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if got := store.get("fr", "me"); got.Status != StatusDone {
+		t.Errorf("got status %q, want %q", got.Status, StatusDone)
+	}
+}
+
+func TestProcessFailed(t *testing.T) {
+	ctx := context.Background()
+	p, tr, store := newTestProcessor()
+	tr.err = errors.New("quota exceeded")
+	data := message(t, Request{Original: "me", Languages: []string{"fr"}})
+
+	if err := p.Process(ctx, "1", data); err == nil {
+		t.Fatalf("Process got no error, want one")
+	}
+	got := store.get("fr", "me")
+	if got.Status != StatusFailed || !strings.Contains(got.Error, "quota exceeded") {
+		t.Errorf("got %+v, want failed with the error", got)
+	}
+
+	// Failed translations are done again by the next request.
+	tr.err = nil
+	if err := p.Process(ctx, "2", data); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if got := store.get("fr", "me"); got.Status != StatusDone || got.Error != "" {
+		t.Errorf("got %+v, want done", got)
+	}
+}
+
+func TestProcessInvalid(t *testing.T) {
+	p, _, _ := newTestProcessor()
+	for _, data := range []string{
+		`not json`,
+		`{"languages": ["fr"]}`,
+		`{"original": "me"}`,
+		`{"original": "me", "languages": ["not a language!"]}`,
+	} {
+		if err := p.Process(context.Background(), "1", []byte(data)); err == nil {
+			t.Errorf("Process(%s) got no error, want one", data)
+		}
+	}
+}
+
+func TestBatcherMaxBatch(t *testing.T) {
+	tr := &fakeTranslator{}
+	// The window is too long to wait for: full batches are sent at once.
+	b := &Batcher{Translator: tr, Window: time.Hour, MaxBatch: 2}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, text := range []string{"a", "b", "c", "d"} {
+		wg.Add(1)
+		go func(text string) {
+			defer wg.Done()
+			res, err := b.Translate(ctx, text, language.German)
+			if err != nil || res.Text != strings.ToUpper(text) {
+				t.Errorf("Translate(%q) got %q, %v", text, res.Text, err)
+			}
+		}(text)
+	}
+	wg.Wait()
+	if got := tr.calls("de"); len(got) != 2 {
+		t.Errorf("got %d Translate calls, want 2", len(got))
+	}
+}
+
+func TestSameLanguage(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want bool
+	}{
+		{"en", "en-US", true},
+		{"zh", "zh-CN", true},
+		{"zh-Hant", "zh-TW", true},
+		{"zh-Hans", "zh-Hant", false},
+		{"zh-TW", "zh-CN", false},
+		{"sr-Latn", "sr-Cyrl", false},
+		{"en", "fr", false},
+	} {
+		if got := sameLanguage(language.MustParse(tc.a), language.MustParse(tc.b)); got != tc.want {
+			t.Errorf("sameLanguage(%s, %s) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
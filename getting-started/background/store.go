@@ -0,0 +1,112 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package background
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Store holds translations by key.
+type Store interface {
+	// Claim saves a pending translation t under key, unless another
+	// message already did the translation or is doing it. It reports
+	// whether t was saved.
+	Claim(ctx context.Context, key string, t Translation) (bool, error)
+	// Put saves t under key.
+	Put(ctx context.Context, key string, t Translation) error
+}
+
+// docName returns the key of the translation of original to lang, a hash of
+// both, so that the same translation is done once. It's also the name of its
+// Firestore document.
+func docName(lang, original string) string {
+	sum := sha512.Sum512([]byte(fmt.Sprintf("%s/%s", lang, original)))
+	// Base64 encode the sum to make a nice string. The [:] converts the byte
+	// array to a byte slice.
+	key := base64.StdEncoding.EncodeToString(sum[:])
+	// Document names cannot contain "/".
+	return strings.Replace(key, "/", "-", -1)
+}
+
+// canClaim reports whether a message can claim the translation old: if it
+// failed, or if the same message is delivered again before it was done.
+// Translations saved without a status are done.
+func canClaim(old Translation, messageID string) bool {
+	switch old.Status {
+	case StatusFailed:
+		return true
+	case StatusPending:
+		return old.MessageID == messageID
+	default:
+		return false
+	}
+}
+
+// FirestoreStore stores translations in a Firestore collection, with keys
+// as document names.
+type FirestoreStore struct {
+	Client     *firestore.Client
+	Collection string
+}
+
+var _ Store = &FirestoreStore{}
+
+// Claim saves a pending translation in a transaction, to prevent
+// concurrent duplicate translations.
+func (s *FirestoreStore) Claim(ctx context.Context, key string, t Translation) (bool, error) {
+	ref := s.Client.Collection(s.Collection).Doc(key)
+	var claimed bool
+	err := s.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		claimed = false
+		doc, err := tx.Get(ref)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return fmt.Errorf("Get: %w", err)
+		}
+		if doc.Exists() {
+			var old Translation
+			if err := doc.DataTo(&old); err != nil {
+				return fmt.Errorf("DataTo: %w", err)
+			}
+			if !canClaim(old, t.MessageID) {
+				return nil
+			}
+		}
+		if err := tx.Set(ref, t); err != nil {
+			return fmt.Errorf("Set: %w", err)
+		}
+		claimed = true
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("RunTransaction: %w", err)
+	}
+	return claimed, nil
+}
+
+// Put saves a translation.
+func (s *FirestoreStore) Put(ctx context.Context, key string, t Translation) error {
+	if _, err := s.Client.Collection(s.Collection).Doc(key).Set(ctx, t); err != nil {
+		return fmt.Errorf("Set: %w", err)
+	}
+	return nil
+}
@@ -16,23 +16,26 @@
 
 // Package background contains a Cloud Function to translate text.
 // The function listens to Pub/Sub, does the translations, and stores the
-// result in Firestore.
+// result in Firestore. Translations requested at about the same time are
+// batched, and each is done once, however often it's requested.
 package background
 
 import (
 	"context"
-	"crypto/sha512"
-	"encoding/base64"
-	"encoding/json"
 	"fmt"
 	"os"
-	"strings"
 
 	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/functions/metadata"
 	"cloud.google.com/go/translate"
 	"golang.org/x/text/language"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+)
+
+// Statuses of a Translation.
+const (
+	StatusPending = "pending"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
 )
 
 // A Translation contains the original and translated text.
@@ -41,12 +44,28 @@ type Translation struct {
 	Translated       string `json:"translated"`
 	OriginalLanguage string `json:"original_language"`
 	Language         string `json:"language"`
+
+	// Status is StatusPending, StatusDone or StatusFailed, with Error.
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// MessageID is the Pub/Sub message that requested the translation.
+	MessageID string `json:"message_id"`
+}
+
+// A Request is the payload of a message, asking for translations of a text.
+type Request struct {
+	Original  string   `json:"original"`
+	Languages []string `json:"languages"`
+	// Language is a single target language, as requested by older
+	// versions of the app.
+	Language string `json:"language,omitempty"`
 }
 
 // Clients reused between function invocations.
 var (
 	translateClient *translate.Client
 	firestoreClient *firestore.Client
+	processor       *Processor
 )
 
 // PubSubMessage is the payload of a Pub/Sub event.
@@ -59,8 +78,8 @@ type PubSubMessage struct {
 
 // [START getting_started_background_translate_init]
 
-// initializeClients creates translateClient and firestoreClient if they haven't
-// been created yet.
+// initializeClients creates translateClient, firestoreClient and processor
+// if they haven't been created yet.
 func initializeClients() error {
 	projectID := os.Getenv("GOOGLE_CLOUD_PROJECT")
 	if projectID == "" {
@@ -85,6 +104,14 @@ func initializeClients() error {
 			return fmt.Errorf("firestore.NewClient: %w", err)
 		}
 	}
+	if processor == nil {
+		// The batcher is shared by the concurrent invocations of an
+		// instance.
+		processor = &Processor{
+			Store:   &FirestoreStore{Client: firestoreClient, Collection: "translations"},
+			Batcher: &Batcher{Translator: translateClient},
+		}
+	}
 	return nil
 }
 
@@ -96,22 +123,13 @@ func initializeClients() error {
 // * the translated text,
 // * the automatically detected source language, and
 // * an error.
-func translateString(ctx context.Context, text string, lang string) (translated string, originalLang string, err error) {
-	l, err := language.Parse(lang)
-	if err != nil {
-		return "", "", fmt.Errorf("language.Parse: %w", err)
-	}
-
-	outs, err := translateClient.Translate(ctx, []string{text}, l, nil)
+// Texts translated at about the same time share a batch of API calls.
+func (p *Processor) translateString(ctx context.Context, text string, lang language.Tag) (translated string, originalLang string, err error) {
+	res, err := p.Batcher.Translate(ctx, text, lang)
 	if err != nil {
-		return "", "", fmt.Errorf("Translate: %w", err)
-	}
-
-	if len(outs) < 1 {
-		return "", "", fmt.Errorf("Translate got %d translations, need at least 1", len(outs))
+		return "", "", fmt.Errorf("Translate(%s): %w", lang, err)
 	}
-
-	return outs[0].Text, outs[0].Source.String(), nil
+	return res.Text, res.Source.String(), nil
 }
 
 // [END getting_started_background_translate_string]
@@ -120,51 +138,17 @@ func translateString(ctx context.Context, text string, lang string) (translated
 
 // Translate translates the given message and stores the result in Firestore.
 func Translate(ctx context.Context, m PubSubMessage) error {
-	initializeClients()
-
-	t := Translation{}
-	if err := json.Unmarshal(m.Data, &t); err != nil {
-		return fmt.Errorf("json.Unmarshal: %w", err)
+	if err := initializeClients(); err != nil {
+		return err
 	}
 
-	// Use a unique document name to prevent duplicate translations.
-	key := fmt.Sprintf("%s/%s", t.Language, t.Original)
-	sum := sha512.Sum512([]byte(key))
-	// Base64 encode the sum to make a nice string. The [:] converts the byte
-	// array to a byte slice.
-	docName := base64.StdEncoding.EncodeToString(sum[:])
-	// The document name cannot contain "/".
-	docName = strings.Replace(docName, "/", "-", -1)
-	ref := firestoreClient.Collection("translations").Doc(docName)
-
-	// Run in a transation to prevent concurrent duplicate translations.
-	err := firestoreClient.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
-		doc, err := tx.Get(ref)
-		if err != nil && status.Code(err) != codes.NotFound {
-			return fmt.Errorf("Get: %w", err)
-		}
-		// Do nothing if the document already exists.
-		if doc.Exists() {
-			return nil
-		}
-
-		translated, originalLang, err := translateString(ctx, t.Original, t.Language)
-		if err != nil {
-			return fmt.Errorf("translateString: %w", err)
-		}
-		t.Translated = translated
-		t.OriginalLanguage = originalLang
-
-		if err := tx.Set(ref, t); err != nil {
-			return fmt.Errorf("Set: %w", err)
-		}
-		return nil
-	})
-
-	if err != nil {
-		return fmt.Errorf("RunTransaction: %w", err)
+	// The event ID is the ID of the message, which is the same when it's
+	// delivered again.
+	var messageID string
+	if meta, err := metadata.FromContext(ctx); err == nil {
+		messageID = meta.EventID
 	}
-	return nil
+	return processor.Process(ctx, messageID, m.Data)
 }
 
 // [END getting_started_background_translate]
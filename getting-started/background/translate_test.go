@@ -82,6 +82,7 @@ func TestTranslate(t *testing.T) {
 				OriginalLanguage: "en",
 				Language:         "fr",
 				Translated:       "Moi",
+				Status:           StatusDone,
 			}
 			if translations[0] != want {
 				errorf("Translate got:\n%+v\nWant:\n%+v", translations[0], want)
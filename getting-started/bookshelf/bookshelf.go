@@ -16,12 +16,15 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 
 	"cloud.google.com/go/errorreporting"
 	"cloud.google.com/go/storage"
+	"github.com/GoogleCloudPlatform/golang-samples/getting-started/bookshelf/internal/covers"
+	"google.golang.org/api/idtoken"
 )
 
 // Book holds metadata about a book.
@@ -32,14 +35,23 @@ type Book struct {
 	PublishedDate string
 	ImageURL      string
 	Description   string
+
+	// ThumbnailURL is set with ImageURL when a cover is processed (see
+	// covers.go).
+	ThumbnailURL string
 }
 
+// errBookNotFound is wrapped by the errors of GetBook for IDs without a
+// book.
+var errBookNotFound = errors.New("book not found")
+
 // BookDatabase provides thread-safe access to a database of books.
 type BookDatabase interface {
 	// ListBooks returns a list of books, ordered by title.
 	ListBooks(context.Context) ([]*Book, error)
 
-	// GetBook retrieves a book by its ID.
+	// GetBook retrieves a book by its ID, or returns an error wrapping
+	// errBookNotFound.
 	GetBook(ctx context.Context, id string) (*Book, error)
 
 	// AddBook saves a given book, assigning it a new ID.
@@ -48,8 +60,13 @@ type BookDatabase interface {
 	// DeleteBook removes a given book by its ID.
 	DeleteBook(ctx context.Context, id string) error
 
-	// UpdateBook updates the entry for a given book.
+	// UpdateBook updates the entry for a given book, except for its cover.
 	UpdateBook(ctx context.Context, b *Book) error
+
+	// SetCover sets the cover URLs of the book with the given ID, leaving
+	// its other fields as they are, or returns an error wrapping
+	// errBookNotFound.
+	SetCover(ctx context.Context, id, imageURL, thumbnailURL string) error
 }
 
 // Bookshelf holds a BookDatabase and storage info.
@@ -59,6 +76,19 @@ type Bookshelf struct {
 	StorageBucket     *storage.BucketHandle
 	StorageBucketName string
 
+	// Covers holds the staged uploads and the processed covers. It can be
+	// replaced by a covers.FileStore for tests.
+	Covers covers.Store
+
+	// CoverEventsServiceAccount is the service account whose ID tokens, for
+	// CoverEventsAudience, authenticate the requests of /covers/events.
+	// Without it, every request is rejected. See coverEventsHandler.
+	CoverEventsServiceAccount string
+	CoverEventsAudience       string
+
+	// validateIDToken validates ID tokens and can be overridden for tests.
+	validateIDToken func(ctx context.Context, token, audience string) (*idtoken.Payload, error)
+
 	// logWriter is used for request logging and can be overridden for tests.
 	//
 	// See https://cloud.google.com/logging/docs/setup/go for how to use the
@@ -100,6 +130,8 @@ func NewBookshelf(projectID string, db BookDatabase) (*Bookshelf, error) {
 		DB:                db,
 		StorageBucketName: bucketName,
 		StorageBucket:     storageClient.Bucket(bucketName),
+		validateIDToken:   idtoken.Validate,
 	}
+	b.Covers = &covers.BucketStore{Bucket: b.StorageBucket, Name: bucketName}
 	return b, nil
 }
@@ -0,0 +1,196 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/golang-samples/getting-started/bookshelf/internal/covers"
+)
+
+// Uploaded images are staged as "staging/<book ID>/<token>", and their
+// covers written as "covers/<book ID>/<token>-<variant>.<format>".
+const (
+	stagingPrefix = "staging"
+	coversPrefix  = "covers"
+)
+
+// processCover makes the covers of a staged image, sets the cover URLs of
+// the book and deletes the staged image. Only the cover URLs are written,
+// so that edits made to the book meanwhile are kept.
+//
+// Events can be delivered more than once: the covers of an image always
+// have the same names, and an image that's gone was already processed.
+func (b *Bookshelf) processCover(ctx context.Context, name string) error {
+	parts := strings.Split(name, "/")
+	if len(parts) != 3 || parts[0] != stagingPrefix || parts[1] == "" || parts[2] == "" {
+		return fmt.Errorf("processCover: invalid staged image %q", name)
+	}
+	bookID, token := parts[1], parts[2]
+
+	data, err := b.Covers.Get(ctx, name)
+	if errors.Is(err, covers.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("processCover: Get: %w", err)
+	}
+
+	images, err := covers.Process(data, covers.DefaultVariants)
+	if errors.Is(err, covers.ErrUnsupported) || errors.Is(err, covers.ErrTooLarge) {
+		// Retrying won't help.
+		fmt.Fprintf(b.logWriter, "Discarding staged image %q: %v\n", name, err)
+		return b.Covers.Delete(ctx, name)
+	}
+	if err != nil {
+		return fmt.Errorf("processCover: %w", err)
+	}
+
+	if _, err := b.DB.GetBook(ctx, bookID); errors.Is(err, errBookNotFound) {
+		// The book was deleted since the upload.
+		return b.Covers.Delete(ctx, name)
+	} else if err != nil {
+		return fmt.Errorf("processCover: GetBook: %w", err)
+	}
+
+	var imageURL, thumbnailURL string
+	for _, m := range images {
+		v := m.Variant
+		coverName := path.Join(coversPrefix, bookID, fmt.Sprintf("%s-%s.%s", token, v.Name, v.Format))
+		if err := b.Covers.Put(ctx, coverName, v.Format.ContentType(), m.Data, true); err != nil {
+			return fmt.Errorf("processCover: Put: %w", err)
+		}
+		url := b.Covers.URL(coverName)
+		switch v.Name {
+		case "cover":
+			imageURL = url
+		case "thumbnail":
+			thumbnailURL = url
+		}
+	}
+	err = b.DB.SetCover(ctx, bookID, imageURL, thumbnailURL)
+	if err != nil && !errors.Is(err, errBookNotFound) {
+		return fmt.Errorf("processCover: SetCover: %w", err)
+	}
+	return b.Covers.Delete(ctx, name)
+}
+
+// coverEventsHandler processes the images staged in the bucket, given the
+// events of the objects created in it. It accepts the events of Eventarc:
+//
+//	gcloud eventarc triggers create bookshelf-covers \
+//	    --destination-run-service=bookshelf --destination-run-path=/covers/events \
+//	    --event-filters=type=google.cloud.storage.object.v1.finalized \
+//	    --event-filters=bucket=my-project_bucket \
+//	    --service-account=PROJECT_NUMBER-compute@developer.gserviceaccount.com
+//
+// and the Pub/Sub notifications of Cloud Storage, pushed by a subscription:
+//
+//	gcloud storage buckets notifications create gs://my-project_bucket \
+//	    --topic=bookshelf-covers --event-types=OBJECT_FINALIZE \
+//	    --object-prefix=staging/
+//	gcloud pubsub subscriptions create bookshelf-covers \
+//	    --topic=bookshelf-covers \
+//	    --push-endpoint=https://my-project.appspot.com/covers/events \
+//	    --push-auth-service-account=bookshelf-push@my-project.iam.gserviceaccount.com
+//
+// Both retry until the handler succeeds. Requests must carry an ID token of
+// the service account of the trigger or subscription, set in the
+// COVER_EVENTS_SERVICE_ACCOUNT environment variable, for the audience in
+// COVER_EVENTS_AUDIENCE, which defaults to the push endpoint of the app.
+func (b *Bookshelf) coverEventsHandler(w http.ResponseWriter, r *http.Request) *appError {
+	if err := b.authenticateEvent(r); err != nil {
+		// Not worth reporting: anyone can send requests.
+		fmt.Fprintf(b.logWriter, "Rejected cover event: %v\n", err)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return nil
+	}
+	name, err := objectFromEvent(r)
+	if err != nil {
+		e := b.appErrorf(r, err, "could not parse event: %v", err)
+		e.code = http.StatusBadRequest
+		return e
+	}
+	if name == "" || !strings.HasPrefix(name, stagingPrefix+"/") {
+		// Not a staged image.
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	if err := b.processCover(r.Context(), name); err != nil {
+		return b.appErrorf(r, err, "could not process cover: %v", err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// authenticateEvent checks that r carries an ID token of the service
+// account of the cover events.
+func (b *Bookshelf) authenticateEvent(r *http.Request) error {
+	if b.CoverEventsServiceAccount == "" {
+		return errors.New("COVER_EVENTS_SERVICE_ACCOUNT is not set")
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return errors.New("no bearer token")
+	}
+	payload, err := b.validateIDToken(r.Context(), token, b.CoverEventsAudience)
+	if err != nil {
+		return fmt.Errorf("idtoken.Validate: %w", err)
+	}
+	email, _ := payload.Claims["email"].(string)
+	if verified, _ := payload.Claims["email_verified"].(bool); !verified || email != b.CoverEventsServiceAccount {
+		return fmt.Errorf("token of %q, want %q", email, b.CoverEventsServiceAccount)
+	}
+	return nil
+}
+
+// objectFromEvent returns the name of the object created according to an
+// Eventarc event or a Pub/Sub push request, or "" for other events.
+func objectFromEvent(r *http.Request) (string, error) {
+	// Eventarc sends CloudEvents in binary mode: the attributes are
+	// headers, and the body is the object.
+	if ceType := r.Header.Get("Ce-Type"); ceType != "" {
+		if ceType != "google.cloud.storage.object.v1.finalized" {
+			return "", nil
+		}
+		var object struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&object); err != nil {
+			return "", fmt.Errorf("json.Decode: %w", err)
+		}
+		return object.Name, nil
+	}
+
+	var push struct {
+		Message struct {
+			Attributes map[string]string `json:"attributes"`
+		} `json:"message"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&push); err != nil {
+		return "", fmt.Errorf("json.Decode: %w", err)
+	}
+	attrs := push.Message.Attributes
+	if attrs["eventType"] != "OBJECT_FINALIZE" {
+		return "", nil
+	}
+	return attrs["objectId"], nil
+}
@@ -0,0 +1,260 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/golang-samples/getting-started/bookshelf/internal/covers"
+	"google.golang.org/api/idtoken"
+)
+
+const testServiceAccount = "push@my-project.iam.gserviceaccount.com"
+
+func newCoverTestBookshelf(t *testing.T) *Bookshelf {
+	return &Bookshelf{
+		DB:                        newMemoryDB(),
+		Covers:                    &covers.FileStore{Dir: t.TempDir(), BaseURL: "http://localhost/files"},
+		logWriter:                 io.Discard,
+		CoverEventsServiceAccount: testServiceAccount,
+		CoverEventsAudience:       "https://my-project.appspot.com/covers/events",
+		validateIDToken:           fakeValidateIDToken,
+	}
+}
+
+// fakeValidateIDToken accepts tokens of the form "<audience> <email>".
+func fakeValidateIDToken(ctx context.Context, token, audience string) (*idtoken.Payload, error) {
+	aud, email, ok := strings.Cut(token, " ")
+	if !ok || aud != audience {
+		return nil, errors.New("invalid token")
+	}
+	return &idtoken.Payload{Audience: aud, Claims: map[string]interface{}{"email": email, "email_verified": true}}, nil
+}
+
+// editingDB is a BookDatabase where the book is edited by someone else
+// right after GetBook.
+type editingDB struct {
+	BookDatabase
+}
+
+func (db editingDB) GetBook(ctx context.Context, id string) (*Book, error) {
+	book, err := db.BookDatabase.GetBook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	got := *book
+	if err := db.BookDatabase.UpdateBook(ctx, &Book{ID: id, Title: "edited"}); err != nil {
+		return nil, err
+	}
+	return &got, nil
+}
+
+func testCover(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1000, 1500))); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessCover(t *testing.T) {
+	ctx := context.Background()
+	b := newCoverTestBookshelf(t)
+	id, err := b.DB.AddBook(ctx, &Book{Title: "book mcbook"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const name = "staging/1/token"
+	if err := b.Covers.Put(ctx, name, "image/png", testCover(t), false); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	// The second time, as if the event was delivered twice, does nothing.
+	for i := 0; i < 2; i++ {
+		if err := b.processCover(ctx, name); err != nil {
+			t.Fatalf("processCover: %v", err)
+		}
+	}
+
+	book, err := b.DB.GetBook(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct{ got, want string }{
+		{book.ImageURL, "http://localhost/files/covers/1/token-cover.jpeg"},
+		{book.ThumbnailURL, "http://localhost/files/covers/1/token-thumbnail.jpeg"},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("got URL %q, want %q", tc.got, tc.want)
+		}
+	}
+	if _, err := b.Covers.Get(ctx, "covers/1/token-thumbnail.jpeg"); err != nil {
+		t.Errorf("Get thumbnail: %v", err)
+	}
+	if _, err := b.Covers.Get(ctx, name); !errors.Is(err, covers.ErrNotExist) {
+		t.Errorf("Get staged image got err %v, want ErrNotExist", err)
+	}
+}
+
+func TestProcessCoverKeepsEdits(t *testing.T) {
+	ctx := context.Background()
+	b := newCoverTestBookshelf(t)
+	b.DB = editingDB{b.DB}
+	id, err := b.DB.AddBook(ctx, &Book{Title: "book mcbook"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const name = "staging/1/token"
+	if err := b.Covers.Put(ctx, name, "image/png", testCover(t), false); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := b.processCover(ctx, name); err != nil {
+		t.Fatalf("processCover: %v", err)
+	}
+
+	book, err := b.DB.(editingDB).BookDatabase.GetBook(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.Title != "edited" || book.ImageURL == "" {
+		t.Errorf("got book %+v, want the edited title and a cover", book)
+	}
+
+	// Editing the book keeps its cover.
+	if err := b.DB.UpdateBook(ctx, &Book{ID: id, Title: "edited again"}); err != nil {
+		t.Fatal(err)
+	}
+	if book, err := b.DB.(editingDB).BookDatabase.GetBook(ctx, id); err != nil || book.ImageURL == "" {
+		t.Errorf("got book %+v, %v after UpdateBook, want a cover", book, err)
+	}
+}
+
+func TestProcessCoverDiscards(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name  string
+		data  []byte
+		addID bool
+	}{
+		{name: "invalid image", data: []byte("not an image"), addID: true},
+		{name: "deleted book", data: testCover(t)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := newCoverTestBookshelf(t)
+			if tc.addID {
+				if _, err := b.DB.AddBook(ctx, &Book{Title: "book mcbook"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			const name = "staging/1/token"
+			if err := b.Covers.Put(ctx, name, "image/png", tc.data, false); err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+			if err := b.processCover(ctx, name); err != nil {
+				t.Fatalf("processCover: %v", err)
+			}
+			if _, err := b.Covers.Get(ctx, name); !errors.Is(err, covers.ErrNotExist) {
+				t.Errorf("Get staged image got err %v, want ErrNotExist", err)
+			}
+		})
+	}
+}
+
+func TestCoverEventsHandler(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name   string
+		header http.Header
+		body   string
+	}{
+		{
+			name:   "eventarc",
+			header: http.Header{"Ce-Type": {"google.cloud.storage.object.v1.finalized"}},
+			body:   `{"bucket": "my-project_bucket", "name": "staging/1/token"}`,
+		},
+		{
+			name: "pubsub",
+			body: `{"message": {"attributes": {"eventType": "OBJECT_FINALIZE", "objectId": "staging/1/token"}}}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := newCoverTestBookshelf(t)
+			id, err := b.DB.AddBook(ctx, &Book{Title: "book mcbook"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := b.Covers.Put(ctx, "staging/1/token", "image/png", testCover(t), false); err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+
+			r := httptest.NewRequest("POST", "/covers/events", strings.NewReader(tc.body))
+			for k, v := range tc.header {
+				r.Header[k] = v
+			}
+			r.Header.Set("Authorization", "Bearer "+b.CoverEventsAudience+" "+testServiceAccount)
+			w := httptest.NewRecorder()
+			appHandler(b.coverEventsHandler).ServeHTTP(w, r)
+			if w.Code != http.StatusNoContent {
+				t.Fatalf("got status %d, want %d: %s", w.Code, http.StatusNoContent, w.Body)
+			}
+
+			book, err := b.DB.GetBook(ctx, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if book.ThumbnailURL == "" {
+				t.Errorf("got no ThumbnailURL after the event")
+			}
+		})
+	}
+}
+
+func TestCoverEventsHandlerUnauthenticated(t *testing.T) {
+	const body = `{"message": {"attributes": {"eventType": "OBJECT_FINALIZE", "objectId": "staging/1/token"}}}`
+	for _, tc := range []struct {
+		name, authorization string
+		serviceAccount      string
+	}{
+		{name: "no token", serviceAccount: testServiceAccount},
+		{name: "invalid token", authorization: "Bearer nonsense", serviceAccount: testServiceAccount},
+		{name: "other audience", authorization: "Bearer https://example.com " + testServiceAccount, serviceAccount: testServiceAccount},
+		{name: "other account", authorization: "Bearer https://my-project.appspot.com/covers/events someone@example.com", serviceAccount: testServiceAccount},
+		{name: "no service account", authorization: "Bearer https://my-project.appspot.com/covers/events " + testServiceAccount},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := newCoverTestBookshelf(t)
+			b.CoverEventsServiceAccount = tc.serviceAccount
+			r := httptest.NewRequest("POST", "/covers/events", strings.NewReader(body))
+			if tc.authorization != "" {
+				r.Header.Set("Authorization", tc.authorization)
+			}
+			w := httptest.NewRecorder()
+			appHandler(b.coverEventsHandler).ServeHTTP(w, r)
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("got status %d, want %d", w.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
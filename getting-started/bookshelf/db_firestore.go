@@ -21,6 +21,8 @@ import (
 
 	"cloud.google.com/go/firestore"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // firestoreDB persists books to Cloud Firestore.
@@ -62,6 +64,9 @@ func (db *firestoreDB) Close(context.Context) error {
 // Book retrieves a book by its ID.
 func (db *firestoreDB) GetBook(ctx context.Context, id string) (*Book, error) {
 	ds, err := db.client.Collection(db.collection).Doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, fmt.Errorf("firestoredb: Get: %w with ID %q", errBookNotFound, id)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("firestoredb: Get: %w", err)
 	}
@@ -90,14 +95,33 @@ func (db *firestoreDB) DeleteBook(ctx context.Context, id string) error {
 	return nil
 }
 
-// UpdateBook updates the entry for a given book.
+// UpdateBook updates the entry for a given book, except for its cover,
+// which SetCover may be setting at the same time.
 func (db *firestoreDB) UpdateBook(ctx context.Context, b *Book) error {
-	if _, err := db.client.Collection(db.collection).Doc(b.ID).Set(ctx, b); err != nil {
+	details := firestore.Merge([]string{"ID"}, []string{"Title"}, []string{"Author"},
+		[]string{"PublishedDate"}, []string{"Description"})
+	if _, err := db.client.Collection(db.collection).Doc(b.ID).Set(ctx, b, details); err != nil {
 		return fmt.Errorf("firestsore: Set: %w", err)
 	}
 	return nil
 }
 
+// SetCover sets the cover URLs of a given book, without changing its other
+// fields.
+func (db *firestoreDB) SetCover(ctx context.Context, id, imageURL, thumbnailURL string) error {
+	_, err := db.client.Collection(db.collection).Doc(id).Update(ctx, []firestore.Update{
+		{Path: "ImageURL", Value: imageURL},
+		{Path: "ThumbnailURL", Value: thumbnailURL},
+	})
+	if status.Code(err) == codes.NotFound {
+		return fmt.Errorf("firestoredb: Update: %w with ID %q", errBookNotFound, id)
+	}
+	if err != nil {
+		return fmt.Errorf("firestoredb: Update: %w", err)
+	}
+	return nil
+}
+
 // ListBooks returns a list of books, ordered by title.
 func (db *firestoreDB) ListBooks(ctx context.Context) ([]*Book, error) {
 	books := make([]*Book, 0)
@@ -56,7 +56,7 @@ func (db *memoryDB) GetBook(_ context.Context, id string) (*Book, error) {
 
 	book, ok := db.books[id]
 	if !ok {
-		return nil, fmt.Errorf("memorydb: book not found with ID %q", id)
+		return nil, fmt.Errorf("memorydb: %w with ID %q", errBookNotFound, id)
 	}
 	return book, nil
 }
@@ -90,7 +90,7 @@ func (db *memoryDB) DeleteBook(_ context.Context, id string) error {
 	return nil
 }
 
-// UpdateBook updates the entry for a given book.
+// UpdateBook updates the entry for a given book, except for its cover.
 func (db *memoryDB) UpdateBook(_ context.Context, b *Book) error {
 	if b.ID == "" {
 		return errors.New("memorydb: book with unassigned ID passed into UpdateBook")
@@ -99,10 +99,26 @@ func (db *memoryDB) UpdateBook(_ context.Context, b *Book) error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
+	if old, ok := db.books[b.ID]; ok {
+		b.ImageURL, b.ThumbnailURL = old.ImageURL, old.ThumbnailURL
+	}
 	db.books[b.ID] = b
 	return nil
 }
 
+// SetCover sets the cover URLs of a given book.
+func (db *memoryDB) SetCover(_ context.Context, id, imageURL, thumbnailURL string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	book, ok := db.books[id]
+	if !ok {
+		return fmt.Errorf("memorydb: %w with ID %q", errBookNotFound, id)
+	}
+	book.ImageURL, book.ThumbnailURL = imageURL, thumbnailURL
+	return nil
+}
+
 // ListBooks returns a list of books, ordered by title.
 func (db *memoryDB) ListBooks(_ context.Context) ([]*Book, error) {
 	db.mu.Lock()
@@ -0,0 +1,279 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package covers validates uploaded cover images and makes the variants
+// the bookshelf app serves.
+//
+// Uploads are checked by their content, not by the name or type the
+// browser sent, and decoded again when processed, so that the variants
+// only hold the pixels: metadata such as EXIF, which may include where a
+// photo was taken, is dropped.
+package covers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// Limits of uploads.
+const (
+	// MaxSize is the largest upload, in bytes.
+	MaxSize = 10 << 20
+	// MaxPixels is the largest number of pixels of an upload, which bounds
+	// the memory used to decode it.
+	MaxPixels = 50e6
+)
+
+// Errors of invalid uploads.
+var (
+	ErrTooLarge    = errors.New("covers: image is too large")
+	ErrUnsupported = errors.New("covers: not a JPEG, PNG, GIF or WebP image")
+)
+
+// decoders are the decoders of the supported types, by the type
+// http.DetectContentType sniffs.
+var decoders = map[string]struct {
+	decode       func(io.Reader) (image.Image, error)
+	decodeConfig func(io.Reader) (image.Config, error)
+}{
+	"image/jpeg": {jpeg.Decode, jpeg.DecodeConfig},
+	"image/png":  {png.Decode, png.DecodeConfig},
+	"image/gif":  {gif.Decode, gif.DecodeConfig},
+	"image/webp": {webp.Decode, webp.DecodeConfig},
+}
+
+// ReadUpload reads an uploaded image of up to MaxSize bytes, and returns it
+// with its content type. It checks the type and the dimensions of the
+// image without decoding its pixels.
+func ReadUpload(r io.Reader) (data []byte, contentType string, err error) {
+	data, err = io.ReadAll(io.LimitReader(r, MaxSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > MaxSize {
+		return nil, "", ErrTooLarge
+	}
+	contentType, err = Check(data)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, contentType, nil
+}
+
+// Check returns the content type of an image, or an error if it isn't a
+// supported image of up to MaxPixels.
+func Check(data []byte) (contentType string, err error) {
+	contentType = http.DetectContentType(data)
+	dec, ok := decoders[contentType]
+	if !ok {
+		return "", ErrUnsupported
+	}
+	cfg, err := dec.decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || float64(cfg.Width)*float64(cfg.Height) > MaxPixels {
+		return "", ErrTooLarge
+	}
+	return contentType, nil
+}
+
+// Format is the encoding of a variant.
+type Format string
+
+// Formats of variants.
+const (
+	JPEG Format = "jpeg"
+)
+
+// ContentType returns the MIME type of f.
+func (f Format) ContentType() string {
+	return "image/" + string(f)
+}
+
+// Variant is an image made from a cover.
+type Variant struct {
+	// Name identifies the variant, such as "thumbnail".
+	Name string
+	// The image fits within MaxWidth x MaxHeight. It isn't enlarged.
+	MaxWidth, MaxHeight int
+	Format              Format
+}
+
+// DefaultVariants are the variants of the bookshelf app: the cover, and a
+// thumbnail for the list of books.
+var DefaultVariants = []Variant{
+	{Name: "cover", MaxWidth: 800, MaxHeight: 1200, Format: JPEG},
+	{Name: "thumbnail", MaxWidth: 200, MaxHeight: 300, Format: JPEG},
+}
+
+// Image is an encoded variant.
+type Image struct {
+	Variant Variant
+	Data    []byte
+}
+
+// Process decodes an uploaded image, turns it upright according to its EXIF
+// orientation, and encodes its variants.
+func Process(data []byte, variants []Variant) ([]Image, error) {
+	contentType, err := Check(data)
+	if err != nil {
+		return nil, err
+	}
+	m, err := decoders[contentType].decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	if contentType == "image/jpeg" {
+		m = orient(m, exifOrientation(data))
+	}
+
+	var images []Image
+	for _, v := range variants {
+		var buf bytes.Buffer
+		r := resize(m, v.MaxWidth, v.MaxHeight)
+		switch v.Format {
+		case JPEG:
+			err = jpeg.Encode(&buf, r, &jpeg.Options{Quality: 85})
+		default:
+			err = fmt.Errorf("covers: unknown format %q", v.Format)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", v.Name, err)
+		}
+		images = append(images, Image{Variant: v, Data: buf.Bytes()})
+	}
+	return images, nil
+}
+
+// resize scales m down to fit within maxWidth x maxHeight, keeping its
+// aspect ratio.
+func resize(m image.Image, maxWidth, maxHeight int) image.Image {
+	b := m.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > maxWidth {
+		w, h = maxWidth, max(1, h*maxWidth/w)
+	}
+	if h > maxHeight {
+		w, h = max(1, w*maxHeight/h), maxHeight
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), m, b, draw.Src, nil)
+	return dst
+}
+
+// orient returns m turned upright, given its EXIF orientation: 1 is
+// upright, 2 to 8 are flipped or rotated.
+func orient(m image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return m
+	}
+	b := m.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		// Rotated by 90 degrees.
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			// (sx, sy) is the pixel of m shown at (x, y).
+			var sx, sy int
+			switch orientation {
+			case 2: // Flip horizontally.
+				sx, sy = w-1-x, y
+			case 3: // Rotate 180 degrees.
+				sx, sy = w-1-x, h-1-y
+			case 4: // Flip vertically.
+				sx, sy = x, h-1-y
+			case 5: // Transpose.
+				sx, sy = y, x
+			case 6: // Rotate 90 degrees clockwise.
+				sx, sy = y, h-1-x
+			case 7: // Transverse.
+				sx, sy = w-1-y, h-1-x
+			case 8: // Rotate 90 degrees counterclockwise.
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, m.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// exifOrientation returns the orientation tag of the EXIF metadata of a
+// JPEG image, or 1 if there is none.
+func exifOrientation(data []byte) int {
+	const orientationTag = 0x0112
+	// Find the APP1 segment with the EXIF metadata, which comes before the
+	// image data.
+	for p := 2; p+4 <= len(data) && data[p] == 0xff; {
+		marker := data[p+1]
+		size := int(data[p+2])<<8 | int(data[p+3])
+		if marker == 0xda || size < 2 || p+2+size > len(data) {
+			// Start of scan: there is no metadata after it.
+			return 1
+		}
+		seg := data[p+4 : p+2+size]
+		p += 2 + size
+		if marker != 0xe1 || !bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+			continue
+		}
+
+		// The metadata is a TIFF file.
+		tiff := seg[6:]
+		if len(tiff) < 8 {
+			return 1
+		}
+		var u16 func([]byte) int
+		var u32 func([]byte) int
+		switch string(tiff[:2]) {
+		case "II":
+			u16 = func(b []byte) int { return int(b[0]) | int(b[1])<<8 }
+			u32 = func(b []byte) int { return u16(b) | u16(b[2:])<<16 }
+		case "MM":
+			u16 = func(b []byte) int { return int(b[0])<<8 | int(b[1]) }
+			u32 = func(b []byte) int { return u16(b)<<16 | u16(b[2:]) }
+		default:
+			return 1
+		}
+		ifd := u32(tiff[4:])
+		if ifd < 8 || ifd+2 > len(tiff) {
+			return 1
+		}
+		n := u16(tiff[ifd:])
+		for i := 0; i < n; i++ {
+			entry := ifd + 2 + 12*i
+			if entry+12 > len(tiff) {
+				return 1
+			}
+			if u16(tiff[entry:]) == orientationTag {
+				return u16(tiff[entry+8:])
+			}
+		}
+		return 1
+	}
+	return 1
+}
@@ -0,0 +1,214 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package covers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, m image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, m); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func gradient(w, h int, alpha uint8) *image.NRGBA {
+	m := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			m.SetNRGBA(x, y, color.NRGBA{uint8(x * 255 / w), uint8(y * 255 / h), uint8(x + y), alpha})
+		}
+	}
+	return m
+}
+
+func TestReadUpload(t *testing.T) {
+	var g bytes.Buffer
+	if err := gif.Encode(&g, image.NewPaletted(image.Rect(0, 0, 1, 1), color.Palette{color.Black}), nil); err != nil {
+		t.Fatalf("gif.Encode: %v", err)
+	}
+	// A GIF claiming a 65535 x 65535 screen.
+	huge := append([]byte(nil), g.Bytes()...)
+	copy(huge[6:10], []byte{0xff, 0xff, 0xff, 0xff})
+
+	for _, tc := range []struct {
+		name     string
+		data     []byte
+		wantType string
+		wantErr  error
+	}{
+		{name: "png", data: encodePNG(t, gradient(3, 2, 0xff)), wantType: "image/png"},
+		{name: "gif", data: g.Bytes(), wantType: "image/gif"},
+		{name: "text", data: []byte("<html>not an image</html>"), wantErr: ErrUnsupported},
+		{name: "truncated", data: encodePNG(t, gradient(3, 2, 0xff))[:20], wantErr: ErrUnsupported},
+		{name: "too many bytes", data: make([]byte, MaxSize+1), wantErr: ErrTooLarge},
+		{name: "too many pixels", data: huge, wantErr: ErrTooLarge},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data, contentType, err := ReadUpload(bytes.NewReader(tc.data))
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("ReadUpload got err %v, want %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if contentType != tc.wantType {
+				t.Errorf("ReadUpload got type %q, want %q", contentType, tc.wantType)
+			}
+			if !bytes.Equal(data, tc.data) {
+				t.Errorf("ReadUpload got %d bytes, want %d", len(data), len(tc.data))
+			}
+		})
+	}
+}
+
+func TestProcess(t *testing.T) {
+	data := encodePNG(t, gradient(1000, 500, 0xff))
+	images, err := Process(data, DefaultVariants)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if len(images) != len(DefaultVariants) {
+		t.Fatalf("Process got %d images, want %d", len(images), len(DefaultVariants))
+	}
+	want := map[string]struct {
+		contentType string
+		size        image.Point
+	}{
+		"cover":     {"image/jpeg", image.Pt(800, 400)},
+		"thumbnail": {"image/jpeg", image.Pt(200, 100)},
+	}
+	for _, m := range images {
+		contentType, err := Check(m.Data)
+		if err != nil {
+			t.Errorf("%s: Check: %v", m.Variant.Name, err)
+			continue
+		}
+		w := want[m.Variant.Name]
+		if contentType != w.contentType {
+			t.Errorf("%s: got type %q, want %q", m.Variant.Name, contentType, w.contentType)
+		}
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(m.Data))
+		if err != nil {
+			t.Errorf("%s: image.DecodeConfig: %v", m.Variant.Name, err)
+			continue
+		}
+		if got := image.Pt(cfg.Width, cfg.Height); got != w.size {
+			t.Errorf("%s: got size %v, want %v", m.Variant.Name, got, w.size)
+		}
+	}
+}
+
+// withOrientation inserts an EXIF APP1 segment with orientation into a JPEG
+// image.
+func withOrientation(jpg []byte, orientation byte) []byte {
+	tiff := []byte{
+		'M', 'M', 0, 42, 0, 0, 0, 8, // Big endian, first IFD at 8.
+		0, 1, // One entry.
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, orientation, 0, 0, // Orientation, SHORT.
+		0, 0, 0, 0, // No next IFD.
+	}
+	seg := append([]byte("Exif\x00\x00"), tiff...)
+	size := len(seg) + 2
+	app1 := append([]byte{0xff, 0xe1, byte(size >> 8), byte(size)}, seg...)
+
+	out := append([]byte(nil), jpg[:2]...) // SOI.
+	out = append(out, app1...)
+	return append(out, jpg[2:]...)
+}
+
+func TestProcessOrientation(t *testing.T) {
+	// Red on the left, blue on the right.
+	m := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			c := color.RGBA{0xff, 0, 0, 0xff}
+			if x >= 20 {
+				c = color.RGBA{0, 0, 0xff, 0xff}
+			}
+			m.SetRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, m, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatalf("jpeg.Encode: %v", err)
+	}
+	data := withOrientation(buf.Bytes(), 6)
+	if got := exifOrientation(data); got != 6 {
+		t.Fatalf("exifOrientation got %d, want 6", got)
+	}
+
+	images, err := Process(data, []Variant{{Name: "cover", MaxWidth: 100, MaxHeight: 100, Format: JPEG}})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	out := images[0].Data
+	if bytes.Contains(out, []byte("Exif\x00\x00")) {
+		t.Errorf("Process kept the EXIF metadata")
+	}
+	got, err := jpeg.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("jpeg.Decode: %v", err)
+	}
+	if size := got.Bounds().Size(); size != image.Pt(20, 40) {
+		t.Fatalf("got size %v, want 20x40", size)
+	}
+	// Turned clockwise, the left half is on top.
+	if r, _, b, _ := got.At(10, 5).RGBA(); r < b {
+		t.Errorf("got top %v, want red", got.At(10, 5))
+	}
+	if r, _, b, _ := got.At(10, 35).RGBA(); r > b {
+		t.Errorf("got bottom %v, want blue", got.At(10, 35))
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	s := &FileStore{Dir: t.TempDir(), BaseURL: "http://localhost:8080/files/"}
+
+	if err := s.Put(ctx, "staging/1/a", "image/png", []byte("data"), false); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	got, err := s.Get(ctx, "staging/1/a")
+	if err != nil || string(got) != "data" {
+		t.Fatalf("Get got %q, %v, want %q", got, err, "data")
+	}
+	if err := s.Delete(ctx, "staging/1/a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Get(ctx, "staging/1/a"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("Get after Delete got err %v, want ErrNotExist", err)
+	}
+	if err := s.Delete(ctx, "staging/1/a"); err != nil {
+		t.Errorf("Delete twice: %v", err)
+	}
+	if err := s.Put(ctx, "../a", "image/png", nil, false); err == nil {
+		t.Errorf("Put(../a) got no error")
+	}
+	if got, want := s.URL("covers/1/a.jpeg"), "http://localhost:8080/files/covers/1/a.jpeg"; got != want {
+		t.Errorf("URL got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package covers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cloud.google.com/go/storage"
+)
+
+// ErrNotExist is returned by Store.Get for objects that don't exist.
+var ErrNotExist = errors.New("covers: object does not exist")
+
+// Store holds staged uploads and the variants of covers, by name, such as
+// "staging/1234/5678".
+type Store interface {
+	// Put writes an object. Public objects can be read by anyone at
+	// URL(name).
+	Put(ctx context.Context, name, contentType string, data []byte, public bool) error
+	// Get reads an object, or returns ErrNotExist.
+	Get(ctx context.Context, name string) ([]byte, error)
+	// Delete deletes an object, if it exists.
+	Delete(ctx context.Context, name string) error
+	// URL returns the URL of a public object.
+	URL(name string) string
+}
+
+// BucketStore stores objects in a Cloud Storage bucket.
+type BucketStore struct {
+	Bucket *storage.BucketHandle
+	// Name is the name of the bucket.
+	Name string
+}
+
+var _ Store = &BucketStore{}
+
+// Put writes an object to the bucket.
+func (s *BucketStore) Put(ctx context.Context, name, contentType string, data []byte, public bool) error {
+	w := s.Bucket.Object(name).NewWriter(ctx)
+	w.ContentType = contentType
+	if public {
+		// Warning: storage.AllUsers gives public read access to anyone.
+		w.ACL = []storage.ACLRule{{Entity: storage.AllUsers, Role: storage.RoleReader}}
+
+		// Entries are immutable, be aggressive about caching (1 day).
+		w.CacheControl = "public, max-age=86400"
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		if errors.Is(err, storage.ErrBucketNotExist) {
+			return fmt.Errorf("bucket %q does not exist: check bookshelf.go", s.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+// Get reads an object from the bucket.
+func (s *BucketStore) Get(ctx context.Context, name string) ([]byte, error) {
+	r, err := s.Bucket.Object(name).NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, ErrNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// Delete deletes an object from the bucket.
+func (s *BucketStore) Delete(ctx context.Context, name string) error {
+	err := s.Bucket.Object(name).Delete(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil
+	}
+	return err
+}
+
+// URL returns the public URL of an object of the bucket.
+func (s *BucketStore) URL(name string) string {
+	const publicURL = "https://storage.googleapis.com/%s/%s"
+	return fmt.Sprintf(publicURL, s.Name, name)
+}
+
+// FileStore stores objects in a local directory, for tests and development.
+// Every object is public: serve Dir at BaseURL, such as with
+// http.FileServer.
+type FileStore struct {
+	Dir     string
+	BaseURL string
+}
+
+var _ Store = &FileStore{}
+
+// path returns the path of the file of an object.
+func (s *FileStore) path(name string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return "", fmt.Errorf("covers: invalid object name %q", name)
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(name)), nil
+}
+
+// Put writes the file of an object.
+func (s *FileStore) Put(ctx context.Context, name, contentType string, data []byte, public bool) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Get reads the file of an object.
+func (s *FileStore) Get(ctx context.Context, name string) ([]byte, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotExist
+	}
+	return data, err
+}
+
+// Delete removes the file of an object.
+func (s *FileStore) Delete(ctx context.Context, name string) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// URL returns BaseURL followed by the name of the object.
+func (s *FileStore) URL(name string) string {
+	return strings.TrimSuffix(s.BaseURL, "/") + "/" + name
+}
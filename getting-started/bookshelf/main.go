@@ -22,7 +22,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"os"
@@ -31,7 +30,7 @@ import (
 
 	"cloud.google.com/go/errorreporting"
 	"cloud.google.com/go/firestore"
-	"cloud.google.com/go/storage"
+	"github.com/GoogleCloudPlatform/golang-samples/getting-started/bookshelf/internal/covers"
 	"github.com/gofrs/uuid"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
@@ -68,6 +67,11 @@ func main() {
 	if err != nil {
 		log.Fatalf("NewBookshelf: %v", err)
 	}
+	b.CoverEventsServiceAccount = os.Getenv("COVER_EVENTS_SERVICE_ACCOUNT")
+	b.CoverEventsAudience = os.Getenv("COVER_EVENTS_AUDIENCE")
+	if b.CoverEventsAudience == "" {
+		b.CoverEventsAudience = "https://" + projectID + ".appspot.com/covers/events"
+	}
 
 	b.registerHandlers()
 
@@ -100,6 +104,9 @@ func (b *Bookshelf) registerHandlers() {
 	r.Methods("POST").Path("/books/{id:[0-9a-zA-Z_\\-]+}:delete").
 		Handler(appHandler(b.deleteHandler)).Name("delete")
 
+	r.Methods("POST").Path("/covers/events").
+		Handler(appHandler(b.coverEventsHandler))
+
 	r.Methods("GET").Path("/logs").Handler(appHandler(b.sendLog))
 	r.Methods("GET").Path("/errors").Handler(appHandler(b.sendError))
 
@@ -163,105 +170,99 @@ func (b *Bookshelf) editFormHandler(w http.ResponseWriter, r *http.Request) *app
 
 // bookFromForm populates the fields of a Book from form values
 // (see templates/edit.html).
-func (b *Bookshelf) bookFromForm(r *http.Request) (*Book, error) {
-	ctx := r.Context()
-	imageURL, err := b.uploadFileFromForm(ctx, r)
-	if err != nil {
-		return nil, fmt.Errorf("could not upload file: %w", err)
-	}
-	if imageURL == "" {
-		imageURL = r.FormValue("imageURL")
-	}
-
-	book := &Book{
+func (b *Bookshelf) bookFromForm(r *http.Request) *Book {
+	return &Book{
 		Title:         r.FormValue("title"),
 		Author:        r.FormValue("author"),
 		PublishedDate: r.FormValue("publishedDate"),
-		ImageURL:      imageURL,
 		Description:   r.FormValue("description"),
 	}
-
-	return book, nil
 }
 
 // [START getting_started_bookshelf_storage]
 
-// uploadFileFromForm uploads a file if it's present in the "image" form field.
-func (b *Bookshelf) uploadFileFromForm(ctx context.Context, r *http.Request) (url string, err error) {
-	f, fh, err := r.FormFile("image")
+// coverFromForm reads the image in the "image" form field, if present. The
+// image is checked by its content, whatever its name and type.
+func coverFromForm(r *http.Request) (data []byte, contentType string, err error) {
+	f, _, err := r.FormFile("image")
 	if err == http.ErrMissingFile {
-		return "", nil
+		return nil, "", nil
 	}
 	if err != nil {
-		return "", err
+		return nil, "", err
 	}
+	defer f.Close()
+	return covers.ReadUpload(f)
+}
 
-	if b.StorageBucket == nil {
-		return "", errors.New("storage bucket is missing: check bookshelf.go")
-	}
-	if _, err := b.StorageBucket.Attrs(ctx); err != nil {
-		if err == storage.ErrBucketNotExist {
-			return "", fmt.Errorf("bucket %q does not exist: check bookshelf.go", b.StorageBucketName)
-		}
-		return "", fmt.Errorf("could not get bucket: %w", err)
+// uploadFileFromForm stages the image of a book, which processCover turns
+// into the covers of the book. Staged images aren't public.
+func (b *Bookshelf) uploadFileFromForm(ctx context.Context, bookID string, data []byte, contentType string) error {
+	if b.Covers == nil {
+		return errors.New("cover store is missing: check bookshelf.go")
 	}
 
-	// random filename, retaining existing extension.
-	name := uuid.Must(uuid.NewV4()).String() + path.Ext(fh.Filename)
-
-	w := b.StorageBucket.Object(name).NewWriter(ctx)
-
-	// Warning: storage.AllUsers gives public read access to anyone.
-	w.ACL = []storage.ACLRule{{Entity: storage.AllUsers, Role: storage.RoleReader}}
-	w.ContentType = fh.Header.Get("Content-Type")
+	// random filename, so that every upload is processed.
+	name := path.Join(stagingPrefix, bookID, uuid.Must(uuid.NewV4()).String())
+	return b.Covers.Put(ctx, name, contentType, data, false)
+}
 
-	// Entries are immutable, be aggressive about caching (1 day).
-	w.CacheControl = "public, max-age=86400"
+// [END getting_started_bookshelf_storage]
 
-	if _, err := io.Copy(w, f); err != nil {
-		return "", err
-	}
-	if err := w.Close(); err != nil {
-		return "", err
+// coverErrorf returns an error about an uploaded image, which is the
+// client's fault if the image isn't valid.
+func (b *Bookshelf) coverErrorf(r *http.Request, err error) *appError {
+	e := b.appErrorf(r, err, "could not upload image: %v", err)
+	if errors.Is(err, covers.ErrUnsupported) || errors.Is(err, covers.ErrTooLarge) {
+		e.code = http.StatusBadRequest
 	}
-
-	const publicURL = "https://storage.googleapis.com/%s/%s"
-	return fmt.Sprintf(publicURL, b.StorageBucketName, name), nil
+	return e
 }
 
-// [END getting_started_bookshelf_storage]
-
 // createHandler adds a book to the database.
 func (b *Bookshelf) createHandler(w http.ResponseWriter, r *http.Request) *appError {
 	ctx := r.Context()
-	book, err := b.bookFromForm(r)
+	data, contentType, err := coverFromForm(r)
 	if err != nil {
-		return b.appErrorf(r, err, "could not parse book from form: %v", err)
+		return b.coverErrorf(r, err)
 	}
+	book := b.bookFromForm(r)
 	id, err := b.DB.AddBook(ctx, book)
 	if err != nil {
 		return b.appErrorf(r, err, "could not save book: %v", err)
 	}
+	if data != nil {
+		if err := b.uploadFileFromForm(ctx, id, data, contentType); err != nil {
+			return b.coverErrorf(r, err)
+		}
+	}
 	http.Redirect(w, r, fmt.Sprintf("/books/%s", id), http.StatusFound)
 	return nil
 }
 
-// updateHandler updates the details of a given book.
+// updateHandler updates the details of a given book. Its cover is replaced
+// once a new image is processed.
 func (b *Bookshelf) updateHandler(w http.ResponseWriter, r *http.Request) *appError {
 	ctx := r.Context()
 	id := mux.Vars(r)["id"]
 	if id == "" {
 		return b.appErrorf(r, errors.New("no book with empty ID"), "no book with empty ID")
 	}
-	book, err := b.bookFromForm(r)
+	data, contentType, err := coverFromForm(r)
 	if err != nil {
-		return b.appErrorf(r, err, "could not parse book from form: %v", err)
+		return b.coverErrorf(r, err)
 	}
+	book := b.bookFromForm(r)
 	book.ID = id
 
 	if err := b.DB.UpdateBook(ctx, book); err != nil {
 		return b.appErrorf(r, err, "UpdateBook: %v", err)
 	}
+	if data != nil {
+		if err := b.uploadFileFromForm(ctx, id, data, contentType); err != nil {
+			return b.coverErrorf(r, err)
+		}
+	}
 	http.Redirect(w, r, fmt.Sprintf("/books/%s", book.ID), http.StatusFound)
 	return nil
 }
@@ -24,6 +24,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 
 	"cloud.google.com/go/firestore"
 	"cloud.google.com/go/storage"
@@ -57,6 +58,7 @@ func main() {
 	http.HandleFunc("/predict", a.predictionRequest)
 	http.HandleFunc("/pldata", a.addPlayData)
 	http.HandleFunc("/bggenerator", a.sendGeneratedBackground)
+	http.HandleFunc("/background", a.sendGeneratedBackground)
 	http.Handle("/", http.StripPrefix("/", http.FileServer(http.Dir("static/gorun/"))))
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -173,6 +175,14 @@ func (a *app) addPlayData(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprint(w, "Recieved data\n")
 }
 
+// seedHeader is the response header of the seed of a background, which
+// clients send back as RequestData.Seed to replay a run.
+const seedHeader = "Gopher-Run-Seed"
+
+// maxBackgroundLength is the longest part of a level a request can
+// generate.
+const maxBackgroundLength = 10000
+
 // sendGeneratedBackground returns cloud/hill placements.
 func (a *app) sendGeneratedBackground(w http.ResponseWriter, r *http.Request) {
 	var d generator.RequestData
@@ -183,7 +193,15 @@ func (a *app) sendGeneratedBackground(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	r.Body.Close()
-	objs := generator.GenerateBackground(d.Xmin, d.Xmax, d.Speed)
+	if d.Xmax-d.Xmin > maxBackgroundLength || d.Speed < 0 {
+		http.Error(w, "Invalid background request", http.StatusBadRequest)
+		return
+	}
+	if d.Seed == 0 {
+		d.Seed = generator.NewSeed()
+	}
+	w.Header().Set(seedHeader, strconv.FormatInt(d.Seed, 10))
+	objs := generator.New(d.Seed).Background(d.Xmin, d.Xmax, d.Speed)
 	s := ""
 	for _, obj := range objs {
 		s += obj.String() + "\n"
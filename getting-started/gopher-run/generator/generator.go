@@ -17,7 +17,9 @@ package generator
 
 import (
 	"fmt"
+	"math"
 	"math/rand"
+	"sort"
 )
 
 // RequestData is the form in which requests for background generation come in.
@@ -25,6 +27,9 @@ type RequestData struct {
 	Xmin  float64
 	Xmax  float64
 	Speed float64
+	// Seed replays the background of a run. If it's 0, a new seed is
+	// chosen.
+	Seed int64
 }
 
 // Vector3 is 3-value vector.
@@ -46,37 +51,197 @@ type GameObject struct {
 	transform Transform
 }
 
-func randRange(i float64, j float64) float64 {
-	return rand.Float64()*(j-i) + i
+func randRange(r *rand.Rand, i float64, j float64) float64 {
+	return r.Float64()*(j-i) + i
 }
 
 func (o GameObject) String() string {
 	return fmt.Sprintf("%v %v %v %v %v %v %v", o.name, o.transform.position.X, o.transform.position.Y, o.transform.position.Z, o.transform.localScale.X, o.transform.localScale.Y, o.transform.localScale.Z)
 }
 
-// GenerateBackground determines positions for background objects.
-func GenerateBackground(start, end, speed float64) []GameObject {
-	objects := []GameObject{}
-	for n := start; n < end; n += 30 {
-		for m := 0; m < 3; m++ {
-			cscale := randRange(0.2, 0.6)
-			cx := randRange(n, n+10)
-			cy := randRange(10, 25)
-			cz := 15 + randRange(-5, 5)
-			objects = append(objects, GameObject{"cloud", Transform{Vector3{cx, cy, cz}, Vector3{cscale, cscale, cscale}}})
+// kind is the placement of a type of object.
+type kind struct {
+	minScale, maxScale float64
+	minY, maxY         float64
+	// obstacle is set for objects on the ground, which the gopher must get
+	// past.
+	obstacle bool
+}
+
+// kinds are the types of background objects, by name.
+var kinds = map[string]kind{
+	"cloud":  {minScale: 0.2, maxScale: 0.6, minY: 10, maxY: 25},
+	"nimbus": {minScale: 0.5, maxScale: 1.5, minY: 30, maxY: 40},
+	"hill":   {minScale: 1.5, maxScale: 2.5, minY: 5, maxY: 5, obstacle: true},
+}
+
+// kindNames are the names of kinds, in a fixed order so that a seed always
+// picks the same kinds.
+var kindNames = func() []string {
+	var names []string
+	for name := range kinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}()
+
+// Weights are the relative frequencies of the types of objects, by name:
+// "cloud", "nimbus" and "hill". Other names are ignored.
+type Weights map[string]float64
+
+// pick returns the name of a random kind with weights w.
+func (w Weights) pick(r *rand.Rand) string {
+	total := 0.0
+	for _, name := range kindNames {
+		total += math.Max(0, w[name])
+	}
+	if total == 0 {
+		return kindNames[0]
+	}
+	x := r.Float64() * total
+	for _, name := range kindNames {
+		x -= math.Max(0, w[name])
+		if x < 0 {
+			return name
 		}
+	}
+	return kindNames[len(kindNames)-1]
+}
+
+// Difficulty is how crowded the background is at a distance.
+type Difficulty struct {
+	// Distance is where the difficulty applies, in units from the start.
+	Distance float64
+	// Spacing is the mean distance between consecutive objects.
+	Spacing float64
+	// Weights are the frequencies of the types of objects.
+	Weights Weights
+}
+
+// Curve is the difficulty of a level by distance, sorted by Distance.
+// Between two points, the difficulty changes linearly; before the first and
+// after the last, it's constant.
+type Curve []Difficulty
+
+// DefaultCurve starts with 3 clouds, a nimbus and a hill every 30 units,
+// and gets twice as many hills over the first 3000 units.
+var DefaultCurve = Curve{
+	{Distance: 0, Spacing: 6, Weights: Weights{"cloud": 3, "nimbus": 1, "hill": 1}},
+	{Distance: 3000, Spacing: 5, Weights: Weights{"cloud": 3, "nimbus": 1, "hill": 2}},
+}
 
-		cscale := randRange(0.5, 1.5)
-		cx := randRange(n, n+10)
-		cy := randRange(30, 40)
-		cz := 15 + randRange(-5, 5)
-		objects = append(objects, GameObject{"nimbus", Transform{Vector3{cx, cy, cz}, Vector3{cscale, cscale, cscale}}})
-
-		scale := randRange(1.5, 2.5)
-		x := randRange(n, n+10)
-		y := 5.0
-		z := 15 + randRange(-5, 5)
-		objects = append(objects, GameObject{"hill", Transform{Vector3{x, y, z}, Vector3{scale, scale, scale}}})
+// At returns the difficulty at distance.
+func (c Curve) At(distance float64) Difficulty {
+	if len(c) == 0 {
+		return DefaultCurve.At(distance)
 	}
-	return objects
+	i := sort.Search(len(c), func(i int) bool { return c[i].Distance > distance })
+	if i == 0 {
+		return c[0]
+	}
+	if i == len(c) {
+		return c[len(c)-1]
+	}
+	a, b := c[i-1], c[i]
+	t := (distance - a.Distance) / (b.Distance - a.Distance)
+	d := Difficulty{
+		Distance: distance,
+		Spacing:  a.Spacing + t*(b.Spacing-a.Spacing),
+		Weights:  Weights{},
+	}
+	for _, name := range kindNames {
+		d.Weights[name] = a.Weights[name] + t*(b.Weights[name]-a.Weights[name])
+	}
+	return d
+}
+
+// ReactionTime is the time in seconds a player needs between two
+// obstacles, and ObstacleLength the length of an obstacle, in units.
+const (
+	ReactionTime   = 0.6
+	ObstacleLength = 5
+)
+
+// MinGap returns the minimum distance between two obstacles at speed, in
+// units per second.
+func MinGap(speed float64) float64 {
+	return ObstacleLength + ReactionTime*math.Max(0, speed)
+}
+
+// Generator generates the backgrounds of a run.
+type Generator struct {
+	// Seed determines the objects: a generator with the same seed and curve
+	// returns the same background for the same request.
+	Seed int64
+	// Curve is the difficulty by distance. Default: DefaultCurve.
+	Curve Curve
+}
+
+// New returns a generator with seed and DefaultCurve.
+func New(seed int64) *Generator {
+	return &Generator{Seed: seed}
+}
+
+// NewSeed returns a random seed, which is never 0.
+func NewSeed() int64 {
+	for {
+		if seed := rand.Int63(); seed != 0 {
+			return seed
+		}
+	}
+}
+
+// rand returns the random source of a background starting at start. Each
+// part of a run has its own source, so that it can be generated again
+// without the parts before it.
+func (g *Generator) rand(start float64) *rand.Rand {
+	// Mix the bits of start, so that nearby starts get unrelated sources.
+	s := uint64(g.Seed) ^ math.Float64bits(start)*0x9e3779b97f4a7c15
+	s ^= s >> 31
+	return rand.New(rand.NewSource(int64(s)))
+}
+
+// Background determines positions for background objects from start to end,
+// in order of X. Obstacles are at least MinGap(speed) apart, and at least
+// half of it from start and end, so that consecutive requests at the same
+// speed keep the gap.
+func (g *Generator) Background(start, end, speed float64) []GameObject {
+	r := g.rand(start)
+	gap := MinGap(speed)
+	lastObstacle := start - gap/2
+	objects := []GameObject{}
+	x := start
+	for {
+		d := g.Curve.At(x)
+		next := x + randRange(r, 0.5, 1.5)*math.Max(d.Spacing, 1)
+		// Also stop at distances too large to advance, or NaN.
+		if !(next < end) || next == x {
+			return objects
+		}
+		x = next
+		name := d.Weights.pick(r)
+		k := kinds[name]
+		if k.obstacle {
+			// Move the obstacle forward, and everything after it, rather
+			// than leave it out, so that the curve's frequencies hold.
+			ox := math.Max(x, lastObstacle+gap)
+			if ox >= end-gap/2 {
+				// Too close to the next request's obstacles.
+				continue
+			}
+			x = ox
+			lastObstacle = x
+		}
+		scale := randRange(r, k.minScale, k.maxScale)
+		y := randRange(r, k.minY, k.maxY)
+		z := 15 + randRange(r, -5, 5)
+		objects = append(objects, GameObject{name, Transform{Vector3{x, y, z}, Vector3{scale, scale, scale}}})
+	}
+}
+
+// GenerateBackground determines positions for background objects with a
+// random seed.
+func GenerateBackground(start, end, speed float64) []GameObject {
+	return New(NewSeed()).Background(start, end, speed)
 }
@@ -13,7 +13,12 @@
 // limitations under the License.
 package generator
 
-import "testing"
+import (
+	"math"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
 
 func TestGenerateBackground(t *testing.T) {
 	objects := GenerateBackground(0, 500, 16)
@@ -29,3 +34,120 @@ func TestGenerateBackground(t *testing.T) {
 		}
 	}
 }
+
+// request is a random request: a part of a run of up to 1000 units, within
+// the first 10000, at a speed up to 60.
+type request struct {
+	Seed              int64
+	Start, End, Speed float64
+}
+
+func (r request) normalize() request {
+	r.Start = math.Mod(math.Abs(r.Start), 10000)
+	r.End = r.Start + math.Mod(math.Abs(r.End), 1000)
+	r.Speed = math.Mod(math.Abs(r.Speed), 60)
+	return r
+}
+
+func (r request) background() []GameObject {
+	r = r.normalize()
+	return New(r.Seed).Background(r.Start, r.End, r.Speed)
+}
+
+func TestBackgroundIsDeterministic(t *testing.T) {
+	f := func(r request) bool {
+		return reflect.DeepEqual(r.background(), r.background())
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBackgroundInRange(t *testing.T) {
+	f := func(r request) bool {
+		objects := r.background()
+		r = r.normalize()
+		prev := r.Start
+		for _, o := range objects {
+			p := o.transform.position
+			if p.X < prev || p.X >= r.End || p.Y < 0 {
+				t.Logf("%+v: object %v out of range or order", r, o)
+				return false
+			}
+			prev = p.X
+		}
+		return true
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestObstacleGap(t *testing.T) {
+	f := func(r request) bool {
+		objects := r.background()
+		r = r.normalize()
+		gap := MinGap(r.Speed)
+		last := r.Start - gap/2
+		for _, o := range objects {
+			if !kinds[o.name].obstacle {
+				continue
+			}
+			x := o.transform.position.X
+			if x-last < gap-1e-9 {
+				t.Logf("%+v: obstacle at %v is %v after the last, want at least %v", r, x, x-last, gap)
+				return false
+			}
+			last = x
+		}
+		if r.End-last < gap/2-1e-9 {
+			t.Logf("%+v: last obstacle at %v is too close to the end", r, last)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCurve(t *testing.T) {
+	c := Curve{
+		{Distance: 100, Spacing: 10, Weights: Weights{"cloud": 1}},
+		{Distance: 200, Spacing: 20, Weights: Weights{"cloud": 1, "hill": 4}},
+	}
+	for _, tc := range []struct {
+		distance    float64
+		spacing     float64
+		hillWeight  float64
+		cloudWeight float64
+	}{
+		{distance: 0, spacing: 10, cloudWeight: 1},
+		{distance: 150, spacing: 15, cloudWeight: 1, hillWeight: 2},
+		{distance: 500, spacing: 20, cloudWeight: 1, hillWeight: 4},
+	} {
+		d := c.At(tc.distance)
+		if d.Spacing != tc.spacing || d.Weights["cloud"] != tc.cloudWeight || d.Weights["hill"] != tc.hillWeight {
+			t.Errorf("At(%v) = %+v, want spacing %v, %v clouds to %v hills", tc.distance, d, tc.spacing, tc.cloudWeight, tc.hillWeight)
+		}
+	}
+}
+
+func TestWeights(t *testing.T) {
+	g := &Generator{Seed: 1, Curve: Curve{{Spacing: 5, Weights: Weights{"nimbus": 1}}}}
+	for _, o := range g.Background(0, 1000, 20) {
+		if o.name != "nimbus" {
+			t.Fatalf("got a %s, want only nimbus", o.name)
+		}
+	}
+
+	// Past the last point of DefaultCurve, there are 3 clouds per 2 hills.
+	counts := map[string]int{}
+	for _, o := range New(1).Background(0, 1000000, 0) {
+		counts[o.name]++
+	}
+	clouds := float64(counts["cloud"])
+	if r := clouds / float64(counts["hill"]); r < 1.45 || r > 1.55 {
+		t.Errorf("got %v clouds per hill, want about 1.5", r)
+	}
+}
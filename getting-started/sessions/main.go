@@ -19,22 +19,24 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"html/template"
 	"log"
 	"math/rand"
 	"net/http"
 	"os"
+	"strings"
 
 	"cloud.google.com/go/firestore"
-	firestoregorilla "github.com/GoogleCloudPlatform/firestore-gorilla-sessions"
-	"github.com/gorilla/sessions"
+	"github.com/GoogleCloudPlatform/golang-samples/getting-started/sessions/session"
 )
 
-// app stores a sessions.Store. Create a new app with newApp.
+// app stores a session.Manager. Create a new app with newApp.
 type app struct {
-	store sessions.Store
-	tmpl  *template.Template
+	sessions *session.Manager
+	tmpl     *template.Template
 }
 
 // greetings are the random greetings that will be assigned to sessions.
@@ -78,11 +80,15 @@ func newApp(projectID string) (*app, error) {
 	ctx := context.Background()
 	client, err := firestore.NewClient(ctx, projectID)
 	if err != nil {
-		log.Fatalf("firestore.NewClient: %v", err)
+		return nil, fmt.Errorf("firestore.NewClient: %w", err)
 	}
-	store, err := firestoregorilla.New(ctx, client)
+	keys, err := sessionKeys()
 	if err != nil {
-		log.Fatalf("firestoregorilla.New: %v", err)
+		return nil, err
+	}
+	codec, err := session.NewCodec(keys...)
+	if err != nil {
+		return nil, fmt.Errorf("session.NewCodec: %w", err)
 	}
 
 	tmpl, err := template.New("Index").Parse(`<body>{{.views}} {{if eq .views 1.0}}view{{else}}views{{end}} for "{{.greeting}}"</body>`)
@@ -91,11 +97,37 @@ func newApp(projectID string) (*app, error) {
 	}
 
 	return &app{
-		store: store,
-		tmpl:  tmpl,
+		sessions: &session.Manager{
+			// name is a non-empty identifier for this app's sessions. Set
+			// it to something descriptive for your app.
+			Name:  "hello-views",
+			Store: &session.FirestoreStore{Client: client, Collection: "hello-views"},
+			Codec: codec,
+		},
+		tmpl: tmpl,
 	}, nil
 }
 
+// sessionKeys returns the keys of session cookies, from SESSION_KEYS: a
+// comma-separated list of base64-encoded keys of 32 bytes or more, newest
+// first. Every instance of the app must use the same keys, or cookies set by
+// one instance are rejected by the others.
+func sessionKeys() ([]session.Key, error) {
+	env := os.Getenv("SESSION_KEYS")
+	if env == "" {
+		return nil, errors.New("SESSION_KEYS must be set, for example to the output of: head -c 32 /dev/urandom | base64")
+	}
+	var keys []session.Key
+	for _, s := range strings.Split(env, ",") {
+		b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("SESSION_KEYS: %w", err)
+		}
+		keys = append(keys, session.Key{Hash: b})
+	}
+	return keys, nil
+}
+
 // [END getting_started_sessions_main]
 
 // [START getting_started_sessions_handler]
@@ -107,29 +139,25 @@ func (a *app) index(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// name is a non-empty identifier for this app's sessions. Set it to
-	// something descriptive for your app. It is used as the Firestore
-	// collection name that stores the sessions.
-	name := "hello-views"
-	session, err := a.store.Get(r, name)
+	s, err := a.sessions.Get(r)
 	if err != nil {
 		// Could not get the session. Log an error and continue, saving a new
 		// session.
-		log.Printf("store.Get: %v", err)
+		log.Printf("sessions.Get: %v", err)
 	}
 
-	if session.IsNew {
-		// firestoregorilla uses JSON, which unmarshals numbers as float64s.
-		session.Values["views"] = float64(0)
-		session.Values["greeting"] = greetings[rand.Intn(len(greetings))]
+	if s.IsNew {
+		// Values are stored as JSON, which unmarshals numbers as float64s.
+		s.Values["views"] = float64(0)
+		s.Values["greeting"] = greetings[rand.Intn(len(greetings))]
 	}
-	session.Values["views"] = session.Values["views"].(float64) + 1
-	if err := session.Save(r, w); err != nil {
+	s.Values["views"] = s.Values["views"].(float64) + 1
+	if err := a.sessions.Save(r.Context(), w, s); err != nil {
 		log.Printf("Save: %v", err)
 		// Don't return early so the user still gets a response.
 	}
 
-	if err := a.tmpl.Execute(w, session.Values); err != nil {
+	if err := a.tmpl.Execute(w, s.Values); err != nil {
 		log.Printf("Execute: %v", err)
 	}
 }
@@ -15,17 +15,54 @@
 package main
 
 import (
+	"encoding/base64"
 	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
+
+	"github.com/GoogleCloudPlatform/golang-samples/getting-started/sessions/session"
 )
 
+// setSessionKeys sets SESSION_KEYS to a random key for the test.
+func setSessionKeys(t *testing.T) {
+	t.Helper()
+	k, err := session.NewKey(false)
+	if err != nil {
+		t.Fatalf("session.NewKey: %v", err)
+	}
+	t.Setenv("SESSION_KEYS", base64.StdEncoding.EncodeToString(k.Hash))
+}
+
+func TestSessionKeys(t *testing.T) {
+	t.Setenv("SESSION_KEYS", "")
+	if _, err := sessionKeys(); err == nil {
+		t.Errorf("sessionKeys without SESSION_KEYS got no error")
+	}
+
+	key := strings.Repeat("k", session.MinHashKeySize)
+	old := strings.Repeat("o", session.MinHashKeySize)
+	t.Setenv("SESSION_KEYS", base64.StdEncoding.EncodeToString([]byte(key))+", "+base64.StdEncoding.EncodeToString([]byte(old)))
+	keys, err := sessionKeys()
+	if err != nil {
+		t.Fatalf("sessionKeys: %v", err)
+	}
+	if len(keys) != 2 || string(keys[0].Hash) != key || string(keys[1].Hash) != old {
+		t.Errorf("sessionKeys got %q, want the new key, then the old one", keys)
+	}
+
+	t.Setenv("SESSION_KEYS", "not base64!")
+	if _, err := sessionKeys(); err == nil {
+		t.Errorf("sessionKeys with invalid keys got no error")
+	}
+}
+
 func TestIndex(t *testing.T) {
 	projectID := os.Getenv("GOLANG_SAMPLES_FIRESTORE_PROJECT")
 	if projectID == "" {
 		t.Skip("GOLANG_SAMPLES_FIRESTORE_PROJECT not set")
 	}
+	setSessionKeys(t)
 
 	a, err := newApp(projectID)
 	if err != nil {
@@ -59,6 +96,7 @@ func TestIndexCorrupted(t *testing.T) {
 	if projectID == "" {
 		t.Skip("GOLANG_SAMPLES_FIRESTORE_PROJECT not set")
 	}
+	setSessionKeys(t)
 
 	a, err := newApp(projectID)
 	if err != nil {
@@ -0,0 +1,156 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidCookie is returned for cookies that weren't encoded by any key
+// of a Codec, or were changed since.
+var ErrInvalidCookie = errors.New("session: invalid cookie")
+
+// MinHashKeySize is the smallest size of the keys that sign cookies.
+const MinHashKeySize = 32
+
+// A Key signs cookies, and encrypts them if Block is set.
+type Key struct {
+	// Hash is the HMAC-SHA256 key, of at least MinHashKeySize bytes.
+	Hash []byte
+	// Block is the AES key, of 16, 24 or 32 bytes, or nil to leave cookies
+	// readable by clients.
+	Block []byte
+}
+
+// NewKey returns a random key, with a Block key if encrypt is set.
+func NewKey(encrypt bool) (Key, error) {
+	k := Key{Hash: make([]byte, MinHashKeySize)}
+	if _, err := rand.Read(k.Hash); err != nil {
+		return Key{}, err
+	}
+	if encrypt {
+		k.Block = make([]byte, 32)
+		if _, err := rand.Read(k.Block); err != nil {
+			return Key{}, err
+		}
+	}
+	return k, nil
+}
+
+// A Codec encodes cookie values with its first key, and decodes them with
+// any of its keys. To rotate keys, add a new key first, and remove the old
+// one once the cookies it encoded have expired.
+type Codec struct {
+	keys []codecKey
+}
+
+type codecKey struct {
+	hash []byte
+	aead cipher.AEAD // nil if cookies aren't encrypted.
+}
+
+// NewCodec returns a codec with keys, newest first.
+func NewCodec(keys ...Key) (*Codec, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("session: no keys")
+	}
+	c := &Codec{}
+	for i, k := range keys {
+		if len(k.Hash) < MinHashKeySize {
+			return nil, fmt.Errorf("session: key %d: hash key of %d bytes, want at least %d", i, len(k.Hash), MinHashKeySize)
+		}
+		ck := codecKey{hash: k.Hash}
+		if k.Block != nil {
+			block, err := aes.NewCipher(k.Block)
+			if err != nil {
+				return nil, fmt.Errorf("session: key %d: %w", i, err)
+			}
+			ck.aead, err = cipher.NewGCM(block)
+			if err != nil {
+				return nil, fmt.Errorf("session: key %d: %w", i, err)
+			}
+		}
+		c.keys = append(c.keys, ck)
+	}
+	return c, nil
+}
+
+// Encode returns value signed, and encrypted if the first key has a Block
+// key, for a cookie called name. A value can't be moved to another cookie.
+func (c *Codec) Encode(name, value string) (string, error) {
+	k := c.keys[0]
+	data := []byte(value)
+	if k.aead != nil {
+		nonce := make([]byte, k.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		data = k.aead.Seal(nonce, nonce, data, []byte(name))
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+	mac := k.mac(name, encoded)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(mac), nil
+}
+
+// Decode returns the value of a cookie called name, or ErrInvalidCookie.
+func (c *Codec) Decode(name, cookie string) (string, error) {
+	encoded, sig, ok := strings.Cut(cookie, ".")
+	if !ok {
+		return "", ErrInvalidCookie
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	for _, k := range c.keys {
+		if !hmac.Equal(mac, k.mac(name, encoded)) {
+			continue
+		}
+		if k.aead == nil {
+			return string(data), nil
+		}
+		n := k.aead.NonceSize()
+		if len(data) < n {
+			return "", ErrInvalidCookie
+		}
+		value, err := k.aead.Open(nil, data[:n], data[n:], []byte(name))
+		if err != nil {
+			return "", ErrInvalidCookie
+		}
+		return string(value), nil
+	}
+	return "", ErrInvalidCookie
+}
+
+// mac returns the signature of the encoded value of a cookie called name.
+func (k codecKey) mac(name, encoded string) []byte {
+	h := hmac.New(sha256.New, k.hash)
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write([]byte(encoded))
+	return h.Sum(nil)
+}
@@ -0,0 +1,93 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FirestoreStore keeps sessions in a Firestore collection, one document per
+// session, named by its ID.
+//
+// Firestore deletes expired sessions with a TTL policy on the "expires"
+// field of Collection, here the default "sessions":
+//
+//	gcloud firestore fields ttls update expires \
+//	    --collection-group=sessions --enable-ttl
+//
+// Deletion can take a day or more: expired sessions are still ignored
+// until then.
+type FirestoreStore struct {
+	Client *firestore.Client
+	// Collection is the collection of the sessions. Default: "sessions".
+	Collection string
+}
+
+var _ Store = &FirestoreStore{}
+
+// firestoreRecord is a Record as a Firestore document.
+type firestoreRecord struct {
+	Values   []byte    `firestore:"values"`
+	Created  time.Time `firestore:"created"`
+	LastSeen time.Time `firestore:"last_seen"`
+	Expires  time.Time `firestore:"expires"`
+}
+
+// Load returns the record of session id.
+func (s *FirestoreStore) Load(ctx context.Context, id string) (*Record, error) {
+	doc, err := s.doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Get: %w", err)
+	}
+	var fr firestoreRecord
+	if err := doc.DataTo(&fr); err != nil {
+		return nil, fmt.Errorf("DataTo: %w", err)
+	}
+	r := Record(fr)
+	return &r, nil
+}
+
+// Save saves the record of session id.
+func (s *FirestoreStore) Save(ctx context.Context, id string, r *Record) error {
+	if _, err := s.doc(id).Set(ctx, firestoreRecord(*r)); err != nil {
+		return fmt.Errorf("Set: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes the record of session id.
+func (s *FirestoreStore) Delete(ctx context.Context, id string) error {
+	if _, err := s.doc(id).Delete(ctx); err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+	return nil
+}
+
+func (s *FirestoreStore) doc(id string) *firestore.DocumentRef {
+	collection := s.Collection
+	if collection == "" {
+		collection = "sessions"
+	}
+	return s.Client.Collection(collection).Doc(id)
+}
@@ -0,0 +1,71 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps sessions in memory, for tests and single instances.
+// Expired sessions are deleted as others are saved.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+var _ Store = &MemoryStore{}
+
+// NewMemoryStore returns an empty store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: map[string]Record{}}
+}
+
+// Load returns a copy of the record of session id.
+func (s *MemoryStore) Load(_ context.Context, id string) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.records[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	r.Values = append([]byte(nil), r.Values...)
+	return &r, nil
+}
+
+// Save saves a copy of the record of session id.
+func (s *MemoryStore) Save(_ context.Context, id string, r *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for id, old := range s.records {
+		if now.After(old.Expires) {
+			delete(s.records, id)
+		}
+	}
+	c := *r
+	c.Values = append([]byte(nil), r.Values...)
+	s.records[id] = c
+	return nil
+}
+
+// Delete deletes the record of session id.
+func (s *MemoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, id)
+	return nil
+}
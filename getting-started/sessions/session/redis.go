@@ -0,0 +1,98 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// RedisStore keeps sessions in Redis, such as a Memorystore for Redis
+// instance. Records expire with their sessions.
+type RedisStore struct {
+	Pool *redis.Pool
+	// Prefix is prepended to session IDs to make keys. Default: "session:".
+	Prefix string
+}
+
+var _ Store = &RedisStore{}
+
+// Load returns the record of session id.
+func (s *RedisStore) Load(ctx context.Context, id string) (*Record, error) {
+	conn, err := s.Pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	b, err := redis.Bytes(conn.Do("GET", s.key(id)))
+	if errors.Is(err, redis.ErrNil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GET: %w", err)
+	}
+	r := &Record{}
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	return r, nil
+}
+
+// Save saves the record of session id, until it expires.
+func (s *RedisStore) Save(ctx context.Context, id string, r *Record) error {
+	ttl := time.Until(r.Expires).Milliseconds()
+	if ttl <= 0 {
+		return s.Delete(ctx, id)
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+	conn, err := s.Pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.Do("SET", s.key(id), b, "PX", ttl); err != nil {
+		return fmt.Errorf("SET: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes the record of session id.
+func (s *RedisStore) Delete(ctx context.Context, id string) error {
+	conn, err := s.Pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.Do("DEL", s.key(id)); err != nil {
+		return fmt.Errorf("DEL: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) key(id string) string {
+	if s.Prefix == "" {
+		return "session:" + id
+	}
+	return s.Prefix + id
+}
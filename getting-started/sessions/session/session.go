@@ -0,0 +1,276 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package session keeps the state of users between requests.
+//
+// The session cookie only holds a random session ID, signed, and optionally
+// encrypted, by a Codec. The values of sessions are kept server side, by a
+// Store: MemoryStore, RedisStore or FirestoreStore. Sessions expire after
+// a period without requests, and after a maximum lifetime in any case.
+//
+// Values are stored as JSON: keys are strings, and numbers come back as
+// float64.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrNotFound is returned by Store.Load for sessions that don't exist.
+var ErrNotFound = errors.New("session: not found")
+
+// A Record is the stored state of a session.
+type Record struct {
+	// Values are the JSON-encoded values of the session.
+	Values []byte
+	// Created is when the session started, and LastSeen when it was last
+	// saved.
+	Created  time.Time
+	LastSeen time.Time
+	// Expires is when the session expires. Stores can delete the record
+	// after it.
+	Expires time.Time
+}
+
+// Store keeps session records by ID.
+type Store interface {
+	// Load returns the record of session id, or ErrNotFound. It may return
+	// expired records.
+	Load(ctx context.Context, id string) (*Record, error)
+	// Save saves the record of session id.
+	Save(ctx context.Context, id string, r *Record) error
+	// Delete deletes the record of session id, if any.
+	Delete(ctx context.Context, id string) error
+}
+
+// Defaults of a Manager.
+const (
+	DefaultName            = "session"
+	DefaultIdleTimeout     = 30 * time.Minute
+	DefaultAbsoluteTimeout = 7 * 24 * time.Hour
+)
+
+// A Manager gets and saves the sessions of requests.
+type Manager struct {
+	Store Store
+	Codec *Codec
+	// Name is the name of the cookie. Default: DefaultName.
+	Name string
+	// IdleTimeout is how long a session lasts without being saved.
+	// Default: DefaultIdleTimeout.
+	IdleTimeout time.Duration
+	// AbsoluteTimeout is how long a session lasts after it's created,
+	// however often it's saved. Default: DefaultAbsoluteTimeout.
+	AbsoluteTimeout time.Duration
+
+	// Cookie attributes. Cookies are always HttpOnly.
+	Path     string // Default: "/".
+	Domain   string
+	Secure   bool
+	SameSite http.SameSite // Default: http.SameSiteLaxMode.
+
+	// now returns the current time. Default: time.Now.
+	now func() time.Time
+}
+
+// A Session holds the values of a user's session.
+type Session struct {
+	// ID identifies the session. It's empty until the session is saved.
+	ID string
+	// Values are the values of the session, which must be JSON-encodable.
+	Values map[string]interface{}
+	// IsNew is set for sessions that weren't stored before the request.
+	IsNew   bool
+	Created time.Time
+
+	// oldID is the ID of a renewed session, to delete when it's saved.
+	oldID string
+}
+
+// Renew gives s a new ID when it's saved, and deletes its old ID. Renew
+// sessions when users sign in, so that an ID set before, by someone else,
+// doesn't get their privileges.
+func (s *Session) Renew() {
+	if s.ID != "" && s.oldID == "" {
+		s.oldID = s.ID
+	}
+	s.ID = ""
+}
+
+// Get returns the session of r. If r has no valid session cookie, or its
+// session expired, Get returns a new session. It also returns an error if
+// the cookie was invalid or the session couldn't be loaded, in which case
+// the new session can still be used.
+func (m *Manager) Get(r *http.Request) (*Session, error) {
+	now := m.time()
+	s := &Session{Values: map[string]interface{}{}, IsNew: true, Created: now}
+
+	c, err := r.Cookie(m.name())
+	if err != nil {
+		// No session yet.
+		return s, nil
+	}
+	id, err := m.Codec.Decode(m.name(), c.Value)
+	if err != nil {
+		return s, err
+	}
+	rec, err := m.Store.Load(r.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("session: Load: %w", err)
+	}
+	if !now.Before(rec.Expires) {
+		if err := m.Store.Delete(r.Context(), id); err != nil {
+			return s, fmt.Errorf("session: Delete: %w", err)
+		}
+		return s, nil
+	}
+	values := map[string]interface{}{}
+	if err := json.Unmarshal(rec.Values, &values); err != nil {
+		return s, fmt.Errorf("session: json.Unmarshal: %w", err)
+	}
+	return &Session{ID: id, Values: values, Created: rec.Created}, nil
+}
+
+// Save stores s, which extends it by IdleTimeout, and sets its cookie on w.
+// Save s before writing the body of the response.
+func (m *Manager) Save(ctx context.Context, w http.ResponseWriter, s *Session) error {
+	now := m.time()
+	expires := now.Add(m.idleTimeout())
+	if end := s.Created.Add(m.absoluteTimeout()); end.Before(expires) {
+		expires = end
+	}
+	if !now.Before(expires) {
+		// Past its lifetime: start again.
+		if s.ID != "" {
+			s.Renew()
+		}
+		s.Created = now
+		expires = now.Add(min(m.idleTimeout(), m.absoluteTimeout()))
+	}
+
+	values, err := json.Marshal(s.Values)
+	if err != nil {
+		return fmt.Errorf("session: json.Marshal: %w", err)
+	}
+	if s.ID == "" {
+		if s.ID, err = newID(); err != nil {
+			return fmt.Errorf("session: %w", err)
+		}
+	}
+	rec := &Record{Values: values, Created: s.Created, LastSeen: now, Expires: expires}
+	if err := m.Store.Save(ctx, s.ID, rec); err != nil {
+		return fmt.Errorf("session: Save: %w", err)
+	}
+	if s.oldID != "" {
+		if err := m.Store.Delete(ctx, s.oldID); err != nil {
+			return fmt.Errorf("session: Delete: %w", err)
+		}
+		s.oldID = ""
+	}
+
+	value, err := m.Codec.Encode(m.name(), s.ID)
+	if err != nil {
+		return fmt.Errorf("session: Encode: %w", err)
+	}
+	c := m.cookie(value)
+	c.Expires = expires
+	c.MaxAge = int(expires.Sub(now) / time.Second)
+	http.SetCookie(w, c)
+	s.IsNew = false
+	return nil
+}
+
+// Destroy deletes s and its cookie.
+func (m *Manager) Destroy(ctx context.Context, w http.ResponseWriter, s *Session) error {
+	for _, id := range []string{s.ID, s.oldID} {
+		if id == "" {
+			continue
+		}
+		if err := m.Store.Delete(ctx, id); err != nil {
+			return fmt.Errorf("session: Delete: %w", err)
+		}
+	}
+	s.ID, s.oldID = "", ""
+	s.Values = map[string]interface{}{}
+	c := m.cookie("")
+	c.MaxAge = -1
+	http.SetCookie(w, c)
+	return nil
+}
+
+func (m *Manager) cookie(value string) *http.Cookie {
+	c := &http.Cookie{
+		Name:     m.name(),
+		Value:    value,
+		Path:     m.Path,
+		Domain:   m.Domain,
+		Secure:   m.Secure,
+		HttpOnly: true,
+		SameSite: m.SameSite,
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	if c.SameSite == 0 {
+		c.SameSite = http.SameSiteLaxMode
+	}
+	return c
+}
+
+func (m *Manager) name() string {
+	if m.Name == "" {
+		return DefaultName
+	}
+	return m.Name
+}
+
+func (m *Manager) idleTimeout() time.Duration {
+	if m.IdleTimeout <= 0 {
+		return DefaultIdleTimeout
+	}
+	return m.IdleTimeout
+}
+
+func (m *Manager) absoluteTimeout() time.Duration {
+	if m.AbsoluteTimeout <= 0 {
+		return DefaultAbsoluteTimeout
+	}
+	return m.AbsoluteTimeout
+}
+
+func (m *Manager) time() time.Time {
+	if m.now == nil {
+		return time.Now()
+	}
+	return m.now()
+}
+
+// newID returns a random session ID.
+func newID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,239 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestKey(t *testing.T, encrypt bool) Key {
+	t.Helper()
+	k, err := NewKey(encrypt)
+	if err != nil {
+		t.Fatalf("NewKey: %v", err)
+	}
+	return k
+}
+
+func newTestCodec(t *testing.T, keys ...Key) *Codec {
+	t.Helper()
+	c, err := NewCodec(keys...)
+	if err != nil {
+		t.Fatalf("NewCodec: %v", err)
+	}
+	return c
+}
+
+func TestCodec(t *testing.T) {
+	for _, encrypt := range []bool{false, true} {
+		c := newTestCodec(t, newTestKey(t, encrypt))
+		cookie, err := c.Encode("name", "value")
+		if err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+		if got := strings.Contains(cookie, "dmFsdWU"); got == encrypt {
+			t.Errorf("encrypt %v: got cookie %q, readable: %v", encrypt, cookie, got)
+		}
+		if got, err := c.Decode("name", cookie); err != nil || got != "value" {
+			t.Errorf("encrypt %v: Decode got %q, %v, want %q", encrypt, got, err, "value")
+		}
+
+		for _, tc := range []struct{ name, cookie string }{
+			{"other", cookie},
+			{"name", "x" + cookie},
+			{"name", cookie[:len(cookie)-2]},
+			{"name", strings.Replace(cookie, ".", "", 1)},
+			{"name", ""},
+		} {
+			if _, err := c.Decode(tc.name, tc.cookie); !errors.Is(err, ErrInvalidCookie) {
+				t.Errorf("encrypt %v: Decode(%q, %q) got err %v, want ErrInvalidCookie", encrypt, tc.name, tc.cookie, err)
+			}
+		}
+	}
+}
+
+func TestCodecRotation(t *testing.T) {
+	oldKey, newKey := newTestKey(t, true), newTestKey(t, true)
+	old := newTestCodec(t, oldKey)
+	cookie, err := old.Encode("name", "value")
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	rotated := newTestCodec(t, newKey, oldKey)
+	if got, err := rotated.Decode("name", cookie); err != nil || got != "value" {
+		t.Errorf("Decode with the old key got %q, %v, want %q", got, err, "value")
+	}
+	cookie, err = rotated.Encode("name", "value")
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if _, err := old.Decode("name", cookie); !errors.Is(err, ErrInvalidCookie) {
+		t.Errorf("Decode of the new key with the old codec got err %v, want ErrInvalidCookie", err)
+	}
+
+	if _, err := NewCodec(Key{Hash: []byte("short")}); err == nil {
+		t.Errorf("NewCodec with a short key got no error")
+	}
+}
+
+// client makes requests to a Manager, keeping its cookie.
+type client struct {
+	t      *testing.T
+	m      *Manager
+	cookie *http.Cookie
+}
+
+// visit gets the session, calls f with it, and saves it.
+func (c *client) visit(f func(*Session)) *Session {
+	c.t.Helper()
+	r := httptest.NewRequest("GET", "/", nil)
+	if c.cookie != nil {
+		r.AddCookie(c.cookie)
+	}
+	s, err := c.m.Get(r)
+	if err != nil {
+		c.t.Fatalf("Get: %v", err)
+	}
+	if f != nil {
+		f(s)
+	}
+	w := httptest.NewRecorder()
+	if err := c.m.Save(r.Context(), w, s); err != nil {
+		c.t.Fatalf("Save: %v", err)
+	}
+	for _, ck := range w.Result().Cookies() {
+		c.cookie = ck
+	}
+	return s
+}
+
+func newTestManager(t *testing.T, now *time.Time) *Manager {
+	return &Manager{
+		Store:           NewMemoryStore(),
+		Codec:           newTestCodec(t, newTestKey(t, false)),
+		IdleTimeout:     time.Hour,
+		AbsoluteTimeout: 3 * time.Hour,
+		now:             func() time.Time { return *now },
+	}
+}
+
+func TestManager(t *testing.T) {
+	now := time.Now()
+	m := newTestManager(t, &now)
+	c := &client{t: t, m: m}
+
+	s := c.visit(func(s *Session) { s.Values["views"] = float64(1) })
+	if !c.cookie.HttpOnly || c.cookie.Name != DefaultName || c.cookie.MaxAge != 3600 {
+		t.Errorf("got cookie %+v, want HttpOnly %q for an hour", c.cookie, DefaultName)
+	}
+	id := s.ID
+
+	now = now.Add(30 * time.Minute)
+	s = c.visit(func(s *Session) {
+		if s.IsNew || s.Values["views"] != float64(1) {
+			t.Errorf("got session %+v, want the first one", s)
+		}
+		s.Values["views"] = s.Values["views"].(float64) + 1
+	})
+	if s.ID != id {
+		t.Errorf("got ID %q, want %q", s.ID, id)
+	}
+
+	// A renewed session keeps its values, with a new ID.
+	s = c.visit(func(s *Session) { s.Renew() })
+	if s.ID == id {
+		t.Errorf("Renew kept ID %q", id)
+	}
+	if _, err := m.Store.Load(context.Background(), id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Load of the old ID got err %v, want ErrNotFound", err)
+	}
+	c.visit(func(s *Session) {
+		if s.Values["views"] != float64(2) {
+			t.Errorf("got views %v after Renew, want 2", s.Values["views"])
+		}
+	})
+
+	w := httptest.NewRecorder()
+	if err := m.Destroy(context.Background(), w, s); err != nil {
+		t.Fatalf("Destroy: %v", err)
+	}
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || cookies[0].MaxAge >= 0 {
+		t.Errorf("Destroy set cookies %v, want one deleted", cookies)
+	}
+	c.visit(func(s *Session) {
+		if !s.IsNew {
+			t.Errorf("got session %+v after Destroy, want a new one", s)
+		}
+	})
+}
+
+func TestManagerExpiry(t *testing.T) {
+	now := time.Now()
+	m := newTestManager(t, &now)
+
+	// Idle for longer than IdleTimeout.
+	c := &client{t: t, m: m}
+	c.visit(nil)
+	now = now.Add(61 * time.Minute)
+	c.visit(func(s *Session) {
+		if !s.IsNew {
+			t.Errorf("got session of %v after idle timeout, want a new one", s.Created)
+		}
+	})
+
+	// Active, but for longer than AbsoluteTimeout.
+	c = &client{t: t, m: m}
+	c.visit(nil)
+	for i := 0; i < 4; i++ {
+		now = now.Add(40 * time.Minute)
+		c.visit(func(s *Session) {
+			if s.IsNew {
+				t.Fatalf("got a new session after %d visits, want the first one", i+2)
+			}
+		})
+	}
+	// The last save expires the session at its absolute timeout, in 20
+	// minutes: MaxAge is cut short.
+	if c.cookie.MaxAge != 20*60 {
+		t.Errorf("got MaxAge %d near the absolute timeout, want %d", c.cookie.MaxAge, 20*60)
+	}
+	now = now.Add(20 * time.Minute)
+	c.visit(func(s *Session) {
+		if !s.IsNew {
+			t.Errorf("got session of %v after absolute timeout, want a new one", s.Created)
+		}
+	})
+}
+
+func TestManagerInvalidCookie(t *testing.T) {
+	now := time.Now()
+	m := newTestManager(t, &now)
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: DefaultName, Value: "forged.c2lnbmF0dXJl"})
+	s, err := m.Get(r)
+	if !errors.Is(err, ErrInvalidCookie) {
+		t.Errorf("Get got err %v, want ErrInvalidCookie", err)
+	}
+	if s == nil || !s.IsNew {
+		t.Errorf("Get got session %+v, want a new one", s)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+)
+
+// testStore checks that s saves, loads and deletes records.
+func testStore(t *testing.T, s Store) {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Millisecond)
+	want := &Record{
+		Values:   []byte(`{"views":1}`),
+		Created:  now.Add(-time.Minute),
+		LastSeen: now,
+		Expires:  now.Add(time.Hour),
+	}
+
+	if _, err := s.Load(ctx, "id"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Load before Save got err %v, want ErrNotFound", err)
+	}
+	if err := s.Save(ctx, "id", want); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, err := s.Load(ctx, "id")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if string(got.Values) != string(want.Values) || !got.Created.Equal(want.Created) ||
+		!got.LastSeen.Equal(want.LastSeen) || !got.Expires.Equal(want.Expires) {
+		t.Errorf("Load got %+v, want %+v", got, want)
+	}
+	if err := s.Delete(ctx, "id"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Load(ctx, "id"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Load after Delete got err %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, "id"); err != nil {
+		t.Errorf("Delete twice: %v", err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestRedisStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	pool := &redis.Pool{
+		Dial: func() (redis.Conn, error) { return redis.Dial("tcp", mr.Addr()) },
+	}
+	defer pool.Close()
+	s := &RedisStore{Pool: pool}
+	testStore(t, s)
+
+	// Records expire with their sessions.
+	ctx := context.Background()
+	if err := s.Save(ctx, "id", &Record{Expires: time.Now().Add(time.Minute)}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	mr.FastForward(2 * time.Minute)
+	if _, err := s.Load(ctx, "id"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Load after expiry got err %v, want ErrNotFound", err)
+	}
+}
+
+func TestFirestoreStore(t *testing.T) {
+	projectID := os.Getenv("GOLANG_SAMPLES_FIRESTORE_PROJECT")
+	if projectID == "" {
+		t.Skip("GOLANG_SAMPLES_FIRESTORE_PROJECT not set")
+	}
+	client, err := firestore.NewClient(context.Background(), projectID)
+	if err != nil {
+		t.Fatalf("firestore.NewClient: %v", err)
+	}
+	defer client.Close()
+	testStore(t, &FirestoreStore{Client: client, Collection: "session-test"})
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dicomweb is a streaming DICOMweb client for Cloud Healthcare API
+// DICOM stores. It uploads many instances in a single STOW-RS request
+// without buffering them in memory, splits WADO-RS multipart/related
+// responses into one instance per part as they arrive, and builds QIDO-RS
+// queries whose DICOM JSON results can be read with typed accessors.
+package dicomweb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2/google"
+)
+
+// Client talks to the DICOMweb API of a single DICOM store.
+type Client struct {
+	hc   *http.Client
+	base string
+}
+
+// StoreURL returns the DICOMweb base URL of a Cloud Healthcare API DICOM
+// store.
+func StoreURL(projectID, location, datasetID, dicomStoreID string) string {
+	return fmt.Sprintf("https://healthcare.googleapis.com/v1/projects/%s/locations/%s/datasets/%s/dicomStores/%s/dicomWeb", projectID, location, datasetID, dicomStoreID)
+}
+
+// NewClient creates a Client for the given DICOM store that authenticates
+// with Application Default Credentials.
+func NewClient(ctx context.Context, projectID, location, datasetID, dicomStoreID string) (*Client, error) {
+	hc, err := google.DefaultClient(ctx, "https://www.googleapis.com/auth/cloud-platform")
+	if err != nil {
+		return nil, fmt.Errorf("google.DefaultClient: %w", err)
+	}
+	return New(hc, StoreURL(projectID, location, datasetID, dicomStoreID)), nil
+}
+
+// New creates a Client that sends requests for the DICOMweb server at
+// baseURL through hc. hc is responsible for authentication.
+func New(hc *http.Client, baseURL string) *Client {
+	return &Client{hc: hc, base: strings.TrimSuffix(baseURL, "/")}
+}
+
+// StatusError is returned when the server responds with a non-2xx status.
+type StatusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: status %d %s: %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+// send issues a request for path, relative to the base URL, and returns the
+// response if it has a 2xx status. The caller must close the body.
+func (c *Client) send(ctx context.Context, method, path string, h http.Header, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.base+"/"+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("NewRequest: %w", err)
+	}
+	for k, v := range h {
+		req.Header[k] = v
+	}
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Do: %w", err)
+	}
+	if resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		p := path
+		if i := strings.IndexByte(p, '?'); i >= 0 {
+			p = p[:i]
+		}
+		return nil, &StatusError{Method: method, Path: p, StatusCode: resp.StatusCode, Body: b}
+	}
+	return resp, nil
+}
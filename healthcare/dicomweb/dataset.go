@@ -0,0 +1,225 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dicomweb
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tag is a DICOM attribute tag in the eight hex digit form used as keys in
+// DICOM JSON, e.g. "0020000D".
+type Tag string
+
+// Tags used by QIDO-RS queries and STOW-RS responses.
+const (
+	TagSOPClassUID                    Tag = "00080016"
+	TagSOPInstanceUID                 Tag = "00080018"
+	TagStudyDate                      Tag = "00080020"
+	TagAccessionNumber                Tag = "00080050"
+	TagModality                       Tag = "00080060"
+	TagModalitiesInStudy              Tag = "00080061"
+	TagStudyDescription               Tag = "00081030"
+	TagSeriesDescription              Tag = "0008103E"
+	TagRetrieveURL                    Tag = "00081190"
+	TagFailureReason                  Tag = "00081197"
+	TagFailedSOPSequence              Tag = "00081198"
+	TagReferencedSOPSequence          Tag = "00081199"
+	TagReferencedSOPClassUID          Tag = "00081150"
+	TagReferencedSOPInstanceUID       Tag = "00081155"
+	TagPatientName                    Tag = "00100010"
+	TagPatientID                      Tag = "00100020"
+	TagPatientBirthDate               Tag = "00100030"
+	TagPatientSex                     Tag = "00100040"
+	TagStudyInstanceUID               Tag = "0020000D"
+	TagSeriesInstanceUID              Tag = "0020000E"
+	TagStudyID                        Tag = "00200010"
+	TagSeriesNumber                   Tag = "00200011"
+	TagInstanceNumber                 Tag = "00200013"
+	TagNumberOfStudyRelatedSeries     Tag = "00201206"
+	TagNumberOfStudyRelatedInstances  Tag = "00201208"
+	TagNumberOfSeriesRelatedInstances Tag = "00201209"
+)
+
+// Element is a single attribute of a DICOM JSON dataset.
+type Element struct {
+	VR          string            `json:"vr"`
+	Value       []json.RawMessage `json:"Value,omitempty"`
+	BulkDataURI string            `json:"BulkDataURI,omitempty"`
+	// InlineBinary is base64 encoded.
+	InlineBinary string `json:"InlineBinary,omitempty"`
+}
+
+// Dataset is a DICOM JSON dataset, as returned by QIDO-RS and STOW-RS.
+type Dataset map[Tag]Element
+
+// PersonName is a decoded PN value.
+type PersonName struct {
+	Alphabetic  string `json:"Alphabetic,omitempty"`
+	Ideographic string `json:"Ideographic,omitempty"`
+	Phonetic    string `json:"Phonetic,omitempty"`
+}
+
+// Strings returns every value of t as a string. Person names are returned
+// in their alphabetic form and numbers in their JSON form.
+func (d Dataset) Strings(t Tag) []string {
+	e, ok := d[t]
+	if !ok {
+		return nil
+	}
+	var out []string
+	for _, raw := range e.Value {
+		var s string
+		switch {
+		case e.VR == "PN":
+			var pn PersonName
+			json.Unmarshal(raw, &pn)
+			s = pn.Alphabetic
+		case json.Unmarshal(raw, &s) == nil:
+		default:
+			s = string(raw)
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// String returns the first value of t, or "".
+func (d Dataset) String(t Tag) string {
+	if v := d.Strings(t); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// Int returns the first value of t as an integer. It accepts both numeric
+// VRs such as US and UL and integer strings (IS).
+func (d Dataset) Int(t Tag) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(d.String(t)))
+	return n, err == nil
+}
+
+// Date returns the first value of a DA attribute.
+func (d Dataset) Date(t Tag) (time.Time, bool) {
+	v, err := time.Parse("20060102", d.String(t))
+	return v, err == nil
+}
+
+// PersonName returns the first value of a PN attribute with all of its
+// component groups.
+func (d Dataset) PersonName(t Tag) PersonName {
+	var pn PersonName
+	if e, ok := d[t]; ok && len(e.Value) > 0 {
+		json.Unmarshal(e.Value[0], &pn)
+	}
+	return pn
+}
+
+// Sequence returns the items of an SQ attribute.
+func (d Dataset) Sequence(t Tag) []Dataset {
+	e, ok := d[t]
+	if !ok {
+		return nil
+	}
+	var out []Dataset
+	for _, raw := range e.Value {
+		var item Dataset
+		if err := json.Unmarshal(raw, &item); err == nil {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// Study is the typed form of a QIDO-RS study result.
+type Study struct {
+	StudyInstanceUID  string
+	StudyDate         time.Time
+	StudyDescription  string
+	AccessionNumber   string
+	PatientName       string
+	PatientID         string
+	ModalitiesInStudy []string
+	NumberOfSeries    int
+	NumberOfInstances int
+	RetrieveURL       string
+}
+
+// Study decodes the study-level attributes of d.
+func (d Dataset) Study() Study {
+	s := Study{
+		StudyInstanceUID:  d.String(TagStudyInstanceUID),
+		StudyDescription:  d.String(TagStudyDescription),
+		AccessionNumber:   d.String(TagAccessionNumber),
+		PatientName:       d.String(TagPatientName),
+		PatientID:         d.String(TagPatientID),
+		ModalitiesInStudy: d.Strings(TagModalitiesInStudy),
+		RetrieveURL:       d.String(TagRetrieveURL),
+	}
+	s.StudyDate, _ = d.Date(TagStudyDate)
+	s.NumberOfSeries, _ = d.Int(TagNumberOfStudyRelatedSeries)
+	s.NumberOfInstances, _ = d.Int(TagNumberOfStudyRelatedInstances)
+	return s
+}
+
+// Series is the typed form of a QIDO-RS series result.
+type Series struct {
+	StudyInstanceUID  string
+	SeriesInstanceUID string
+	Modality          string
+	SeriesNumber      int
+	SeriesDescription string
+	NumberOfInstances int
+	RetrieveURL       string
+}
+
+// Series decodes the series-level attributes of d.
+func (d Dataset) Series() Series {
+	s := Series{
+		StudyInstanceUID:  d.String(TagStudyInstanceUID),
+		SeriesInstanceUID: d.String(TagSeriesInstanceUID),
+		Modality:          d.String(TagModality),
+		SeriesDescription: d.String(TagSeriesDescription),
+		RetrieveURL:       d.String(TagRetrieveURL),
+	}
+	s.SeriesNumber, _ = d.Int(TagSeriesNumber)
+	s.NumberOfInstances, _ = d.Int(TagNumberOfSeriesRelatedInstances)
+	return s
+}
+
+// Instance is the typed form of a QIDO-RS instance result.
+type Instance struct {
+	StudyInstanceUID  string
+	SeriesInstanceUID string
+	SOPInstanceUID    string
+	SOPClassUID       string
+	InstanceNumber    int
+	RetrieveURL       string
+}
+
+// Instance decodes the instance-level attributes of d.
+func (d Dataset) Instance() Instance {
+	i := Instance{
+		StudyInstanceUID:  d.String(TagStudyInstanceUID),
+		SeriesInstanceUID: d.String(TagSeriesInstanceUID),
+		SOPInstanceUID:    d.String(TagSOPInstanceUID),
+		SOPClassUID:       d.String(TagSOPClassUID),
+		RetrieveURL:       d.String(TagRetrieveURL),
+	}
+	i.InstanceNumber, _ = d.Int(TagInstanceNumber)
+	return i
+}
@@ -0,0 +1,247 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dicomweb
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var testInstances = []instance{
+	{study: "1.2.3", series: "1.2.3.1", sop: "1.2.3.1.1", patientID: "P1", modality: "CT"},
+	{study: "1.2.3", series: "1.2.3.1", sop: "1.2.3.1.2", patientID: "P1", modality: "CT"},
+	{study: "1.2.3", series: "1.2.3.2", sop: "1.2.3.2.1", patientID: "P1", modality: "MR"},
+	{study: "4.5.6", series: "4.5.6.1", sop: "4.5.6.1.1", patientID: "P2", modality: "CT"},
+}
+
+func storeAll(t *testing.T, c *Client) {
+	t.Helper()
+	var sources []Source
+	for _, in := range testInstances {
+		sources = append(sources, ReaderSource(in.sop, bytes.NewReader(in.encode())))
+	}
+	res, err := c.Store(context.Background(), "", sources)
+	if err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if len(res.Stored) != len(testInstances) {
+		t.Fatalf("Store stored %d instances, want %d", len(res.Stored), len(testInstances))
+	}
+}
+
+func TestStoreFromFiles(t *testing.T) {
+	_, c := newFakeDICOMweb(t)
+	dir := t.TempDir()
+
+	var sources []Source
+	for _, in := range testInstances[:3] {
+		path := filepath.Join(dir, in.sop+".dcm")
+		if err := os.WriteFile(path, in.encode(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		sources = append(sources, FileSource(path))
+	}
+
+	res, err := c.Store(context.Background(), "1.2.3", sources)
+	if err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if len(res.Stored) != 3 || len(res.Failed) != 0 {
+		t.Fatalf("Store = %d stored, %d failed; want 3, 0", len(res.Stored), len(res.Failed))
+	}
+	if got := res.Stored[1].SOPInstanceUID; got != "1.2.3.1.2" {
+		t.Errorf("Stored[1].SOPInstanceUID = %q", got)
+	}
+	if res.RetrieveURL == "" {
+		t.Errorf("RetrieveURL is empty")
+	}
+}
+
+func TestStorePartialFailure(t *testing.T) {
+	_, c := newFakeDICOMweb(t)
+
+	// The last instance belongs to another study.
+	var sources []Source
+	for _, in := range []instance{testInstances[0], testInstances[3]} {
+		sources = append(sources, ReaderSource(in.sop, bytes.NewReader(in.encode())))
+	}
+	res, err := c.Store(context.Background(), "1.2.3", sources)
+	if !errors.Is(err, ErrPartialStore) {
+		t.Fatalf("Store got err %v, want ErrPartialStore", err)
+	}
+	if len(res.Stored) != 1 || len(res.Failed) != 1 {
+		t.Fatalf("Store = %d stored, %d failed; want 1, 1", len(res.Stored), len(res.Failed))
+	}
+	if res.Failed[0].FailureReason != 0xA900 {
+		t.Errorf("FailureReason = %#x, want 0xa900", res.Failed[0].FailureReason)
+	}
+}
+
+func TestStoreMissingFile(t *testing.T) {
+	_, c := newFakeDICOMweb(t)
+	_, err := c.Store(context.Background(), "", []Source{FileSource(filepath.Join(t.TempDir(), "missing.dcm"))})
+	if err == nil {
+		t.Fatal("Store of a missing file succeeded")
+	}
+}
+
+func TestRetrieveStudy(t *testing.T) {
+	_, c := newFakeDICOMweb(t)
+	storeAll(t, c)
+
+	d := &DirWriter{Dir: t.TempDir()}
+	if err := c.RetrieveStudy(context.Background(), "1.2.3", d.Write); err != nil {
+		t.Fatalf("RetrieveStudy: %v", err)
+	}
+	if len(d.Files) != 3 {
+		t.Fatalf("RetrieveStudy wrote %d files, want 3", len(d.Files))
+	}
+	for i, in := range testInstances[:3] {
+		if want := filepath.Join(d.Dir, in.sop+".dcm"); d.Files[i] != want {
+			t.Errorf("file %d = %s, want %s", i, d.Files[i], want)
+		}
+		got, err := os.ReadFile(d.Files[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, in.encode()) {
+			t.Errorf("%s: retrieved bytes differ from stored bytes", in.sop)
+		}
+	}
+
+	var n int
+	err := c.RetrieveInstance(context.Background(), "4.5.6", "4.5.6.1", "4.5.6.1.1", func(p *Part) error {
+		n++
+		if p.Meta == nil || p.Meta.TransferSyntaxUID != explicitVRLittleEnd {
+			t.Errorf("Meta = %+v", p.Meta)
+		}
+		_, err := io.Copy(io.Discard, p.Body)
+		return err
+	})
+	if err != nil || n != 1 {
+		t.Errorf("RetrieveInstance = %d parts, err %v; want 1 part", n, err)
+	}
+
+	var se *StatusError
+	if err := c.RetrieveStudy(context.Background(), "9.9.9", d.Write); !errors.As(err, &se) || se.StatusCode != 404 {
+		t.Errorf("RetrieveStudy of a missing study got err %v, want 404", err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	_, c := newFakeDICOMweb(t)
+	storeAll(t, c)
+	ctx := context.Background()
+
+	studies, err := c.SearchStudies(ctx, NewQuery().PatientID("P1"))
+	if err != nil {
+		t.Fatalf("SearchStudies: %v", err)
+	}
+	if len(studies) != 1 {
+		t.Fatalf("SearchStudies returned %d studies, want 1", len(studies))
+	}
+	s := studies[0].Study()
+	if s.StudyInstanceUID != "1.2.3" || s.PatientName != "DOE^JANE" || s.NumberOfInstances != 3 {
+		t.Errorf("Study() = %+v", s)
+	}
+	if pn := studies[0].PersonName(TagPatientName); pn.Alphabetic != "DOE^JANE" {
+		t.Errorf("PersonName = %+v", pn)
+	}
+
+	series, err := c.SearchSeries(ctx, "1.2.3", NewQuery().Modality("MR"))
+	if err != nil {
+		t.Fatalf("SearchSeries: %v", err)
+	}
+	if len(series) != 1 || series[0].Series().SeriesInstanceUID != "1.2.3.2" {
+		t.Errorf("SearchSeries = %+v", series)
+	}
+
+	instances, err := c.SearchInstances(ctx, "1.2.3", "1.2.3.1", NewQuery().Limit(1).Offset(1))
+	if err != nil {
+		t.Fatalf("SearchInstances: %v", err)
+	}
+	if len(instances) != 1 || instances[0].Instance().SOPInstanceUID != "1.2.3.1.2" {
+		t.Errorf("SearchInstances = %+v", instances)
+	}
+
+	none, err := c.SearchStudies(ctx, NewQuery().PatientID("nobody"))
+	if err != nil || len(none) != 0 {
+		t.Errorf("SearchStudies with no matches = %v, %v; want empty", none, err)
+	}
+
+	if _, err := c.SearchInstances(ctx, "", "1.2.3.1", nil); err == nil {
+		t.Errorf("SearchInstances with a series but no study succeeded")
+	}
+}
+
+func TestQueryEncode(t *testing.T) {
+	from := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		q    *Query
+		want string
+	}{
+		{NewQuery().StudyDate(from, to), "00080020=20240102-20240304"},
+		{NewQuery().StudyDate(from, time.Time{}), "00080020=20240102-"},
+		{NewQuery().StudyDate(time.Time{}, to), "00080020=-20240304"},
+		{NewQuery().StudyDate(from, from), "00080020=20240102"},
+		{NewQuery().PatientName("DOE^J*").Fuzzy(), "00100010=DOE%5EJ%2A&fuzzymatching=true"},
+		{NewQuery().IncludeField(TagStudyDescription, TagPatientBirthDate), "includefield=00081030&includefield=00100030"},
+		{(&Query{}).Limit(5), "limit=5"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.q.Encode(); got != tt.want {
+			t.Errorf("Encode() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestPeekMetaTestdata(t *testing.T) {
+	f, err := os.Open("../testdata/dicom_00000001_000.dcm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	br := bufio.NewReaderSize(f, 64<<10)
+	meta, err := peekMeta(br)
+	if err != nil {
+		t.Fatalf("peekMeta: %v", err)
+	}
+	if want := "1.2.840.10008.5.1.4.1.1.7"; meta.MediaStorageSOPClassUID != want {
+		t.Errorf("MediaStorageSOPClassUID = %q, want %q", meta.MediaStorageSOPClassUID, want)
+	}
+	if want := "1.2.840.10008.1.2"; meta.TransferSyntaxUID != want {
+		t.Errorf("TransferSyntaxUID = %q, want %q", meta.TransferSyntaxUID, want)
+	}
+
+	// Peeking must not consume the file.
+	head := make([]byte, 132)
+	if _, err := io.ReadFull(br, head); err != nil || string(head[128:]) != "DICM" {
+		t.Errorf("stream was consumed by peekMeta")
+	}
+
+	if _, err := peekMeta(bufio.NewReader(bytes.NewReader([]byte("not dicom")))); err == nil {
+		t.Errorf("peekMeta of non-DICOM data succeeded")
+	}
+}
@@ -0,0 +1,352 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dicomweb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// instance is a synthetic DICOM instance. The tests only need the
+// identifying attributes, so the dataset holds nothing else apart from some
+// pixel data filler.
+type instance struct {
+	study, series, sop, patientID, modality string
+}
+
+const (
+	ctImageStorage      = "1.2.840.10008.5.1.4.1.1.2"
+	explicitVRLittleEnd = "1.2.840.10008.1.2.1"
+)
+
+// element encodes an explicit VR little endian element with an even-length
+// value.
+func element(group, elem uint16, vr, value string) []byte {
+	v := []byte(value)
+	if len(v)%2 == 1 {
+		if vr == "UI" {
+			v = append(v, 0)
+		} else {
+			v = append(v, ' ')
+		}
+	}
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, group)
+	binary.Write(&b, binary.LittleEndian, elem)
+	b.WriteString(vr)
+	switch vr {
+	case "OB", "OW", "UN":
+		b.Write([]byte{0, 0})
+		binary.Write(&b, binary.LittleEndian, uint32(len(v)))
+	default:
+		binary.Write(&b, binary.LittleEndian, uint16(len(v)))
+	}
+	b.Write(v)
+	return b.Bytes()
+}
+
+// encode returns the instance as a DICOM Part 10 file.
+func (in instance) encode() []byte {
+	var meta bytes.Buffer
+	meta.Write(element(0x0002, 0x0001, "OB", "\x00\x01"))
+	meta.Write(element(0x0002, 0x0002, "UI", ctImageStorage))
+	meta.Write(element(0x0002, 0x0003, "UI", in.sop))
+	meta.Write(element(0x0002, 0x0010, "UI", explicitVRLittleEnd))
+
+	var b bytes.Buffer
+	b.Write(make([]byte, 128))
+	b.WriteString("DICM")
+	groupLen := make([]byte, 4)
+	binary.LittleEndian.PutUint32(groupLen, uint32(meta.Len()))
+	b.Write(element(0x0002, 0x0000, "UL", string(groupLen)))
+	b.Write(meta.Bytes())
+
+	b.Write(element(0x0008, 0x0016, "UI", ctImageStorage))
+	b.Write(element(0x0008, 0x0018, "UI", in.sop))
+	b.Write(element(0x0008, 0x0060, "CS", in.modality))
+	b.Write(element(0x0010, 0x0020, "LO", in.patientID))
+	b.Write(element(0x0020, 0x000D, "UI", in.study))
+	b.Write(element(0x0020, 0x000E, "UI", in.series))
+	b.Write(element(0x7FE0, 0x0010, "OB", strings.Repeat("\x7f", 100<<10)))
+	return b.Bytes()
+}
+
+// decodeInstance reads the identifying attributes back from a file written
+// by encode.
+func decodeInstance(data []byte) (instance, error) {
+	if len(data) < preambleLen+12 || string(data[128:132]) != "DICM" {
+		return instance{}, errNotPart10
+	}
+	groupLen := int(binary.LittleEndian.Uint32(data[preambleLen+8:]))
+	b := data[preambleLen+12+groupLen:]
+
+	var in instance
+	for len(b) >= 8 {
+		tag := fmt.Sprintf("%04X%04X", binary.LittleEndian.Uint16(b[0:]), binary.LittleEndian.Uint16(b[2:]))
+		vr := string(b[4:6])
+		n, hdr := int(binary.LittleEndian.Uint16(b[6:])), 8
+		if vr == "OB" || vr == "OW" || vr == "UN" {
+			n, hdr = int(binary.LittleEndian.Uint32(b[8:])), 12
+		}
+		if len(b) < hdr+n {
+			return instance{}, fmt.Errorf("truncated element %s", tag)
+		}
+		v := strings.TrimRight(string(b[hdr:hdr+n]), "\x00 ")
+		switch Tag(tag) {
+		case TagSOPInstanceUID:
+			in.sop = v
+		case TagModality:
+			in.modality = v
+		case TagPatientID:
+			in.patientID = v
+		case TagStudyInstanceUID:
+			in.study = v
+		case TagSeriesInstanceUID:
+			in.series = v
+		}
+		b = b[hdr+n:]
+	}
+	return in, nil
+}
+
+// fakeDICOMweb is an in-memory DICOMweb server supporting STOW-RS, WADO-RS
+// retrieval of studies, series and instances, and QIDO-RS searches on
+// PatientID, Modality and the UID attributes.
+type fakeDICOMweb struct {
+	srv *httptest.Server
+
+	mu        sync.Mutex
+	instances map[string]instance
+	data      map[string][]byte
+}
+
+func newFakeDICOMweb(t *testing.T) (*fakeDICOMweb, *Client) {
+	t.Helper()
+	f := &fakeDICOMweb{instances: make(map[string]instance), data: make(map[string][]byte)}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.srv.Close)
+	return f, New(f.srv.Client(), f.srv.URL+"/dicomWeb")
+}
+
+func (f *fakeDICOMweb) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/dicomWeb"), "/"), "/")
+	// Map the path to study, series and instance filters.
+	filter := map[Tag]string{}
+	level := parts[len(parts)-1]
+	for i := 0; i+1 < len(parts); i += 2 {
+		switch parts[i] {
+		case "studies":
+			filter[TagStudyInstanceUID] = parts[i+1]
+		case "series":
+			filter[TagSeriesInstanceUID] = parts[i+1]
+		case "instances":
+			filter[TagSOPInstanceUID] = parts[i+1]
+		}
+	}
+
+	switch {
+	case r.Method == http.MethodPost && parts[0] == "studies":
+		f.store(w, r, filter[TagStudyInstanceUID])
+	case r.Method == http.MethodGet && strings.HasPrefix(r.Header.Get("Accept"), "multipart/related"):
+		f.retrieve(w, filter)
+	case r.Method == http.MethodGet:
+		f.search(w, r, level, filter)
+	default:
+		http.Error(w, "unsupported", http.StatusBadRequest)
+	}
+}
+
+func (f *fakeDICOMweb) store(w http.ResponseWriter, r *http.Request, study string) {
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var stored, failed []interface{}
+	mr := multipart.NewReader(r.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(p)
+		in, err := decodeInstance(data)
+		if err != nil || (study != "" && in.study != study) {
+			failed = append(failed, map[string]interface{}{
+				string(TagReferencedSOPInstanceUID): uiValue(in.sop),
+				string(TagFailureReason):            map[string]interface{}{"vr": "US", "Value": []int{0xA900}},
+			})
+			continue
+		}
+		f.mu.Lock()
+		f.instances[in.sop] = in
+		f.data[in.sop] = data
+		f.mu.Unlock()
+		stored = append(stored, map[string]interface{}{
+			string(TagReferencedSOPClassUID):    uiValue(ctImageStorage),
+			string(TagReferencedSOPInstanceUID): uiValue(in.sop),
+			string(TagRetrieveURL):              uiValue(f.srv.URL + "/dicomWeb/studies/" + in.study + "/series/" + in.series + "/instances/" + in.sop),
+		})
+	}
+
+	resp := map[string]interface{}{}
+	if study != "" {
+		resp[string(TagRetrieveURL)] = uiValue(f.srv.URL + "/dicomWeb/studies/" + study)
+	}
+	if len(stored) > 0 {
+		resp[string(TagReferencedSOPSequence)] = map[string]interface{}{"vr": "SQ", "Value": stored}
+	}
+	status := http.StatusOK
+	if len(failed) > 0 {
+		resp[string(TagFailedSOPSequence)] = map[string]interface{}{"vr": "SQ", "Value": failed}
+		status = http.StatusConflict
+	}
+	w.Header().Set("Content-Type", "application/dicom+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// uiValue returns a single-valued UI element in DICOM JSON form.
+func uiValue(v string) map[string]interface{} {
+	return map[string]interface{}{"vr": "UI", "Value": []string{v}}
+}
+
+func (f *fakeDICOMweb) match(filter map[Tag]string) []instance {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []instance
+	for _, in := range f.instances {
+		attrs := map[Tag]string{
+			TagStudyInstanceUID:  in.study,
+			TagSeriesInstanceUID: in.series,
+			TagSOPInstanceUID:    in.sop,
+			TagPatientID:         in.patientID,
+			TagModality:          in.modality,
+		}
+		ok := true
+		for t, v := range filter {
+			if attrs[t] != v {
+				ok = false
+			}
+		}
+		if ok {
+			out = append(out, in)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].sop < out[j].sop })
+	return out
+}
+
+func (f *fakeDICOMweb) retrieve(w http.ResponseWriter, filter map[Tag]string) {
+	matches := f.match(filter)
+	if len(matches) == 0 {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	mw := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", fmt.Sprintf(`multipart/related; type="application/dicom"; boundary=%s`, mw.Boundary()))
+	for _, in := range matches {
+		pw, _ := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"application/dicom; transfer-syntax=" + explicitVRLittleEnd}})
+		f.mu.Lock()
+		data := f.data[in.sop]
+		f.mu.Unlock()
+		pw.Write(data)
+	}
+	mw.Close()
+}
+
+func (f *fakeDICOMweb) search(w http.ResponseWriter, r *http.Request, level string, filter map[Tag]string) {
+	q := r.URL.Query()
+	for _, t := range []Tag{TagPatientID, TagModality, TagStudyInstanceUID, TagSeriesInstanceUID} {
+		if v := q.Get(string(t)); v != "" {
+			filter[t] = v
+		}
+	}
+	matches := f.match(filter)
+
+	// Collapse instances to the requested level.
+	var results []map[string]interface{}
+	seen := map[string]int{}
+	for _, in := range matches {
+		key := in.sop
+		switch level {
+		case "studies":
+			key = in.study
+		case "series":
+			key = in.series
+		}
+		if i, ok := seen[key]; ok {
+			n := results[i]["count"].(int) + 1
+			results[i]["count"] = n
+			continue
+		}
+		seen[key] = len(results)
+		res := map[string]interface{}{
+			string(TagStudyInstanceUID): uiValue(in.study),
+			string(TagPatientID):        map[string]interface{}{"vr": "LO", "Value": []string{in.patientID}},
+			string(TagPatientName):      map[string]interface{}{"vr": "PN", "Value": []interface{}{map[string]string{"Alphabetic": "DOE^JANE"}}},
+			"count":                     1,
+		}
+		if level != "studies" {
+			res[string(TagSeriesInstanceUID)] = uiValue(in.series)
+			res[string(TagModality)] = map[string]interface{}{"vr": "CS", "Value": []string{in.modality}}
+		}
+		if level == "instances" {
+			res[string(TagSOPInstanceUID)] = uiValue(in.sop)
+		}
+		results = append(results, res)
+	}
+
+	for _, res := range results {
+		n := res["count"].(int)
+		delete(res, "count")
+		switch level {
+		case "studies":
+			res[string(TagNumberOfStudyRelatedInstances)] = map[string]interface{}{"vr": "IS", "Value": []int{n}}
+		case "series":
+			res[string(TagNumberOfSeriesRelatedInstances)] = map[string]interface{}{"vr": "IS", "Value": []int{n}}
+		}
+	}
+
+	if off, err := strconv.Atoi(q.Get("offset")); err == nil && off < len(results) {
+		results = results[off:]
+	}
+	if lim, err := strconv.Atoi(q.Get("limit")); err == nil && lim < len(results) {
+		results = results[:lim]
+	}
+	if len(results) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/dicom+json")
+	json.NewEncoder(w).Encode(results)
+}
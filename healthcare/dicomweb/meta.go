@@ -0,0 +1,96 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dicomweb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Meta holds the File Meta Information (group 0002) of a DICOM Part 10 file.
+type Meta struct {
+	MediaStorageSOPClassUID    string
+	MediaStorageSOPInstanceUID string
+	TransferSyntaxUID          string
+}
+
+// preambleLen is the length of the preamble plus the "DICM" prefix.
+const preambleLen = 128 + 4
+
+// errNotPart10 is returned for data without a DICOM Part 10 header.
+var errNotPart10 = errors.New("dicomweb: not a DICOM Part 10 file")
+
+// peekMeta reads the File Meta Information of the Part 10 file at the start
+// of br without consuming any input, so the file can still be copied in
+// full afterwards.
+func peekMeta(br *bufio.Reader) (*Meta, error) {
+	// The first element is always (0002,0000) UL, whose value is the
+	// length of the rest of the group.
+	head, err := br.Peek(preambleLen + 12)
+	if err != nil {
+		return nil, errNotPart10
+	}
+	if string(head[128:132]) != "DICM" {
+		return nil, errNotPart10
+	}
+	e := head[preambleLen:]
+	if binary.LittleEndian.Uint16(e[0:]) != 0x0002 || binary.LittleEndian.Uint16(e[2:]) != 0x0000 || string(e[4:6]) != "UL" {
+		return nil, fmt.Errorf("dicomweb: missing file meta group length")
+	}
+	groupLen := int(binary.LittleEndian.Uint32(e[8:]))
+	full, err := br.Peek(preambleLen + 12 + groupLen)
+	if err != nil {
+		return nil, fmt.Errorf("dicomweb: reading file meta information: %w", err)
+	}
+	return parseMeta(full[preambleLen+12:])
+}
+
+// parseMeta parses the elements of group 0002, which are always encoded as
+// explicit VR little endian.
+func parseMeta(b []byte) (*Meta, error) {
+	m := &Meta{}
+	for len(b) >= 8 {
+		group := binary.LittleEndian.Uint16(b[0:])
+		elem := binary.LittleEndian.Uint16(b[2:])
+		vr := string(b[4:6])
+		var n, hdr int
+		switch vr {
+		case "OB", "OD", "OF", "OL", "OV", "OW", "SQ", "SV", "UC", "UN", "UR", "UT", "UV":
+			if len(b) < 12 {
+				return nil, errors.New("dicomweb: truncated file meta information")
+			}
+			n, hdr = int(binary.LittleEndian.Uint32(b[8:])), 12
+		default:
+			n, hdr = int(binary.LittleEndian.Uint16(b[6:])), 8
+		}
+		if group != 0x0002 || len(b) < hdr+n {
+			return nil, errors.New("dicomweb: malformed file meta information")
+		}
+		v := strings.TrimRight(string(b[hdr:hdr+n]), "\x00 ")
+		switch elem {
+		case 0x0002:
+			m.MediaStorageSOPClassUID = v
+		case 0x0003:
+			m.MediaStorageSOPInstanceUID = v
+		case 0x0010:
+			m.TransferSyntaxUID = v
+		}
+		b = b[hdr+n:]
+	}
+	return m, nil
+}
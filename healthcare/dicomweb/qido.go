@@ -0,0 +1,188 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dicomweb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Query holds QIDO-RS search parameters. The zero value is an empty query.
+// Methods return the receiver so calls can be chained:
+//
+//	q := dicomweb.NewQuery().
+//		PatientID("PID1234").
+//		Modality("CT").
+//		StudyDate(from, time.Time{}).
+//		Limit(20)
+type Query struct {
+	v url.Values
+}
+
+// NewQuery returns an empty Query.
+func NewQuery() *Query {
+	return &Query{v: url.Values{}}
+}
+
+// Match adds an attribute matching parameter. value may contain the * and ?
+// wildcards for attributes that support wildcard matching.
+func (q *Query) Match(t Tag, value string) *Query {
+	q.values().Add(string(t), value)
+	return q
+}
+
+// PatientName matches on PatientName, e.g. "DOE^J*".
+func (q *Query) PatientName(v string) *Query { return q.Match(TagPatientName, v) }
+
+// PatientID matches on PatientID.
+func (q *Query) PatientID(v string) *Query { return q.Match(TagPatientID, v) }
+
+// AccessionNumber matches on AccessionNumber.
+func (q *Query) AccessionNumber(v string) *Query { return q.Match(TagAccessionNumber, v) }
+
+// Modality matches series or instances of the given modality.
+func (q *Query) Modality(v string) *Query { return q.Match(TagModality, v) }
+
+// StudyInstanceUID matches a single study.
+func (q *Query) StudyInstanceUID(v string) *Query { return q.Match(TagStudyInstanceUID, v) }
+
+// SeriesInstanceUID matches a single series.
+func (q *Query) SeriesInstanceUID(v string) *Query { return q.Match(TagSeriesInstanceUID, v) }
+
+// StudyDate matches studies performed in the inclusive date range. A zero
+// from or to leaves that end of the range open.
+func (q *Query) StudyDate(from, to time.Time) *Query {
+	var v string
+	if !from.IsZero() {
+		v = from.Format("20060102")
+	}
+	if !from.Equal(to) {
+		v += "-"
+		if !to.IsZero() {
+			v += to.Format("20060102")
+		}
+	}
+	return q.Match(TagStudyDate, v)
+}
+
+// IncludeField asks for attributes that are not returned by default.
+func (q *Query) IncludeField(tags ...Tag) *Query {
+	for _, t := range tags {
+		q.values().Add("includefield", string(t))
+	}
+	return q
+}
+
+// IncludeAll asks for every available attribute.
+func (q *Query) IncludeAll() *Query {
+	q.values().Set("includefield", "all")
+	return q
+}
+
+// Fuzzy enables fuzzy matching of person names.
+func (q *Query) Fuzzy() *Query {
+	q.values().Set("fuzzymatching", "true")
+	return q
+}
+
+// Limit caps the number of results.
+func (q *Query) Limit(n int) *Query {
+	q.values().Set("limit", strconv.Itoa(n))
+	return q
+}
+
+// Offset skips the first n results.
+func (q *Query) Offset(n int) *Query {
+	q.values().Set("offset", strconv.Itoa(n))
+	return q
+}
+
+func (q *Query) values() url.Values {
+	if q.v == nil {
+		q.v = url.Values{}
+	}
+	return q.v
+}
+
+// Encode returns the parameters in URL query form. A nil Query is empty.
+func (q *Query) Encode() string {
+	if q == nil {
+		return ""
+	}
+	return q.v.Encode()
+}
+
+// SearchStudies searches for studies.
+func (c *Client) SearchStudies(ctx context.Context, q *Query) ([]Dataset, error) {
+	return c.search(ctx, "studies", q)
+}
+
+// SearchSeries searches for series, within a study if studyUID is not
+// empty.
+func (c *Client) SearchSeries(ctx context.Context, studyUID string, q *Query) ([]Dataset, error) {
+	path := "series"
+	if studyUID != "" {
+		path = "studies/" + studyUID + "/series"
+	}
+	return c.search(ctx, path, q)
+}
+
+// SearchInstances searches for instances, within a study and series if
+// they are not empty.
+func (c *Client) SearchInstances(ctx context.Context, studyUID, seriesUID string, q *Query) ([]Dataset, error) {
+	path := "instances"
+	switch {
+	case studyUID != "" && seriesUID != "":
+		path = "studies/" + studyUID + "/series/" + seriesUID + "/instances"
+	case studyUID != "":
+		path = "studies/" + studyUID + "/instances"
+	case seriesUID != "":
+		return nil, fmt.Errorf("dicomweb: searching a series requires its study UID")
+	}
+	return c.search(ctx, path, q)
+}
+
+func (c *Client) search(ctx context.Context, path string, q *Query) ([]Dataset, error) {
+	if enc := q.Encode(); enc != "" {
+		path += "?" + enc
+	}
+	resp, err := c.send(ctx, http.MethodGet, path, http.Header{"Accept": {"application/dicom+json"}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read response: %w", err)
+	}
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var results []Dataset
+	if err := json.Unmarshal(b, &results); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	return results, nil
+}
@@ -0,0 +1,178 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dicomweb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+)
+
+// Source is a DICOM Part 10 file to upload. Sources are opened one at a
+// time while the request body is written, so only one file is open at once
+// and none is held in memory.
+type Source struct {
+	// Name identifies the source in errors.
+	Name string
+	Open func() (io.ReadCloser, error)
+}
+
+// FileSource returns a Source that reads the file at path.
+func FileSource(path string) Source {
+	return Source{
+		Name: path,
+		Open: func() (io.ReadCloser, error) { return os.Open(path) },
+	}
+}
+
+// ReaderSource returns a Source that reads from r. It can only be stored
+// once.
+func ReaderSource(name string, r io.Reader) Source {
+	return Source{
+		Name: name,
+		Open: func() (io.ReadCloser, error) { return io.NopCloser(r), nil },
+	}
+}
+
+// SOPReference identifies a stored or failed instance in a STOW-RS
+// response.
+type SOPReference struct {
+	SOPClassUID    string
+	SOPInstanceUID string
+	RetrieveURL    string
+	// FailureReason is the DICOM failure reason code of a failed
+	// instance, e.g. 0xA700 (out of resources) or 0xC000 (cannot
+	// understand).
+	FailureReason int
+}
+
+// StoreResult is the decoded STOW-RS response.
+type StoreResult struct {
+	// RetrieveURL is the URL of the study the instances were stored in.
+	RetrieveURL string
+	Stored      []SOPReference
+	Failed      []SOPReference
+}
+
+// ErrPartialStore is wrapped by the error returned from Store when the
+// server stored some instances but rejected others. The StoreResult is
+// still returned.
+var ErrPartialStore = errors.New("dicomweb: some instances were not stored")
+
+// Store uploads instances in a single multipart/related STOW-RS request. If
+// studyUID is not empty, every instance must belong to that study.
+//
+// The request body is streamed: each source is opened, copied and closed in
+// turn while the request is in flight.
+func (c *Client) Store(ctx context.Context, studyUID string, sources []Source) (*StoreResult, error) {
+	if len(sources) == 0 {
+		return nil, errors.New("dicomweb: no instances to store")
+	}
+	path := "studies"
+	if studyUID != "" {
+		path += "/" + studyUID
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeParts(mw, sources))
+	}()
+
+	h := http.Header{
+		"Content-Type": {fmt.Sprintf(`multipart/related; type="application/dicom"; boundary=%s`, mw.Boundary())},
+		"Accept":       {"application/dicom+json"},
+	}
+	resp, err := c.send(ctx, http.MethodPost, path, h, pr)
+	// Unblock the writer if the request failed before reading the body.
+	pr.CloseWithError(io.ErrClosedPipe)
+
+	var se *StatusError
+	if errors.As(err, &se) && se.StatusCode == http.StatusConflict {
+		// Some instances failed. The body still describes what happened.
+		res, perr := decodeStoreResult(se.Body)
+		if perr != nil {
+			return nil, err
+		}
+		return res, fmt.Errorf("%w: %d stored, %d failed", ErrPartialStore, len(res.Stored), len(res.Failed))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read response: %w", err)
+	}
+	return decodeStoreResult(b)
+}
+
+func writeParts(mw *multipart.Writer, sources []Source) error {
+	for _, s := range sources {
+		if err := writePart(mw, s); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+func writePart(mw *multipart.Writer, s Source) error {
+	rc, err := s.Open()
+	if err != nil {
+		return fmt.Errorf("open %s: %w", s.Name, err)
+	}
+	defer rc.Close()
+
+	w, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"application/dicom"}})
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, rc); err != nil {
+		return fmt.Errorf("copy %s: %w", s.Name, err)
+	}
+	return nil
+}
+
+func decodeStoreResult(b []byte) (*StoreResult, error) {
+	var ds Dataset
+	if err := json.Unmarshal(b, &ds); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	res := &StoreResult{RetrieveURL: ds.String(TagRetrieveURL)}
+	for _, item := range ds.Sequence(TagReferencedSOPSequence) {
+		res.Stored = append(res.Stored, sopReference(item))
+	}
+	for _, item := range ds.Sequence(TagFailedSOPSequence) {
+		res.Failed = append(res.Failed, sopReference(item))
+	}
+	return res, nil
+}
+
+func sopReference(d Dataset) SOPReference {
+	r := SOPReference{
+		SOPClassUID:    d.String(TagReferencedSOPClassUID),
+		SOPInstanceUID: d.String(TagReferencedSOPInstanceUID),
+		RetrieveURL:    d.String(TagRetrieveURL),
+	}
+	r.FailureReason, _ = d.Int(TagFailureReason)
+	return r
+}
@@ -0,0 +1,131 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dicomweb
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// Part is one instance of a WADO-RS multipart/related response.
+type Part struct {
+	// Index is the 0-based position of the part in the response.
+	Index       int
+	ContentType string
+	// Meta is the instance's File Meta Information, or nil if the part is
+	// not a DICOM Part 10 file (for example a rendered image).
+	Meta *Meta
+	// Body streams the part. It is only valid until the handler returns.
+	Body io.Reader
+}
+
+// PartHandler is called for each part of a retrieve response, in order.
+type PartHandler func(p *Part) error
+
+// RetrieveStudy retrieves every instance of a study and calls fn for each
+// one as it arrives.
+func (c *Client) RetrieveStudy(ctx context.Context, studyUID string, fn PartHandler) error {
+	return c.retrieve(ctx, "studies/"+studyUID, fn)
+}
+
+// RetrieveSeries retrieves every instance of a series.
+func (c *Client) RetrieveSeries(ctx context.Context, studyUID, seriesUID string, fn PartHandler) error {
+	return c.retrieve(ctx, "studies/"+studyUID+"/series/"+seriesUID, fn)
+}
+
+// RetrieveInstance retrieves a single instance.
+func (c *Client) RetrieveInstance(ctx context.Context, studyUID, seriesUID, instanceUID string, fn PartHandler) error {
+	return c.retrieve(ctx, "studies/"+studyUID+"/series/"+seriesUID+"/instances/"+instanceUID, fn)
+}
+
+func (c *Client) retrieve(ctx context.Context, path string, fn PartHandler) error {
+	h := http.Header{"Accept": {`multipart/related; type="application/dicom"; transfer-syntax=*`}}
+	resp, err := c.send(ctx, http.MethodGet, path, h, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return fmt.Errorf("mime.ParseMediaType: %w", err)
+	}
+	if mediaType != "multipart/related" || params["boundary"] == "" {
+		return fmt.Errorf("dicomweb: unexpected response type %q", mediaType)
+	}
+
+	mr := multipart.NewReader(resp.Body, params["boundary"])
+	for i := 0; ; i++ {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading part %d: %w", i, err)
+		}
+
+		br := bufio.NewReaderSize(p, 64<<10)
+		part := &Part{Index: i, ContentType: p.Header.Get("Content-Type"), Body: br}
+		if meta, err := peekMeta(br); err == nil {
+			part.Meta = meta
+		}
+		if err := fn(part); err != nil {
+			return err
+		}
+	}
+}
+
+// safeName matches characters that may appear in a file name derived from
+// a SOP Instance UID.
+var safeName = regexp.MustCompile(`^[0-9.]+$`)
+
+// DirWriter is a PartHandler that writes each retrieved instance to its own
+// file in Dir, named after its SOP Instance UID.
+type DirWriter struct {
+	Dir string
+	// Files lists the files written so far.
+	Files []string
+}
+
+// Write implements PartHandler.
+func (d *DirWriter) Write(p *Part) error {
+	name := fmt.Sprintf("part-%04d.dcm", p.Index)
+	if p.Meta != nil && safeName.MatchString(p.Meta.MediaStorageSOPInstanceUID) {
+		name = p.Meta.MediaStorageSOPInstanceUID + ".dcm"
+	}
+	path := filepath.Join(d.Dir, name)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("os.Create: %w", err)
+	}
+	if _, err := io.Copy(f, p.Body); err != nil {
+		f.Close()
+		return fmt.Errorf("io.Copy: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("f.Close: %w", err)
+	}
+	d.Files = append(d.Files, path)
+	return nil
+}
@@ -0,0 +1,231 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fhirclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Transaction builds a FHIR transaction Bundle. Every entry is applied
+// atomically by the server: either all of them succeed or none do.
+//
+// Create and ConditionalCreate return a temporary "urn:uuid:" reference for
+// the new resource. Use it in reference fields of other resources in the
+// same transaction and the server will replace it with the assigned ID:
+//
+//	tx := fhirclient.NewTransaction()
+//	patient := tx.Create(fhirclient.Resource{"resourceType": "Patient"})
+//	tx.Create(fhirclient.Resource{
+//		"resourceType": "Encounter",
+//		"subject":      map[string]interface{}{"reference": patient},
+//	})
+type Transaction struct {
+	entries []bundleEntry
+}
+
+// NewTransaction returns an empty Transaction.
+func NewTransaction() *Transaction {
+	return &Transaction{}
+}
+
+// Create adds a create of r and returns its temporary reference.
+func (t *Transaction) Create(r Resource) string {
+	return t.add(r, &entryRequest{Method: http.MethodPost, URL: r.Type()})
+}
+
+// ConditionalCreate adds a create of r that is skipped if a resource matching
+// ifNoneExist already exists. The returned reference resolves to whichever
+// resource ends up matching.
+func (t *Transaction) ConditionalCreate(r Resource, ifNoneExist *Search) string {
+	return t.add(r, &entryRequest{Method: http.MethodPost, URL: r.Type(), IfNoneExist: ifNoneExist.Encode()})
+}
+
+// Update adds an update of r, which must have an ID. If versionID is not
+// empty the update only succeeds if the resource is still at that version.
+func (t *Transaction) Update(r Resource, versionID string) {
+	req := &entryRequest{Method: http.MethodPut, URL: r.Reference()}
+	if versionID != "" {
+		req.IfMatch = fmt.Sprintf("W/%q", versionID)
+	}
+	t.entries = append(t.entries, bundleEntry{Resource: r, Request: req})
+}
+
+// ConditionalUpdate adds an update of the resource matching criteria, or a
+// create of r if nothing matches, and returns r's temporary reference.
+func (t *Transaction) ConditionalUpdate(r Resource, criteria *Search) string {
+	return t.add(r, &entryRequest{Method: http.MethodPut, URL: r.Type() + "?" + criteria.Encode()})
+}
+
+// Delete adds a delete of the given resource.
+func (t *Transaction) Delete(resourceType, id string) {
+	t.entries = append(t.entries, bundleEntry{
+		Request: &entryRequest{Method: http.MethodDelete, URL: resourceType + "/" + id},
+	})
+}
+
+// Len returns the number of entries in the transaction.
+func (t *Transaction) Len() int {
+	return len(t.entries)
+}
+
+func (t *Transaction) add(r Resource, req *entryRequest) string {
+	ref := "urn:uuid:" + uuid.NewString()
+	t.entries = append(t.entries, bundleEntry{FullURL: ref, Resource: r, Request: req})
+	return ref
+}
+
+// Bundle returns the transaction Bundle as a Resource, e.g. to log it or to
+// send it with another client.
+func (t *Transaction) Bundle() (Resource, error) {
+	b, err := json.Marshal(bundle{ResourceType: "Bundle", Type: "transaction", Entry: t.entries})
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal: %w", err)
+	}
+	var r Resource
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	return r, nil
+}
+
+// validate checks that every urn:uuid reference used in the transaction
+// points at an entry of the transaction. The server would otherwise reject
+// the whole bundle with a less helpful error.
+func (t *Transaction) validate() error {
+	known := make(map[string]bool)
+	for _, e := range t.entries {
+		if strings.HasPrefix(e.FullURL, "urn:uuid:") {
+			known[e.FullURL] = true
+		}
+	}
+	for i, e := range t.entries {
+		var missing []string
+		walkReferences(e.Resource, func(ref string) {
+			if strings.HasPrefix(ref, "urn:uuid:") && !known[ref] {
+				missing = append(missing, ref)
+			}
+		})
+		if len(missing) > 0 {
+			return fmt.Errorf("entry %d (%s): unresolved references %s", i, e.Resource.Type(), strings.Join(missing, ", "))
+		}
+	}
+	return nil
+}
+
+// walkReferences calls fn for the value of every "reference" field in v.
+func walkReferences(v interface{}, fn func(string)) {
+	switch v := v.(type) {
+	case Resource:
+		walkReferences(map[string]interface{}(v), fn)
+	case map[string]interface{}:
+		for k, child := range v {
+			if s, ok := child.(string); ok && k == "reference" {
+				fn(s)
+				continue
+			}
+			walkReferences(child, fn)
+		}
+	case []interface{}:
+		for _, child := range v {
+			walkReferences(child, fn)
+		}
+	case []map[string]interface{}:
+		for _, child := range v {
+			walkReferences(child, fn)
+		}
+	}
+}
+
+// EntryResult is the outcome of one transaction entry.
+type EntryResult struct {
+	// Status is the HTTP status line of the entry, e.g. "201 Created".
+	Status string
+	// Location is the versioned location of the created or updated
+	// resource, e.g. "Patient/123/_history/1".
+	Location string
+	// Resource is the resulting resource, if the server returned it.
+	Resource Resource
+}
+
+// TransactionResult is the response to an executed Transaction.
+type TransactionResult struct {
+	// Entries holds one result per transaction entry, in order.
+	Entries []EntryResult
+
+	refs map[string]string
+}
+
+// Resolve maps a temporary reference returned by Transaction.Create,
+// ConditionalCreate or ConditionalUpdate to the server-assigned reference,
+// e.g. "Patient/123".
+func (r *TransactionResult) Resolve(ref string) (string, bool) {
+	s, ok := r.refs[ref]
+	return s, ok
+}
+
+// ExecuteTransaction executes t as a single transaction.
+func (c *Client) ExecuteTransaction(ctx context.Context, t *Transaction) (*TransactionResult, error) {
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	req := bundle{ResourceType: "Bundle", Type: "transaction", Entry: t.entries}
+	var resp bundle
+	if _, err := c.do(ctx, http.MethodPost, c.base, req, nil, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Entry) != len(t.entries) {
+		return nil, fmt.Errorf("transaction: got %d response entries, want %d", len(resp.Entry), len(t.entries))
+	}
+
+	res := &TransactionResult{refs: make(map[string]string)}
+	for i, e := range resp.Entry {
+		er := EntryResult{Resource: e.Resource}
+		if e.Response != nil {
+			er.Status = e.Response.Status
+			er.Location = e.Response.Location
+		}
+		res.Entries = append(res.Entries, er)
+
+		full := t.entries[i].FullURL
+		if !strings.HasPrefix(full, "urn:uuid:") {
+			continue
+		}
+		if ref := locationReference(er.Location); ref != "" {
+			res.refs[full] = ref
+		} else if e.Resource != nil && e.Resource.ID() != "" {
+			res.refs[full] = e.Resource.Reference()
+		}
+	}
+	return res, nil
+}
+
+// locationReference reduces a response location such as
+// "https://.../fhir/Patient/123/_history/1" to "Patient/123".
+func locationReference(loc string) string {
+	if i := strings.Index(loc, "/_history/"); i >= 0 {
+		loc = loc[:i]
+	}
+	parts := strings.Split(strings.TrimSuffix(loc, "/"), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[len(parts)-2] + "/" + parts[len(parts)-1]
+}
@@ -0,0 +1,218 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fhirclient is a small, reusable client for the FHIR REST API of a
+// Cloud Healthcare API FHIR store. It covers the operations used by the FHIR
+// snippets in this directory: reads, searches that follow Bundle paging
+// links, conditional creates and updates, and transaction bundles.
+package fhirclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2/google"
+)
+
+// fhirContentType is the media type used for FHIR request bodies.
+const fhirContentType = "application/fhir+json;charset=utf-8"
+
+// Client talks to a single FHIR store.
+type Client struct {
+	hc   *http.Client
+	base string
+}
+
+// StoreURL returns the FHIR base URL of a Cloud Healthcare API FHIR store.
+func StoreURL(projectID, location, datasetID, fhirStoreID string) string {
+	return fmt.Sprintf("https://healthcare.googleapis.com/v1/projects/%s/locations/%s/datasets/%s/fhirStores/%s/fhir", projectID, location, datasetID, fhirStoreID)
+}
+
+// NewClient creates a Client for the given FHIR store that authenticates with
+// Application Default Credentials.
+func NewClient(ctx context.Context, projectID, location, datasetID, fhirStoreID string) (*Client, error) {
+	hc, err := google.DefaultClient(ctx, "https://www.googleapis.com/auth/cloud-platform")
+	if err != nil {
+		return nil, fmt.Errorf("google.DefaultClient: %w", err)
+	}
+	return New(hc, StoreURL(projectID, location, datasetID, fhirStoreID)), nil
+}
+
+// New creates a Client that sends requests for the FHIR server at baseURL
+// through hc. hc is responsible for authentication.
+func New(hc *http.Client, baseURL string) *Client {
+	return &Client{hc: hc, base: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Read returns the current version of a resource.
+func (c *Client) Read(ctx context.Context, resourceType, id string) (Resource, error) {
+	var r Resource
+	_, err := c.do(ctx, http.MethodGet, c.base+"/"+resourceType+"/"+id, nil, nil, &r)
+	return r, err
+}
+
+// Create creates a new resource. The server assigns its ID.
+func (c *Client) Create(ctx context.Context, r Resource) (Resource, error) {
+	var created Resource
+	_, err := c.do(ctx, http.MethodPost, c.base+"/"+r.Type(), r, nil, &created)
+	return created, err
+}
+
+// ConditionalCreate creates r only if no resource of the same type matches
+// the search criteria, using the If-None-Exist header. created reports
+// whether a new resource was created; if a single match already existed it
+// is returned instead.
+func (c *Client) ConditionalCreate(ctx context.Context, r Resource, ifNoneExist *Search) (res Resource, created bool, err error) {
+	h := http.Header{"If-None-Exist": {ifNoneExist.Encode()}}
+	resp, err := c.do(ctx, http.MethodPost, c.base+"/"+r.Type(), r, h, &res)
+	if err != nil {
+		return nil, false, err
+	}
+	if resp.StatusCode == http.StatusOK && res == nil {
+		// Some servers return an empty body when the resource already
+		// exists. Fetch it so the caller always gets a resource back.
+		loc := resp.Header.Get("Location")
+		if loc == "" {
+			return nil, false, fmt.Errorf("ConditionalCreate %s: existing resource returned with no body and no Location", r.Type())
+		}
+		res, err = c.readLocation(ctx, loc)
+		return res, false, err
+	}
+	return res, resp.StatusCode == http.StatusCreated, nil
+}
+
+// UpdateOption configures an Update call.
+type UpdateOption func(h http.Header)
+
+// IfMatch makes an update conditional on the resource still being at the
+// given version ID, so concurrent updates are rejected with a 412 status
+// instead of being silently overwritten.
+func IfMatch(versionID string) UpdateOption {
+	return func(h http.Header) {
+		h.Set("If-Match", fmt.Sprintf("W/%q", versionID))
+	}
+}
+
+// Update replaces the resource identified by r's type and ID.
+func (c *Client) Update(ctx context.Context, r Resource, opts ...UpdateOption) (Resource, error) {
+	if r.ID() == "" {
+		return nil, fmt.Errorf("update %s: resource has no id", r.Type())
+	}
+	h := http.Header{}
+	for _, opt := range opts {
+		opt(h)
+	}
+	var updated Resource
+	_, err := c.do(ctx, http.MethodPut, c.base+"/"+r.Type()+"/"+r.ID(), r, h, &updated)
+	return updated, err
+}
+
+// ConditionalUpdate updates the single resource of r's type that matches the
+// search criteria, or creates r if there is no match. created reports which
+// of the two happened.
+func (c *Client) ConditionalUpdate(ctx context.Context, r Resource, criteria *Search) (res Resource, created bool, err error) {
+	u := c.base + "/" + r.Type() + "?" + criteria.Encode()
+	resp, err := c.do(ctx, http.MethodPut, u, r, nil, &res)
+	if err != nil {
+		return nil, false, err
+	}
+	return res, resp.StatusCode == http.StatusCreated, nil
+}
+
+// Delete deletes a resource. Deleting a resource that does not exist, or was
+// already deleted, is not an error.
+func (c *Client) Delete(ctx context.Context, resourceType, id string) error {
+	_, err := c.do(ctx, http.MethodDelete, c.base+"/"+resourceType+"/"+id, nil, nil, nil)
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// readLocation reads a resource from a Location header value, which may be
+// absolute or relative to the base URL and may include a version.
+func (c *Client) readLocation(ctx context.Context, loc string) (Resource, error) {
+	loc = strings.TrimPrefix(loc, c.base+"/")
+	if i := strings.Index(loc, "/_history/"); i >= 0 {
+		loc = loc[:i]
+	}
+	parts := strings.Split(loc, "/")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("unexpected Location %q", loc)
+	}
+	return c.Read(ctx, parts[0], parts[1])
+}
+
+// do sends a request and decodes a successful JSON response into out, if out
+// is non-nil and the response has a body. Non-2xx responses are returned as
+// *Error.
+func (c *Client) do(ctx context.Context, method, u string, body interface{}, h http.Header, out interface{}) (*http.Response, error) {
+	var rd io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("json.Marshal: %w", err)
+		}
+		rd = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, rd)
+	if err != nil {
+		return nil, fmt.Errorf("NewRequest: %w", err)
+	}
+	for k, v := range h {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/fhir+json")
+	if body != nil {
+		req.Header.Set("Content-Type", fhirContentType)
+	}
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read response: %w", err)
+	}
+
+	if resp.StatusCode > 299 {
+		return resp, newError(method, redact(u), resp.StatusCode, respBytes)
+	}
+	if out != nil && len(bytes.TrimSpace(respBytes)) > 0 {
+		if err := json.Unmarshal(respBytes, out); err != nil {
+			return resp, fmt.Errorf("json.Unmarshal: %w", err)
+		}
+	}
+	return resp, nil
+}
+
+// redact strips the query from u so that search criteria, which may contain
+// patient identifiers, do not end up in error messages.
+func redact(u string) string {
+	p, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+	p.RawQuery = ""
+	return p.String()
+}
@@ -0,0 +1,272 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fhirclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func patient(family, mrn string) Resource {
+	return Resource{
+		"resourceType": "Patient",
+		"name": []interface{}{
+			map[string]interface{}{"family": family},
+		},
+		"identifier": []interface{}{
+			map[string]interface{}{"system": "urn:mrn", "value": mrn},
+		},
+	}
+}
+
+func TestSearchEncode(t *testing.T) {
+	s := NewSearch().
+		String("family", "Smith", Exact).
+		Token("identifier", "urn:mrn", "42").
+		Date("birthdate", Ge, time.Date(1970, 1, 2, 0, 0, 0, 0, time.UTC)).
+		Reference("general-practitioner", "Practitioner/7").
+		Include("Patient:organization").
+		Sort("-_lastUpdated", "family").
+		Count(10)
+
+	want := "_count=10&_include=Patient%3Aorganization&_sort=-_lastUpdated%2Cfamily&birthdate=ge1970-01-02&family%3Aexact=Smith&general-practitioner=Practitioner%2F7&identifier=urn%3Amrn%7C42"
+	if got := s.Encode(); got != want {
+		t.Errorf("Encode() =\n%s\nwant\n%s", got, want)
+	}
+
+	var nilSearch *Search
+	if got := nilSearch.Encode(); got != "" {
+		t.Errorf("nil Encode() = %q, want empty", got)
+	}
+}
+
+func TestCRUD(t *testing.T) {
+	_, c := newFakeFHIR(t)
+	ctx := context.Background()
+
+	created, err := c.Create(ctx, patient("Smith", "1"))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if created.ID() == "" || created.VersionID() != "1" {
+		t.Fatalf("Create returned id=%q version=%q", created.ID(), created.VersionID())
+	}
+
+	got, err := c.Read(ctx, "Patient", created.ID())
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	var p struct {
+		Name []struct {
+			Family string `json:"family"`
+		} `json:"name"`
+	}
+	if err := got.Decode(&p); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(p.Name) != 1 || p.Name[0].Family != "Smith" {
+		t.Errorf("Read got name %+v, want Smith", p.Name)
+	}
+
+	got["active"] = true
+	updated, err := c.Update(ctx, got, IfMatch(got.VersionID()))
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.VersionID() != "2" {
+		t.Errorf("Update version = %q, want 2", updated.VersionID())
+	}
+
+	// The same update again is now stale.
+	_, err = c.Update(ctx, got, IfMatch("1"))
+	if !IsPreconditionFailed(err) {
+		t.Fatalf("stale Update got err %v, want precondition failed", err)
+	}
+	var fe *Error
+	if !errors.As(err, &fe) || len(fe.Issues) != 1 || fe.Issues[0].Code != "conflict" {
+		t.Errorf("stale Update error = %#v, want one conflict issue", err)
+	}
+	if !strings.Contains(err.Error(), "version mismatch") {
+		t.Errorf("error %q does not include diagnostics", err)
+	}
+
+	if err := c.Delete(ctx, "Patient", created.ID()); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := c.Read(ctx, "Patient", created.ID()); !IsNotFound(err) {
+		t.Errorf("Read after Delete got err %v, want not found", err)
+	}
+	// The resource is gone already: deleting it again succeeds.
+	if err := c.Delete(ctx, "Patient", created.ID()); err != nil {
+		t.Errorf("Delete of a deleted resource: %v", err)
+	}
+}
+
+func TestConditionalCreateEmptyResponse(t *testing.T) {
+	// A server that reports an existing match without saying which.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	c := New(srv.Client(), srv.URL)
+
+	res, created, err := c.ConditionalCreate(context.Background(), patient("Jones", "42"), NewSearch().Token("identifier", "urn:mrn", "42"))
+	if err == nil {
+		t.Errorf("ConditionalCreate = %v, created %v, want an error", res, created)
+	}
+}
+
+func TestConditionalCreateAndUpdate(t *testing.T) {
+	_, c := newFakeFHIR(t)
+	ctx := context.Background()
+	byMRN := NewSearch().Token("identifier", "urn:mrn", "42")
+
+	first, created, err := c.ConditionalCreate(ctx, patient("Jones", "42"), byMRN)
+	if err != nil || !created {
+		t.Fatalf("first ConditionalCreate = created %v, err %v; want created", created, err)
+	}
+	second, created, err := c.ConditionalCreate(ctx, patient("Jones", "42"), byMRN)
+	if err != nil || created {
+		t.Fatalf("second ConditionalCreate = created %v, err %v; want existing", created, err)
+	}
+	if first.ID() != second.ID() {
+		t.Errorf("ConditionalCreate returned %q, want existing %q", second.ID(), first.ID())
+	}
+
+	p := patient("Jones-Smith", "42")
+	res, created, err := c.ConditionalUpdate(ctx, p, byMRN)
+	if err != nil || created {
+		t.Fatalf("ConditionalUpdate = created %v, err %v; want update", created, err)
+	}
+	if res.ID() != first.ID() || res.VersionID() != "2" {
+		t.Errorf("ConditionalUpdate updated %s v%s, want %s v2", res.ID(), res.VersionID(), first.ID())
+	}
+
+	// Two matches make the condition ambiguous.
+	if _, err := c.Create(ctx, patient("Other", "42")); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, _, err := c.ConditionalCreate(ctx, patient("Jones", "42"), byMRN); !IsPreconditionFailed(err) {
+		t.Errorf("ambiguous ConditionalCreate got err %v, want precondition failed", err)
+	}
+}
+
+func TestSearchPaging(t *testing.T) {
+	_, c := newFakeFHIR(t)
+	ctx := context.Background()
+	for i, family := range []string{"Smith", "Smithers", "Jones", "Smythe", "smith"} {
+		if _, err := c.Create(ctx, patient(family, string(rune('a'+i)))); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	it := c.Search(ctx, "Patient", NewSearch().String("family", "smith", StartsWith).Count(2))
+	all, err := it.All()
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("Search returned %d resources, want 3", len(all))
+	}
+	if it.Pages() != 2 {
+		t.Errorf("Search fetched %d pages, want 2", it.Pages())
+	}
+	if total, ok := it.Total(); !ok || total != 3 {
+		t.Errorf("Total() = %d, %v; want 3, true", total, ok)
+	}
+
+	exact, err := c.Search(ctx, "Patient", NewSearch().String("family", "Smith", Exact)).All()
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(exact) != 1 {
+		t.Errorf("exact Search returned %d resources, want 1", len(exact))
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	_, c := newFakeFHIR(t)
+	ctx := context.Background()
+
+	tx := NewTransaction()
+	pRef := tx.Create(patient("Doe", "7"))
+	eRef := tx.Create(Resource{
+		"resourceType": "Encounter",
+		"subject":      map[string]interface{}{"reference": pRef},
+	})
+	res, err := c.ExecuteTransaction(ctx, tx)
+	if err != nil {
+		t.Fatalf("ExecuteTransaction: %v", err)
+	}
+	if len(res.Entries) != 2 || !strings.HasPrefix(res.Entries[0].Status, "201") {
+		t.Fatalf("ExecuteTransaction entries = %+v", res.Entries)
+	}
+
+	patientRef, ok := res.Resolve(pRef)
+	if !ok || !strings.HasPrefix(patientRef, "Patient/") {
+		t.Fatalf("Resolve(%q) = %q, %v", pRef, patientRef, ok)
+	}
+	encounterRef, ok := res.Resolve(eRef)
+	if !ok {
+		t.Fatalf("Resolve(%q) failed", eRef)
+	}
+	id := strings.TrimPrefix(encounterRef, "Encounter/")
+	enc, err := c.Read(ctx, "Encounter", id)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	var e struct {
+		Subject struct {
+			Reference string `json:"reference"`
+		} `json:"subject"`
+	}
+	if err := enc.Decode(&e); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if e.Subject.Reference != patientRef {
+		t.Errorf("Encounter.subject = %q, want %q", e.Subject.Reference, patientRef)
+	}
+
+	// A conditional create in a second transaction resolves to the
+	// existing patient.
+	tx = NewTransaction()
+	ref := tx.ConditionalCreate(patient("Doe", "7"), NewSearch().Token("identifier", "urn:mrn", "7"))
+	res, err = c.ExecuteTransaction(ctx, tx)
+	if err != nil {
+		t.Fatalf("ExecuteTransaction: %v", err)
+	}
+	if got, _ := res.Resolve(ref); got != patientRef {
+		t.Errorf("conditional Resolve = %q, want %q", got, patientRef)
+	}
+}
+
+func TestTransactionUnresolvedReference(t *testing.T) {
+	_, c := newFakeFHIR(t)
+
+	tx := NewTransaction()
+	tx.Create(Resource{
+		"resourceType": "Observation",
+		"subject":      map[string]interface{}{"reference": "urn:uuid:does-not-exist"},
+	})
+	_, err := c.ExecuteTransaction(context.Background(), tx)
+	if err == nil || !strings.Contains(err.Error(), "urn:uuid:does-not-exist") {
+		t.Errorf("ExecuteTransaction got err %v, want unresolved reference error", err)
+	}
+}
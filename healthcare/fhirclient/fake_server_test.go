@@ -0,0 +1,369 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fhirclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeFHIR is a minimal in-memory FHIR server. It implements just enough of
+// the REST API for the client tests: CRUD, conditional create/update,
+// If-Match, paged search over string and token parameters, and transaction
+// bundles with urn:uuid reference rewriting.
+type fakeFHIR struct {
+	srv *httptest.Server
+
+	mu        sync.Mutex
+	resources map[string]map[string]Resource
+	nextID    int
+}
+
+func newFakeFHIR(t *testing.T) (*fakeFHIR, *Client) {
+	t.Helper()
+	f := &fakeFHIR{resources: make(map[string]map[string]Resource)}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.srv.Close)
+	return f, New(f.srv.Client(), f.srv.URL+"/fhir")
+}
+
+func (f *fakeFHIR) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/fhir"), "/")
+	parts := strings.Split(path, "/")
+	if path == "" {
+		parts = nil
+	}
+
+	var body Resource
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&body)
+	}
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		f.transaction(w, body)
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		f.search(w, r, parts[0])
+	case len(parts) == 1 && r.Method == http.MethodPost:
+		status, res := f.create(parts[0], body, r.Header.Get("If-None-Exist"))
+		writeJSON(w, status, res)
+	case len(parts) == 1 && r.Method == http.MethodPut:
+		status, res := f.conditionalUpdate(parts[0], body, r.URL.Query())
+		writeJSON(w, status, res)
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		res, ok := f.resources[parts[0]][parts[1]]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, outcome("not-found", "resource not found"))
+			return
+		}
+		writeJSON(w, http.StatusOK, res)
+	case len(parts) == 2 && r.Method == http.MethodPut:
+		status, res := f.update(parts[0], parts[1], body, r.Header.Get("If-Match"))
+		writeJSON(w, status, res)
+	case len(parts) == 2 && r.Method == http.MethodDelete:
+		// Like some servers, the fake doesn't accept deleting a resource
+		// that doesn't exist.
+		if _, ok := f.resources[parts[0]][parts[1]]; !ok {
+			writeJSON(w, http.StatusNotFound, outcome("not-found", "resource not found"))
+			return
+		}
+		delete(f.resources[parts[0]], parts[1])
+		w.WriteHeader(http.StatusOK)
+	default:
+		writeJSON(w, http.StatusBadRequest, outcome("not-supported", r.Method+" "+r.URL.Path))
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/fhir+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func outcome(code, msg string) Resource {
+	return Resource{
+		"resourceType": "OperationOutcome",
+		"issue": []interface{}{
+			map[string]interface{}{"severity": "error", "code": code, "diagnostics": msg},
+		},
+	}
+}
+
+func (f *fakeFHIR) store(typ, id string, r Resource) Resource {
+	if f.resources[typ] == nil {
+		f.resources[typ] = make(map[string]Resource)
+	}
+	version := 1
+	if old, ok := f.resources[typ][id]; ok {
+		version, _ = strconv.Atoi(old.VersionID())
+		version++
+	}
+	r["resourceType"] = typ
+	r["id"] = id
+	r["meta"] = map[string]interface{}{"versionId": strconv.Itoa(version)}
+	f.resources[typ][id] = r
+	return r
+}
+
+func (f *fakeFHIR) newID() string {
+	f.nextID++
+	return strconv.Itoa(f.nextID)
+}
+
+func (f *fakeFHIR) create(typ string, r Resource, ifNoneExist string) (int, Resource) {
+	if ifNoneExist != "" {
+		q, _ := url.ParseQuery(ifNoneExist)
+		switch m := f.match(typ, q); len(m) {
+		case 0:
+		case 1:
+			return http.StatusOK, m[0]
+		default:
+			return http.StatusPreconditionFailed, outcome("duplicate", "multiple matches")
+		}
+	}
+	return http.StatusCreated, f.store(typ, f.newID(), r)
+}
+
+func (f *fakeFHIR) update(typ, id string, r Resource, ifMatch string) (int, Resource) {
+	old, exists := f.resources[typ][id]
+	if ifMatch != "" && (!exists || fmt.Sprintf("W/%q", old.VersionID()) != ifMatch) {
+		return http.StatusPreconditionFailed, outcome("conflict", "version mismatch")
+	}
+	status := http.StatusOK
+	if !exists {
+		status = http.StatusCreated
+	}
+	return status, f.store(typ, id, r)
+}
+
+func (f *fakeFHIR) conditionalUpdate(typ string, r Resource, q url.Values) (int, Resource) {
+	switch m := f.match(typ, q); len(m) {
+	case 0:
+		return http.StatusCreated, f.store(typ, f.newID(), r)
+	case 1:
+		return http.StatusOK, f.store(typ, m[0].ID(), r)
+	default:
+		return http.StatusPreconditionFailed, outcome("duplicate", "multiple matches")
+	}
+}
+
+// match returns the resources of typ matching every non-control parameter
+// in q, ordered by ID.
+func (f *fakeFHIR) match(typ string, q url.Values) []Resource {
+	var ids []string
+	for id := range f.resources[typ] {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, _ := strconv.Atoi(ids[i])
+		b, _ := strconv.Atoi(ids[j])
+		return a < b
+	})
+
+	var out []Resource
+	for _, id := range ids {
+		r := f.resources[typ][id]
+		ok := true
+		for key, values := range q {
+			if strings.HasPrefix(key, "_") {
+				continue
+			}
+			param, mod, _ := strings.Cut(key, ":")
+			for _, v := range values {
+				if !matches(r, param, mod, v) {
+					ok = false
+				}
+			}
+		}
+		if ok {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// matches reports whether any value stored under a field named param
+// anywhere in r matches v. Token values of the form system|value are
+// compared against identifier-like objects.
+func matches(r Resource, param, mod, v string) bool {
+	found := false
+	var walk func(interface{})
+	walk = func(n interface{}) {
+		switch n := n.(type) {
+		case Resource:
+			walk(map[string]interface{}(n))
+		case map[string]interface{}:
+			for k, child := range n {
+				if k == param {
+					found = found || valueMatches(child, mod, v)
+				}
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range n {
+				walk(child)
+			}
+		}
+	}
+	walk(r)
+	return found
+}
+
+func valueMatches(n interface{}, mod, v string) bool {
+	switch n := n.(type) {
+	case string:
+		if mod == "exact" {
+			return n == v
+		}
+		return strings.HasPrefix(strings.ToLower(n), strings.ToLower(v))
+	case map[string]interface{}:
+		system, value, ok := strings.Cut(v, "|")
+		if !ok {
+			return n["value"] == v
+		}
+		return n["system"] == system && n["value"] == value
+	case []interface{}:
+		for _, child := range n {
+			if valueMatches(child, mod, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (f *fakeFHIR) search(w http.ResponseWriter, r *http.Request, typ string) {
+	q := r.URL.Query()
+	all := f.match(typ, q)
+
+	count := len(all)
+	if c, err := strconv.Atoi(q.Get("_count")); err == nil && c > 0 {
+		count = c
+	}
+	page, _ := strconv.Atoi(q.Get("_page"))
+	start := page * count
+	end := start + count
+	if end > len(all) {
+		end = len(all)
+	}
+
+	b := bundle{ResourceType: "Bundle", Type: "searchset"}
+	total := len(all)
+	b.Total = &total
+	for _, res := range all[start:end] {
+		b.Entry = append(b.Entry, bundleEntry{Resource: res, Search: &entrySearch{Mode: "match"}})
+	}
+	if end < len(all) {
+		q.Set("_page", strconv.Itoa(page+1))
+		b.Link = append(b.Link, bundleLink{Relation: "next", URL: f.srv.URL + r.URL.Path + "?" + q.Encode()})
+	}
+	writeJSON(w, http.StatusOK, b)
+}
+
+func (f *fakeFHIR) transaction(w http.ResponseWriter, body Resource) {
+	var b bundle
+	if err := body.Decode(&b); err != nil || b.Type != "transaction" {
+		writeJSON(w, http.StatusBadRequest, outcome("invalid", "not a transaction"))
+		return
+	}
+
+	// Assign IDs to new resources first so that references between entries
+	// can be rewritten before anything is stored.
+	refs := make(map[string]string)
+	ids := make([]string, len(b.Entry))
+	for i, e := range b.Entry {
+		if e.Request.Method != http.MethodPost {
+			continue
+		}
+		if e.Request.IfNoneExist != "" {
+			q, _ := url.ParseQuery(e.Request.IfNoneExist)
+			if m := f.match(e.Resource.Type(), q); len(m) == 1 {
+				refs[e.FullURL] = m[0].Reference()
+				continue
+			}
+		}
+		ids[i] = f.newID()
+		refs[e.FullURL] = e.Resource.Type() + "/" + ids[i]
+	}
+
+	resp := bundle{ResourceType: "Bundle", Type: "transaction-response"}
+	for i, e := range b.Entry {
+		rewriteReferences(e.Resource, refs)
+		var status int
+		var res Resource
+		switch e.Request.Method {
+		case http.MethodPost:
+			if ids[i] == "" {
+				status = http.StatusOK
+				ref := refs[e.FullURL]
+				typ, id, _ := strings.Cut(ref, "/")
+				res = f.resources[typ][id]
+			} else {
+				status, res = http.StatusCreated, f.store(e.Resource.Type(), ids[i], e.Resource)
+			}
+		case http.MethodPut:
+			typ, rest, _ := strings.Cut(e.Request.URL, "/")
+			if q := strings.Index(e.Request.URL, "?"); q >= 0 {
+				params, _ := url.ParseQuery(e.Request.URL[q+1:])
+				status, res = f.conditionalUpdate(e.Request.URL[:q], e.Resource, params)
+			} else {
+				status, res = f.update(typ, rest, e.Resource, e.Request.IfMatch)
+			}
+		case http.MethodDelete:
+			typ, id, _ := strings.Cut(e.Request.URL, "/")
+			delete(f.resources[typ], id)
+			status = http.StatusOK
+		}
+		entry := bundleEntry{Response: &entryResponse{Status: fmt.Sprintf("%d %s", status, http.StatusText(status))}}
+		if res != nil && res.Type() != "OperationOutcome" {
+			entry.Resource = res
+			entry.Response.Location = fmt.Sprintf("%s/fhir/%s/_history/%s", f.srv.URL, res.Reference(), res.VersionID())
+		}
+		resp.Entry = append(resp.Entry, entry)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func rewriteReferences(n interface{}, refs map[string]string) {
+	switch n := n.(type) {
+	case Resource:
+		rewriteReferences(map[string]interface{}(n), refs)
+	case map[string]interface{}:
+		for k, child := range n {
+			if s, ok := child.(string); ok && k == "reference" {
+				if to, ok := refs[s]; ok {
+					n[k] = to
+				}
+				continue
+			}
+			rewriteReferences(child, refs)
+		}
+	case []interface{}:
+		for _, child := range n {
+			rewriteReferences(child, refs)
+		}
+	}
+}
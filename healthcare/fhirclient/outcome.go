@@ -0,0 +1,101 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fhirclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Issue is a single issue of a FHIR OperationOutcome.
+type Issue struct {
+	Severity    string   `json:"severity"`
+	Code        string   `json:"code"`
+	Diagnostics string   `json:"diagnostics,omitempty"`
+	Expression  []string `json:"expression,omitempty"`
+	Details     struct {
+		Text string `json:"text,omitempty"`
+	} `json:"details,omitempty"`
+}
+
+// Error is returned for non-2xx responses. When the server returned an
+// OperationOutcome its issues are available in Issues; otherwise Body holds
+// the raw response.
+type Error struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Issues     []Issue
+	Body       []byte
+}
+
+func newError(method, u string, status int, body []byte) *Error {
+	e := &Error{Method: method, URL: u, StatusCode: status}
+	var oo struct {
+		ResourceType string  `json:"resourceType"`
+		Issue        []Issue `json:"issue"`
+	}
+	if err := json.Unmarshal(body, &oo); err == nil && oo.ResourceType == "OperationOutcome" {
+		e.Issues = oo.Issue
+	} else {
+		e.Body = body
+	}
+	return e
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s: status %d %s", e.Method, e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+	if len(e.Issues) == 0 {
+		if len(e.Body) > 0 {
+			fmt.Fprintf(&b, ": %s", e.Body)
+		}
+		return b.String()
+	}
+	for _, is := range e.Issues {
+		msg := is.Diagnostics
+		if msg == "" {
+			msg = is.Details.Text
+		}
+		fmt.Fprintf(&b, "; %s %s: %s", is.Severity, is.Code, msg)
+	}
+	return b.String()
+}
+
+// statusCode reports the HTTP status of err if it is an *Error.
+func statusCode(err error) int {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound reports whether err is a 404 or 410 response, i.e. the resource
+// does not exist or was deleted.
+func IsNotFound(err error) bool {
+	s := statusCode(err)
+	return s == http.StatusNotFound || s == http.StatusGone
+}
+
+// IsPreconditionFailed reports whether err is a 412 response, which is
+// returned when an IfMatch version is stale or a conditional operation
+// matched more than one resource.
+func IsPreconditionFailed(err error) bool {
+	return statusCode(err) == http.StatusPreconditionFailed
+}
@@ -0,0 +1,56 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fhirclient
+
+import "encoding/json"
+
+// Resource is a FHIR resource in its JSON representation. Using a map keeps
+// the client independent of any particular FHIR version; callers that want
+// typed structs can use Decode.
+type Resource map[string]interface{}
+
+// Type returns the resourceType of r.
+func (r Resource) Type() string {
+	s, _ := r["resourceType"].(string)
+	return s
+}
+
+// ID returns the logical ID of r.
+func (r Resource) ID() string {
+	s, _ := r["id"].(string)
+	return s
+}
+
+// VersionID returns meta.versionId of r, which is used with IfMatch.
+func (r Resource) VersionID() string {
+	meta, _ := r["meta"].(map[string]interface{})
+	s, _ := meta["versionId"].(string)
+	return s
+}
+
+// Reference returns the relative reference to r, e.g. "Patient/123".
+func (r Resource) Reference() string {
+	return r.Type() + "/" + r.ID()
+}
+
+// Decode unmarshals r into v, which is typically a struct with JSON tags for
+// the fields the caller cares about.
+func (r Resource) Decode(v interface{}) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
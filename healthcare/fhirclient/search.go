@@ -0,0 +1,279 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fhirclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/iterator"
+)
+
+// StringModifier changes how a string search parameter is matched.
+type StringModifier string
+
+// String search modifiers. The default matches values that start with the
+// search term, ignoring case and accents.
+const (
+	StartsWith StringModifier = ""
+	Exact      StringModifier = ":exact"
+	Contains   StringModifier = ":contains"
+)
+
+// Prefix is a comparison prefix for date and number search parameters.
+type Prefix string
+
+// Comparison prefixes.
+const (
+	Eq Prefix = "eq"
+	Ne Prefix = "ne"
+	Gt Prefix = "gt"
+	Lt Prefix = "lt"
+	Ge Prefix = "ge"
+	Le Prefix = "le"
+)
+
+// Search holds FHIR search parameters. The zero value and nil are both empty
+// searches. Methods return the receiver so calls can be chained:
+//
+//	s := fhirclient.NewSearch().
+//		String("family", "Smith", fhirclient.Exact).
+//		Date("birthdate", fhirclient.Ge, time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)).
+//		Count(50)
+type Search struct {
+	v url.Values
+}
+
+// NewSearch returns an empty Search.
+func NewSearch() *Search {
+	return &Search{v: url.Values{}}
+}
+
+// Where adds a raw parameter, for search parameter types that have no typed
+// helper.
+func (s *Search) Where(param, value string) *Search {
+	if s.v == nil {
+		s.v = url.Values{}
+	}
+	s.v.Add(param, value)
+	return s
+}
+
+// String adds a string parameter such as family or name.
+func (s *Search) String(param, value string, mod StringModifier) *Search {
+	return s.Where(param+string(mod), value)
+}
+
+// Token adds a token parameter such as identifier or code. An empty system
+// matches codes from any system.
+func (s *Search) Token(param, system, code string) *Search {
+	if system == "" {
+		return s.Where(param, code)
+	}
+	return s.Where(param, system+"|"+code)
+}
+
+// Date adds a date parameter compared at day precision.
+func (s *Search) Date(param string, p Prefix, t time.Time) *Search {
+	return s.Where(param, string(p)+t.Format("2006-01-02"))
+}
+
+// DateTime adds a date parameter compared at second precision.
+func (s *Search) DateTime(param string, p Prefix, t time.Time) *Search {
+	return s.Where(param, string(p)+t.Format(time.RFC3339))
+}
+
+// Reference adds a reference parameter, e.g. subject=Patient/123.
+func (s *Search) Reference(param, ref string) *Search {
+	return s.Where(param, ref)
+}
+
+// Include adds an _include parameter, e.g. "Encounter:subject", so referenced
+// resources are returned in the same pages as the matches.
+func (s *Search) Include(include string) *Search {
+	return s.Where("_include", include)
+}
+
+// RevInclude adds a _revinclude parameter, e.g. "Observation:subject".
+func (s *Search) RevInclude(include string) *Search {
+	return s.Where("_revinclude", include)
+}
+
+// Sort sets the sort order. Prefix a parameter with "-" to sort descending.
+func (s *Search) Sort(params ...string) *Search {
+	if s.v == nil {
+		s.v = url.Values{}
+	}
+	s.v.Set("_sort", strings.Join(params, ","))
+	return s
+}
+
+// Count sets the page size.
+func (s *Search) Count(n int) *Search {
+	if s.v == nil {
+		s.v = url.Values{}
+	}
+	s.v.Set("_count", strconv.Itoa(n))
+	return s
+}
+
+// Encode returns the parameters in URL query form.
+func (s *Search) Encode() string {
+	if s == nil {
+		return ""
+	}
+	return s.v.Encode()
+}
+
+// bundle is the subset of a FHIR Bundle needed for paging and transactions.
+type bundle struct {
+	ResourceType string        `json:"resourceType"`
+	Type         string        `json:"type,omitempty"`
+	Total        *int          `json:"total,omitempty"`
+	Link         []bundleLink  `json:"link,omitempty"`
+	Entry        []bundleEntry `json:"entry,omitempty"`
+}
+
+type bundleLink struct {
+	Relation string `json:"relation"`
+	URL      string `json:"url"`
+}
+
+type bundleEntry struct {
+	FullURL  string         `json:"fullUrl,omitempty"`
+	Resource Resource       `json:"resource,omitempty"`
+	Search   *entrySearch   `json:"search,omitempty"`
+	Request  *entryRequest  `json:"request,omitempty"`
+	Response *entryResponse `json:"response,omitempty"`
+}
+
+type entrySearch struct {
+	Mode string `json:"mode,omitempty"`
+}
+
+type entryRequest struct {
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	IfNoneExist string `json:"ifNoneExist,omitempty"`
+	IfMatch     string `json:"ifMatch,omitempty"`
+}
+
+type entryResponse struct {
+	Status   string   `json:"status"`
+	Location string   `json:"location,omitempty"`
+	Outcome  Resource `json:"outcome,omitempty"`
+}
+
+func (b *bundle) next() string {
+	for _, l := range b.Link {
+		if l.Relation == "next" {
+			return l.URL
+		}
+	}
+	return ""
+}
+
+// Search returns an iterator over the resources of resourceType matching s.
+// Pages are fetched lazily by following the Bundle's next links. Resources
+// added by Include and RevInclude are returned alongside the matches; use
+// ResourceIterator.Included to tell them apart.
+func (c *Client) Search(ctx context.Context, resourceType string, s *Search) *ResourceIterator {
+	u := c.base + "/" + resourceType
+	if q := s.Encode(); q != "" {
+		u += "?" + q
+	}
+	return &ResourceIterator{ctx: ctx, c: c, next: u}
+}
+
+// ResourceIterator iterates over search results.
+type ResourceIterator struct {
+	ctx   context.Context
+	c     *Client
+	next  string
+	buf   []bundleEntry
+	cur   bundleEntry
+	total *int
+	pages int
+	err   error
+}
+
+// Next returns the next resource. It returns iterator.Done when there are no
+// more results.
+func (it *ResourceIterator) Next() (Resource, error) {
+	for len(it.buf) == 0 {
+		if it.err != nil {
+			return nil, it.err
+		}
+		if it.next == "" {
+			return nil, iterator.Done
+		}
+		it.fetch()
+	}
+	it.cur, it.buf = it.buf[0], it.buf[1:]
+	return it.cur.Resource, nil
+}
+
+// Included reports whether the resource last returned by Next was added by an
+// _include or _revinclude parameter rather than matching the search.
+func (it *ResourceIterator) Included() bool {
+	return it.cur.Search != nil && it.cur.Search.Mode == "include"
+}
+
+// Total returns the total number of matches reported by the server, if the
+// first page has been fetched and the server reported it.
+func (it *ResourceIterator) Total() (int, bool) {
+	if it.total == nil {
+		return 0, false
+	}
+	return *it.total, true
+}
+
+// Pages returns the number of pages fetched so far.
+func (it *ResourceIterator) Pages() int {
+	return it.pages
+}
+
+func (it *ResourceIterator) fetch() {
+	var b bundle
+	if _, err := it.c.do(it.ctx, http.MethodGet, it.next, nil, nil, &b); err != nil {
+		it.err = err
+		return
+	}
+	it.pages++
+	if it.total == nil {
+		it.total = b.Total
+	}
+	it.next = b.next()
+	it.buf = b.Entry
+}
+
+// All drains the iterator and returns every resource.
+func (it *ResourceIterator) All() ([]Resource, error) {
+	var all []Resource
+	for {
+		r, err := it.Next()
+		if err == iterator.Done {
+			return all, nil
+		}
+		if err != nil {
+			return all, err
+		}
+		all = append(all, r)
+	}
+}
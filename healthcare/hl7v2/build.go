@@ -0,0 +1,276 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hl7v2
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Timestamp layouts for DTM and DT values.
+const (
+	timeLayout = "20060102150405"
+	dateLayout = "20060102"
+)
+
+// now is replaced in tests.
+var now = time.Now
+
+// Header holds the MSH fields of a new message.
+type Header struct {
+	SendingApplication   string
+	SendingFacility      string
+	ReceivingApplication string
+	ReceivingFacility    string
+	// Time is MSH-7. It defaults to the current time.
+	Time time.Time
+	// ControlID is MSH-10 and must be unique per sender.
+	ControlID string
+	// ProcessingID is MSH-11: "P" (production), "T" (training) or "D"
+	// (debugging). It defaults to "P".
+	ProcessingID string
+	// Version is MSH-12. It defaults to "2.5.1".
+	Version string
+}
+
+// NewMessage returns a message containing only an MSH segment built from h.
+// MSH-9 is set to messageType^triggerEvent^structure, e.g. ADT^A01^ADT_A01.
+func NewMessage(h Header, messageType, triggerEvent, structure string) *Message {
+	if h.Time.IsZero() {
+		h.Time = now()
+	}
+	if h.ProcessingID == "" {
+		h.ProcessingID = "P"
+	}
+	if h.Version == "" {
+		h.Version = "2.5.1"
+	}
+
+	m := &Message{Delimiters: DefaultDelimiters}
+	msh := m.AddSegment("MSH")
+	msh.Fields = []Field{
+		{{{string(m.Delimiters.Field)}}},
+		{{{m.Delimiters.encodingCharacters()}}},
+	}
+	msh.Set(3, 1, 1, 1, h.SendingApplication)
+	msh.Set(4, 1, 1, 1, h.SendingFacility)
+	msh.Set(5, 1, 1, 1, h.ReceivingApplication)
+	msh.Set(6, 1, 1, 1, h.ReceivingFacility)
+	msh.Set(7, 1, 1, 1, h.Time.Format(timeLayout))
+	msh.Set(9, 1, 1, 1, messageType)
+	msh.Set(9, 1, 2, 1, triggerEvent)
+	if structure != "" {
+		msh.Set(9, 1, 3, 1, structure)
+	}
+	msh.Set(10, 1, 1, 1, h.ControlID)
+	msh.Set(11, 1, 1, 1, h.ProcessingID)
+	msh.Set(12, 1, 1, 1, h.Version)
+	return m
+}
+
+// Patient holds the PID fields set by NewADT and NewORU.
+type Patient struct {
+	// ID is the medical record number, PID-3.1.
+	ID string
+	// AssigningAuthority is PID-3.4.
+	AssigningAuthority string
+	Family             string
+	Given              string
+	BirthDate          time.Time
+	// Sex is PID-8, e.g. "F", "M", "O" or "U".
+	Sex string
+}
+
+func (m *Message) addPID(p Patient) {
+	pid := m.AddSegment("PID")
+	pid.Set(1, 1, 1, 1, "1")
+	pid.Set(3, 1, 1, 1, p.ID)
+	if p.AssigningAuthority != "" {
+		pid.Set(3, 1, 4, 1, p.AssigningAuthority)
+	}
+	pid.Set(3, 1, 5, 1, "MR")
+	pid.Set(5, 1, 1, 1, p.Family)
+	pid.Set(5, 1, 2, 1, p.Given)
+	if !p.BirthDate.IsZero() {
+		pid.Set(7, 1, 1, 1, p.BirthDate.Format(dateLayout))
+	}
+	pid.Set(8, 1, 1, 1, p.Sex)
+}
+
+// Visit holds the PV1 fields set by NewADT.
+type Visit struct {
+	// Class is PV1-2, e.g. "I" (inpatient), "O" (outpatient) or "E"
+	// (emergency). It defaults to "U" (unknown).
+	Class string
+	// PointOfCare, Room and Bed make up the assigned location, PV1-3.
+	PointOfCare string
+	Room        string
+	Bed         string
+	// VisitNumber is PV1-19.
+	VisitNumber string
+}
+
+// NewADT builds an ADT message for the given trigger event, e.g. "A01"
+// (admit) or "A08" (update patient information), with MSH, EVN, PID and PV1
+// segments.
+func NewADT(h Header, trigger string, p Patient, v Visit) *Message {
+	m := NewMessage(h, "ADT", trigger, "ADT_"+adtStructure(trigger))
+
+	evn := m.AddSegment("EVN")
+	evn.Set(1, 1, 1, 1, trigger)
+	evn.Set(2, 1, 1, 1, m.Get("MSH-7"))
+
+	m.addPID(p)
+
+	if v.Class == "" {
+		v.Class = "U"
+	}
+	pv1 := m.AddSegment("PV1")
+	pv1.Set(1, 1, 1, 1, "1")
+	pv1.Set(2, 1, 1, 1, v.Class)
+	pv1.Set(3, 1, 1, 1, v.PointOfCare)
+	pv1.Set(3, 1, 2, 1, v.Room)
+	pv1.Set(3, 1, 3, 1, v.Bed)
+	pv1.Set(19, 1, 1, 1, v.VisitNumber)
+	return m
+}
+
+// adtStructure returns the message structure of an ADT trigger event.
+// Several events share the A01 and A06 structures.
+func adtStructure(trigger string) string {
+	switch trigger {
+	case "A04", "A08", "A13":
+		return "A01"
+	case "A07", "A10", "A11", "A12":
+		return "A06"
+	default:
+		return trigger
+	}
+}
+
+// Order holds the OBR fields set by NewORU.
+type Order struct {
+	PlacerOrderNumber string
+	FillerOrderNumber string
+	// ServiceID, ServiceText and CodingSystem make up the universal
+	// service identifier, OBR-4, e.g. "24331-1", "Lipid panel", "LN".
+	ServiceID       string
+	ServiceText     string
+	CodingSystem    string
+	ObservationTime time.Time
+	// ResultStatus is OBR-25. It defaults to "F" (final).
+	ResultStatus string
+}
+
+// Observation holds the OBX fields set by NewORU.
+type Observation struct {
+	// ValueType is OBX-2, e.g. "NM" (numeric) or "ST" (string).
+	ValueType string
+	// ID, Text and CodingSystem make up the observation identifier,
+	// OBX-3.
+	ID             string
+	Text           string
+	CodingSystem   string
+	Value          string
+	Units          string
+	ReferenceRange string
+	// AbnormalFlag is OBX-8, e.g. "H" or "L".
+	AbnormalFlag string
+	// Status is OBX-11. It defaults to "F" (final).
+	Status string
+}
+
+// NewORU builds an ORU^R01 observation result message with MSH, PID, OBR
+// and one OBX segment per observation.
+func NewORU(h Header, p Patient, o Order, obs []Observation) *Message {
+	m := NewMessage(h, "ORU", "R01", "ORU_R01")
+	m.addPID(p)
+
+	if o.ResultStatus == "" {
+		o.ResultStatus = "F"
+	}
+	obr := m.AddSegment("OBR")
+	obr.Set(1, 1, 1, 1, "1")
+	obr.Set(2, 1, 1, 1, o.PlacerOrderNumber)
+	obr.Set(3, 1, 1, 1, o.FillerOrderNumber)
+	obr.Set(4, 1, 1, 1, o.ServiceID)
+	obr.Set(4, 1, 2, 1, o.ServiceText)
+	obr.Set(4, 1, 3, 1, o.CodingSystem)
+	if !o.ObservationTime.IsZero() {
+		obr.Set(7, 1, 1, 1, o.ObservationTime.Format(timeLayout))
+	}
+	obr.Set(25, 1, 1, 1, o.ResultStatus)
+
+	for i, x := range obs {
+		if x.Status == "" {
+			x.Status = "F"
+		}
+		obx := m.AddSegment("OBX")
+		obx.Set(1, 1, 1, 1, strconv.Itoa(i+1))
+		obx.Set(2, 1, 1, 1, x.ValueType)
+		obx.Set(3, 1, 1, 1, x.ID)
+		obx.Set(3, 1, 2, 1, x.Text)
+		obx.Set(3, 1, 3, 1, x.CodingSystem)
+		obx.Set(5, 1, 1, 1, x.Value)
+		obx.Set(6, 1, 1, 1, x.Units)
+		obx.Set(7, 1, 1, 1, x.ReferenceRange)
+		obx.Set(8, 1, 1, 1, x.AbnormalFlag)
+		obx.Set(11, 1, 1, 1, x.Status)
+	}
+	return m
+}
+
+// AckCode is an acknowledgment code for MSA-1.
+type AckCode string
+
+// Original mode acknowledgment codes.
+const (
+	ApplicationAccept AckCode = "AA"
+	ApplicationError  AckCode = "AE"
+	ApplicationReject AckCode = "AR"
+)
+
+// ACK builds an acknowledgment of m. The sending and receiving application
+// and facility are swapped and MSA-2 references m's control ID. If text is
+// not empty it is returned in MSA-3 and, for error codes, in ERR-8.
+func (m *Message) ACK(code AckCode, controlID, text string) (*Message, error) {
+	if m.Segment("MSH") == nil {
+		return nil, fmt.Errorf("hl7v2: cannot acknowledge a message without MSH")
+	}
+	ack := NewMessage(Header{
+		SendingApplication:   m.Get("MSH-5"),
+		SendingFacility:      m.Get("MSH-6"),
+		ReceivingApplication: m.Get("MSH-3"),
+		ReceivingFacility:    m.Get("MSH-4"),
+		ControlID:            controlID,
+		ProcessingID:         m.Get("MSH-11"),
+		Version:              m.Get("MSH-12"),
+	}, "ACK", m.Get("MSH-9.2"), "ACK")
+	ack.SetDelimiters(m.Delimiters)
+
+	msa := ack.AddSegment("MSA")
+	msa.Set(1, 1, 1, 1, string(code))
+	msa.Set(2, 1, 1, 1, m.Get("MSH-10"))
+	if text != "" {
+		msa.Set(3, 1, 1, 1, text)
+	}
+	if code != ApplicationAccept && text != "" {
+		err := ack.AddSegment("ERR")
+		err.Set(4, 1, 1, 1, "E")
+		err.Set(8, 1, 1, 1, text)
+	}
+	return ack, nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hl7v2
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// unescape decodes the delimiter escape sequences \F\, \S\, \T\, \R\, \E\
+// and hexadecimal \Xhh..\ sequences. Formatting sequences such as \.br\ and
+// \H\ have no plain-text equivalent and are kept as they are.
+func (d Delimiters) unescape(s string) string {
+	esc := d.Escape
+	if strings.IndexByte(s, esc) < 0 {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != esc {
+			b.WriteByte(s[i])
+			continue
+		}
+		end := strings.IndexByte(s[i+1:], esc)
+		if end < 0 {
+			// Unterminated sequence: keep the rest verbatim.
+			b.WriteString(s[i:])
+			break
+		}
+		seq := s[i+1 : i+1+end]
+		switch {
+		case seq == "F":
+			b.WriteByte(d.Field)
+		case seq == "S":
+			b.WriteByte(d.Component)
+		case seq == "T":
+			b.WriteByte(d.SubComponent)
+		case seq == "R":
+			b.WriteByte(d.Repetition)
+		case seq == "E":
+			b.WriteByte(esc)
+		case len(seq) > 1 && seq[0] == 'X':
+			raw, err := hex.DecodeString(seq[1:])
+			if err != nil {
+				b.WriteString(s[i : i+end+2])
+			} else {
+				b.Write(raw)
+			}
+		default:
+			b.WriteString(s[i : i+end+2])
+		}
+		i += end + 1
+	}
+	return b.String()
+}
+
+// isFormatting reports whether seq, the text between two escape
+// characters, is a formatting sequence: \H\ and \N\ for highlighting,
+// \.br\, \.sp\ and the other \.xx\ commands, or a locally defined \Z..\
+// sequence.
+func (d Delimiters) isFormatting(seq string) bool {
+	if strings.ContainsAny(seq, string([]byte{d.Field, d.Component, d.Repetition, d.SubComponent})) {
+		return false
+	}
+	switch {
+	case seq == "H" || seq == "N":
+		return true
+	case len(seq) > 1 && (seq[0] == '.' || seq[0] == 'Z'):
+		return true
+	}
+	return false
+}
+
+// escape replaces delimiter characters in s with escape sequences. It is the
+// inverse of unescape: the formatting sequences that unescape keeps are
+// written as they are.
+func (d Delimiters) escape(s string) string {
+	if !strings.ContainsAny(s, string([]byte{d.Field, d.Component, d.Repetition, d.Escape, d.SubComponent})) {
+		return s
+	}
+
+	var b strings.Builder
+	e := string(d.Escape)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case d.Escape:
+			if end := strings.IndexByte(s[i+1:], d.Escape); end >= 0 && d.isFormatting(s[i+1:i+1+end]) {
+				b.WriteString(s[i : i+end+2])
+				i += end + 1
+				continue
+			}
+			b.WriteString(e + "E" + e)
+		case d.Field:
+			b.WriteString(e + "F" + e)
+		case d.Component:
+			b.WriteString(e + "S" + e)
+		case d.SubComponent:
+			b.WriteString(e + "T" + e)
+		case d.Repetition:
+			b.WriteString(e + "R" + e)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
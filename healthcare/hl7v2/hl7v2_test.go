@@ -0,0 +1,295 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hl7v2
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const adtA01 = "MSH|^~\\&|ADT1|GOOD HEALTH HOSPITAL|GHH LAB|ELAB-3|20240101120000||ADT^A01^ADT_A01|MSG00001|P|2.5.1\r" +
+	"EVN|A01|20240101120000\r" +
+	"PID|1||PATID1234^^^GHH^MR~123456789^^^USSSA^SS||EVERYWOMAN^EVE^E||19620320|F\r" +
+	"NK1|1|NUCLEAR^NELDA^W|SPO^SPOUSE\r" +
+	"NK1|2|NUCLEAR^NED|FTH^FATHER\r" +
+	"OBX|1|ST|NOTE^Note||Patient \\T\\ family said \\F\\yes\\F\\ \\X41\\\\.br\\ok\r"
+
+func TestParseAndGet(t *testing.T) {
+	m, err := Parse([]byte(adtA01))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"MSH-1", "|"},
+		{"MSH-2", "^~\\&"},
+		{"MSH-9.2", "A01"},
+		{"MSH-10", "MSG00001"},
+		{"PID-3", "PATID1234"},
+		{"PID-3.4", "GHH"},
+		{"PID-3(2).1", "123456789"},
+		{"PID-3(2).5", "SS"},
+		{"PID-5.1", "EVERYWOMAN"},
+		{"PID-5.2", "EVE"},
+		{"NK1(2)-2.2", "NED"},
+		{"NK1(3)-2", ""},
+		{"PID-99", ""},
+		{"OBX-5", "Patient & family said |yes| A\\.br\\ok"},
+		{"bad path", ""},
+	}
+	for _, tt := range tests {
+		if got := m.Get(tt.path); got != tt.want {
+			t.Errorf("Get(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	if got := len(m.All("NK1")); got != 2 {
+		t.Errorf("All(NK1) returned %d segments, want 2", got)
+	}
+	if got := m.Segment("PID").Repetitions(3); got != 2 {
+		t.Errorf("PID-3 has %d repetitions, want 2", got)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	m, err := Parse([]byte(adtA01))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	// Hexadecimal sequences are decoded; formatting sequences such as \.br\
+	// are kept.
+	want := strings.Replace(adtA01, "\\X41\\", "A", 1)
+	if got := string(m.Encode()); got != want {
+		t.Errorf("Encode() =\n%q\nwant\n%q", got, want)
+	}
+
+	// Formatted text keeps its formatting, while other escape characters
+	// are escaped.
+	obx := "OBX|1|FT|NOTE||\\H\\Result\\N\\\\.br\\line\\.sp2\\ \\Zfoo\\ C:\\E\\temp\r"
+	m, err = Parse([]byte("MSH|^~\\&|A|B|C|D|||ORU^R01|1|P|2.5.1\r" + obx))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got, want := m.Get("OBX-5"), "\\H\\Result\\N\\\\.br\\line\\.sp2\\ \\Zfoo\\ C:\\temp"; got != want {
+		t.Errorf("OBX-5 = %q, want %q", got, want)
+	}
+	if got := string(m.Encode()); !strings.HasSuffix(got, obx) {
+		t.Errorf("Encode() = %q, want it to end with %q", got, obx)
+	}
+}
+
+func TestCustomDelimiters(t *testing.T) {
+	in := "MSH#*@!%#APP#FAC#RCV#RFAC#20240101##ADT*A08#42#T#2.3\n" +
+		"PID#1##ID1@ID2##DOE*JOHN%Q#\n"
+	m, err := Parse([]byte(in))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := Delimiters{Field: '#', Component: '*', Repetition: '@', Escape: '!', SubComponent: '%'}
+	if m.Delimiters != want {
+		t.Errorf("Delimiters = %+v, want %+v", m.Delimiters, want)
+	}
+	if got := m.Get("PID-3(2)"); got != "ID2" {
+		t.Errorf("PID-3(2) = %q, want ID2", got)
+	}
+	if got := m.Get("PID-5.2.2"); got != "Q" {
+		t.Errorf("PID-5.2.2 = %q, want Q", got)
+	}
+
+	// Re-encode with the default delimiters.
+	if err := m.Set("PID-5.1", "O|NEIL"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	m.SetDelimiters(DefaultDelimiters)
+	out := m.String()
+	if !strings.HasPrefix(out, "MSH|^~\\&|APP|") {
+		t.Errorf("re-encoded MSH = %q", out)
+	}
+	if !strings.Contains(out, "PID|1||ID1~ID2||O\\F\\NEIL^JOHN&Q|") {
+		t.Errorf("re-encoded PID = %q", out)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"PID|1\r",
+		"MSH|^~\r",
+		"MSH|^^\\&|A\r",
+		"MSH|^~\\&|A\rpid|1\r",
+	} {
+		if _, err := Parse([]byte(in)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestTestdataMessage(t *testing.T) {
+	data, err := os.ReadFile("../testdata/hl7v2message.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if err := Validate(m); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+	if got := m.Get("PID-3(2).5"); got != "ORGNMBR" {
+		t.Errorf("PID-3(2).5 = %q, want ORGNMBR", got)
+	}
+}
+
+func TestNewADT(t *testing.T) {
+	m := NewADT(Header{
+		SendingApplication:   "REG",
+		SendingFacility:      "HOSP",
+		ReceivingApplication: "EHR",
+		ReceivingFacility:    "HOSP",
+		Time:                 time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC),
+		ControlID:            "1001",
+	}, "A04", Patient{
+		ID:                 "MRN1",
+		AssigningAuthority: "HOSP",
+		Family:             "Doe",
+		Given:              "Jane",
+		BirthDate:          time.Date(1980, 2, 3, 0, 0, 0, 0, time.UTC),
+		Sex:                "F",
+	}, Visit{Class: "O", PointOfCare: "CLINIC", Room: "12"})
+
+	want := "MSH|^~\\&|REG|HOSP|EHR|HOSP|20240506070809||ADT^A04^ADT_A01|1001|P|2.5.1\n" +
+		"EVN|A04|20240506070809\n" +
+		"PID|1||MRN1^^^HOSP^MR||Doe^Jane||19800203|F\n" +
+		"PV1|1|O|CLINIC^12^||||||||||||||||"
+	if got := m.String(); got != want {
+		t.Errorf("NewADT =\n%s\nwant\n%s", got, want)
+	}
+	if err := Validate(m); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestNewORU(t *testing.T) {
+	m := NewORU(Header{SendingApplication: "LAB", ControlID: "77"},
+		Patient{ID: "MRN1", Family: "Doe", Given: "Jane"},
+		Order{FillerOrderNumber: "F1", ServiceID: "24331-1", ServiceText: "Lipid panel", CodingSystem: "LN"},
+		[]Observation{
+			{ValueType: "NM", ID: "2093-3", Text: "Cholesterol", CodingSystem: "LN", Value: "196", Units: "mg/dL", ReferenceRange: "<200"},
+			{ValueType: "NM", ID: "2571-8", Text: "Triglyceride", CodingSystem: "LN", Value: "250", Units: "mg/dL", AbnormalFlag: "H"},
+		})
+
+	if err := Validate(m); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	for path, want := range map[string]string{
+		"MSH-9":     "ORU",
+		"MSH-9.3":   "ORU_R01",
+		"OBR-4.2":   "Lipid panel",
+		"OBR-25":    "F",
+		"OBX-1":     "1",
+		"OBX(2)-1":  "2",
+		"OBX(2)-3":  "2571-8",
+		"OBX(2)-8":  "H",
+		"OBX-11":    "F",
+		"OBX-7":     "<200",
+		"PID-5.2":   "Jane",
+		"OBX(2)-11": "F",
+	} {
+		if got := m.Get(path); got != want {
+			t.Errorf("Get(%q) = %q, want %q", path, got, want)
+		}
+	}
+
+	// Parsing the encoded message gives the same values back.
+	parsed, err := Parse(m.Encode())
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got := parsed.Get("OBX(2)-5"); got != "250" {
+		t.Errorf("parsed OBX(2)-5 = %q, want 250", got)
+	}
+}
+
+func TestACK(t *testing.T) {
+	m, err := Parse([]byte(adtA01))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now = func() time.Time { return time.Date(2024, 1, 1, 12, 0, 1, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	ack, err := m.ACK(ApplicationError, "ACK00001", "unknown patient")
+	if err != nil {
+		t.Fatalf("ACK: %v", err)
+	}
+	want := "MSH|^~\\&|GHH LAB|ELAB-3|ADT1|GOOD HEALTH HOSPITAL|20240101120001||ACK^A01^ACK|ACK00001|P|2.5.1\n" +
+		"MSA|AE|MSG00001|unknown patient\n" +
+		"ERR||||E||||unknown patient"
+	if got := ack.String(); got != want {
+		t.Errorf("ACK =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSegmentSetInvalid(t *testing.T) {
+	seg := &Segment{Name: "PID"}
+	for _, pos := range [][4]int{{0, 1, 1, 1}, {1, 0, 1, 1}, {1, 1, 0, 1}, {1, 1, 1, -1}} {
+		if err := seg.Set(pos[0], pos[1], pos[2], pos[3], "x"); err == nil {
+			t.Errorf("Set(%v) succeeded, want error", pos)
+		}
+	}
+	if err := seg.Set(2, 1, 1, 1, "x"); err != nil {
+		t.Errorf("Set(2, 1, 1, 1): %v", err)
+	}
+}
+
+func TestSetPath(t *testing.T) {
+	m := NewMessage(Header{ControlID: "1"}, "ADT", "A08", "ADT_A01")
+	if err := m.Set("PID-5.1", "Doe"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := m.Set("NK1(2)-2", "x"); err == nil {
+		t.Errorf("Set(NK1(2)-2) without NK1(1) succeeded, want error")
+	}
+	if err := m.Set("MSH-2", "x"); err == nil {
+		t.Errorf("Set(MSH-2) succeeded, want error")
+	}
+	if got := m.Get("PID-5.1"); got != "Doe" {
+		t.Errorf("PID-5.1 = %q, want Doe", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	m, err := Parse([]byte("MSH|^~\\&|A|B|C|D|||ADT|\rPID|1\rPID|2\r"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Validate(m)
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("Validate got %v, want *ValidationError", err)
+	}
+	for _, want := range []string{"MSH-7", "MSH-9.2", "MSH-10", "MSH-12", "2 PID"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate error %q does not mention %s", err, want)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hl7v2 parses and builds HL7v2 messages in ER7 (pipe and hat)
+// encoding, as stored by Cloud Healthcare API HL7v2 stores.
+//
+// A Message is a list of segments. Each segment holds fields, each field
+// holds repetitions, each repetition holds components and each component
+// holds subcomponents. Values are stored unescaped; escape sequences are
+// decoded by Parse and re-applied by Encode. Values can be read and written
+// with terser-style paths such as "PID-5.1" or "OBX(2)-5":
+//
+//	m, err := hl7v2.Parse(data)
+//	if err != nil {
+//		return err
+//	}
+//	family := m.Get("PID-5.1")
+package hl7v2
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Delimiters are the separator and escape characters declared in MSH-1 and
+// MSH-2.
+type Delimiters struct {
+	Field        byte
+	Component    byte
+	Repetition   byte
+	Escape       byte
+	SubComponent byte
+	// Truncation is the HL7 v2.7 truncation character, or 0 if the
+	// message does not declare one.
+	Truncation byte
+}
+
+// DefaultDelimiters are the delimiters used by almost every message:
+// MSH|^~\&.
+var DefaultDelimiters = Delimiters{
+	Field:        '|',
+	Component:    '^',
+	Repetition:   '~',
+	Escape:       '\\',
+	SubComponent: '&',
+}
+
+// encodingCharacters returns the value of MSH-2.
+func (d Delimiters) encodingCharacters() string {
+	s := string([]byte{d.Component, d.Repetition, d.Escape, d.SubComponent})
+	if d.Truncation != 0 {
+		s += string(d.Truncation)
+	}
+	return s
+}
+
+// Component is a list of subcomponents.
+type Component []string
+
+// Repetition is one occurrence of a repeating field: a list of components.
+type Repetition []Component
+
+// Field is a list of repetitions. Most fields have a single repetition.
+type Field []Repetition
+
+// Segment is a single segment such as MSH or PID. Fields[0] is field 1 in
+// HL7 numbering, so for MSH it holds the field separator (MSH-1) and
+// Fields[1] holds the encoding characters (MSH-2).
+type Segment struct {
+	Name   string
+	Fields []Field
+}
+
+// Message is a parsed HL7v2 message.
+type Message struct {
+	Delimiters Delimiters
+	Segments   []*Segment
+}
+
+// Parse parses an ER7 encoded message. Segments may be terminated by \r,
+// \n or \r\n. The delimiters are read from MSH-1 and MSH-2, so the message
+// must start with an MSH segment.
+func Parse(data []byte) (*Message, error) {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\r"))
+	data = bytes.ReplaceAll(data, []byte("\n"), []byte("\r"))
+
+	var lines []string
+	for _, l := range strings.Split(string(data), "\r") {
+		if strings.TrimSpace(l) != "" {
+			lines = append(lines, l)
+		}
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("hl7v2: empty message")
+	}
+
+	d, err := parseDelimiters(lines[0])
+	if err != nil {
+		return nil, err
+	}
+	m := &Message{Delimiters: d}
+	for i, l := range lines {
+		seg, err := m.parseSegment(l)
+		if err != nil {
+			return nil, fmt.Errorf("hl7v2: segment %d: %w", i+1, err)
+		}
+		m.Segments = append(m.Segments, seg)
+	}
+	return m, nil
+}
+
+func parseDelimiters(msh string) (Delimiters, error) {
+	if !strings.HasPrefix(msh, "MSH") || len(msh) < 8 {
+		return Delimiters{}, errors.New("hl7v2: message does not start with an MSH segment")
+	}
+	d := Delimiters{Field: msh[3]}
+	enc := msh[4:]
+	if i := strings.IndexByte(enc, d.Field); i >= 0 {
+		enc = enc[:i]
+	}
+	if len(enc) < 4 || len(enc) > 5 {
+		return Delimiters{}, fmt.Errorf("hl7v2: invalid encoding characters %q in MSH-2", enc)
+	}
+	d.Component, d.Repetition, d.Escape, d.SubComponent = enc[0], enc[1], enc[2], enc[3]
+	if len(enc) == 5 {
+		d.Truncation = enc[4]
+	}
+
+	seen := map[byte]bool{d.Field: true}
+	for i := 0; i < len(enc); i++ {
+		if seen[enc[i]] {
+			return Delimiters{}, fmt.Errorf("hl7v2: duplicate delimiter %q in MSH-1/MSH-2", enc[i])
+		}
+		seen[enc[i]] = true
+	}
+	return d, nil
+}
+
+func (m *Message) parseSegment(line string) (*Segment, error) {
+	d := m.Delimiters
+	parts := strings.Split(line, string(d.Field))
+	name := parts[0]
+	if !validSegmentName(name) {
+		return nil, fmt.Errorf("invalid segment name %q", name)
+	}
+	seg := &Segment{Name: name}
+
+	values := parts[1:]
+	if name == "MSH" {
+		// MSH-1 is the field separator itself and MSH-2 is taken
+		// literally, so neither is split or unescaped.
+		if len(values) == 0 {
+			return nil, errors.New("MSH segment has no encoding characters")
+		}
+		seg.Fields = append(seg.Fields,
+			Field{{{string(d.Field)}}},
+			Field{{{values[0]}}})
+		values = values[1:]
+	}
+	for _, v := range values {
+		seg.Fields = append(seg.Fields, m.parseField(v))
+	}
+	return seg, nil
+}
+
+func (m *Message) parseField(s string) Field {
+	d := m.Delimiters
+	var f Field
+	for _, rs := range strings.Split(s, string(d.Repetition)) {
+		var r Repetition
+		for _, cs := range strings.Split(rs, string(d.Component)) {
+			var c Component
+			for _, ss := range strings.Split(cs, string(d.SubComponent)) {
+				c = append(c, d.unescape(ss))
+			}
+			r = append(r, c)
+		}
+		f = append(f, r)
+	}
+	return f
+}
+
+// Encode returns the message in ER7 encoding with \r segment terminators.
+func (m *Message) Encode() []byte {
+	var b bytes.Buffer
+	for _, seg := range m.Segments {
+		m.encodeSegment(&b, seg)
+		b.WriteByte('\r')
+	}
+	return b.Bytes()
+}
+
+// String returns the encoded message with segments on separate lines, which
+// is convenient for logging.
+func (m *Message) String() string {
+	return strings.ReplaceAll(strings.TrimSuffix(string(m.Encode()), "\r"), "\r", "\n")
+}
+
+func (m *Message) encodeSegment(b *bytes.Buffer, seg *Segment) {
+	d := m.Delimiters
+	b.WriteString(seg.Name)
+	fields := seg.Fields
+	if seg.Name == "MSH" {
+		b.WriteByte(d.Field)
+		b.WriteString(d.encodingCharacters())
+		if len(fields) > 2 {
+			fields = fields[2:]
+		} else {
+			fields = nil
+		}
+	}
+	for _, f := range fields {
+		b.WriteByte(d.Field)
+		for ri, r := range f {
+			if ri > 0 {
+				b.WriteByte(d.Repetition)
+			}
+			for ci, c := range r {
+				if ci > 0 {
+					b.WriteByte(d.Component)
+				}
+				for si, s := range c {
+					if si > 0 {
+						b.WriteByte(d.SubComponent)
+					}
+					b.WriteString(d.escape(s))
+				}
+			}
+		}
+	}
+}
+
+// SetDelimiters changes the delimiters used by Encode and updates MSH-1 and
+// MSH-2 to match. Values are stored unescaped, so nothing else changes.
+func (m *Message) SetDelimiters(d Delimiters) {
+	m.Delimiters = d
+	if msh := m.Segment("MSH"); msh != nil && len(msh.Fields) >= 2 {
+		msh.Fields[0] = Field{{{string(d.Field)}}}
+		msh.Fields[1] = Field{{{d.encodingCharacters()}}}
+	}
+}
+
+// Segment returns the first segment with the given name, or nil.
+func (m *Message) Segment(name string) *Segment {
+	return m.segmentN(name, 1)
+}
+
+// All returns every segment with the given name, in order.
+func (m *Message) All(name string) []*Segment {
+	var out []*Segment
+	for _, s := range m.Segments {
+		if s.Name == name {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func (m *Message) segmentN(name string, n int) *Segment {
+	for _, s := range m.Segments {
+		if s.Name == name {
+			n--
+			if n == 0 {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+// AddSegment appends an empty segment and returns it.
+func (m *Message) AddSegment(name string) *Segment {
+	s := &Segment{Name: name}
+	m.Segments = append(m.Segments, s)
+	return s
+}
+
+// Get returns the value at the given position, using 1-based HL7 numbering
+// for field, repetition, component and subcomponent. Missing positions
+// return "".
+func (s *Segment) Get(field, rep, comp, sub int) string {
+	if field < 1 || field > len(s.Fields) {
+		return ""
+	}
+	f := s.Fields[field-1]
+	if rep < 1 || rep > len(f) {
+		return ""
+	}
+	r := f[rep-1]
+	if comp < 1 || comp > len(r) {
+		return ""
+	}
+	c := r[comp-1]
+	if sub < 1 || sub > len(c) {
+		return ""
+	}
+	return c[sub-1]
+}
+
+// Set stores value at the given position, growing the segment as needed.
+// Positions are numbered from 1, as in HL7.
+func (s *Segment) Set(field, rep, comp, sub int, value string) error {
+	if field < 1 || rep < 1 || comp < 1 || sub < 1 {
+		return fmt.Errorf("hl7v2: invalid position %s-%d(%d).%d.%d", s.Name, field, rep, comp, sub)
+	}
+	for len(s.Fields) < field {
+		s.Fields = append(s.Fields, Field{{{""}}})
+	}
+	f := s.Fields[field-1]
+	for len(f) < rep {
+		f = append(f, Repetition{{""}})
+	}
+	r := f[rep-1]
+	for len(r) < comp {
+		r = append(r, Component{""})
+	}
+	c := r[comp-1]
+	for len(c) < sub {
+		c = append(c, "")
+	}
+	c[sub-1] = value
+	r[comp-1] = c
+	f[rep-1] = r
+	s.Fields[field-1] = f
+	return nil
+}
+
+// Repetitions returns the number of repetitions of a field.
+func (s *Segment) Repetitions(field int) int {
+	if field < 1 || field > len(s.Fields) {
+		return 0
+	}
+	return len(s.Fields[field-1])
+}
+
+func validSegmentName(name string) bool {
+	if len(name) != 3 {
+		return false
+	}
+	for i := 0; i < 3; i++ {
+		c := name[i]
+		if !('A' <= c && c <= 'Z' || '0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return 'A' <= name[0] && name[0] <= 'Z'
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hl7v2
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// pathRe matches SEG[(n)]-field[(rep)][.comp[.sub]].
+var pathRe = regexp.MustCompile(`^([A-Z][A-Z0-9]{2})(?:\((\d+)\))?-(\d+)(?:\((\d+)\))?(?:\.(\d+)(?:\.(\d+))?)?$`)
+
+// Path identifies a single value in a message. All indexes are 1-based.
+type Path struct {
+	Segment    string
+	Occurrence int
+	Field      int
+	Repetition int
+	Component  int
+	Sub        int
+}
+
+// ParsePath parses a terser-style path such as "PID-5.1", "PID-3(2).1" or
+// "OBX(2)-5". Omitted segment occurrences, repetitions, components and
+// subcomponents default to 1.
+func ParsePath(s string) (Path, error) {
+	m := pathRe.FindStringSubmatch(s)
+	if m == nil {
+		return Path{}, fmt.Errorf("hl7v2: invalid path %q", s)
+	}
+	p := Path{Segment: m[1]}
+	ints := []*int{&p.Occurrence, &p.Field, &p.Repetition, &p.Component, &p.Sub}
+	for i, v := range m[2:] {
+		n := 1
+		if v != "" {
+			var err error
+			if n, err = strconv.Atoi(v); err != nil || n < 1 {
+				return Path{}, fmt.Errorf("hl7v2: invalid index %q in path %q", v, s)
+			}
+		}
+		*ints[i] = n
+	}
+	return p, nil
+}
+
+func (p Path) String() string {
+	return fmt.Sprintf("%s(%d)-%d(%d).%d.%d", p.Segment, p.Occurrence, p.Field, p.Repetition, p.Component, p.Sub)
+}
+
+// Get returns the value at path, or "" if the path is invalid or the
+// message has no value there.
+func (m *Message) Get(path string) string {
+	p, err := ParsePath(path)
+	if err != nil {
+		return ""
+	}
+	seg := m.segmentN(p.Segment, p.Occurrence)
+	if seg == nil {
+		return ""
+	}
+	return seg.Get(p.Field, p.Repetition, p.Component, p.Sub)
+}
+
+// Set stores value at path. Missing segments are appended to the message;
+// the occurrence must be at most one past the existing number of segments
+// with that name.
+func (m *Message) Set(path, value string) error {
+	p, err := ParsePath(path)
+	if err != nil {
+		return err
+	}
+	if p.Segment == "MSH" && p.Field <= 2 {
+		return fmt.Errorf("hl7v2: %s is derived from Delimiters and cannot be set", path)
+	}
+	seg := m.segmentN(p.Segment, p.Occurrence)
+	if seg == nil {
+		if n := len(m.All(p.Segment)); p.Occurrence != n+1 {
+			return fmt.Errorf("hl7v2: cannot set %s: message has %d %s segments", path, n, p.Segment)
+		}
+		seg = m.AddSegment(p.Segment)
+	}
+	return seg.Set(p.Field, p.Repetition, p.Component, p.Sub, value)
+}
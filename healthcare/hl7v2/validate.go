@@ -0,0 +1,67 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hl7v2
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError lists the problems found by Validate.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "hl7v2: invalid message: " + strings.Join(e.Problems, "; ")
+}
+
+// required lists the MSH fields an HL7v2 store needs to index a message.
+var required = []struct {
+	path, name string
+}{
+	{"MSH-7", "date/time of message"},
+	{"MSH-9.1", "message type"},
+	{"MSH-9.2", "trigger event"},
+	{"MSH-10", "message control ID"},
+	{"MSH-11", "processing ID"},
+	{"MSH-12", "version ID"},
+}
+
+// Validate checks the structural requirements a message must meet before it
+// is sent to an HL7v2 store: a single leading MSH segment with the required
+// header fields, and at most one PID segment. It does not validate messages
+// against a specific HL7 version's message structures.
+func Validate(m *Message) error {
+	var problems []string
+	if len(m.Segments) == 0 || m.Segments[0].Name != "MSH" {
+		problems = append(problems, "first segment is not MSH")
+	}
+	if n := len(m.All("MSH")); n > 1 {
+		problems = append(problems, fmt.Sprintf("found %d MSH segments", n))
+	}
+	for _, r := range required {
+		if m.Get(r.path) == "" {
+			problems = append(problems, fmt.Sprintf("%s (%s) is empty", r.path, r.name))
+		}
+	}
+	if n := len(m.All("PID")); n > 1 {
+		problems = append(problems, fmt.Sprintf("found %d PID segments", n))
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/GoogleCloudPlatform/golang-samples/healthcare/hl7v2"
+	healthcare "google.golang.org/api/healthcare/v1"
+)
+
+// ingestValidatedHL7V2Message parses and validates an HL7V2 message before
+// ingesting it, then reports the acknowledgment code returned by the store.
+func ingestValidatedHL7V2Message(w io.Writer, projectID, location, datasetID, hl7V2StoreID, messageFile string) error {
+	ctx := context.Background()
+
+	data, err := os.ReadFile(messageFile)
+	if err != nil {
+		return fmt.Errorf("ReadFile: %w", err)
+	}
+
+	// Reject malformed messages locally instead of sending them to the store.
+	msg, err := hl7v2.Parse(data)
+	if err != nil {
+		return fmt.Errorf("hl7v2.Parse: %w", err)
+	}
+	if err := hl7v2.Validate(msg); err != nil {
+		return err
+	}
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.NewService: %w", err)
+	}
+
+	messagesService := healthcareService.Projects.Locations.Datasets.Hl7V2Stores.Messages
+
+	req := &healthcare.IngestMessageRequest{
+		Message: &healthcare.Message{
+			Data: base64.StdEncoding.EncodeToString(data),
+		},
+	}
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/hl7V2Stores/%s", projectID, location, datasetID, hl7V2StoreID)
+
+	resp, err := messagesService.Ingest(parent, req).Do()
+	if err != nil {
+		return fmt.Errorf("Ingest: %w", err)
+	}
+
+	ackData, err := base64.StdEncoding.DecodeString(resp.Hl7Ack)
+	if err != nil {
+		return fmt.Errorf("could not decode ACK: %w", err)
+	}
+	ack, err := hl7v2.Parse(ackData)
+	if err != nil {
+		return fmt.Errorf("could not parse ACK: %w", err)
+	}
+
+	fmt.Fprintf(w, "Ingested %s^%s message %s: %q (ACK %s)\n",
+		msg.Get("MSH-9.1"), msg.Get("MSH-9.2"), msg.Get("MSH-10"), resp.Message.Name, ack.Get("MSA-1"))
+	return nil
+}
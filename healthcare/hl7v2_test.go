@@ -88,6 +88,16 @@ func TestHL7V2Store(t *testing.T) {
 		}
 	})
 
+	testutil.Retry(t, 10, time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := ingestValidatedHL7V2Message(buf, tc.ProjectID, location, datasetID, hl7V2StoreID, dataFile); err != nil {
+			r.Errorf("ingestValidatedHL7V2Message got err: %v", err)
+		}
+		if got, wantContain := buf.String(), messageID; !strings.Contains(got, wantContain) {
+			r.Errorf("ingestValidatedHL7V2Message got\n----\n%v\n----\nWant to contain:\n----\n%v\n----\n", got, wantContain)
+		}
+	})
+
 	testutil.Retry(t, 10, time.Second, func(r *testutil.R) {
 		buf.Reset()
 		if err := getHL7V2Message(buf, tc.ProjectID, location, datasetID, hl7V2StoreID, messageID); err != nil {
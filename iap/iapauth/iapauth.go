@@ -0,0 +1,231 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package iapauth validates the signed headers that Identity-Aware Proxy
+// adds to requests, and provides net/http middleware that puts the
+// authenticated identity in the request context.
+//
+// IAP signs a JWT with ES256 and sends it in the x-goog-iap-jwt-assertion
+// header. Its audience identifies the protected resource; use
+// AppEngineAudience, BackendServiceAudience or CloudRunAudience to build
+// it. The iaptest package signs assertions for tests.
+package iapauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Header is the request header that holds the IAP assertion.
+const Header = "X-Goog-IAP-JWT-Assertion"
+
+// Issuer is the issuer of IAP assertions.
+const Issuer = "https://cloud.google.com/iap"
+
+// AppEngineAudience returns the audience of an App Engine app.
+func AppEngineAudience(projectNumber, projectID string) string {
+	return fmt.Sprintf("/projects/%s/apps/%s", projectNumber, projectID)
+}
+
+// BackendServiceAudience returns the audience of a backend service, as used
+// for Compute Engine and GKE behind a load balancer.
+func BackendServiceAudience(projectNumber, backendServiceID string) string {
+	return fmt.Sprintf("/projects/%s/global/backendServices/%s", projectNumber, backendServiceID)
+}
+
+// CloudRunAudience returns the audience of a Cloud Run service with IAP
+// enabled directly on the service.
+func CloudRunAudience(projectNumber, region, service string) string {
+	return fmt.Sprintf("/projects/%s/locations/%s/services/%s", projectNumber, region, service)
+}
+
+// Identity is the user authenticated by IAP.
+type Identity struct {
+	Email   string
+	Subject string
+	// HostedDomain is the Google Workspace domain of the user, if any.
+	HostedDomain string
+	// AccessLevels are the names of the Access Context Manager access
+	// levels that the request satisfied.
+	AccessLevels []string
+	Audience     string
+	IssuedAt     time.Time
+	Expires      time.Time
+}
+
+// HasAccessLevel reports whether the request satisfied the access level,
+// given by its full name.
+func (id *Identity) HasAccessLevel(level string) bool {
+	for _, l := range id.AccessLevels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// NewContext returns a context that carries id.
+func NewContext(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the identity stored by NewContext or the middleware.
+func FromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(contextKey{}).(*Identity)
+	return id, ok
+}
+
+// Validation errors. Errors returned by Validate wrap one of these.
+// ErrMissing and ErrInvalid mean the request is not authenticated, while
+// ErrForbidden means it is authenticated but not allowed.
+var (
+	ErrMissing   = errors.New("iapauth: no IAP assertion")
+	ErrInvalid   = errors.New("iapauth: invalid IAP assertion")
+	ErrForbidden = errors.New("iapauth: identity not allowed")
+)
+
+// leeway is the clock skew allowed when checking times.
+const leeway = 30 * time.Second
+
+// Validator validates IAP assertions for one audience.
+type Validator struct {
+	Audience string
+	// Keys provides the public keys that assertions are signed with.
+	// Default GoogleKeys.
+	Keys KeySource
+	// HostedDomain, if set, is the only Workspace domain allowed.
+	HostedDomain string
+	// AccessLevels, if set, must all be satisfied.
+	AccessLevels []string
+	// Now returns the current time. Default time.Now.
+	Now func() time.Time
+}
+
+// NewValidator returns a Validator for audience that fetches Google's IAP
+// keys.
+func NewValidator(audience string) *Validator {
+	return &Validator{Audience: audience, Keys: GoogleKeys}
+}
+
+type claims struct {
+	Audience     string `json:"aud"`
+	Issuer       string `json:"iss"`
+	Subject      string `json:"sub"`
+	Email        string `json:"email"`
+	HostedDomain string `json:"hd"`
+	IssuedAt     int64  `json:"iat"`
+	Expires      int64  `json:"exp"`
+	Google       struct {
+		AccessLevels []string `json:"access_levels"`
+	} `json:"google"`
+}
+
+type header struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// Validate checks the signature, issuer, times and audience of assertion,
+// and the HostedDomain and AccessLevels requirements, and returns the
+// identity it asserts.
+func (v *Validator) Validate(ctx context.Context, assertion string) (*Identity, error) {
+	if assertion == "" {
+		return nil, ErrMissing
+	}
+	parts := strings.Split(assertion, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: want 3 parts, got %d", ErrInvalid, len(parts))
+	}
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, err
+	}
+	if h.Algorithm != "ES256" {
+		return nil, fmt.Errorf("%w: unexpected algorithm %q", ErrInvalid, h.Algorithm)
+	}
+	keys := v.Keys
+	if keys == nil {
+		keys = GoogleKeys
+	}
+	key, err := keys.PublicKey(ctx, h.KeyID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: key %q: %v", ErrInvalid, h.KeyID, err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(sig) != 64 {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalid)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	if !ecdsa.Verify(key, digest[:], r, s) {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalid)
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+	id := &Identity{
+		Email:        c.Email,
+		Subject:      c.Subject,
+		HostedDomain: c.HostedDomain,
+		AccessLevels: c.Google.AccessLevels,
+		Audience:     c.Audience,
+		IssuedAt:     time.Unix(c.IssuedAt, 0),
+		Expires:      time.Unix(c.Expires, 0),
+	}
+	switch {
+	case c.Issuer != Issuer:
+		return nil, fmt.Errorf("%w: issuer %q", ErrInvalid, c.Issuer)
+	case now.After(id.Expires.Add(leeway)):
+		return nil, fmt.Errorf("%w: expired at %v", ErrInvalid, id.Expires.UTC())
+	case now.Add(leeway).Before(id.IssuedAt):
+		return nil, fmt.Errorf("%w: issued in the future at %v", ErrInvalid, id.IssuedAt.UTC())
+	case c.Audience != v.Audience:
+		return nil, fmt.Errorf("%w: audience %q does not match %q", ErrForbidden, c.Audience, v.Audience)
+	case v.HostedDomain != "" && c.HostedDomain != v.HostedDomain:
+		return nil, fmt.Errorf("%w: domain %q", ErrForbidden, c.HostedDomain)
+	}
+	for _, l := range v.AccessLevels {
+		if !id.HasAccessLevel(l) {
+			return nil, fmt.Errorf("%w: access level %q not satisfied", ErrForbidden, l)
+		}
+	}
+	return id, nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return nil
+}
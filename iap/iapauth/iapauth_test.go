@@ -0,0 +1,194 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iapauth_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/golang-samples/iap/iapauth"
+	"github.com/GoogleCloudPlatform/golang-samples/iap/iapauth/iaptest"
+)
+
+var aud = iapauth.AppEngineAudience("123456789", "my-project")
+
+func newSigner(t *testing.T) *iaptest.Signer {
+	t.Helper()
+	s, err := iaptest.NewSigner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestValidate(t *testing.T) {
+	s := newSigner(t)
+	other := newSigner(t)
+	now := time.Now()
+	level := "accessPolicies/1/accessLevels/corp"
+
+	cases := []struct {
+		name   string
+		signer *iaptest.Signer
+		claims iaptest.Claims
+		v      iapauth.Validator
+		want   error
+	}{
+		{"valid", s, iaptest.Claims{Audience: aud, Email: "ada@example.com", HostedDomain: "example.com", AccessLevels: []string{level}},
+			iapauth.Validator{HostedDomain: "example.com", AccessLevels: []string{level}}, nil},
+		{"wrong key", other, iaptest.Claims{Audience: aud, Email: "ada@example.com"}, iapauth.Validator{}, iapauth.ErrInvalid},
+		{"expired", s, iaptest.Claims{Audience: aud, IssuedAt: now.Add(-time.Hour), Expires: now.Add(-time.Minute)}, iapauth.Validator{}, iapauth.ErrInvalid},
+		{"future", s, iaptest.Claims{Audience: aud, IssuedAt: now.Add(time.Hour)}, iapauth.Validator{}, iapauth.ErrInvalid},
+		{"issuer", s, iaptest.Claims{Audience: aud, Issuer: "https://accounts.google.com"}, iapauth.Validator{}, iapauth.ErrInvalid},
+		{"audience", s, iaptest.Claims{Audience: iapauth.BackendServiceAudience("123456789", "42")}, iapauth.Validator{}, iapauth.ErrForbidden},
+		{"domain", s, iaptest.Claims{Audience: aud, HostedDomain: "evil.example"}, iapauth.Validator{HostedDomain: "example.com"}, iapauth.ErrForbidden},
+		{"access level", s, iaptest.Claims{Audience: aud}, iapauth.Validator{AccessLevels: []string{level}}, iapauth.ErrForbidden},
+	}
+	for _, c := range cases {
+		c.v.Audience = aud
+		c.v.Keys = s.Keys()
+		assertion, err := c.signer.Sign(c.claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := c.v.Validate(context.Background(), assertion)
+		if !errors.Is(err, c.want) {
+			t.Errorf("%s: Validate = %v, want %v", c.name, err, c.want)
+			continue
+		}
+		if err == nil {
+			want := &iapauth.Identity{
+				Email:        "ada@example.com",
+				Subject:      "accounts.google.com:ada@example.com",
+				HostedDomain: "example.com",
+				AccessLevels: []string{level},
+				Audience:     aud,
+				IssuedAt:     time.Unix(id.IssuedAt.Unix(), 0),
+				Expires:      time.Unix(id.IssuedAt.Unix(), 0).Add(10 * time.Minute),
+			}
+			if !reflect.DeepEqual(id, want) {
+				t.Errorf("%s: identity = %+v, want %+v", c.name, id, want)
+			}
+		}
+	}
+
+	v := iapauth.Validator{Audience: aud, Keys: s.Keys()}
+	for _, a := range []string{"", "a.b", "e30.e30.e30"} {
+		if _, err := v.Validate(context.Background(), a); err == nil {
+			t.Errorf("Validate(%q) succeeded", a)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	s := newSigner(t)
+	v := &iapauth.Validator{Audience: aud, Keys: s.Keys()}
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	h := v.Middleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := iapauth.FromContext(r.Context())
+		if !ok {
+			t.Errorf("no identity in context")
+			return
+		}
+		fmt.Fprint(w, id.Email)
+	}))
+
+	good, err := s.Sign(iaptest.Claims{Audience: aud, Email: "ada@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherApp, err := s.Sign(iaptest.Claims{Audience: iapauth.CloudRunAudience("123456789", "us-central1", "other"), Email: "ada@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		assertion string
+		status    int
+		body      string
+	}{
+		{good, http.StatusOK, "ada@example.com"},
+		{"", http.StatusUnauthorized, "Unauthorized\n"},
+		{good + "x", http.StatusUnauthorized, "Unauthorized\n"},
+		{otherApp, http.StatusForbidden, "Forbidden\n"},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "/private", nil)
+		if c.assertion != "" {
+			r.Header.Set(iapauth.Header, c.assertion)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		if rec.Code != c.status || rec.Body.String() != c.body {
+			t.Errorf("status %d, body %q; want %d, %q", rec.Code, rec.Body, c.status, c.body)
+		}
+	}
+	if n := strings.Count(logs.String(), `"msg":"IAP validation failed"`); n != 3 {
+		t.Errorf("logged %d failures, want 3:\n%s", n, logs.String())
+	}
+	if !strings.Contains(logs.String(), `"status":403`) {
+		t.Errorf("logs do not record the 403:\n%s", logs.String())
+	}
+}
+
+func TestJWKSource(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		enc := base64.RawURLEncoding
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kid": "k1", "kty": "EC", "crv": "P-256", "alg": "ES256", "use": "sig",
+			"x": enc.EncodeToString(key.X.Bytes()), "y": enc.EncodeToString(key.Y.Bytes()),
+		}}})
+	}))
+	defer srv.Close()
+
+	src := iapauth.NewJWKSource(srv.URL, srv.Client())
+	for i := 0; i < 2; i++ {
+		got, err := src.PublicKey(context.Background(), "k1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(&key.PublicKey) {
+			t.Errorf("PublicKey returned a different key")
+		}
+	}
+	// Unknown keys are refetched at most once a minute.
+	if _, err := src.PublicKey(context.Background(), "k2"); err == nil {
+		t.Errorf("PublicKey(k2) succeeded")
+	}
+	if fetches != 1 {
+		t.Errorf("fetched %d times, want 1", fetches)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iapauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// KeySource provides the public keys that verify assertions.
+type KeySource interface {
+	PublicKey(ctx context.Context, keyID string) (*ecdsa.PublicKey, error)
+}
+
+// PublicKeyURL serves IAP's public keys as a JSON Web Key Set.
+const PublicKeyURL = "https://www.gstatic.com/iap/verify/public_key-jwk"
+
+// GoogleKeys fetches IAP's public keys from PublicKeyURL.
+var GoogleKeys KeySource = NewJWKSource(PublicKeyURL, nil)
+
+// JWKSource fetches keys from a JSON Web Key Set and caches them. The set
+// is fetched again when it is an hour old, or when an unknown key is
+// requested, at most once a minute, as IAP rotates its keys.
+type JWKSource struct {
+	url    string
+	client *http.Client
+
+	mu      sync.Mutex
+	keys    map[string]*ecdsa.PublicKey
+	fetched time.Time
+}
+
+// NewJWKSource returns a JWKSource for url. If client is nil,
+// http.DefaultClient is used.
+func NewJWKSource(url string, client *http.Client) *JWKSource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &JWKSource{url: url, client: client}
+}
+
+// PublicKey returns the key with the given ID.
+func (s *JWKSource) PublicKey(ctx context.Context, keyID string) (*ecdsa.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	age := time.Since(s.fetched)
+	if k, ok := s.keys[keyID]; ok && age < time.Hour {
+		return k, nil
+	}
+	if s.keys == nil || age >= time.Minute {
+		keys, err := s.fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.keys, s.fetched = keys, time.Now()
+	}
+	if k, ok := s.keys[keyID]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown key ID %q", keyID)
+}
+
+type jwk struct {
+	KeyID string `json:"kid"`
+	Type  string `json:"kty"`
+	Curve string `json:"crv"`
+	X     string `json:"x"`
+	Y     string `json:"y"`
+}
+
+func (s *JWKSource) fetch(ctx context.Context) (map[string]*ecdsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("http.NewRequest: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Get: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Get %s: %s", s.url, resp.Status)
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("Decode: %w", err)
+	}
+	keys := make(map[string]*ecdsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Type != "EC" || k.Curve != "P-256" {
+			continue
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.KeyID, err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.KeyID, err)
+		}
+		keys[k.KeyID] = &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	}
+	return keys, nil
+}
+
+// StaticKeys is a KeySource holding fixed keys, by key ID.
+type StaticKeys map[string]*ecdsa.PublicKey
+
+// PublicKey returns the key with the given ID.
+func (s StaticKeys) PublicKey(_ context.Context, keyID string) (*ecdsa.PublicKey, error) {
+	if k, ok := s[keyID]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown key ID %q", keyID)
+}
@@ -0,0 +1,51 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iapauth
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// Middleware returns a handler that validates the IAP assertion of each
+// request and calls next with the identity in the request context.
+// Requests without a valid assertion get 401 Unauthorized, and requests
+// from identities the Validator does not allow get 403 Forbidden. Failures
+// are logged to logger, or to slog.Default if logger is nil.
+func (v *Validator) Middleware(logger *slog.Logger, next http.Handler) http.Handler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := v.Validate(r.Context(), r.Header.Get(Header))
+		if err != nil {
+			status := http.StatusUnauthorized
+			if errors.Is(err, ErrForbidden) {
+				status = http.StatusForbidden
+			}
+			logger.WarnContext(r.Context(), "IAP validation failed",
+				slog.String("error", err.Error()),
+				slog.Int("status", status),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("remoteAddr", r.RemoteAddr),
+			)
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+	})
+}
@@ -0,0 +1,174 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The taillogs command streams log entries from Cloud Logging as they are
+// ingested, like tail -f.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	logging "cloud.google.com/go/logging/apiv2"
+	"cloud.google.com/go/logging/apiv2/loggingpb"
+	"github.com/GoogleCloudPlatform/golang-samples/logging/taillogs"
+	ltype "google.golang.org/genproto/googleapis/logging/type"
+)
+
+// stringList is a flag that can be repeated or given a comma separated
+// list.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(s string) error {
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+func main() {
+	var resources stringList
+	projectID := flag.String("project", os.Getenv("GOOGLE_CLOUD_PROJECT"), "Google Cloud project ID to tail, if -resource is not set.")
+	flag.Var(&resources, "resource", "Resource to tail, such as projects/my-project or folders/123. Can be repeated.")
+	filter := flag.String("filter", "", "Logging query entries must match, such as 'resource.type=\"cloud_run_revision\"'.")
+	severity := flag.String("severity", "", "Minimum severity: debug, info, notice, warning, error, critical, alert, emergency, or d, i, n, w, e, c, a.")
+	format := flag.String("format", "text", "Output format: text, json, or template.")
+	tmpl := flag.String("template", "{{.Timestamp.Format \"15:04:05\"}} {{.Severity}} {{.Message}}", "text/template for -format template, executed with a taillogs.Entry.")
+	color := flag.String("color", "auto", "Color text output: auto, always, or never.")
+	labels := flag.Bool("labels", false, "Show entry labels in text output.")
+	buffer := flag.Duration("buffer", 0, "Buffer window to sort entries by timestamp, up to 1m. Default: 2s.")
+	limit := flag.Int("limit", 0, "Stop after this many entries. Default: no limit.")
+	flag.Parse()
+
+	if len(resources) == 0 {
+		if *projectID == "" {
+			log.Fatal(`Error: -project, -resource or GOOGLE_CLOUD_PROJECT must be set.
+
+Examples:
+  go run ./cmd/taillogs -project my-project -severity w
+  go run ./cmd/taillogs -project my-project -filter 'resource.type="cloud_run_revision"' -format json`)
+		}
+		resources = stringList{"projects/" + *projectID}
+	}
+	minSeverity := ltype.LogSeverity_DEFAULT
+	if *severity != "" {
+		var err error
+		if minSeverity, err = taillogs.ParseSeverity(*severity); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var f taillogs.Formatter
+	switch *format {
+	case "text":
+		f = &taillogs.TextFormatter{Color: useColor(*color), ShowLabels: *labels}
+	case "json":
+		f = taillogs.JSONFormatter{}
+	case "template":
+		tf, err := taillogs.NewTemplateFormatter(*tmpl)
+		if err != nil {
+			log.Fatal(err)
+		}
+		f = tf
+	default:
+		log.Fatalf("Error: unknown -format %q, want text, json, or template", *format)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	client, err := logging.NewClient(ctx)
+	if err != nil {
+		log.Fatalf("logging.NewClient: %v", err)
+	}
+	defer client.Close()
+
+	suppressed := make(map[string]int64)
+	t := &taillogs.Tailer{
+		Client:        client,
+		ResourceNames: resources,
+		Filter:        taillogs.Filter(*filter, minSeverity),
+		BufferWindow:  *buffer,
+		OnSuppressed: func(reason loggingpb.TailLogEntriesResponse_SuppressionInfo_Reason, count int32) {
+			suppressed[reason.String()] += int64(count)
+			log.Printf("%d entries suppressed (%s)", count, reason)
+		},
+		OnReconnect: func(err error, delay time.Duration) {
+			log.Printf("Stream ended (%v), reconnecting in %v", err, delay.Round(time.Millisecond))
+		},
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	n := 0
+	err = t.Tail(ctx, func(e *loggingpb.LogEntry) error {
+		if err := f.Format(out, e); err != nil {
+			return err
+		}
+		// Show entries as they arrive.
+		if err := out.Flush(); err != nil {
+			return err
+		}
+		n++
+		if *limit > 0 && n >= *limit {
+			return taillogs.ErrStop
+		}
+		return nil
+	})
+	out.Flush()
+	printSuppressed(suppressed)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// useColor reports whether to color output for the -color flag. auto
+// colors output to a terminal.
+func useColor(mode string) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func printSuppressed(suppressed map[string]int64) {
+	if len(suppressed) == 0 {
+		return
+	}
+	reasons := make([]string, 0, len(suppressed))
+	for r := range suppressed {
+		reasons = append(reasons, r)
+	}
+	sort.Strings(reasons)
+	for _, r := range reasons {
+		fmt.Fprintf(os.Stderr, "Suppressed %d entries: %s\n", suppressed[r], r)
+	}
+}
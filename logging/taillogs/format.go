@@ -0,0 +1,239 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taillogs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"cloud.google.com/go/logging/apiv2/loggingpb"
+	ltype "google.golang.org/genproto/googleapis/logging/type"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	// Register the audit log payload type, so JSON output can include the
+	// protoPayload of audit logs.
+	_ "google.golang.org/genproto/googleapis/cloud/audit"
+)
+
+// Entry is a log entry flattened for display and for templates.
+type Entry struct {
+	Timestamp time.Time
+	Severity  string
+	// LogName is the full log name, and LogID its last part, such as
+	// "run.googleapis.com/stdout".
+	LogName string
+	LogID   string
+	// Resource is the monitored resource type, such as "cloud_run_revision".
+	Resource       string
+	ResourceLabels map[string]string
+	Labels         map[string]string
+	// Message is the text payload, the "message" field of a JSON payload,
+	// or a compact form of the payload.
+	Message string
+	// JSON is the JSON payload, if any.
+	JSON        map[string]interface{}
+	Trace       string
+	SpanID      string
+	InsertID    string
+	HTTPRequest *ltype.HttpRequest
+	// Raw is the entry as received.
+	Raw *loggingpb.LogEntry
+}
+
+// NewEntry flattens e.
+func NewEntry(e *loggingpb.LogEntry) *Entry {
+	x := &Entry{
+		Timestamp:      e.GetTimestamp().AsTime(),
+		Severity:       e.GetSeverity().String(),
+		LogName:        e.GetLogName(),
+		LogID:          logID(e.GetLogName()),
+		Resource:       e.GetResource().GetType(),
+		ResourceLabels: e.GetResource().GetLabels(),
+		Labels:         e.GetLabels(),
+		Trace:          e.GetTrace(),
+		SpanID:         e.GetSpanId(),
+		InsertID:       e.GetInsertId(),
+		HTTPRequest:    e.GetHttpRequest(),
+		Raw:            e,
+	}
+	switch p := e.GetPayload().(type) {
+	case *loggingpb.LogEntry_TextPayload:
+		x.Message = p.TextPayload
+	case *loggingpb.LogEntry_JsonPayload:
+		x.JSON = p.JsonPayload.AsMap()
+		if msg, ok := x.JSON["message"].(string); ok {
+			x.Message = msg
+		} else if b, err := protojson.Marshal(p.JsonPayload); err == nil {
+			x.Message = string(compactJSON(b))
+		}
+	case *loggingpb.LogEntry_ProtoPayload:
+		x.Message = "[" + strings.TrimPrefix(p.ProtoPayload.GetTypeUrl(), "type.googleapis.com/") + "]"
+	}
+	return x
+}
+
+// logID returns the unescaped log ID of a log name such as
+// "projects/p/logs/run.googleapis.com%2Fstdout".
+func logID(logName string) string {
+	i := strings.Index(logName, "/logs/")
+	if i < 0 {
+		return logName
+	}
+	return strings.ReplaceAll(logName[i+len("/logs/"):], "%2F", "/")
+}
+
+func compactJSON(b []byte) []byte {
+	var buf bytes.Buffer
+	// protojson output is valid JSON, but may contain random spaces.
+	if err := json.Compact(&buf, b); err != nil {
+		return b
+	}
+	return buf.Bytes()
+}
+
+// Formatter writes log entries.
+type Formatter interface {
+	Format(w io.Writer, e *loggingpb.LogEntry) error
+}
+
+// TextFormatter writes one line per entry: the time, severity, log ID,
+// HTTP request if any, and message.
+type TextFormatter struct {
+	// Color colors the severity with ANSI escape codes.
+	Color bool
+	// Location is the time zone to show times in. Default: local time.
+	Location *time.Location
+	// ShowLabels appends the entry labels to each line.
+	ShowLabels bool
+}
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiGray   = "\x1b[90m"
+	ansiBlue   = "\x1b[34m"
+	ansiYellow = "\x1b[33m"
+	ansiRed    = "\x1b[31m"
+	ansiBold   = "\x1b[1;31m"
+)
+
+func severityColor(s ltype.LogSeverity) string {
+	switch {
+	case s >= ltype.LogSeverity_CRITICAL:
+		return ansiBold
+	case s >= ltype.LogSeverity_ERROR:
+		return ansiRed
+	case s >= ltype.LogSeverity_WARNING:
+		return ansiYellow
+	case s >= ltype.LogSeverity_INFO:
+		return ansiBlue
+	}
+	return ansiGray
+}
+
+// Format writes e as a line of text.
+func (f *TextFormatter) Format(w io.Writer, e *loggingpb.LogEntry) error {
+	x := NewEntry(e)
+	loc := f.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	sev := fmt.Sprintf("%-9s", x.Severity)
+	if f.Color {
+		sev = severityColor(e.GetSeverity()) + sev + ansiReset
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s", x.Timestamp.In(loc).Format("2006-01-02T15:04:05.000Z07:00"), sev, x.LogID)
+	if r := x.HTTPRequest; r != nil {
+		fmt.Fprintf(&b, " %s %d %s", r.GetRequestMethod(), r.GetStatus(), r.GetRequestUrl())
+		if l := r.GetLatency(); l != nil {
+			fmt.Fprintf(&b, " %v", l.AsDuration().Round(time.Millisecond))
+		}
+	}
+	if x.Message != "" {
+		b.WriteString(": ")
+		// Keep one line per entry.
+		b.WriteString(strings.ReplaceAll(strings.TrimRight(x.Message, "\n"), "\n", `\n`))
+	}
+	if f.ShowLabels && len(x.Labels) > 0 {
+		keys := make([]string, 0, len(x.Labels))
+		for k := range x.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, " %s=%s", k, x.Labels[k])
+		}
+	}
+	b.WriteByte('\n')
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// JSONFormatter writes each entry as a line of JSON, in the LogEntry JSON
+// format used by the Logging API.
+type JSONFormatter struct{}
+
+// Format writes e as a line of JSON.
+func (JSONFormatter) Format(w io.Writer, e *loggingpb.LogEntry) error {
+	b, err := protojson.Marshal(e)
+	if err != nil {
+		// The protoPayload type is unknown, so leave it out rather than
+		// losing the entry.
+		e = proto.Clone(e).(*loggingpb.LogEntry)
+		e.Payload = nil
+		if b, err = protojson.Marshal(e); err != nil {
+			return fmt.Errorf("protojson.Marshal: %w", err)
+		}
+	}
+	_, err = w.Write(append(compactJSON(b), '\n'))
+	return err
+}
+
+// TemplateFormatter executes a text/template with the Entry of each log
+// entry, followed by a newline.
+type TemplateFormatter struct {
+	tmpl *template.Template
+}
+
+// NewTemplateFormatter parses text, such as
+// "{{.Timestamp}} {{.Severity}} {{.Message}}".
+func NewTemplateFormatter(text string) (*TemplateFormatter, error) {
+	t, err := template.New("entry").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("template.Parse: %w", err)
+	}
+	return &TemplateFormatter{tmpl: t}, nil
+}
+
+// Format executes the template with e.
+func (f *TemplateFormatter) Format(w io.Writer, e *loggingpb.LogEntry) error {
+	var b bytes.Buffer
+	if err := f.tmpl.Execute(&b, NewEntry(e)); err != nil {
+		return fmt.Errorf("template.Execute: %w", err)
+	}
+	if !bytes.HasSuffix(b.Bytes(), []byte("\n")) {
+		b.WriteByte('\n')
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
@@ -0,0 +1,204 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package taillogs streams log entries from Cloud Logging as they are
+// ingested, using the TailLogEntries API.
+package taillogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	logging "cloud.google.com/go/logging/apiv2"
+	"cloud.google.com/go/logging/apiv2/loggingpb"
+	"github.com/googleapis/gax-go/v2"
+	ltype "google.golang.org/genproto/googleapis/logging/type"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// ErrStop can be returned by the func passed to Tail to stop tailing
+// without an error.
+var ErrStop = errors.New("taillogs: stop")
+
+// Tailer streams the log entries of one or more resources.
+type Tailer struct {
+	Client *logging.Client
+	// ResourceNames are the parents to tail, such as "projects/my-project"
+	// or "folders/123".
+	ResourceNames []string
+	// Filter is a Logging query, as built by Filter. Empty tails every
+	// entry.
+	Filter string
+	// BufferWindow is how long the service waits to sort entries by
+	// timestamp before sending them. Zero uses the service default of 2s.
+	BufferWindow time.Duration
+	// Backoff controls the delay before reconnecting. The zero value
+	// starts at 1s and doubles up to 30s.
+	Backoff gax.Backoff
+
+	// OnSuppressed, if set, is called when the service reports entries it
+	// did not send, because of rate limits or because the client did not
+	// read them fast enough.
+	OnSuppressed func(reason loggingpb.TailLogEntriesResponse_SuppressionInfo_Reason, count int32)
+	// OnReconnect, if set, is called before waiting delay to reconnect
+	// after the stream ended with err. err is io.EOF when the session
+	// timed out.
+	OnReconnect func(err error, delay time.Duration)
+}
+
+// Tail calls fn with each entry until ctx is done, fn returns an error, or
+// the stream fails with an error that retrying can't fix. Streams are
+// reopened when the service ends the session, which it does after an
+// hour, or when they fail with a temporary error. Entries ingested while
+// reconnecting are not sent.
+//
+// Tail returns nil if ctx is done or fn returns ErrStop.
+func (t *Tailer) Tail(ctx context.Context, fn func(*loggingpb.LogEntry) error) error {
+	bo := t.Backoff
+	if bo.Initial == 0 {
+		bo.Initial = time.Second
+	}
+	if bo.Max == 0 {
+		bo.Max = 30 * time.Second
+	}
+	for {
+		received, err := t.session(ctx, fn)
+		switch {
+		case errors.Is(err, ErrStop) || ctx.Err() != nil:
+			return nil
+		case err != io.EOF && !retryable(err):
+			return err
+		}
+		if received {
+			// The stream worked, so start backing off from the
+			// beginning again.
+			bo = gax.Backoff{Initial: bo.Initial, Max: bo.Max, Multiplier: bo.Multiplier}
+		}
+		delay := bo.Pause()
+		if t.OnReconnect != nil {
+			t.OnReconnect(err, delay)
+		}
+		if err := gax.Sleep(ctx, delay); err != nil {
+			return nil
+		}
+	}
+}
+
+// session runs one TailLogEntries stream. It reports whether any response
+// was received.
+func (t *Tailer) session(ctx context.Context, fn func(*loggingpb.LogEntry) error) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := t.Client.TailLogEntries(ctx)
+	if err != nil {
+		return false, fmt.Errorf("TailLogEntries: %w", err)
+	}
+	req := &loggingpb.TailLogEntriesRequest{
+		ResourceNames: t.ResourceNames,
+		Filter:        t.Filter,
+	}
+	if t.BufferWindow > 0 {
+		req.BufferWindow = durationpb.New(t.BufferWindow)
+	}
+	if err := stream.Send(req); err != nil {
+		return false, fmt.Errorf("stream.Send: %w", err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		return false, fmt.Errorf("stream.CloseSend: %w", err)
+	}
+
+	received := false
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return received, io.EOF
+		}
+		if err != nil {
+			return received, fmt.Errorf("stream.Recv: %w", err)
+		}
+		received = true
+		for _, s := range resp.GetSuppressionInfo() {
+			if t.OnSuppressed != nil && s.GetSuppressedCount() > 0 {
+				t.OnSuppressed(s.GetReason(), s.GetSuppressedCount())
+			}
+		}
+		for _, e := range resp.GetEntries() {
+			if err := fn(e); err != nil {
+				return received, err
+			}
+		}
+	}
+}
+
+// retryable reports whether a stream that failed with err should be
+// reopened.
+func retryable(err error) bool {
+	s, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	switch s.Code() {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Aborted, codes.Internal, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
+
+// severityAliases are the short names accepted by ParseSeverity.
+var severityAliases = map[string]ltype.LogSeverity{
+	"D":     ltype.LogSeverity_DEBUG,
+	"I":     ltype.LogSeverity_INFO,
+	"N":     ltype.LogSeverity_NOTICE,
+	"W":     ltype.LogSeverity_WARNING,
+	"WARN":  ltype.LogSeverity_WARNING,
+	"E":     ltype.LogSeverity_ERROR,
+	"ERR":   ltype.LogSeverity_ERROR,
+	"C":     ltype.LogSeverity_CRITICAL,
+	"CRIT":  ltype.LogSeverity_CRITICAL,
+	"A":     ltype.LogSeverity_ALERT,
+	"EMERG": ltype.LogSeverity_EMERGENCY,
+}
+
+// ParseSeverity parses a severity name, such as "warning", or a shortcut
+// such as "w", "warn", "err" or "crit". Case is ignored.
+func ParseSeverity(s string) (ltype.LogSeverity, error) {
+	u := strings.ToUpper(strings.TrimSpace(s))
+	if v, ok := ltype.LogSeverity_value[u]; ok {
+		return ltype.LogSeverity(v), nil
+	}
+	if sev, ok := severityAliases[u]; ok {
+		return sev, nil
+	}
+	return 0, fmt.Errorf("taillogs: unknown severity %q", s)
+}
+
+// Filter returns a Logging query that matches expr and entries of at least
+// minSeverity. Either may be empty or DEFAULT to leave it out.
+func Filter(expr string, minSeverity ltype.LogSeverity) string {
+	var parts []string
+	if expr = strings.TrimSpace(expr); expr != "" {
+		parts = append(parts, "("+expr+")")
+	}
+	if minSeverity > ltype.LogSeverity_DEFAULT {
+		parts = append(parts, "severity>="+minSeverity.String())
+	}
+	return strings.Join(parts, " AND ")
+}
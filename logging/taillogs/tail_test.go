@@ -0,0 +1,301 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taillogs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	logging "cloud.google.com/go/logging/apiv2"
+	"cloud.google.com/go/logging/apiv2/loggingpb"
+	"github.com/googleapis/gax-go/v2"
+	"google.golang.org/api/option"
+	"google.golang.org/genproto/googleapis/api/monitoredres"
+	ltype "google.golang.org/genproto/googleapis/logging/type"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeLogging is a LoggingServiceV2 server whose TailLogEntries streams
+// play back sessions in order.
+type fakeLogging struct {
+	loggingpb.UnimplementedLoggingServiceV2Server
+
+	mu       sync.Mutex
+	sessions []session
+	requests []*loggingpb.TailLogEntriesRequest
+}
+
+// session is the responses of one stream, and how it ends. A nil err
+// ends the stream normally, as when the session times out.
+type session struct {
+	responses []*loggingpb.TailLogEntriesResponse
+	err       error
+}
+
+func (s *fakeLogging) TailLogEntries(stream loggingpb.LoggingServiceV2_TailLogEntriesServer) error {
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	if len(s.sessions) == 0 {
+		s.mu.Unlock()
+		// Hold the stream open until the client goes away.
+		<-stream.Context().Done()
+		return nil
+	}
+	sess := s.sessions[0]
+	s.sessions = s.sessions[1:]
+	s.mu.Unlock()
+
+	for _, resp := range sess.responses {
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+	return sess.err
+}
+
+func newFakeClient(t *testing.T, srv *fakeLogging) *logging.Client {
+	t.Helper()
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := grpc.NewServer()
+	loggingpb.RegisterLoggingServiceV2Server(gs, srv)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	client, err := logging.NewClient(context.Background(),
+		option.WithEndpoint(lis.Addr().String()),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func entries(texts ...string) *loggingpb.TailLogEntriesResponse {
+	resp := &loggingpb.TailLogEntriesResponse{}
+	for _, s := range texts {
+		resp.Entries = append(resp.Entries, &loggingpb.LogEntry{
+			LogName: "projects/p/logs/test",
+			Payload: &loggingpb.LogEntry_TextPayload{TextPayload: s},
+		})
+	}
+	return resp
+}
+
+func TestTailReconnects(t *testing.T) {
+	srv := &fakeLogging{sessions: []session{
+		{responses: []*loggingpb.TailLogEntriesResponse{
+			entries("a", "b"),
+			{SuppressionInfo: []*loggingpb.TailLogEntriesResponse_SuppressionInfo{{
+				Reason:          loggingpb.TailLogEntriesResponse_SuppressionInfo_RATE_LIMIT,
+				SuppressedCount: 7,
+			}}},
+		}},
+		{err: status.Error(codes.Unavailable, "try again")},
+		{responses: []*loggingpb.TailLogEntriesResponse{entries("c")}, err: status.Error(codes.DeadlineExceeded, "session timeout")},
+		{responses: []*loggingpb.TailLogEntriesResponse{entries("d", "e")}},
+	}}
+
+	var reconnects []error
+	suppressed := make(map[loggingpb.TailLogEntriesResponse_SuppressionInfo_Reason]int32)
+	tl := &Tailer{
+		Client:        newFakeClient(t, srv),
+		ResourceNames: []string{"projects/p", "folders/1"},
+		Filter:        Filter(`logName:"test"`, ltype.LogSeverity_WARNING),
+		BufferWindow:  5 * time.Second,
+		Backoff:       gax.Backoff{Initial: time.Millisecond, Max: time.Millisecond},
+		OnSuppressed: func(r loggingpb.TailLogEntriesResponse_SuppressionInfo_Reason, n int32) {
+			suppressed[r] += n
+		},
+		OnReconnect: func(err error, _ time.Duration) { reconnects = append(reconnects, err) },
+	}
+
+	var got []string
+	err := tl.Tail(context.Background(), func(e *loggingpb.LogEntry) error {
+		got = append(got, e.GetTextPayload())
+		if len(got) == 4 {
+			return ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Tail: %v", err)
+	}
+	if want := []string{"a", "b", "c", "d"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got entries %q, want %q", got, want)
+	}
+	if len(reconnects) != 3 || reconnects[0] != io.EOF {
+		t.Errorf("reconnects = %v, want EOF then 2 errors", reconnects)
+	}
+	if n := suppressed[loggingpb.TailLogEntriesResponse_SuppressionInfo_RATE_LIMIT]; n != 7 {
+		t.Errorf("suppressed = %v, want 7 rate limited", suppressed)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.requests) != 4 {
+		t.Fatalf("got %d requests, want 4", len(srv.requests))
+	}
+	for _, req := range srv.requests {
+		if got, want := req.GetFilter(), `(logName:"test") AND severity>=WARNING`; got != want {
+			t.Errorf("Filter = %q, want %q", got, want)
+		}
+		if len(req.GetResourceNames()) != 2 || req.GetBufferWindow().AsDuration() != 5*time.Second {
+			t.Errorf("request = %v", req)
+		}
+	}
+}
+
+func TestTailStops(t *testing.T) {
+	srv := &fakeLogging{sessions: []session{
+		{responses: []*loggingpb.TailLogEntriesResponse{entries("a")}, err: status.Error(codes.InvalidArgument, "bad filter")},
+	}}
+	tl := &Tailer{Client: newFakeClient(t, srv), ResourceNames: []string{"projects/p"}}
+	err := tl.Tail(context.Background(), func(*loggingpb.LogEntry) error { return nil })
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Tail = %v, want InvalidArgument", err)
+	}
+
+	// Errors from fn are returned as they are.
+	srv.sessions = []session{{responses: []*loggingpb.TailLogEntriesResponse{entries("a")}}}
+	errWrite := errors.New("write failed")
+	if err := tl.Tail(context.Background(), func(*loggingpb.LogEntry) error { return errWrite }); err != errWrite {
+		t.Errorf("Tail = %v, want %v", err, errWrite)
+	}
+
+	// Canceling ctx stops an open stream.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := tl.Tail(ctx, func(*loggingpb.LogEntry) error { return nil }); err != nil {
+		t.Errorf("Tail after cancel = %v, want nil", err)
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	for in, want := range map[string]ltype.LogSeverity{
+		"warning": ltype.LogSeverity_WARNING,
+		"W":       ltype.LogSeverity_WARNING,
+		"err":     ltype.LogSeverity_ERROR,
+		"e":       ltype.LogSeverity_ERROR,
+		"Emerg":   ltype.LogSeverity_EMERGENCY,
+		"debug":   ltype.LogSeverity_DEBUG,
+	} {
+		if got, err := ParseSeverity(in); err != nil || got != want {
+			t.Errorf("ParseSeverity(%q) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseSeverity("loud"); err == nil {
+		t.Error("ParseSeverity(loud) succeeded")
+	}
+	if got := Filter("", ltype.LogSeverity_DEFAULT); got != "" {
+		t.Errorf("empty Filter = %q", got)
+	}
+	if got, want := Filter(" a OR b ", 0), "(a OR b)"; got != want {
+		t.Errorf("Filter = %q, want %q", got, want)
+	}
+}
+
+func testEntry() *loggingpb.LogEntry {
+	payload, _ := structpb.NewStruct(map[string]interface{}{"message": "line one\nline two", "user": "gopher"})
+	return &loggingpb.LogEntry{
+		LogName:   "projects/p/logs/run.googleapis.com%2Fstdout",
+		Resource:  &monitoredres.MonitoredResource{Type: "cloud_run_revision"},
+		Timestamp: timestamppb.New(time.Date(2026, 10, 19, 12, 30, 0, 250e6, time.UTC)),
+		Severity:  ltype.LogSeverity_ERROR,
+		Labels:    map[string]string{"b": "2", "a": "1"},
+		Payload:   &loggingpb.LogEntry_JsonPayload{JsonPayload: payload},
+		HttpRequest: &ltype.HttpRequest{
+			RequestMethod: "GET",
+			RequestUrl:    "/x",
+			Status:        500,
+			Latency:       durationpb.New(1234567 * time.Microsecond),
+		},
+	}
+}
+
+func TestFormatters(t *testing.T) {
+	e := testEntry()
+
+	var b bytes.Buffer
+	text := &TextFormatter{Location: time.UTC, ShowLabels: true}
+	if err := text.Format(&b, e); err != nil {
+		t.Fatal(err)
+	}
+	want := "2026-10-19T12:30:00.250Z ERROR     run.googleapis.com/stdout GET 500 /x 1.235s: line one\\nline two a=1 b=2\n"
+	if b.String() != want {
+		t.Errorf("text = %q, want %q", b.String(), want)
+	}
+
+	b.Reset()
+	text = &TextFormatter{Color: true, Location: time.UTC}
+	if err := text.Format(&b, e); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), ansiRed+"ERROR    "+ansiReset) {
+		t.Errorf("colored text = %q", b.String())
+	}
+
+	b.Reset()
+	if err := (JSONFormatter{}).Format(&b, e); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(b.String(), "\n") != 1 {
+		t.Errorf("JSON is not one line: %q", b.String())
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &m); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if m["severity"] != "ERROR" || m["jsonPayload"].(map[string]interface{})["user"] != "gopher" {
+		t.Errorf("JSON = %v", m)
+	}
+
+	b.Reset()
+	tf, err := NewTemplateFormatter(`{{.Severity}} {{.Resource}} {{index .JSON "user"}} {{.HTTPRequest.Status}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tf.Format(&b, e); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), "ERROR cloud_run_revision gopher 500\n"; got != want {
+		t.Errorf("template = %q, want %q", got, want)
+	}
+	if _, err := NewTemplateFormatter("{{.Nope"); err == nil {
+		t.Error("NewTemplateFormatter succeeded with a bad template")
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of the rate limit of a response, from the IETF draft
+// "RateLimit header fields for HTTP".
+const (
+	HeaderLimit     = "RateLimit-Limit"
+	HeaderRemaining = "RateLimit-Remaining"
+	HeaderReset     = "RateLimit-Reset"
+	HeaderPolicy    = "RateLimit-Policy"
+)
+
+// KeyFunc returns the key a request is limited by, such as the client
+// address or user ID.
+type KeyFunc func(r *http.Request) string
+
+// ClientIP is a KeyFunc that returns the address of the client. Behind the
+// front end of Cloud Run or App Engine, that is the last address of the
+// X-Forwarded-For header, which the front end appends; earlier addresses
+// are set by the client and can't be trusted. Behind a load balancer, which
+// appends its own address, use a KeyFunc that takes the second to last.
+func ClientIP(r *http.Request) string {
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		addrs := strings.Split(xff, ",")
+		return strings.TrimSpace(addrs[len(addrs)-1])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Middleware returns middleware that limits requests by their key. It sets
+// the RateLimit headers of each response, and responds 429 Too Many
+// Requests, with a Retry-After header, to requests over the limit.
+//
+// If the limiter fails, for example because Redis is unavailable, the
+// request is logged and served: an outage of the limiter doesn't take the
+// service down with it.
+func Middleware(l Limiter, key KeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res, err := l.Allow(r.Context(), key(r))
+			if err != nil {
+				log.Printf("ratelimit: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			SetHeaders(w.Header(), res)
+			if !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(seconds(res.RetryAfter)))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SetHeaders sets the RateLimit headers of a result.
+func SetHeaders(h http.Header, res *Result) {
+	h.Set(HeaderLimit, strconv.Itoa(res.Limit))
+	h.Set(HeaderRemaining, strconv.Itoa(res.Remaining))
+	h.Set(HeaderReset, strconv.Itoa(seconds(res.Reset)))
+	h.Set(HeaderPolicy, strconv.Itoa(res.Limit)+";w="+strconv.Itoa(seconds(res.Window)))
+}
+
+// seconds rounds d up to whole seconds, as headers can't have fractions of
+// a second and a client that retries early would be rejected again.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
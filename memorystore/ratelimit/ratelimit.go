@@ -0,0 +1,210 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimit limits the rate of requests with counters in a
+// Memorystore for Redis instance, so that all the instances of a service
+// share the same limits.
+//
+// Each limiter runs a Lua script, so that checking and updating a counter
+// is atomic, and uses the clock of the Redis server, so that the clocks of
+// the service's instances don't need to agree. This needs Redis 5 or later,
+// where scripts can write after reading the time.
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// Limiter limits the rate of events, such as requests, per key, such as a
+// client address.
+type Limiter interface {
+	// Allow records an event for key, if the limit allows it.
+	Allow(ctx context.Context, key string) (*Result, error)
+}
+
+// Result is the outcome of a call to Allow.
+type Result struct {
+	// Allowed reports whether the event is allowed.
+	Allowed bool
+	// Limit is the maximum number of events in Window.
+	Limit int
+	// Window is the period of the limit.
+	Window time.Duration
+	// Remaining is the number of events still allowed now.
+	Remaining int
+	// Reset is the time until all of Limit is available again.
+	Reset time.Duration
+	// RetryAfter is the time until the next event is allowed, if this one
+	// isn't.
+	RetryAfter time.Duration
+}
+
+// slidingWindowScript keeps the times of the events of the last window in
+// a sorted set.
+//
+// KEYS[1]: the key of the sorted set
+// ARGV[1]: the limit
+// ARGV[2]: the window, in milliseconds
+// ARGV[3]: a unique member for the event
+//
+// It returns {allowed (0 or 1), remaining, reset ms, retry after ms}.
+var slidingWindowScript = redis.NewScript(1, `
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[3])
+	count = count + 1
+	allowed = 1
+end
+
+local reset, retry = 0, 0
+if count > 0 then
+	local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+	local newest = redis.call('ZRANGE', KEYS[1], -1, -1, 'WITHSCORES')
+	reset = tonumber(newest[2]) + window - now
+	if allowed == 0 then
+		retry = tonumber(oldest[2]) + window - now
+	end
+	redis.call('PEXPIRE', KEYS[1], reset)
+end
+return {allowed, limit - count, reset, retry}
+`)
+
+// SlidingWindow allows Limit events in any period of Window. It keeps the
+// time of each event of the last Window, so it suits limits of up to a few
+// thousand events per key.
+type SlidingWindow struct {
+	Pool   *redis.Pool
+	Limit  int
+	Window time.Duration
+	// Prefix is prepended to keys, to keep them apart from other data.
+	// Default: "ratelimit:".
+	Prefix string
+}
+
+// Allow records an event for key, if there were fewer than Limit events in
+// the last Window.
+func (l *SlidingWindow) Allow(ctx context.Context, key string) (*Result, error) {
+	member := make([]byte, 8)
+	if _, err := rand.Read(member); err != nil {
+		return nil, fmt.Errorf("SlidingWindow.Allow: %w", err)
+	}
+	r, err := run(ctx, l.Pool, slidingWindowScript, prefix(l.Prefix)+key, l.Limit, l.Window.Milliseconds(), hex.EncodeToString(member))
+	if err != nil {
+		return nil, fmt.Errorf("SlidingWindow.Allow: %w", err)
+	}
+	r.Limit = l.Limit
+	r.Window = l.Window
+	return r, nil
+}
+
+// tokenBucketScript keeps the tokens of a bucket and the time it was last
+// updated in a hash.
+//
+// KEYS[1]: the key of the hash
+// ARGV[1]: the tokens added per second
+// ARGV[2]: the size of the bucket
+//
+// It returns {allowed (0 or 1), remaining, reset ms, retry after ms}.
+var tokenBucketScript = redis.NewScript(1, `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) + tonumber(t[2]) / 1000000
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(bucket[1]) or burst
+local updated = tonumber(bucket[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - updated) * rate)
+
+local allowed, retry = 0, 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate * 1000)
+end
+
+local reset = math.ceil((burst - tokens) / rate * 1000)
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.max(reset, 1))
+return {allowed, math.floor(tokens), reset, retry}
+`)
+
+// TokenBucket allows bursts of up to Burst events, and Rate events per
+// second on average. Unlike SlidingWindow, it keeps two numbers per key,
+// whatever the rate.
+type TokenBucket struct {
+	Pool  *redis.Pool
+	Rate  float64
+	Burst int
+	// Prefix is prepended to keys, to keep them apart from other data.
+	// Default: "ratelimit:".
+	Prefix string
+}
+
+// Allow records an event for key, if its bucket has a token left.
+func (l *TokenBucket) Allow(ctx context.Context, key string) (*Result, error) {
+	r, err := run(ctx, l.Pool, tokenBucketScript, prefix(l.Prefix)+key, l.Rate, l.Burst)
+	if err != nil {
+		return nil, fmt.Errorf("TokenBucket.Allow: %w", err)
+	}
+	r.Limit = l.Burst
+	// An empty bucket fills up in Burst/Rate seconds.
+	r.Window = time.Duration(math.Ceil(float64(l.Burst)/l.Rate)) * time.Second
+	return r, nil
+}
+
+func prefix(p string) string {
+	if p == "" {
+		return "ratelimit:"
+	}
+	return p
+}
+
+// run runs a limiter script and parses its result.
+func run(ctx context.Context, pool *redis.Pool, script *redis.Script, key string, args ...interface{}) (*Result, error) {
+	conn, err := pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	values, err := redis.Int64s(script.Do(conn, append([]interface{}{key}, args...)...))
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 4 {
+		return nil, fmt.Errorf("got %d values from script, want 4", len(values))
+	}
+	return &Result{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		Reset:      time.Duration(values[2]) * time.Millisecond,
+		RetryAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
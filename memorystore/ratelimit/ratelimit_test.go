@@ -0,0 +1,190 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+)
+
+// testRedis is an in-process Redis server, with a clock the tests set.
+type testRedis struct {
+	*miniredis.Miniredis
+	now time.Time
+}
+
+// tick advances the clock of the server, and expires keys.
+func (mr *testRedis) tick(d time.Duration) {
+	mr.now = mr.now.Add(d)
+	mr.SetTime(mr.now)
+	mr.FastForward(d)
+}
+
+// newPool returns a pool of connections to a test Redis server.
+func newPool(t *testing.T) (*redis.Pool, *testRedis) {
+	t.Helper()
+	mr := &testRedis{Miniredis: miniredis.RunT(t), now: time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)}
+	mr.SetTime(mr.now)
+	addr := mr.Addr()
+	pool := &redis.Pool{
+		MaxIdle: 10,
+		Dial:    func() (redis.Conn, error) { return redis.Dial("tcp", addr) },
+	}
+	t.Cleanup(func() { pool.Close() })
+	return pool, mr
+}
+
+func allow(t *testing.T, l Limiter, key string) *Result {
+	t.Helper()
+	res, err := l.Allow(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Allow(%q): %v", key, err)
+	}
+	return res
+}
+
+func TestSlidingWindow(t *testing.T) {
+	pool, mr := newPool(t)
+	l := &SlidingWindow{Pool: pool, Limit: 3, Window: time.Minute}
+
+	for i := 0; i < 3; i++ {
+		res := allow(t, l, "client")
+		if !res.Allowed || res.Remaining != 2-i {
+			t.Fatalf("event %d: got %+v, want allowed with %d remaining", i, res, 2-i)
+		}
+		mr.tick(10 * time.Second)
+	}
+	// The events were at 0s, 10s and 20s; it's now 30s.
+	res := allow(t, l, "client")
+	if res.Allowed || res.Remaining != 0 {
+		t.Fatalf("event over the limit: got %+v, want denied", res)
+	}
+	if res.RetryAfter != 30*time.Second {
+		t.Errorf("RetryAfter: got %v, want 30s, when the first event leaves the window", res.RetryAfter)
+	}
+	if res.Reset != 50*time.Second {
+		t.Errorf("Reset: got %v, want 50s, when the last event leaves the window", res.Reset)
+	}
+	if res := allow(t, l, "other"); !res.Allowed {
+		t.Errorf("other key: got %+v, want allowed", res)
+	}
+
+	mr.tick(31 * time.Second)
+	if res := allow(t, l, "client"); !res.Allowed || res.Remaining != 0 {
+		t.Errorf("after the first event left the window: got %+v, want allowed with 0 remaining", res)
+	}
+	mr.tick(2 * time.Minute)
+	if mr.Exists("ratelimit:client") {
+		t.Errorf("key still exists after the window")
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	pool, mr := newPool(t)
+	// 2 tokens per second, up to 4.
+	l := &TokenBucket{Pool: pool, Rate: 2, Burst: 4, Prefix: "tb:"}
+
+	for i := 0; i < 4; i++ {
+		if res := allow(t, l, "client"); !res.Allowed || res.Remaining != 3-i {
+			t.Fatalf("event %d: got %+v, want allowed with %d remaining", i, res, 3-i)
+		}
+	}
+	res := allow(t, l, "client")
+	if res.Allowed {
+		t.Fatalf("event over the burst: got %+v, want denied", res)
+	}
+	if res.RetryAfter != 500*time.Millisecond || res.Reset != 2*time.Second {
+		t.Errorf("got RetryAfter %v and Reset %v, want 500ms and 2s", res.RetryAfter, res.Reset)
+	}
+	if res.Limit != 4 || res.Window != 2*time.Second {
+		t.Errorf("got Limit %d in %v, want 4 in 2s", res.Limit, res.Window)
+	}
+
+	mr.tick(time.Second)
+	for i := 0; i < 2; i++ {
+		if res := allow(t, l, "client"); !res.Allowed {
+			t.Errorf("event %d after 1s: got %+v, want allowed", i, res)
+		}
+	}
+	if res := allow(t, l, "client"); res.Allowed {
+		t.Errorf("third event after 1s: got %+v, want denied", res)
+	}
+	if !mr.Exists("tb:client") {
+		t.Errorf("bucket not stored under the prefix")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	pool, _ := newPool(t)
+	l := &SlidingWindow{Pool: pool, Limit: 1, Window: 90 * time.Second}
+	h := Middleware(l, ClientIP)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	serve := func(xff string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Forwarded-For", xff)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serve("203.0.113.7")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("first request: got status %d, want 200", rr.Code)
+	}
+	want := map[string]string{
+		HeaderLimit:     "1",
+		HeaderRemaining: "0",
+		HeaderReset:     "90",
+		HeaderPolicy:    "1;w=90",
+	}
+	for k, v := range want {
+		if got := rr.Header().Get(k); got != v {
+			t.Errorf("%s: got %q, want %q", k, got, v)
+		}
+	}
+
+	// The client can't get around the limit by adding addresses.
+	rr = serve("198.51.100.1, 203.0.113.7")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: got status %d, want 429", rr.Code)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "90" {
+		t.Errorf("Retry-After: got %q, want 90", got)
+	}
+
+	if rr := serve("198.51.100.1"); rr.Code != http.StatusOK {
+		t.Errorf("another client: got status %d, want 200", rr.Code)
+	}
+}
+
+func TestMiddlewareFailsOpen(t *testing.T) {
+	pool, mr := newPool(t)
+	mr.Close()
+	l := &TokenBucket{Pool: pool, Rate: 1, Burst: 1}
+	h := Middleware(l, ClientIP)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("got status %d with Redis down, want 200", rr.Code)
+	}
+}
@@ -21,7 +21,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 
 	monitoring "cloud.google.com/go/monitoring/apiv3"
 	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
@@ -260,7 +259,7 @@ func restorePolicies(w io.Writer, projectID string, r io.Reader) error {
 				AlertPolicy: policy.AlertPolicy,
 			}
 			if _, err = alertClient.CreateAlertPolicy(ctx, req); err != nil {
-				log.Fatal(err)
+				return fmt.Errorf("CreateAlertPolicy: %w", err)
 			}
 		}
 	}
@@ -0,0 +1,107 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The alertsync command keeps a project's alert policies and notification
+// channels in sync with a directory of YAML files.
+//
+//	alertsync export -project my-project -dir alerts
+//	alertsync sync -project other-project -dir alerts -dry-run
+//	alertsync sync -project other-project -dir alerts
+//
+// export writes one file per policy and channel. sync shows the changes
+// needed to make the project match the files, and then makes them unless
+// -dry-run is set. Policies refer to channels by display name, so files
+// exported from one project can be synced to another.
+//
+// Secret labels of channels, such as Slack auth tokens, are not exported.
+// Add them to the files of channels to create; they are left as they are on
+// existing channels.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	monitoring "cloud.google.com/go/monitoring/apiv3"
+	"github.com/GoogleCloudPlatform/golang-samples/monitoring/alert"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: alertsync [export|sync] -project <project-id> -dir <dir> [-dry-run] [-prune]")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	command := os.Args[1]
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	projectID := fs.String("project", os.Getenv("GOOGLE_CLOUD_PROJECT"), "Google Cloud project ID.")
+	dir := fs.String("dir", "alerts", "Directory of YAML files.")
+	dryRun := fs.Bool("dry-run", false, "sync: show the plan without making changes.")
+	prune := fs.Bool("prune", false, "sync: delete policies and channels that are not in the files.")
+	fs.Parse(os.Args[2:])
+	if *projectID == "" {
+		log.Fatal("Error: -project or GOOGLE_CLOUD_PROJECT must be set")
+	}
+
+	ctx := context.Background()
+	policies, err := monitoring.NewAlertPolicyClient(ctx)
+	if err != nil {
+		log.Fatalf("NewAlertPolicyClient: %v", err)
+	}
+	defer policies.Close()
+	channels, err := monitoring.NewNotificationChannelClient(ctx)
+	if err != nil {
+		log.Fatalf("NewNotificationChannelClient: %v", err)
+	}
+	defer channels.Close()
+	s := &alert.Syncer{Policies: policies, Channels: channels, ProjectID: *projectID, Prune: *prune}
+
+	switch command {
+	case "export":
+		cfg, err := s.Export(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := alert.WriteDir(*dir, cfg); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Exported %d policies and %d channels to %s\n", len(cfg.Policies), len(cfg.Channels), *dir)
+
+	case "sync":
+		cfg, err := alert.LoadDir(*dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		plan, err := s.Plan(ctx, cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		plan.WriteTo(os.Stdout)
+		if *dryRun || plan.Empty() {
+			return
+		}
+		if err := s.Apply(ctx, plan, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+
+	default:
+		usage()
+	}
+}
@@ -0,0 +1,306 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of resources in config files.
+const (
+	KindAlertPolicy         = "AlertPolicy"
+	KindNotificationChannel = "NotificationChannel"
+)
+
+// Config is a set of alert policies and notification channels in the
+// portable form kept in config files: output only fields are left out, and
+// policies refer to notification channels by display name, so the same
+// files can be applied to any project.
+//
+// Display names identify policies and channels, so they must be unique
+// within each kind.
+type Config struct {
+	Policies []*monitoringpb.AlertPolicy
+	Channels []*monitoringpb.NotificationChannel
+}
+
+// LoadDir reads every .yaml and .yml file under dir. Each file holds one
+// or more YAML documents. Each document has a kind of AlertPolicy or
+// NotificationChannel, and the fields of the resource in the JSON form of
+// the Monitoring API, such as displayName and conditions.
+func LoadDir(dir string) (*Config, error) {
+	cfg := &Config{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if ext := filepath.Ext(path); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := cfg.read(f); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// read adds the resources in the YAML documents of r to cfg.
+func (cfg *Config) read(r io.Reader) error {
+	dec := yaml.NewDecoder(r)
+	for i := 1; ; i++ {
+		var doc map[string]interface{}
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("yaml.Decode: %w", err)
+		}
+		if doc == nil {
+			continue
+		}
+		kind, _ := doc["kind"].(string)
+		delete(doc, "kind")
+		b, err := json.Marshal(doc)
+		if err != nil {
+			return fmt.Errorf("document %d: %w", i, err)
+		}
+		switch kind {
+		case KindAlertPolicy:
+			p := &monitoringpb.AlertPolicy{}
+			if err := protojson.Unmarshal(b, p); err != nil {
+				return fmt.Errorf("document %d: %w", i, err)
+			}
+			cfg.Policies = append(cfg.Policies, portablePolicy(p, nil))
+		case KindNotificationChannel:
+			c := &monitoringpb.NotificationChannel{}
+			if err := protojson.Unmarshal(b, c); err != nil {
+				return fmt.Errorf("document %d: %w", i, err)
+			}
+			cfg.Channels = append(cfg.Channels, portableChannel(c))
+		default:
+			return fmt.Errorf("document %d: kind is %q, want %s or %s", i, kind, KindAlertPolicy, KindNotificationChannel)
+		}
+	}
+}
+
+// validate checks that display names are set and unique.
+func (cfg *Config) validate() error {
+	var errs []error
+	seen := make(map[string]bool)
+	for _, c := range cfg.Channels {
+		if c.GetDisplayName() == "" {
+			errs = append(errs, fmt.Errorf("a %s has no displayName", KindNotificationChannel))
+		} else if seen[c.GetDisplayName()] {
+			errs = append(errs, fmt.Errorf("more than one %s is named %q", KindNotificationChannel, c.GetDisplayName()))
+		}
+		seen[c.GetDisplayName()] = true
+	}
+	seen = make(map[string]bool)
+	for _, p := range cfg.Policies {
+		if p.GetDisplayName() == "" {
+			errs = append(errs, fmt.Errorf("an %s has no displayName", KindAlertPolicy))
+		} else if seen[p.GetDisplayName()] {
+			errs = append(errs, fmt.Errorf("more than one %s is named %q", KindAlertPolicy, p.GetDisplayName()))
+		}
+		seen[p.GetDisplayName()] = true
+	}
+	return errors.Join(errs...)
+}
+
+// WriteDir writes each policy and channel of cfg to its own file in dir,
+// named after its kind and display name, such as policy-high-cpu.yaml.
+func WriteDir(dir string, cfg *Config) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	used := make(map[string]bool)
+	write := func(prefix, kind, displayName string, m proto.Message) error {
+		b, err := marshalYAML(kind, m)
+		if err != nil {
+			return fmt.Errorf("%s %q: %w", kind, displayName, err)
+		}
+		name := prefix + "-" + slug(displayName)
+		for i := 2; used[name]; i++ {
+			name = fmt.Sprintf("%s-%s-%d", prefix, slug(displayName), i)
+		}
+		used[name] = true
+		return os.WriteFile(filepath.Join(dir, name+".yaml"), b, 0o644)
+	}
+	for _, c := range cfg.Channels {
+		if err := write("channel", KindNotificationChannel, c.GetDisplayName(), c); err != nil {
+			return err
+		}
+	}
+	for _, p := range cfg.Policies {
+		if err := write("policy", KindAlertPolicy, p.GetDisplayName(), p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+func slug(s string) string {
+	s = strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(s), "-"), "-")
+	if s == "" {
+		return "unnamed"
+	}
+	return s
+}
+
+// marshalYAML returns m as a YAML document of kind, with its fields in the
+// JSON form of the API and sorted by name.
+func marshalYAML(kind string, m proto.Message) ([]byte, error) {
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	var node yaml.Node
+	if err := node.Encode(yamlNumbers(v)); err != nil {
+		return nil, err
+	}
+	// Put the kind first.
+	node.Content = append([]*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: "kind"},
+		{Kind: yaml.ScalarNode, Value: kind},
+	}, node.Content...)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// yamlNumbers replaces the json.Numbers in v with int64 or float64 values,
+// so whole numbers aren't written in exponent form.
+func yamlNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = yamlNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = yamlNumbers(e)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil && !math.IsInf(f, 0) {
+			return f
+		}
+		return v.String()
+	}
+	return v
+}
+
+// portablePolicy returns a copy of p without output only fields, with its
+// notification channels given by display name when channelNames, which
+// maps resource names to display names, has them.
+func portablePolicy(p *monitoringpb.AlertPolicy, channelNames map[string]string) *monitoringpb.AlertPolicy {
+	p = proto.Clone(p).(*monitoringpb.AlertPolicy)
+	p.Name = ""
+	p.CreationRecord = nil
+	p.MutationRecord = nil
+	p.Validity = nil
+	for _, c := range p.GetConditions() {
+		c.Name = ""
+	}
+	for i, ref := range p.GetNotificationChannels() {
+		if dn, ok := channelNames[ref]; ok {
+			p.NotificationChannels[i] = dn
+		}
+	}
+	// The order of notification channels doesn't matter.
+	sort.Strings(p.NotificationChannels)
+	return p
+}
+
+// portableChannel returns a copy of c without output only fields.
+func portableChannel(c *monitoringpb.NotificationChannel) *monitoringpb.NotificationChannel {
+	c = proto.Clone(c).(*monitoringpb.NotificationChannel)
+	c.Name = ""
+	c.VerificationStatus = monitoringpb.NotificationChannel_VERIFICATION_STATUS_UNSPECIFIED
+	c.CreationRecord = nil
+	c.MutationRecords = nil
+	return c
+}
+
+// sensitiveLabels are the labels of notification channels, by channel
+// type, that hold secrets. The API returns them obfuscated, so they're left
+// out of exported files and diffs, and only set when a channel is created.
+var sensitiveLabels = map[string]map[string]bool{
+	"slack":             {"auth_token": true},
+	"pagerduty":         {"service_key": true},
+	"webhook_basicauth": {"password": true},
+}
+
+// withoutSensitiveLabels returns a copy of c without its sensitive labels.
+func withoutSensitiveLabels(c *monitoringpb.NotificationChannel) *monitoringpb.NotificationChannel {
+	c = proto.Clone(c).(*monitoringpb.NotificationChannel)
+	for k := range c.Labels {
+		if sensitiveLabels[c.GetType()][k] {
+			delete(c.Labels, k)
+		}
+	}
+	return c
+}
@@ -0,0 +1,110 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alert
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// diffContext is the number of unchanged lines shown around changes.
+const diffContext = 2
+
+// diff returns a line diff of the config file forms of have and want.
+// have may be nil, for a resource that will be created.
+func diff(have, want proto.Message, kind string) string {
+	lines := func(m proto.Message) []string {
+		if m == nil {
+			return nil
+		}
+		b, err := marshalYAML(kind, m)
+		if err != nil {
+			return []string{"<" + err.Error() + ">"}
+		}
+		return strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	}
+	return diffLines(lines(have), lines(want))
+}
+
+// diffLines returns the lines of a and b prefixed by "- ", "+ " or "  ",
+// from a longest common subsequence. Runs of unchanged lines are cut down
+// to diffContext lines around each change.
+func diffLines(a, b []string) string {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type line struct {
+		op   byte
+		text string
+	}
+	var out []line
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out = append(out, line{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			out = append(out, line{'-', a[i]})
+			i++
+		default:
+			out = append(out, line{'+', b[j]})
+			j++
+		}
+	}
+
+	// Keep unchanged lines near a change.
+	keep := make([]bool, len(out))
+	for k, l := range out {
+		if l.op == ' ' {
+			continue
+		}
+		for c := max(0, k-diffContext); c <= min(len(out)-1, k+diffContext); c++ {
+			keep[c] = true
+		}
+	}
+	var sb strings.Builder
+	skipped := false
+	for k, l := range out {
+		if !keep[k] {
+			if !skipped {
+				sb.WriteString("  ...\n")
+			}
+			skipped = true
+			continue
+		}
+		skipped = false
+		sb.WriteByte(l.op)
+		sb.WriteByte(' ')
+		sb.WriteString(l.text)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
@@ -0,0 +1,363 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alert
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	monitoring "cloud.google.com/go/monitoring/apiv3"
+	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"google.golang.org/api/iterator"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// Syncer keeps the alert policies and notification channels of a project
+// in sync with a Config.
+type Syncer struct {
+	Policies  *monitoring.AlertPolicyClient
+	Channels  *monitoring.NotificationChannelClient
+	ProjectID string
+	// Prune deletes live policies and channels that are not in the
+	// Config. Without it, they are left alone.
+	Prune bool
+}
+
+// Action is what a Change does.
+type Action int
+
+// Actions.
+const (
+	Create Action = iota + 1
+	Update
+	Delete
+)
+
+func (a Action) String() string {
+	switch a {
+	case Create:
+		return "create"
+	case Update:
+		return "update"
+	case Delete:
+		return "delete"
+	}
+	return fmt.Sprintf("Action(%d)", int(a))
+}
+
+// Change is one step of a Plan.
+type Change struct {
+	Action      Action
+	Kind        string
+	DisplayName string
+	// Name is the resource name of the live resource. It is empty for
+	// Create.
+	Name string
+	// Diff is a line diff from the live resource to the desired one, in
+	// config file form.
+	Diff string
+
+	policy  *monitoringpb.AlertPolicy
+	channel *monitoringpb.NotificationChannel
+	// mask is the fields of channel to update.
+	mask *fieldmaskpb.FieldMask
+}
+
+// Plan is the changes that bring a project in line with a Config.
+// Channels are changed before the policies that use them, and policies
+// are deleted before channels.
+type Plan struct {
+	ProjectID string
+	Changes   []Change
+
+	// channelNames maps display names to the resource names of live
+	// channels.
+	channelNames map[string]string
+}
+
+// Empty reports whether the project already matches the Config.
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// WriteTo writes a readable summary of the plan, with the diff of each
+// update.
+func (p *Plan) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	if p.Empty() {
+		fmt.Fprintf(&b, "No changes to projects/%s.\n", p.ProjectID)
+	}
+	counts := make(map[Action]int)
+	for _, c := range p.Changes {
+		counts[c.Action]++
+		sign := map[Action]string{Create: "+", Update: "~", Delete: "-"}[c.Action]
+		fmt.Fprintf(&b, "%s %s %s %q\n", sign, c.Action, c.Kind, c.DisplayName)
+		for _, line := range strings.SplitAfter(c.Diff, "\n") {
+			if line != "" {
+				b.WriteString("    " + line)
+			}
+		}
+	}
+	if !p.Empty() {
+		fmt.Fprintf(&b, "Plan for projects/%s: %d to create, %d to update, %d to delete.\n",
+			p.ProjectID, counts[Create], counts[Update], counts[Delete])
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// live returns the policies and channels of the project.
+func (s *Syncer) live(ctx context.Context) ([]*monitoringpb.AlertPolicy, []*monitoringpb.NotificationChannel, error) {
+	var policies []*monitoringpb.AlertPolicy
+	pit := s.Policies.ListAlertPolicies(ctx, &monitoringpb.ListAlertPoliciesRequest{Name: "projects/" + s.ProjectID})
+	for {
+		p, err := pit.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("ListAlertPolicies: %w", err)
+		}
+		policies = append(policies, p)
+	}
+
+	var channels []*monitoringpb.NotificationChannel
+	cit := s.Channels.ListNotificationChannels(ctx, &monitoringpb.ListNotificationChannelsRequest{Name: "projects/" + s.ProjectID})
+	for {
+		c, err := cit.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("ListNotificationChannels: %w", err)
+		}
+		channels = append(channels, c)
+	}
+	return policies, channels, nil
+}
+
+// Export returns the policies and channels of the project in portable
+// form.
+func (s *Syncer) Export(ctx context.Context) (*Config, error) {
+	policies, channels, err := s.live(ctx)
+	if err != nil {
+		return nil, err
+	}
+	displayNames := make(map[string]string)
+	cfg := &Config{}
+	for _, c := range channels {
+		displayNames[c.GetName()] = c.GetDisplayName()
+		cfg.Channels = append(cfg.Channels, withoutSensitiveLabels(portableChannel(c)))
+	}
+	for _, p := range policies {
+		cfg.Policies = append(cfg.Policies, portablePolicy(p, displayNames))
+	}
+	return cfg, nil
+}
+
+// Plan compares cfg with the live state of the project and returns the
+// changes needed to match it.
+func (s *Syncer) Plan(ctx context.Context, cfg *Config) (*Plan, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	policies, channels, err := s.live(ctx)
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{ProjectID: s.ProjectID, channelNames: make(map[string]string)}
+
+	// Channels, matched by display name.
+	displayNames := make(map[string]string)
+	liveChannels := make(map[string]*monitoringpb.NotificationChannel)
+	for _, c := range channels {
+		if _, ok := liveChannels[c.GetDisplayName()]; ok {
+			return nil, fmt.Errorf("more than one live %s is named %q", KindNotificationChannel, c.GetDisplayName())
+		}
+		liveChannels[c.GetDisplayName()] = c
+		plan.channelNames[c.GetDisplayName()] = c.GetName()
+		displayNames[c.GetName()] = c.GetDisplayName()
+	}
+	wantChannels := make(map[string]bool)
+	for _, want := range cfg.Channels {
+		wantChannels[want.GetDisplayName()] = true
+		want = portableChannel(want)
+		live, ok := liveChannels[want.GetDisplayName()]
+		// Sensitive labels are obfuscated in live channels: they are
+		// neither compared nor shown.
+		shown := withoutSensitiveLabels(want)
+		if !ok {
+			plan.Changes = append(plan.Changes, Change{
+				Action: Create, Kind: KindNotificationChannel, DisplayName: want.GetDisplayName(),
+				Diff: diff(nil, shown, KindNotificationChannel), channel: want,
+			})
+			continue
+		}
+		if have := withoutSensitiveLabels(portableChannel(live)); !proto.Equal(have, shown) {
+			plan.Changes = append(plan.Changes, Change{
+				Action: Update, Kind: KindNotificationChannel, DisplayName: want.GetDisplayName(), Name: live.GetName(),
+				Diff: diff(have, shown, KindNotificationChannel), channel: want, mask: channelUpdateMask(have, shown),
+			})
+		}
+	}
+
+	// Policies, with channel references checked and compared by display
+	// name.
+	livePolicies := make(map[string]*monitoringpb.AlertPolicy)
+	for _, p := range policies {
+		if _, ok := livePolicies[p.GetDisplayName()]; ok {
+			return nil, fmt.Errorf("more than one live %s is named %q", KindAlertPolicy, p.GetDisplayName())
+		}
+		livePolicies[p.GetDisplayName()] = p
+	}
+	wantPolicies := make(map[string]bool)
+	for _, want := range cfg.Policies {
+		wantPolicies[want.GetDisplayName()] = true
+		want = portablePolicy(want, displayNames)
+		for _, ref := range want.GetNotificationChannels() {
+			if !wantChannels[ref] && plan.channelNames[ref] == "" && !strings.HasPrefix(ref, "projects/") {
+				return nil, fmt.Errorf("%s %q uses unknown %s %q", KindAlertPolicy, want.GetDisplayName(), KindNotificationChannel, ref)
+			}
+		}
+		live, ok := livePolicies[want.GetDisplayName()]
+		if !ok {
+			plan.Changes = append(plan.Changes, Change{
+				Action: Create, Kind: KindAlertPolicy, DisplayName: want.GetDisplayName(),
+				Diff: diff(nil, want, KindAlertPolicy), policy: want,
+			})
+			continue
+		}
+		if have := portablePolicy(live, displayNames); !proto.Equal(have, want) {
+			plan.Changes = append(plan.Changes, Change{
+				Action: Update, Kind: KindAlertPolicy, DisplayName: want.GetDisplayName(), Name: live.GetName(),
+				Diff: diff(have, want, KindAlertPolicy), policy: want,
+			})
+		}
+	}
+
+	if s.Prune {
+		for _, p := range policies {
+			if !wantPolicies[p.GetDisplayName()] {
+				plan.Changes = append(plan.Changes, Change{
+					Action: Delete, Kind: KindAlertPolicy, DisplayName: p.GetDisplayName(), Name: p.GetName(),
+				})
+			}
+		}
+		for _, c := range channels {
+			if !wantChannels[c.GetDisplayName()] {
+				plan.Changes = append(plan.Changes, Change{
+					Action: Delete, Kind: KindNotificationChannel, DisplayName: c.GetDisplayName(), Name: c.GetName(),
+				})
+			}
+		}
+	}
+	return plan, nil
+}
+
+// Apply makes the changes of plan, writing a line to w for each. It stops
+// at the first error; running Plan again picks up from there.
+func (s *Syncer) Apply(ctx context.Context, plan *Plan, w io.Writer) error {
+	parent := "projects/" + s.ProjectID
+	channelNames := make(map[string]string)
+	for dn, name := range plan.channelNames {
+		channelNames[dn] = name
+	}
+
+	for _, c := range plan.Changes {
+		switch {
+		case c.Kind == KindNotificationChannel && c.Action == Create:
+			ch, err := s.Channels.CreateNotificationChannel(ctx, &monitoringpb.CreateNotificationChannelRequest{
+				Name:                parent,
+				NotificationChannel: c.channel,
+			})
+			if err != nil {
+				return fmt.Errorf("CreateNotificationChannel %q: %w", c.DisplayName, err)
+			}
+			channelNames[c.DisplayName] = ch.GetName()
+			fmt.Fprintf(w, "Created %s %q: %s\n", c.Kind, c.DisplayName, ch.GetName())
+
+		case c.Kind == KindNotificationChannel && c.Action == Update:
+			ch := proto.Clone(c.channel).(*monitoringpb.NotificationChannel)
+			ch.Name = c.Name
+			if _, err := s.Channels.UpdateNotificationChannel(ctx, &monitoringpb.UpdateNotificationChannelRequest{
+				NotificationChannel: ch,
+				UpdateMask:          c.mask,
+			}); err != nil {
+				return fmt.Errorf("UpdateNotificationChannel %q: %w", c.DisplayName, err)
+			}
+			fmt.Fprintf(w, "Updated %s %q\n", c.Kind, c.DisplayName)
+
+		case c.Kind == KindAlertPolicy && (c.Action == Create || c.Action == Update):
+			p := proto.Clone(c.policy).(*monitoringpb.AlertPolicy)
+			for i, ref := range p.GetNotificationChannels() {
+				if name, ok := channelNames[ref]; ok {
+					p.NotificationChannels[i] = name
+				}
+			}
+			if c.Action == Create {
+				created, err := s.Policies.CreateAlertPolicy(ctx, &monitoringpb.CreateAlertPolicyRequest{
+					Name:        parent,
+					AlertPolicy: p,
+				})
+				if err != nil {
+					return fmt.Errorf("CreateAlertPolicy %q: %w", c.DisplayName, err)
+				}
+				fmt.Fprintf(w, "Created %s %q: %s\n", c.Kind, c.DisplayName, created.GetName())
+				continue
+			}
+			p.Name = c.Name
+			if _, err := s.Policies.UpdateAlertPolicy(ctx, &monitoringpb.UpdateAlertPolicyRequest{AlertPolicy: p}); err != nil {
+				return fmt.Errorf("UpdateAlertPolicy %q: %w", c.DisplayName, err)
+			}
+			fmt.Fprintf(w, "Updated %s %q\n", c.Kind, c.DisplayName)
+
+		case c.Kind == KindAlertPolicy && c.Action == Delete:
+			if err := s.Policies.DeleteAlertPolicy(ctx, &monitoringpb.DeleteAlertPolicyRequest{Name: c.Name}); err != nil {
+				return fmt.Errorf("DeleteAlertPolicy %q: %w", c.DisplayName, err)
+			}
+			fmt.Fprintf(w, "Deleted %s %q\n", c.Kind, c.DisplayName)
+
+		case c.Kind == KindNotificationChannel && c.Action == Delete:
+			if err := s.Channels.DeleteNotificationChannel(ctx, &monitoringpb.DeleteNotificationChannelRequest{Name: c.Name}); err != nil {
+				return fmt.Errorf("DeleteNotificationChannel %q: %w", c.DisplayName, err)
+			}
+			fmt.Fprintf(w, "Deleted %s %q\n", c.Kind, c.DisplayName)
+		}
+	}
+	return nil
+}
+
+// channelUpdateMask returns the fields to update from have to want: every
+// field but the labels, and the labels that either has, one by one, so that
+// the sensitive labels left out of both are kept.
+func channelUpdateMask(have, want *monitoringpb.NotificationChannel) *fieldmaskpb.FieldMask {
+	mask := &fieldmaskpb.FieldMask{Paths: []string{"display_name", "description", "user_labels", "enabled"}}
+	var labels []string
+	for k := range have.GetLabels() {
+		labels = append(labels, "labels."+k)
+	}
+	for k := range want.GetLabels() {
+		if _, ok := have.GetLabels()[k]; !ok {
+			labels = append(labels, "labels."+k)
+		}
+	}
+	sort.Strings(labels)
+	mask.Paths = append(mask.Paths, labels...)
+	return mask
+}
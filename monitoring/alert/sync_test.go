@@ -0,0 +1,509 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alert
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	monitoring "cloud.google.com/go/monitoring/apiv3"
+	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeMonitoring is an in-memory AlertPolicyService and
+// NotificationChannelService. Like the real services, it sets output only
+// fields on the resources it stores.
+type fakeMonitoring struct {
+	monitoringpb.UnimplementedAlertPolicyServiceServer
+	monitoringpb.UnimplementedNotificationChannelServiceServer
+
+	mu       sync.Mutex
+	next     int
+	policies map[string]*monitoringpb.AlertPolicy
+	channels map[string]*monitoringpb.NotificationChannel
+}
+
+func (f *fakeMonitoring) newName(parent, collection string) string {
+	f.next++
+	return fmt.Sprintf("%s/%s/%d", parent, collection, f.next)
+}
+
+func (f *fakeMonitoring) ListAlertPolicies(ctx context.Context, req *monitoringpb.ListAlertPoliciesRequest) (*monitoringpb.ListAlertPoliciesResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := &monitoringpb.ListAlertPoliciesResponse{}
+	for name, p := range f.policies {
+		if strings.HasPrefix(name, req.GetName()+"/") {
+			resp.AlertPolicies = append(resp.AlertPolicies, p)
+		}
+	}
+	sort.Slice(resp.AlertPolicies, func(i, j int) bool { return resp.AlertPolicies[i].Name < resp.AlertPolicies[j].Name })
+	return resp, nil
+}
+
+func (f *fakeMonitoring) setPolicyOutputFields(p *monitoringpb.AlertPolicy) {
+	for _, c := range p.GetConditions() {
+		c.Name = f.newName(p.GetName(), "conditions")
+	}
+	p.MutationRecord = &monitoringpb.MutationRecord{MutateTime: timestamppb.Now(), MutatedBy: "fake"}
+}
+
+func (f *fakeMonitoring) CreateAlertPolicy(ctx context.Context, req *monitoringpb.CreateAlertPolicyRequest) (*monitoringpb.AlertPolicy, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if req.GetAlertPolicy().GetName() != "" {
+		return nil, status.Error(codes.InvalidArgument, "name must be empty")
+	}
+	for _, ref := range req.GetAlertPolicy().GetNotificationChannels() {
+		if f.channels[ref] == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown channel %q", ref)
+		}
+	}
+	p := proto.Clone(req.GetAlertPolicy()).(*monitoringpb.AlertPolicy)
+	p.Name = f.newName(req.GetName(), "alertPolicies")
+	p.CreationRecord = &monitoringpb.MutationRecord{MutateTime: timestamppb.Now(), MutatedBy: "fake"}
+	f.setPolicyOutputFields(p)
+	f.policies[p.Name] = p
+	return p, nil
+}
+
+func (f *fakeMonitoring) UpdateAlertPolicy(ctx context.Context, req *monitoringpb.UpdateAlertPolicyRequest) (*monitoringpb.AlertPolicy, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	old, ok := f.policies[req.GetAlertPolicy().GetName()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "no such policy")
+	}
+	p := proto.Clone(req.GetAlertPolicy()).(*monitoringpb.AlertPolicy)
+	p.CreationRecord = old.CreationRecord
+	f.setPolicyOutputFields(p)
+	f.policies[p.Name] = p
+	return p, nil
+}
+
+func (f *fakeMonitoring) DeleteAlertPolicy(ctx context.Context, req *monitoringpb.DeleteAlertPolicyRequest) (*emptypb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.policies[req.GetName()]; !ok {
+		return nil, status.Error(codes.NotFound, "no such policy")
+	}
+	delete(f.policies, req.GetName())
+	return &emptypb.Empty{}, nil
+}
+
+func (f *fakeMonitoring) ListNotificationChannels(ctx context.Context, req *monitoringpb.ListNotificationChannelsRequest) (*monitoringpb.ListNotificationChannelsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := &monitoringpb.ListNotificationChannelsResponse{}
+	for name, c := range f.channels {
+		if strings.HasPrefix(name, req.GetName()+"/") {
+			resp.NotificationChannels = append(resp.NotificationChannels, obfuscated(c))
+		}
+	}
+	sort.Slice(resp.NotificationChannels, func(i, j int) bool {
+		return resp.NotificationChannels[i].Name < resp.NotificationChannels[j].Name
+	})
+	return resp, nil
+}
+
+func (f *fakeMonitoring) CreateNotificationChannel(ctx context.Context, req *monitoringpb.CreateNotificationChannelRequest) (*monitoringpb.NotificationChannel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := proto.Clone(req.GetNotificationChannel()).(*monitoringpb.NotificationChannel)
+	c.Name = f.newName(req.GetName(), "notificationChannels")
+	c.VerificationStatus = monitoringpb.NotificationChannel_VERIFIED
+	c.CreationRecord = &monitoringpb.MutationRecord{MutateTime: timestamppb.Now(), MutatedBy: "fake"}
+	f.channels[c.Name] = c
+	return c, nil
+}
+
+func (f *fakeMonitoring) UpdateNotificationChannel(ctx context.Context, req *monitoringpb.UpdateNotificationChannelRequest) (*monitoringpb.NotificationChannel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	old, ok := f.channels[req.GetNotificationChannel().GetName()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "no such channel")
+	}
+	c := proto.Clone(req.GetNotificationChannel()).(*monitoringpb.NotificationChannel)
+	if paths := req.GetUpdateMask().GetPaths(); len(paths) > 0 {
+		c = applyChannelMask(old, c, paths)
+	}
+	c.VerificationStatus = old.VerificationStatus
+	c.CreationRecord = old.CreationRecord
+	c.MutationRecords = append(old.MutationRecords, &monitoringpb.MutationRecord{MutateTime: timestamppb.Now(), MutatedBy: "fake"})
+	f.channels[c.Name] = c
+	return c, nil
+}
+
+// obfuscated returns a copy of c with its sensitive labels masked, as the
+// service does.
+func obfuscated(c *monitoringpb.NotificationChannel) *monitoringpb.NotificationChannel {
+	c = proto.Clone(c).(*monitoringpb.NotificationChannel)
+	for k, v := range c.Labels {
+		if sensitiveLabels[c.GetType()][k] {
+			c.Labels[k] = strings.Repeat("*", len(v))
+		}
+	}
+	return c
+}
+
+// applyChannelMask returns old with the fields of c in paths.
+func applyChannelMask(old, c *monitoringpb.NotificationChannel, paths []string) *monitoringpb.NotificationChannel {
+	out := proto.Clone(old).(*monitoringpb.NotificationChannel)
+	for _, p := range paths {
+		switch p {
+		case "display_name":
+			out.DisplayName = c.DisplayName
+		case "description":
+			out.Description = c.Description
+		case "user_labels":
+			out.UserLabels = c.UserLabels
+		case "enabled":
+			out.Enabled = c.Enabled
+		default:
+			k := strings.TrimPrefix(p, "labels.")
+			if v, ok := c.Labels[k]; ok {
+				if out.Labels == nil {
+					out.Labels = make(map[string]string)
+				}
+				out.Labels[k] = v
+			} else {
+				delete(out.Labels, k)
+			}
+		}
+	}
+	return out
+}
+
+func (f *fakeMonitoring) DeleteNotificationChannel(ctx context.Context, req *monitoringpb.DeleteNotificationChannelRequest) (*emptypb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, p := range f.policies {
+		for _, ref := range p.GetNotificationChannels() {
+			if ref == req.GetName() && !req.GetForce() {
+				return nil, status.Error(codes.FailedPrecondition, "channel is in use")
+			}
+		}
+	}
+	delete(f.channels, req.GetName())
+	return &emptypb.Empty{}, nil
+}
+
+// newFakeSyncer returns a Syncer for projectID backed by f.
+func newFakeSyncer(t *testing.T, f *fakeMonitoring, projectID string) *Syncer {
+	t.Helper()
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := grpc.NewServer()
+	monitoringpb.RegisterAlertPolicyServiceServer(gs, f)
+	monitoringpb.RegisterNotificationChannelServiceServer(gs, f)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	ctx := context.Background()
+	opts := []option.ClientOption{
+		option.WithEndpoint(lis.Addr().String()),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	}
+	policies, err := monitoring.NewAlertPolicyClient(ctx, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { policies.Close() })
+	channels, err := monitoring.NewNotificationChannelClient(ctx, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { channels.Close() })
+	return &Syncer{Policies: policies, Channels: channels, ProjectID: projectID}
+}
+
+func newFakeMonitoring() *fakeMonitoring {
+	return &fakeMonitoring{
+		policies: make(map[string]*monitoringpb.AlertPolicy),
+		channels: make(map[string]*monitoringpb.NotificationChannel),
+	}
+}
+
+func actions(p *Plan) []string {
+	var got []string
+	for _, c := range p.Changes {
+		got = append(got, fmt.Sprintf("%s %s %s", c.Action, c.Kind, c.DisplayName))
+	}
+	return got
+}
+
+// diffChanges returns the trimmed removed and added lines of a diff.
+func diffChanges(d string) (removed, added []string) {
+	for _, line := range strings.Split(d, "\n") {
+		switch {
+		case strings.HasPrefix(line, "- "):
+			removed = append(removed, strings.TrimSpace(line[2:]))
+		case strings.HasPrefix(line, "+ "):
+			added = append(added, strings.TrimSpace(line[2:]))
+		}
+	}
+	return removed, added
+}
+
+func mustPlan(t *testing.T, s *Syncer, cfg *Config) *Plan {
+	t.Helper()
+	p, err := s.Plan(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	return p
+}
+
+func TestSync(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeMonitoring()
+	s := newFakeSyncer(t, f, "a")
+	cfg, err := LoadDir("testdata/alerts")
+	if err != nil {
+		t.Fatalf("LoadDir: %v", err)
+	}
+
+	plan := mustPlan(t, s, cfg)
+	want := []string{
+		"create NotificationChannel Ops email",
+		"create NotificationChannel Oncall email",
+		"create AlertPolicy High CPU",
+		"create AlertPolicy Many 5xx responses",
+	}
+	if got := actions(plan); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("plan = %q, want %q", got, want)
+	}
+	var out bytes.Buffer
+	if err := s.Apply(ctx, plan, &out); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if strings.Count(out.String(), "Created") != 4 {
+		t.Errorf("Apply output:\n%s", out.String())
+	}
+
+	// Policies refer to the new channels by resource name.
+	for _, p := range f.policies {
+		for _, ref := range p.GetNotificationChannels() {
+			if f.channels[ref] == nil {
+				t.Errorf("policy %q refers to %q", p.GetDisplayName(), ref)
+			}
+		}
+	}
+
+	// Syncing again changes nothing, although the service added output
+	// only fields.
+	if plan := mustPlan(t, s, cfg); !plan.Empty() {
+		t.Fatalf("second plan = %q, want no changes", actions(plan))
+	}
+
+	// A changed threshold and a removed channel are updates, with a diff.
+	cfg.Policies[0].Conditions[0].GetConditionThreshold().ThresholdValue = 0.9
+	cfg.Policies[0].NotificationChannels = []string{"Ops email"}
+	plan = mustPlan(t, s, cfg)
+	if got := actions(plan); len(got) != 1 || got[0] != "update AlertPolicy High CPU" {
+		t.Fatalf("plan = %q, want one update", got)
+	}
+	removed, added := diffChanges(plan.Changes[0].Diff)
+	if strings.Join(removed, "|") != "thresholdValue: 0.8|- Oncall email" || strings.Join(added, "|") != "thresholdValue: 0.9" {
+		t.Errorf("diff removed %q and added %q:\n%s", removed, added, plan.Changes[0].Diff)
+	}
+	out.Reset()
+	plan.WriteTo(&out)
+	if !strings.Contains(out.String(), `~ update AlertPolicy "High CPU"`) || !strings.Contains(out.String(), "0 to create, 1 to update, 0 to delete") {
+		t.Errorf("WriteTo:\n%s", out.String())
+	}
+	if err := s.Apply(ctx, plan, &out); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if plan := mustPlan(t, s, cfg); !plan.Empty() {
+		t.Fatalf("plan after update = %q, want no changes", actions(plan))
+	}
+
+	// Without Prune, resources missing from the config are kept.
+	cfg.Policies = cfg.Policies[:1]
+	cfg.Channels = cfg.Channels[:1]
+	if plan := mustPlan(t, s, cfg); !plan.Empty() {
+		t.Fatalf("plan without prune = %q, want no changes", actions(plan))
+	}
+	s.Prune = true
+	plan = mustPlan(t, s, cfg)
+	want = []string{"delete AlertPolicy Many 5xx responses", "delete NotificationChannel Oncall email"}
+	if got := actions(plan); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("prune plan = %q, want %q", got, want)
+	}
+	if err := s.Apply(ctx, plan, &out); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if len(f.policies) != 1 || len(f.channels) != 1 {
+		t.Errorf("after prune: %d policies, %d channels, want 1 and 1", len(f.policies), len(f.channels))
+	}
+}
+
+func TestSensitiveLabels(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeMonitoring()
+	s := newFakeSyncer(t, f, "a")
+	cfg := &Config{Channels: []*monitoringpb.NotificationChannel{{
+		Type:        "slack",
+		DisplayName: "Ops Slack",
+		Labels:      map[string]string{"channel_name": "#ops", "auth_token": "xoxb-secret"},
+	}}}
+
+	plan := mustPlan(t, s, cfg)
+	if strings.Contains(plan.Changes[0].Diff, "xoxb-secret") {
+		t.Errorf("create diff shows the token:\n%s", plan.Changes[0].Diff)
+	}
+	if err := s.Apply(ctx, plan, &bytes.Buffer{}); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	// The live token is obfuscated, which isn't a change.
+	if plan := mustPlan(t, s, cfg); !plan.Empty() {
+		t.Fatalf("second plan = %q, want no changes", actions(plan))
+	}
+
+	exported, err := s.Export(ctx)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if _, ok := exported.Channels[0].GetLabels()["auth_token"]; ok {
+		t.Errorf("Export wrote the obfuscated token: %v", exported.Channels[0].GetLabels())
+	}
+
+	// Updates from the exported files keep the live token.
+	exported.Channels[0].Labels["channel_name"] = "#oncall"
+	plan = mustPlan(t, s, exported)
+	if got := actions(plan); len(got) != 1 || got[0] != "update NotificationChannel Ops Slack" {
+		t.Fatalf("plan = %q, want one update", got)
+	}
+	if err := s.Apply(ctx, plan, &bytes.Buffer{}); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	for _, c := range f.channels {
+		if got := c.GetLabels(); got["channel_name"] != "#oncall" || got["auth_token"] != "xoxb-secret" {
+			t.Errorf("after update, labels = %v, want the new channel and the old token", got)
+		}
+	}
+}
+
+func TestExportToOtherProject(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeMonitoring()
+	src := newFakeSyncer(t, f, "a")
+	cfg, err := LoadDir("testdata/alerts")
+	if err != nil {
+		t.Fatalf("LoadDir: %v", err)
+	}
+	if err := src.Apply(ctx, mustPlan(t, src, cfg), &bytes.Buffer{}); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	// Export and reload the files.
+	exported, err := src.Export(ctx)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	dir := t.TempDir()
+	if err := WriteDir(dir, exported); err != nil {
+		t.Fatalf("WriteDir: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "policy-high-cpu.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b), "kind: AlertPolicy\n") || strings.Contains(string(b), "projects/") || !strings.Contains(string(b), "- Oncall email") {
+		t.Errorf("policy-high-cpu.yaml:\n%s", b)
+	}
+	reloaded, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("LoadDir: %v", err)
+	}
+	if plan := mustPlan(t, src, reloaded); !plan.Empty() {
+		t.Fatalf("exported files differ from the project: %q", actions(plan))
+	}
+
+	// Syncing to another project creates everything there, with channel
+	// references remapped to that project's channels.
+	dst := newFakeSyncer(t, f, "b")
+	if err := dst.Apply(ctx, mustPlan(t, dst, reloaded), &bytes.Buffer{}); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	n := 0
+	for name, p := range f.policies {
+		if !strings.HasPrefix(name, "projects/b/") {
+			continue
+		}
+		n++
+		for _, ref := range p.GetNotificationChannels() {
+			if !strings.HasPrefix(ref, "projects/b/notificationChannels/") {
+				t.Errorf("policy %q in project b refers to %q", p.GetDisplayName(), ref)
+			}
+		}
+	}
+	if n != 2 {
+		t.Errorf("project b has %d policies, want 2", n)
+	}
+}
+
+func TestPlanErrors(t *testing.T) {
+	s := newFakeSyncer(t, newFakeMonitoring(), "a")
+	cfg := &Config{Policies: []*monitoringpb.AlertPolicy{{DisplayName: "p", NotificationChannels: []string{"nope"}}}}
+	if _, err := s.Plan(context.Background(), cfg); err == nil || !strings.Contains(err.Error(), `unknown NotificationChannel "nope"`) {
+		t.Errorf("Plan with unknown channel = %v", err)
+	}
+	cfg = &Config{Channels: []*monitoringpb.NotificationChannel{{DisplayName: "c"}, {DisplayName: "c"}}}
+	if _, err := s.Plan(context.Background(), cfg); err == nil {
+		t.Error("Plan with duplicate channels succeeded")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "bad.yaml"), []byte("kind: Dashboard\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDir(dir); err == nil || !strings.Contains(err.Error(), "bad.yaml") {
+		t.Errorf("LoadDir with unknown kind = %v", err)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	a := []string{"1", "2", "3", "4", "5", "6", "7", "8"}
+	b := []string{"1", "2", "3", "4", "five", "6", "7", "8"}
+	want := "  ...\n  3\n  4\n- 5\n+ five\n  6\n  7\n  ...\n"
+	if got := diffLines(a, b); got != want {
+		t.Errorf("diffLines = %q, want %q", got, want)
+	}
+	if got, want := diffLines(nil, []string{"x"}), "+ x\n"; got != want {
+		t.Errorf("diffLines(nil) = %q, want %q", got, want)
+	}
+}
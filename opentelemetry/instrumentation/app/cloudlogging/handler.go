@@ -0,0 +1,333 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cloudlogging provides a log/slog handler that writes JSON in the
+// structured logging format of Cloud Logging, for services whose stdout is
+// collected by Cloud Run, GKE or the Ops Agent.
+//
+// Records are written with the special fields described in
+// https://cloud.google.com/logging/docs/structured-logging#special-payload-fields:
+// severity, timestamp and message; the trace, span and sampling decision of
+// the OpenTelemetry span in the context; sourceLocation; labels; and
+// httpRequest, using the HTTPRequest attribute. Records at LevelError and
+// above that have an error attribute are reported to Error Reporting with a
+// stack trace.
+package cloudlogging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Levels for the Cloud Logging severities that slog has no level for.
+const (
+	LevelNotice    = slog.Level(2)
+	LevelCritical  = slog.Level(12)
+	LevelAlert     = slog.Level(16)
+	LevelEmergency = slog.Level(20)
+)
+
+// Keys of the special fields.
+const (
+	TraceKey          = "logging.googleapis.com/trace"
+	SpanIDKey         = "logging.googleapis.com/spanId"
+	TraceSampledKey   = "logging.googleapis.com/trace_sampled"
+	SourceLocationKey = "logging.googleapis.com/sourceLocation"
+	LabelsKey         = "logging.googleapis.com/labels"
+	HTTPRequestKey    = "httpRequest"
+)
+
+// reportedErrorEvent is the type that makes Error Reporting pick up an
+// entry.
+const reportedErrorEvent = "type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent"
+
+// Severity returns the Cloud Logging severity for level. Levels between
+// two severities round down.
+func Severity(level slog.Level) string {
+	switch {
+	case level >= LevelEmergency:
+		return "EMERGENCY"
+	case level >= LevelAlert:
+		return "ALERT"
+	case level >= LevelCritical:
+		return "CRITICAL"
+	case level >= slog.LevelError:
+		return "ERROR"
+	case level >= slog.LevelWarn:
+		return "WARNING"
+	case level >= LevelNotice:
+		return "NOTICE"
+	case level >= slog.LevelInfo:
+		return "INFO"
+	}
+	return "DEBUG"
+}
+
+// Options configures a Handler.
+type Options struct {
+	// ProjectID formats trace IDs as projects/PROJECT_ID/traces/TRACE_ID,
+	// which Cloud Logging needs to link entries to traces. Without it the
+	// bare trace ID is written.
+	ProjectID string
+	// Level is the minimum level logged. Default slog.LevelInfo.
+	Level slog.Leveler
+	// AddSource writes the sourceLocation of the logging call.
+	AddSource bool
+	// Labels are added to every entry.
+	Labels map[string]string
+}
+
+// Handler is a slog.Handler that writes Cloud Logging structured JSON.
+type Handler struct {
+	json   slog.Handler
+	opts   Options
+	labels map[string]string
+	// groups and groupAttrs hold the groups opened by WithGroup and the
+	// attributes added inside each. They are applied in Handle, so that
+	// the special fields stay at the top level.
+	groups     []string
+	groupAttrs [][]slog.Attr
+}
+
+// NewHandler returns a Handler that writes to w. opts may be nil.
+func NewHandler(w io.Writer, opts *Options) *Handler {
+	if opts == nil {
+		opts = &Options{}
+	}
+	labels := make(map[string]string)
+	for k, v := range opts.Labels {
+		labels[k] = v
+	}
+	return &Handler{
+		json: slog.NewJSONHandler(w, &slog.HandlerOptions{
+			AddSource:   opts.AddSource,
+			Level:       opts.Level,
+			ReplaceAttr: replaceAttr,
+		}),
+		opts:   *opts,
+		labels: labels,
+	}
+}
+
+// replaceAttr renames the built-in attributes to the special fields.
+func replaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return a
+	}
+	switch a.Key {
+	case slog.LevelKey:
+		a.Key = "severity"
+		a.Value = slog.StringValue(Severity(a.Value.Any().(slog.Level)))
+	case slog.TimeKey:
+		a.Key = "timestamp"
+	case slog.MessageKey:
+		a.Key = "message"
+	case slog.SourceKey:
+		a.Key = SourceLocationKey
+		if s, ok := a.Value.Any().(*slog.Source); ok {
+			a.Value = slog.GroupValue(
+				slog.String("file", s.File),
+				// Cloud Logging encodes this int64 as a string.
+				slog.String("line", strconv.Itoa(s.Line)),
+				slog.String("function", s.Function),
+			)
+		}
+	}
+	return a
+}
+
+// Enabled reports whether level is logged.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.json.Enabled(ctx, level)
+}
+
+// WithAttrs returns a Handler that adds attrs to every record. Label
+// attributes made by Labels are merged into the labels field.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := h.clone()
+	var rest []slog.Attr
+	for _, a := range attrs {
+		if a.Key == LabelsKey {
+			mergeLabels(h2.labels, a)
+			continue
+		}
+		rest = append(rest, a)
+	}
+	if len(h2.groups) == 0 {
+		h2.json = h2.json.WithAttrs(rest)
+	} else {
+		last := len(h2.groupAttrs) - 1
+		h2.groupAttrs[last] = append(append([]slog.Attr(nil), h2.groupAttrs[last]...), rest...)
+	}
+	return h2
+}
+
+// WithGroup returns a Handler that puts the attributes of later calls and
+// records in the group name.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := h.clone()
+	h2.groups = append(h2.groups, name)
+	h2.groupAttrs = append(h2.groupAttrs, nil)
+	return h2
+}
+
+func (h *Handler) clone() *Handler {
+	h2 := *h
+	h2.labels = copyLabels(h.labels)
+	h2.groups = append([]string(nil), h.groups...)
+	h2.groupAttrs = append([][]slog.Attr(nil), h.groupAttrs...)
+	return &h2
+}
+
+// Handle writes r with the special fields.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	labels, copied := h.labels, false
+	var attrs []slog.Attr
+	var errs []error
+	var httpRequest *slog.Attr
+	r.Attrs(func(a slog.Attr) bool {
+		switch {
+		case a.Key == LabelsKey:
+			if !copied {
+				labels, copied = copyLabels(h.labels), true
+			}
+			mergeLabels(labels, a)
+			return true
+		case a.Key == HTTPRequestKey && len(h.groups) == 0:
+			a := a
+			httpRequest = &a
+			return true
+		}
+		if err, ok := a.Value.Any().(error); ok {
+			errs = append(errs, err)
+		}
+		attrs = append(attrs, a)
+		return true
+	})
+
+	out := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	if s := trace.SpanContextFromContext(ctx); s.IsValid() {
+		traceID := s.TraceID().String()
+		if h.opts.ProjectID != "" {
+			traceID = fmt.Sprintf("projects/%s/traces/%s", h.opts.ProjectID, traceID)
+		}
+		out.AddAttrs(
+			slog.String(TraceKey, traceID),
+			slog.String(SpanIDKey, s.SpanID().String()),
+			slog.Bool(TraceSampledKey, s.TraceFlags().IsSampled()),
+		)
+	}
+	if len(labels) > 0 {
+		keys := make([]string, 0, len(labels))
+		for k := range labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var la []any
+		for _, k := range keys {
+			la = append(la, slog.String(k, labels[k]))
+		}
+		out.AddAttrs(slog.Group(LabelsKey, la...))
+	}
+	if httpRequest != nil {
+		out.AddAttrs(*httpRequest)
+	}
+	if r.Level >= slog.LevelError && len(errs) > 0 {
+		out.AddAttrs(
+			slog.String("@type", reportedErrorEvent),
+			slog.String("stack_trace", stackTrace(r.Message, errs)),
+		)
+	}
+
+	// Nest the record's attributes in the open groups, innermost first.
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		var args []any
+		for _, a := range append(h.groupAttrs[i], attrs...) {
+			args = append(args, a)
+		}
+		attrs = []slog.Attr{slog.Group(h.groups[i], args...)}
+	}
+	out.AddAttrs(attrs...)
+	return h.json.Handle(ctx, out)
+}
+
+func copyLabels(m map[string]string) map[string]string {
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func mergeLabels(labels map[string]string, a slog.Attr) {
+	if a.Value.Kind() != slog.KindGroup {
+		return
+	}
+	for _, l := range a.Value.Group() {
+		labels[l.Key] = l.Value.String()
+	}
+}
+
+// Labels returns an attribute that adds labels to an entry. Labels are
+// indexed by Cloud Logging, and values are always strings.
+func Labels(kv ...string) slog.Attr {
+	var args []any
+	for i := 0; i+1 < len(kv); i += 2 {
+		args = append(args, slog.String(kv[i], kv[i+1]))
+	}
+	return slog.Group(LabelsKey, args...)
+}
+
+// stackTrace returns the text Error Reporting parses: the message and
+// errors, followed by the stack of the goroutine in the format of a Go
+// panic, without the frames of slog and this package.
+func stackTrace(msg string, errs []error) string {
+	var b strings.Builder
+	b.WriteString(msg)
+	for _, err := range errs {
+		b.WriteString(": ")
+		b.WriteString(err.Error())
+	}
+	b.WriteString("\n\n")
+
+	buf := make([]byte, 16<<10)
+	buf = buf[:runtime.Stack(buf, false)]
+	lines := strings.Split(strings.TrimSpace(string(buf)), "\n")
+	b.WriteString(lines[0])
+	b.WriteByte('\n')
+	// After the goroutine header, each frame is a function line followed
+	// by a file line.
+	for i := 1; i+1 < len(lines); i += 2 {
+		fn := lines[i]
+		if strings.HasPrefix(fn, "runtime.Stack(") || strings.HasPrefix(fn, "log/slog.") || strings.Contains(fn, "/cloudlogging.stackTrace(") || strings.Contains(fn, "/cloudlogging.(*Handler).") {
+			continue
+		}
+		b.WriteString(fn)
+		b.WriteByte('\n')
+		b.WriteString(lines[i+1])
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
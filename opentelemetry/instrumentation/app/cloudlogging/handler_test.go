@@ -0,0 +1,157 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudlogging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// logOne logs with a new Handler and returns the decoded entry.
+func logOne(t *testing.T, opts *Options, log func(*slog.Logger)) map[string]interface{} {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	log(slog.New(NewHandler(buf, opts)))
+	entry := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry), buf.String())
+	return entry
+}
+
+func TestHandlerSeverity(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		level          slog.Level
+		expectSeverity string
+	}{
+		{slog.LevelDebug, "DEBUG"},
+		{slog.LevelInfo, "INFO"},
+		{LevelNotice, "NOTICE"},
+		{slog.LevelWarn, "WARNING"},
+		{slog.LevelError, "ERROR"},
+		{LevelCritical, "CRITICAL"},
+		{LevelAlert, "ALERT"},
+		{LevelEmergency, "EMERGENCY"},
+		// Levels in between round down.
+		{slog.LevelWarn + 1, "WARNING"},
+		{slog.LevelDebug - 4, "DEBUG"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expectSeverity, func(t *testing.T) {
+			entry := logOne(t, &Options{Level: slog.LevelDebug - 4}, func(l *slog.Logger) {
+				l.Log(ctx, tc.level, "message")
+			})
+			require.Equal(t, tc.expectSeverity, entry["severity"])
+			require.Equal(t, "message", entry["message"])
+		})
+	}
+}
+
+func TestHandlerTimestamp(t *testing.T) {
+	entry := logOne(t, nil, func(l *slog.Logger) { l.Info("foo") })
+	ts, ok := entry["timestamp"].(string)
+	require.True(t, ok, "timestamp missing: %v", entry)
+
+	_, err := time.Parse(time.RFC3339Nano, ts)
+	require.NoErrorf(t, err, "could not parse timestamp as RFC3339 with nanos")
+}
+
+func TestHandlerTrace(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	entry := logOne(t, &Options{ProjectID: "my-project"}, func(l *slog.Logger) { l.InfoContext(ctx, "traced") })
+	require.Equal(t, "projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736", entry[TraceKey])
+	require.Equal(t, "00f067aa0ba902b7", entry[SpanIDKey])
+	require.Equal(t, true, entry[TraceSampledKey])
+
+	entry = logOne(t, nil, func(l *slog.Logger) { l.InfoContext(ctx, "traced") })
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", entry[TraceKey])
+
+	entry = logOne(t, nil, func(l *slog.Logger) { l.Info("untraced") })
+	require.NotContains(t, entry, TraceKey)
+}
+
+func TestHandlerSourceLocation(t *testing.T) {
+	entry := logOne(t, &Options{AddSource: true}, func(l *slog.Logger) { l.Info("here") })
+	loc, ok := entry[SourceLocationKey].(map[string]interface{})
+	require.True(t, ok, "sourceLocation missing: %v", entry)
+	require.True(t, strings.HasSuffix(loc["file"].(string), "handler_test.go"), loc)
+	require.NotEmpty(t, loc["line"])
+	require.Contains(t, loc["function"], "TestHandlerSourceLocation")
+}
+
+func TestHandlerLabelsAndGroups(t *testing.T) {
+	entry := logOne(t, &Options{Labels: map[string]string{"service": "api", "env": "dev"}}, func(l *slog.Logger) {
+		l = l.With(Labels("env", "prod"), slog.String("top", "t")).WithGroup("req").With(slog.Int("n", 1))
+		l.Info("grouped", slog.String("user", "ada"), Labels("tenant", "t1"))
+	})
+	require.Equal(t, map[string]interface{}{"service": "api", "env": "prod", "tenant": "t1"}, entry[LabelsKey])
+	require.Equal(t, "t", entry["top"])
+	require.Equal(t, map[string]interface{}{"n": float64(1), "user": "ada"}, entry["req"])
+	require.Equal(t, "grouped", entry["message"])
+}
+
+func TestHandlerHTTPRequest(t *testing.T) {
+	r := httptest.NewRequest("POST", "http://example.com/items?id=1", strings.NewReader("body"))
+	r.Header.Set("User-Agent", "curl/8.0")
+	r.Header.Set("Referer", "https://example.com/")
+	entry := logOne(t, nil, func(l *slog.Logger) {
+		l.Info("served", HTTPRequest(r, 201, 42, 1500*time.Millisecond))
+	})
+	require.Equal(t, map[string]interface{}{
+		"requestMethod": "POST",
+		"requestUrl":    "http://example.com/items?id=1",
+		"status":        float64(201),
+		"userAgent":     "curl/8.0",
+		"protocol":      "HTTP/1.1",
+		"latency":       "1.500000000s",
+		"requestSize":   "4",
+		"responseSize":  "42",
+		"referer":       "https://example.com/",
+		"remoteIp":      "192.0.2.1",
+	}, entry[HTTPRequestKey])
+}
+
+func TestHandlerErrorReporting(t *testing.T) {
+	err := errors.New("connection refused")
+	entry := logOne(t, nil, func(l *slog.Logger) { l.Error("fetch failed", slog.Any("error", err)) })
+	require.Equal(t, reportedErrorEvent, entry["@type"])
+	require.Equal(t, "connection refused", entry["error"])
+	stack, _ := entry["stack_trace"].(string)
+	require.True(t, strings.HasPrefix(stack, "fetch failed: connection refused\n\ngoroutine "), stack)
+	require.Contains(t, stack, "TestHandlerErrorReporting")
+	require.NotContains(t, stack, "log/slog.")
+
+	// Warnings and errors without an error value are not reported.
+	entry = logOne(t, nil, func(l *slog.Logger) { l.Warn("retrying", slog.Any("error", err)) })
+	require.NotContains(t, entry, "stack_trace")
+	entry = logOne(t, nil, func(l *slog.Logger) { l.Error("bad input") })
+	require.NotContains(t, entry, "stack_trace")
+}
@@ -0,0 +1,63 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudlogging
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HTTPRequest returns an httpRequest attribute describing r and its
+// response, which Cloud Logging shows as the request of the entry. size is
+// the response body size in bytes, or -1 if unknown.
+func HTTPRequest(r *http.Request, status int, size int64, latency time.Duration) slog.Attr {
+	attrs := []any{
+		slog.String("requestMethod", r.Method),
+		slog.String("requestUrl", requestURL(r)),
+		slog.Int("status", status),
+		slog.String("userAgent", r.UserAgent()),
+		slog.String("protocol", r.Proto),
+		// Durations are encoded as seconds with an "s" suffix.
+		slog.String("latency", fmt.Sprintf("%.9fs", latency.Seconds())),
+	}
+	if r.ContentLength > 0 {
+		attrs = append(attrs, slog.String("requestSize", strconv.FormatInt(r.ContentLength, 10)))
+	}
+	if size >= 0 {
+		attrs = append(attrs, slog.String("responseSize", strconv.FormatInt(size, 10)))
+	}
+	if ref := r.Referer(); ref != "" {
+		attrs = append(attrs, slog.String("referer", ref))
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		attrs = append(attrs, slog.String("remoteIp", host))
+	}
+	return slog.Group(HTTPRequestKey, attrs...)
+}
+
+func requestURL(r *http.Request) string {
+	if r.URL.IsAbs() {
+		return r.URL.String()
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
@@ -0,0 +1,221 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package backlog inspects and moves the committed cursors of Pub/Sub Lite
+// subscriptions.
+package backlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/pubsublite"
+	vkit "cloud.google.com/go/pubsublite/apiv1"
+	pb "cloud.google.com/go/pubsublite/apiv1/pubsublitepb"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// Client inspects and seeks the subscriptions of a region.
+type Client struct {
+	Admin   *pubsublite.AdminClient
+	Cursors *vkit.CursorClient
+	Stats   *vkit.TopicStatsClient
+}
+
+// NewClient returns a client of the subscriptions of region, such as
+// us-central1. Close must be called when it's no longer needed.
+func NewClient(ctx context.Context, region string, opts ...option.ClientOption) (*Client, error) {
+	admin, err := pubsublite.NewAdminClient(ctx, region, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("pubsublite.NewAdminClient: %w", err)
+	}
+	// The cursor and topic stats APIs are served by regional endpoints, like
+	// the admin API. The options given override the endpoint.
+	opts = append([]option.ClientOption{option.WithEndpoint(region + "-pubsublite.googleapis.com:443")}, opts...)
+	cursors, err := vkit.NewCursorClient(ctx, opts...)
+	if err != nil {
+		admin.Close()
+		return nil, fmt.Errorf("NewCursorClient: %w", err)
+	}
+	stats, err := vkit.NewTopicStatsClient(ctx, opts...)
+	if err != nil {
+		admin.Close()
+		cursors.Close()
+		return nil, fmt.Errorf("NewTopicStatsClient: %w", err)
+	}
+	return &Client{Admin: admin, Cursors: cursors, Stats: stats}, nil
+}
+
+// Close closes the clients of c.
+func (c *Client) Close() error {
+	return errors.Join(c.Admin.Close(), c.Cursors.Close(), c.Stats.Close())
+}
+
+// PartitionBacklog is the backlog of a subscription in a partition: the
+// messages from its committed cursor to the head of the partition.
+type PartitionBacklog struct {
+	Partition int
+	// Committed is the offset of the first message not acked, and Head the
+	// offset of the next message to be published.
+	Committed, Head int64
+	// Messages and Bytes are the size of the backlog.
+	Messages, Bytes int64
+	// Oldest is the publish time of the oldest message of the backlog, or
+	// zero if it's empty.
+	Oldest time.Time
+}
+
+// Backlog returns the backlog of subscription in each partition of its
+// topic.
+func (c *Client) Backlog(ctx context.Context, subscription string) ([]PartitionBacklog, error) {
+	sub, err := c.Admin.Subscription(ctx, subscription)
+	if err != nil {
+		return nil, fmt.Errorf("Subscription: %w", err)
+	}
+	committed, err := c.committed(ctx, subscription)
+	if err != nil {
+		return nil, err
+	}
+	n, err := c.Admin.TopicPartitionCount(ctx, sub.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("TopicPartitionCount: %w", err)
+	}
+
+	backlog := make([]PartitionBacklog, n)
+	for p := range backlog {
+		head, err := c.Stats.ComputeHeadCursor(ctx, &pb.ComputeHeadCursorRequest{Topic: sub.Topic, Partition: int64(p)})
+		if err != nil {
+			return nil, fmt.Errorf("ComputeHeadCursor: %w", err)
+		}
+		stats, err := c.Stats.ComputeMessageStats(ctx, &pb.ComputeMessageStatsRequest{
+			Topic:       sub.Topic,
+			Partition:   int64(p),
+			StartCursor: &pb.Cursor{Offset: committed[p]},
+			EndCursor:   head.GetHeadCursor(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("ComputeMessageStats: %w", err)
+		}
+		backlog[p] = PartitionBacklog{
+			Partition: p,
+			Committed: committed[p],
+			Head:      head.GetHeadCursor().GetOffset(),
+			Messages:  stats.GetMessageCount(),
+			Bytes:     stats.GetMessageBytes(),
+		}
+		if t := stats.GetMinimumPublishTime(); t != nil {
+			backlog[p].Oldest = t.AsTime()
+		}
+	}
+	return backlog, nil
+}
+
+// committed returns the committed cursors of subscription, by partition.
+// Partitions without a committed cursor are at offset 0.
+func (c *Client) committed(ctx context.Context, subscription string) (map[int]int64, error) {
+	committed := make(map[int]int64)
+	it := c.Cursors.ListPartitionCursors(ctx, &pb.ListPartitionCursorsRequest{Parent: subscription})
+	for {
+		pc, err := it.Next()
+		if err == iterator.Done {
+			return committed, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ListPartitionCursors: %w", err)
+		}
+		committed[int(pc.GetPartition())] = pc.GetCursor().GetOffset()
+	}
+}
+
+// Seek seeks subscription to target, such as pubsublite.PublishTime, and
+// waits until the seek is done. Subscribers receive messages from target
+// once they reconnect.
+func (c *Client) Seek(ctx context.Context, subscription string, target pubsublite.SeekTarget) error {
+	op, err := c.Admin.SeekSubscription(ctx, subscription, target)
+	if err != nil {
+		return fmt.Errorf("SeekSubscription: %w", err)
+	}
+	if _, err := op.Wait(ctx); err != nil {
+		return fmt.Errorf("Wait: %w", err)
+	}
+	return nil
+}
+
+// SeekOffsets commits the cursors of subscription to offsets, by partition.
+//
+// Unlike Seek, the subscribers aren't notified: they must be stopped, or
+// they overwrite the cursors with their own.
+func (c *Client) SeekOffsets(ctx context.Context, subscription string, offsets map[int]int64) error {
+	for p, offset := range offsets {
+		_, err := c.Cursors.CommitCursor(ctx, &pb.CommitCursorRequest{
+			Subscription: subscription,
+			Partition:    int64(p),
+			Cursor:       &pb.Cursor{Offset: offset},
+		})
+		if err != nil {
+			return fmt.Errorf("CommitCursor(partition %d): %w", p, err)
+		}
+	}
+	return nil
+}
+
+// Replay creates the subscription replayID, in the location and of the
+// topic of subscription, at target. Its subscribers receive the messages
+// from target without moving the cursors of subscription.
+func (c *Client) Replay(ctx context.Context, subscription, replayID string, target pubsublite.SeekTarget) (*pubsublite.SubscriptionConfig, error) {
+	sub, err := c.Admin.Subscription(ctx, subscription)
+	if err != nil {
+		return nil, fmt.Errorf("Subscription: %w", err)
+	}
+	location, _, ok := strings.Cut(subscription, "/subscriptions/")
+	if !ok {
+		return nil, fmt.Errorf("backlog: invalid subscription %q", subscription)
+	}
+	replay, err := c.Admin.CreateSubscription(ctx, pubsublite.SubscriptionConfig{
+		Name:                location + "/subscriptions/" + replayID,
+		Topic:               sub.Topic,
+		DeliveryRequirement: sub.DeliveryRequirement,
+	}, pubsublite.AtTargetLocation(target))
+	if err != nil {
+		return nil, fmt.Errorf("CreateSubscription: %w", err)
+	}
+	return replay, nil
+}
+
+// ReplayFromOffsets is like Replay, with the cursors of the new
+// subscription at offsets, by partition. Partitions not in offsets start
+// at the committed cursors of subscription.
+func (c *Client) ReplayFromOffsets(ctx context.Context, subscription, replayID string, offsets map[int]int64) (*pubsublite.SubscriptionConfig, error) {
+	committed, err := c.committed(ctx, subscription)
+	if err != nil {
+		return nil, err
+	}
+	for p, offset := range offsets {
+		committed[p] = offset
+	}
+	replay, err := c.Replay(ctx, subscription, replayID, pubsublite.Beginning)
+	if err != nil {
+		return nil, err
+	}
+	// Nothing receives from the new subscription yet, so its cursors can
+	// be committed.
+	if err := c.SeekOffsets(ctx, replay.Name, committed); err != nil {
+		return nil, err
+	}
+	return replay, nil
+}
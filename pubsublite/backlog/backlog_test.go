@@ -0,0 +1,143 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backlog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsublite"
+	"github.com/GoogleCloudPlatform/golang-samples/pubsublite/internal/psltest"
+)
+
+const (
+	location     = "projects/p/locations/us-central1-a"
+	topic        = location + "/topics/t"
+	subscription = location + "/subscriptions/s"
+)
+
+var start = time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+
+// newClient returns a client of a fake topic with 2 partitions of 5
+// messages of 10 bytes, a second apart, and a subscription at offset 2 of
+// each partition.
+func newClient(t *testing.T) (*Client, *psltest.Fake) {
+	t.Helper()
+	f := psltest.NewFake(topic, 2)
+	for i := 0; i < 10; i++ {
+		f.Publish(i%2, start.Add(time.Duration(i)*time.Second), make([]byte, 10), nil)
+	}
+	f.AddSubscription(subscription)
+
+	ctx := context.Background()
+	c, err := NewClient(ctx, "us-central1", f.Serve(t)...)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	if err := c.SeekOffsets(ctx, subscription, map[int]int64{0: 2, 1: 2}); err != nil {
+		t.Fatalf("SeekOffsets: %v", err)
+	}
+	return c, f
+}
+
+func TestBacklog(t *testing.T) {
+	c, _ := newClient(t)
+	got, err := c.Backlog(context.Background(), subscription)
+	if err != nil {
+		t.Fatalf("Backlog: %v", err)
+	}
+	want := []PartitionBacklog{
+		{Partition: 0, Committed: 2, Head: 5, Messages: 3, Bytes: 30, Oldest: start.Add(4 * time.Second)},
+		{Partition: 1, Committed: 2, Head: 5, Messages: 3, Bytes: 30, Oldest: start.Add(5 * time.Second)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d partitions, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !got[i].Oldest.Equal(want[i].Oldest) {
+			t.Errorf("partition %d: got oldest %v, want %v", i, got[i].Oldest, want[i].Oldest)
+		}
+		got[i].Oldest = want[i].Oldest
+		if got[i] != want[i] {
+			t.Errorf("partition %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSeek(t *testing.T) {
+	c, f := newClient(t)
+	ctx := context.Background()
+
+	// Messages 6 to 9 were published from 6s on: offsets 3 and 4 of
+	// partition 0, and offsets 3 and 4 of partition 1.
+	if err := c.Seek(ctx, subscription, pubsublite.PublishTime(start.Add(6*time.Second))); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	for p := 0; p < 2; p++ {
+		if got := f.Committed(subscription, p); got != 3 {
+			t.Errorf("partition %d: got offset %d after seeking to a time, want 3", p, got)
+		}
+	}
+
+	if err := c.Seek(ctx, subscription, pubsublite.End); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	if got := f.Committed(subscription, 0); got != 5 {
+		t.Errorf("got offset %d after seeking to the end, want 5", got)
+	}
+	if err := c.Seek(ctx, subscription, pubsublite.Beginning); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	if got := f.Committed(subscription, 0); got != 0 {
+		t.Errorf("got offset %d after seeking to the beginning, want 0", got)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	c, f := newClient(t)
+	ctx := context.Background()
+
+	replay, err := c.Replay(ctx, subscription, "replay", pubsublite.PublishTime(start.Add(7*time.Second)))
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if want := location + "/subscriptions/replay"; replay.Name != want {
+		t.Errorf("got subscription %q, want %q", replay.Name, want)
+	}
+	if replay.Topic != topic {
+		t.Errorf("got topic %q, want %q", replay.Topic, topic)
+	}
+	// Message 7 is offset 3 of partition 1, message 8 offset 4 of 0.
+	if got0, got1 := f.Committed(replay.Name, 0), f.Committed(replay.Name, 1); got0 != 4 || got1 != 3 {
+		t.Errorf("got replay offsets %d and %d, want 4 and 3", got0, got1)
+	}
+	if got := f.Committed(subscription, 0); got != 2 {
+		t.Errorf("replay moved the source subscription to %d", got)
+	}
+
+	replay, err = c.ReplayFromOffsets(ctx, subscription, "replay-offsets", map[int]int64{1: 0})
+	if err != nil {
+		t.Fatalf("ReplayFromOffsets: %v", err)
+	}
+	if got0, got1 := f.Committed(replay.Name, 0), f.Committed(replay.Name, 1); got0 != 2 || got1 != 0 {
+		t.Errorf("got replay offsets %d and %d, want 2, like the source, and 0", got0, got1)
+	}
+
+	if _, err := c.Replay(ctx, subscription, "replay", pubsublite.Beginning); err == nil {
+		t.Errorf("Replay to an existing subscription succeeded")
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The pslctl command inspects and moves the cursors of a Pub/Sub Lite
+// subscription.
+//
+//	pslctl backlog projects/my-project/locations/us-central1-a/subscriptions/my-sub
+//	pslctl seek -time 2026-01-05T09:00:00Z projects/my-project/locations/us-central1-a/subscriptions/my-sub
+//	pslctl seek -offsets 0=1200,1=980 projects/my-project/locations/us-central1-a/subscriptions/my-sub
+//	pslctl replay -to my-sub-replay -time 2026-01-05T09:00:00Z projects/my-project/locations/us-central1-a/subscriptions/my-sub
+//
+// backlog prints the committed offset, head and unprocessed messages of each
+// partition. seek moves the subscription to a publish time, to the
+// beginning or end of the backlog, or to offsets by partition; seeking to
+// offsets requires the subscribers to be stopped. replay creates a new
+// subscription of the topic at a time or offsets, leaving the subscription
+// as it is.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"cloud.google.com/go/pubsublite"
+	"github.com/GoogleCloudPlatform/golang-samples/pubsublite/backlog"
+)
+
+const usage = `Usage:
+	pslctl backlog SUBSCRIPTION
+	pslctl seek [-time RFC3339 | -offsets P=OFFSET,... | -beginning | -end] SUBSCRIPTION
+	pslctl replay -to SUBSCRIPTION_ID [-time RFC3339 | -offsets P=OFFSET,...] SUBSCRIPTION
+`
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+	cmd, args := os.Args[1], os.Args[2:]
+
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	at := fs.String("time", "", "Publish time to seek to, in RFC 3339 format.")
+	offsets := fs.String("offsets", "", "Offsets to seek to, by partition, such as 0=1200,1=980.")
+	beginning := fs.Bool("beginning", false, "Seek to the oldest retained message.")
+	end := fs.Bool("end", false, "Seek past the last published message.")
+	to := fs.String("to", "", "ID of the subscription to create for the replay.")
+	fs.Usage = func() { fmt.Fprint(fs.Output(), usage); fs.PrintDefaults() }
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	subscription := fs.Arg(0)
+
+	ctx := context.Background()
+	c, err := backlog.NewClient(ctx, region(subscription))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.Close()
+
+	switch cmd {
+	case "backlog":
+		printBacklog(ctx, c, subscription)
+	case "seek":
+		target, byOffset := seekTarget(*at, *offsets, *beginning, *end)
+		if byOffset != nil {
+			err = c.SeekOffsets(ctx, subscription, byOffset)
+		} else {
+			err = c.Seek(ctx, subscription, target)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Seeked %s\n", subscription)
+	case "replay":
+		if *to == "" {
+			log.Fatal("Error: -to must be set")
+		}
+		target, byOffset := seekTarget(*at, *offsets, *beginning, *end)
+		var replay *pubsublite.SubscriptionConfig
+		if byOffset != nil {
+			replay, err = c.ReplayFromOffsets(ctx, subscription, *to, byOffset)
+		} else {
+			replay, err = c.Replay(ctx, subscription, *to, target)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Created %s\n", replay.Name)
+	default:
+		log.Fatalf("Error: unknown command %q\n\n%s", cmd, usage)
+	}
+}
+
+// region returns the region of subscription, such as us-central1 for
+// projects/my-project/locations/us-central1-a/subscriptions/my-sub.
+func region(subscription string) string {
+	parts := strings.Split(subscription, "/")
+	if len(parts) != 6 || parts[0] != "projects" || parts[2] != "locations" || parts[4] != "subscriptions" {
+		log.Fatalf("Error: invalid subscription %q", subscription)
+	}
+	location := strings.Split(parts[3], "-")
+	if len(location) == 3 {
+		// A zone.
+		location = location[:2]
+	}
+	return strings.Join(location, "-")
+}
+
+// seekTarget returns the target of the flags, or the offsets by partition
+// if -offsets is set.
+func seekTarget(at, offsets string, beginning, end bool) (pubsublite.SeekTarget, map[int]int64) {
+	n := 0
+	for _, set := range []bool{at != "", offsets != "", beginning, end} {
+		if set {
+			n++
+		}
+	}
+	if n != 1 {
+		log.Fatal("Error: exactly one of -time, -offsets, -beginning and -end must be set")
+	}
+	switch {
+	case beginning:
+		return pubsublite.Beginning, nil
+	case end:
+		return pubsublite.End, nil
+	case at != "":
+		t, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			log.Fatalf("Error: -time: %v", err)
+		}
+		return pubsublite.PublishTime(t), nil
+	}
+	byOffset := make(map[int]int64)
+	for _, kv := range strings.Split(offsets, ",") {
+		p, o, ok := strings.Cut(kv, "=")
+		partition, err := strconv.Atoi(p)
+		if !ok || err != nil {
+			log.Fatalf("Error: -offsets: invalid partition in %q", kv)
+		}
+		offset, err := strconv.ParseInt(o, 10, 64)
+		if err != nil {
+			log.Fatalf("Error: -offsets: invalid offset in %q", kv)
+		}
+		byOffset[partition] = offset
+	}
+	return nil, byOffset
+}
+
+func printBacklog(ctx context.Context, c *backlog.Client, subscription string) {
+	partitions, err := c.Backlog(ctx, subscription)
+	if err != nil {
+		log.Fatal(err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "PARTITION\tCOMMITTED\tHEAD\tMESSAGES\tBYTES\tOLDEST\t")
+	var messages, bytes int64
+	for _, p := range partitions {
+		oldest := "-"
+		if !p.Oldest.IsZero() {
+			oldest = p.Oldest.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%d\t%d\t%d\t%d\t%s\t\n", p.Partition, p.Committed, p.Head, p.Messages, p.Bytes, oldest)
+		messages += p.Messages
+		bytes += p.Bytes
+	}
+	fmt.Fprintf(w, "total\t\t\t%d\t%d\t\t\n", messages, bytes)
+	w.Flush()
+}
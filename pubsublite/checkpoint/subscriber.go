@@ -0,0 +1,426 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package checkpoint processes Pub/Sub Lite messages at least once.
+//
+// A Pub/Sub Lite subscription commits a cursor per partition: the offset of
+// the first message that hasn't been acked. A Subscriber acks a message
+// only after its handler succeeds, so the cursor never moves past a message
+// that wasn't processed, and a restarted subscriber resumes from the first
+// unprocessed message. Messages after it may be processed again, so
+// handlers must be idempotent.
+//
+// Every message received is acked or nacked, which a
+// pscompat.SubscriberClient requires before Receive returns: when the
+// subscriber shuts down, the messages it received are still handled, on a
+// context that isn't canceled, and acked if their handlers succeed.
+package checkpoint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	vkit "cloud.google.com/go/pubsublite/apiv1"
+	pb "cloud.google.com/go/pubsublite/apiv1/pubsublitepb"
+	"cloud.google.com/go/pubsublite/pscompat"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Receiver receives messages, such as a pscompat.SubscriberClient.
+type Receiver interface {
+	Receive(ctx context.Context, f func(context.Context, *pubsub.Message)) error
+}
+
+// Acker is implemented by Receivers whose messages are acked and nacked
+// by the receiver, rather than by the methods of the message, such as
+// psltest.FakeSubscriber.
+type Acker interface {
+	Ack(msg *pubsub.Message)
+	Nack(msg *pubsub.Message)
+}
+
+// Handler processes a message. The message is acked if it returns nil.
+// Its context isn't canceled when the subscriber shuts down, so that the
+// messages already received are processed: handlers should not block
+// indefinitely.
+type Handler func(ctx context.Context, msg *pubsub.Message) error
+
+// HeadFunc returns the head offset of a partition: the offset of the next
+// message to be published to it.
+type HeadFunc func(ctx context.Context, partition int) (int64, error)
+
+// TopicHead returns a HeadFunc for the partitions of topic, such as
+// projects/my-project/locations/us-central1-a/topics/my-topic.
+func TopicHead(c *vkit.TopicStatsClient, topic string) HeadFunc {
+	return func(ctx context.Context, partition int) (int64, error) {
+		resp, err := c.ComputeHeadCursor(ctx, &pb.ComputeHeadCursorRequest{Topic: topic, Partition: int64(partition)})
+		if err != nil {
+			return 0, err
+		}
+		return resp.GetHeadCursor().GetOffset(), nil
+	}
+}
+
+// Defaults of a Subscriber.
+const (
+	DefaultConcurrency = 1
+	DefaultMaxAttempts = 5
+	DefaultMinBackoff  = 100 * time.Millisecond
+	DefaultMaxBackoff  = 10 * time.Second
+	DefaultLagInterval = 30 * time.Second
+)
+
+// Subscriber receives messages from a Receiver and processes them with a
+// Handler, acking each message after the handler succeeds.
+//
+// A handler that fails is retried with exponential backoff. After
+// MaxAttempts, the message is nacked: a pscompat.SubscriberClient without a
+// NackHandler then stops, and Receive returns an error, without committing
+// the message. Restarting the subscriber retries it.
+type Subscriber struct {
+	Receiver Receiver
+
+	// Concurrency is the number of messages of each partition that are
+	// processed at once. With 1, the messages of a partition are processed
+	// in order. Default: DefaultConcurrency.
+	Concurrency int
+
+	// MaxAttempts is the number of times a message is handled before it is
+	// nacked. Default: DefaultMaxAttempts.
+	MaxAttempts int
+	// MinBackoff and MaxBackoff bound the time between attempts. Defaults:
+	// DefaultMinBackoff and DefaultMaxBackoff.
+	MinBackoff, MaxBackoff time.Duration
+
+	// Head, if set, is polled every LagInterval to compute the lag of each
+	// partition. Default LagInterval: DefaultLagInterval.
+	Head        HeadFunc
+	LagInterval time.Duration
+
+	// MeterProvider provides the meter of the subscriber's metrics.
+	// Default: otel.GetMeterProvider().
+	MeterProvider metric.MeterProvider
+
+	mu         sync.Mutex
+	partitions map[int]*partition
+}
+
+// partition is the state of a partition. Its fields are guarded by
+// Subscriber.mu.
+type partition struct {
+	sem      chan struct{}
+	inFlight map[int64]bool
+	next     int64 // the offset after the last received message
+	head     int64
+	hasHead  bool
+	acked    int64
+	nacked   int64
+}
+
+// committed returns the offset the partition's cursor will reach once the
+// messages in flight are acked: the lowest in flight, or the next.
+func (p *partition) committed() int64 {
+	c := p.next
+	for o := range p.inFlight {
+		if o < c {
+			c = o
+		}
+	}
+	return c
+}
+
+// PartitionStats are statistics of a partition since Receive started.
+type PartitionStats struct {
+	Partition int
+	// Committed is the offset of the first message that hasn't been
+	// processed, which the cursor of the subscription moves to.
+	Committed int64
+	// InFlight is the number of messages being processed.
+	InFlight int
+	// Acked and Nacked are the numbers of messages acked and nacked.
+	Acked, Nacked int64
+	// Head is the head offset of the partition, and Lag the number of
+	// messages from Committed to Head, as of the last poll of Head. They
+	// are -1 if Head hasn't been polled.
+	Head, Lag int64
+}
+
+// Stats returns the statistics of the partitions the subscriber has
+// received messages from, by partition.
+func (s *Subscriber) Stats() []PartitionStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var stats []PartitionStats
+	for n, p := range s.partitions {
+		ps := PartitionStats{
+			Partition: n,
+			Committed: p.committed(),
+			InFlight:  len(p.inFlight),
+			Acked:     p.acked,
+			Nacked:    p.nacked,
+			Head:      -1,
+			Lag:       -1,
+		}
+		if p.hasHead {
+			ps.Head = p.head
+			ps.Lag = max(0, p.head-ps.Committed)
+		}
+		stats = append(stats, ps)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Partition < stats[j].Partition })
+	return stats
+}
+
+// Receive processes messages with h until ctx is done or the receiver
+// fails. When ctx is done, the messages already received are processed
+// before Receive returns, but failed messages aren't retried: they are
+// nacked.
+func (s *Subscriber) Receive(ctx context.Context, h Handler) error {
+	if s.Receiver == nil {
+		return errors.New("checkpoint: Subscriber.Receiver is not set")
+	}
+	s.mu.Lock()
+	s.partitions = make(map[int]*partition)
+	s.mu.Unlock()
+
+	unregister, err := s.registerMetrics()
+	if err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+	defer unregister()
+
+	if s.Head != nil {
+		pollCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go s.pollHeads(pollCtx)
+	}
+
+	// Handlers finish the messages received before ctx is done.
+	hctx := context.WithoutCancel(ctx)
+	var wg sync.WaitGroup
+	err = s.Receiver.Receive(ctx, func(_ context.Context, msg *pubsub.Message) {
+		md, err := pscompat.ParseMessageMetadata(msg.ID)
+		if err != nil {
+			// Not a Pub/Sub Lite message: there's no cursor to hold back.
+			log.Printf("checkpoint: %v", err)
+			s.nack(msg)
+			return
+		}
+		p := s.partition(md.Partition)
+		// Block the partition's delivery while all its slots are taken.
+		// Slots free up as handlers return, even after ctx is done.
+		p.sem <- struct{}{}
+		s.mu.Lock()
+		p.inFlight[md.Offset] = true
+		p.next = max(p.next, md.Offset+1)
+		s.mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-p.sem }()
+			ok := s.process(ctx, hctx, h, msg)
+			s.mu.Lock()
+			delete(p.inFlight, md.Offset)
+			if ok {
+				p.acked++
+			} else {
+				p.nacked++
+			}
+			s.mu.Unlock()
+			if ok {
+				s.ack(msg)
+			} else {
+				s.nack(msg)
+			}
+		}()
+	})
+	wg.Wait()
+	return err
+}
+
+// process handles msg with hctx until the handler succeeds, MaxAttempts is
+// reached or ctx is done, and reports whether it succeeded.
+func (s *Subscriber) process(ctx, hctx context.Context, h Handler, msg *pubsub.Message) bool {
+	attempts := s.MaxAttempts
+	if attempts <= 0 {
+		attempts = DefaultMaxAttempts
+	}
+	for attempt := 1; ; attempt++ {
+		err := h(hctx, msg)
+		if err == nil {
+			return true
+		}
+		if ctx.Err() != nil {
+			log.Printf("checkpoint: message %s failed while shutting down: %v", msg.ID, err)
+			return false
+		}
+		if attempt >= attempts {
+			log.Printf("checkpoint: message %s failed after %d attempts: %v", msg.ID, attempt, err)
+			return false
+		}
+		select {
+		case <-time.After(s.backoff(attempt)):
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// backoff returns the time to wait after attempt failed: the minimum
+// backoff doubled for each attempt, up to the maximum, with jitter.
+func (s *Subscriber) backoff(attempt int) time.Duration {
+	minBackoff, maxBackoff := s.MinBackoff, s.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = DefaultMinBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+	d := minBackoff << min(attempt-1, 30)
+	if d <= 0 || d > maxBackoff {
+		d = maxBackoff
+	}
+	// Spread retries of messages that failed together.
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func (s *Subscriber) ack(msg *pubsub.Message) {
+	if a, ok := s.Receiver.(Acker); ok {
+		a.Ack(msg)
+		return
+	}
+	msg.Ack()
+}
+
+func (s *Subscriber) nack(msg *pubsub.Message) {
+	if a, ok := s.Receiver.(Acker); ok {
+		a.Nack(msg)
+		return
+	}
+	msg.Nack()
+}
+
+// partition returns the state of partition n, adding it on its first
+// message.
+func (s *Subscriber) partition(n int) *partition {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.partitions[n]
+	if !ok {
+		concurrency := s.Concurrency
+		if concurrency <= 0 {
+			concurrency = DefaultConcurrency
+		}
+		p = &partition{sem: make(chan struct{}, concurrency), inFlight: make(map[int64]bool)}
+		s.partitions[n] = p
+	}
+	return p
+}
+
+// pollHeads polls the head of the partitions every LagInterval until ctx is
+// done.
+func (s *Subscriber) pollHeads(ctx context.Context) {
+	interval := s.LagInterval
+	if interval <= 0 {
+		interval = DefaultLagInterval
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		s.mu.Lock()
+		var ns []int
+		for n := range s.partitions {
+			ns = append(ns, n)
+		}
+		s.mu.Unlock()
+		for _, n := range ns {
+			head, err := s.Head(ctx, n)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("checkpoint: head of partition %d: %v", n, err)
+				}
+				continue
+			}
+			s.mu.Lock()
+			if p, ok := s.partitions[n]; ok {
+				p.head, p.hasHead = head, true
+			}
+			s.mu.Unlock()
+		}
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// registerMetrics registers the gauges of the subscriber, which report
+// Stats, and returns a function that unregisters them.
+func (s *Subscriber) registerMetrics() (func(), error) {
+	mp := s.MeterProvider
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+	meter := mp.Meter("github.com/GoogleCloudPlatform/golang-samples/pubsublite/checkpoint")
+	lag, err := meter.Int64ObservableGauge("pubsublite.subscriber.lag",
+		metric.WithDescription("Messages from the committed offset to the head of the partition."),
+		metric.WithUnit("{message}"))
+	if err != nil {
+		return nil, err
+	}
+	inFlight, err := meter.Int64ObservableGauge("pubsublite.subscriber.in_flight",
+		metric.WithDescription("Messages of the partition being processed."),
+		metric.WithUnit("{message}"))
+	if err != nil {
+		return nil, err
+	}
+	processed, err := meter.Int64ObservableCounter("pubsublite.subscriber.processed",
+		metric.WithDescription("Messages of the partition acked or nacked, by result."),
+		metric.WithUnit("{message}"))
+	if err != nil {
+		return nil, err
+	}
+	reg, err := meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		for _, ps := range s.Stats() {
+			attr := attribute.String("partition", strconv.Itoa(ps.Partition))
+			if ps.Lag >= 0 {
+				o.ObserveInt64(lag, ps.Lag, metric.WithAttributes(attr))
+			}
+			o.ObserveInt64(inFlight, int64(ps.InFlight), metric.WithAttributes(attr))
+			o.ObserveInt64(processed, ps.Acked, metric.WithAttributes(attr, attribute.String("result", "ack")))
+			o.ObserveInt64(processed, ps.Nacked, metric.WithAttributes(attr, attribute.String("result", "nack")))
+		}
+		return nil
+	}, lag, inFlight, processed)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		if err := reg.Unregister(); err != nil {
+			log.Printf("checkpoint: %v", err)
+		}
+	}, nil
+}
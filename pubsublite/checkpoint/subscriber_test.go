@@ -0,0 +1,246 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checkpoint
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/GoogleCloudPlatform/golang-samples/pubsublite/internal/psltest"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+const (
+	topic        = "projects/p/locations/us-central1-a/topics/t"
+	subscription = "projects/p/locations/us-central1-a/subscriptions/s"
+)
+
+func newFake(t *testing.T, partitions, messages int) *psltest.Fake {
+	t.Helper()
+	f := psltest.NewFake(topic, partitions)
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < messages; i++ {
+		f.Publish(i%partitions, start.Add(time.Duration(i)*time.Second), []byte{byte(i)}, nil)
+	}
+	return f
+}
+
+// receiveUntilCommitted runs s until every message is committed.
+func receiveUntilCommitted(t *testing.T, f *psltest.Fake, s *Subscriber, h Handler) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	errc := make(chan error, 1)
+	go func() { errc <- s.Receive(ctx, h) }()
+	if err := f.WaitCommitted(ctx, subscription); err != nil {
+		t.Fatalf("WaitCommitted: %v", err)
+	}
+	cancel()
+	if err := <-errc; err != nil {
+		t.Fatalf("Receive: %v", err)
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+	f := newFake(t, 2, 40)
+	s := &Subscriber{Receiver: f.Subscriber(subscription), Concurrency: 3}
+
+	var mu sync.Mutex
+	inFlight := map[int]int{}
+	maxInFlight := map[int]int{}
+	receiveUntilCommitted(t, f, s, func(ctx context.Context, msg *pubsub.Message) error {
+		p := int(msg.Data[0]) % 2
+		mu.Lock()
+		inFlight[p]++
+		maxInFlight[p] = max(maxInFlight[p], inFlight[p])
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		inFlight[p]--
+		mu.Unlock()
+		return nil
+	})
+	for p := 0; p < 2; p++ {
+		if maxInFlight[p] < 2 || maxInFlight[p] > 3 {
+			t.Errorf("partition %d: got up to %d messages at once, want 2 or 3", p, maxInFlight[p])
+		}
+	}
+	for _, ps := range s.Stats() {
+		if ps.Acked != 20 || ps.Committed != 20 || ps.InFlight != 0 {
+			t.Errorf("got stats %+v, want 20 acked and committed", ps)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	f := newFake(t, 1, 3)
+	s := &Subscriber{Receiver: f.Subscriber(subscription), MinBackoff: time.Millisecond}
+
+	var attempts atomic.Int32
+	receiveUntilCommitted(t, f, s, func(ctx context.Context, msg *pubsub.Message) error {
+		if msg.Data[0] == 1 && attempts.Add(1) < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	if got := attempts.Load(); got != 3 {
+		t.Errorf("got %d attempts, want 3", got)
+	}
+}
+
+func TestNack(t *testing.T) {
+	f := newFake(t, 1, 5)
+	s := &Subscriber{Receiver: f.Subscriber(subscription), MaxAttempts: 2, MinBackoff: time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := s.Receive(ctx, func(ctx context.Context, msg *pubsub.Message) error {
+		if msg.Data[0] == 2 {
+			return errors.New("poison")
+		}
+		return nil
+	})
+	if !errors.Is(err, psltest.ErrNacked) {
+		t.Fatalf("Receive: got %v, want ErrNacked", err)
+	}
+	// Only the messages before the failed one are committed.
+	if got := f.Committed(subscription, 0); got != 2 {
+		t.Errorf("got committed offset %d, want 2", got)
+	}
+
+	// A restarted subscriber receives the failed message again.
+	var redelivered []byte
+	receiveUntilCommitted(t, f, s, func(ctx context.Context, msg *pubsub.Message) error {
+		redelivered = append(redelivered, msg.Data[0])
+		return nil
+	})
+	if len(redelivered) == 0 || redelivered[0] != 2 {
+		t.Errorf("got messages %v after the restart, want from 2", redelivered)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	f := newFake(t, 1, 5)
+	s := &Subscriber{Receiver: f.Subscriber(subscription)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var handlerErr error
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.Receive(ctx, func(hctx context.Context, msg *pubsub.Message) error {
+			if msg.Data[0] == 1 {
+				close(started)
+				<-release
+				handlerErr = hctx.Err()
+			}
+			return nil
+		})
+	}()
+
+	// Shut down while message 1 is being handled, and message 2 waits
+	// for its slot.
+	<-started
+	cancel()
+	close(release)
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Fatalf("Receive: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Receive didn't return after shutdown")
+	}
+	if handlerErr != nil {
+		t.Errorf("handler got context error %v, want none", handlerErr)
+	}
+	// Every message received was acked.
+	if got := f.Committed(subscription, 0); got < 2 {
+		t.Errorf("got committed offset %d, want at least 2", got)
+	}
+}
+
+func TestLag(t *testing.T) {
+	f := newFake(t, 2, 10)
+	reader := sdkmetric.NewManualReader()
+	s := &Subscriber{
+		Receiver:      f.Subscriber(subscription),
+		Head:          f.Head,
+		LagInterval:   time.Millisecond,
+		MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	release := make(chan struct{})
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.Receive(ctx, func(ctx context.Context, msg *pubsub.Message) error {
+			// Partition 1 is stuck on its first message.
+			if msg.Data[0]%2 == 1 {
+				<-release
+			}
+			return nil
+		})
+	}()
+
+	// Wait for partition 0 to catch up, and the heads to be polled.
+	lags := map[string]int64{}
+	for {
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(ctx, &rm); err != nil {
+			t.Fatal(err)
+		}
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				if m.Name != "pubsublite.subscriber.lag" {
+					continue
+				}
+				for _, dp := range m.Data.(metricdata.Gauge[int64]).DataPoints {
+					p, _ := dp.Attributes.Value("partition")
+					lags[p.AsString()] = dp.Value
+				}
+			}
+		}
+		if l, ok := lags["0"]; ok && l == 0 && len(lags) == 2 {
+			break
+		}
+		select {
+		case <-time.After(time.Millisecond):
+		case <-ctx.Done():
+			t.Fatalf("got lags %v, want partition 0 to catch up", lags)
+		}
+	}
+	if lags["1"] != 5 {
+		t.Errorf("partition 1: got lag %d, want 5", lags["1"])
+	}
+
+	close(release)
+	if err := f.WaitCommitted(ctx, subscription); err != nil {
+		t.Fatalf("WaitCommitted: %v", err)
+	}
+	cancel()
+	if err := <-errc; err != nil {
+		t.Fatalf("Receive: %v", err)
+	}
+}
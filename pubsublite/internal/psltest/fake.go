@@ -0,0 +1,287 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package psltest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsublite/pscompat"
+)
+
+// ErrNacked is returned by FakeSubscriber.Receive when a message is nacked,
+// like a pscompat.SubscriberClient without a NackHandler.
+var ErrNacked = errors.New("psltest: message nacked")
+
+// Fake is an in-memory Pub/Sub Lite topic and its subscriptions, for unit
+// tests that don't need the service.
+//
+// Like the service, each subscription has a committed cursor per partition:
+// the offset of the first message that hasn't been acked. Messages after an
+// unacked message are delivered again by the next Receive, even if they
+// were acked.
+type Fake struct {
+	// Topic is the path of the topic, such as
+	// projects/my-project/locations/us-central1-a/topics/my-topic.
+	Topic string
+
+	mu         sync.Mutex
+	changed    chan struct{} // closed and replaced on every change
+	partitions [][]fakeMessage
+	subs       map[string]*fakeSubscription
+}
+
+type fakeMessage struct {
+	data        []byte
+	attributes  map[string]string
+	publishTime time.Time
+}
+
+type fakeSubscription struct {
+	cursors []int64
+	acked   []map[int64]bool
+}
+
+// NewFake returns a fake topic with partitions partitions.
+func NewFake(topic string, partitions int) *Fake {
+	return &Fake{
+		Topic:      topic,
+		changed:    make(chan struct{}),
+		partitions: make([][]fakeMessage, partitions),
+		subs:       make(map[string]*fakeSubscription),
+	}
+}
+
+// notify wakes up the goroutines waiting for a change. f.mu must be held.
+func (f *Fake) notify() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// Partitions returns the number of partitions of the topic.
+func (f *Fake) Partitions() int {
+	return len(f.partitions)
+}
+
+// Publish appends a message to a partition, and returns its offset.
+func (f *Fake) Publish(partition int, publishTime time.Time, data []byte, attributes map[string]string) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.partitions[partition] = append(f.partitions[partition], fakeMessage{data, attributes, publishTime})
+	f.notify()
+	return int64(len(f.partitions[partition]) - 1)
+}
+
+// Head returns the offset of the next message to be published to a
+// partition. Its signature matches checkpoint.HeadFunc.
+func (f *Fake) Head(ctx context.Context, partition int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if partition < 0 || partition >= len(f.partitions) {
+		return 0, fmt.Errorf("psltest: no partition %d", partition)
+	}
+	return int64(len(f.partitions[partition])), nil
+}
+
+// AddSubscription adds a subscription at the beginning of the backlog, if
+// it doesn't exist.
+func (f *Fake) AddSubscription(subscription string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subscription(subscription, false)
+}
+
+// subscription returns a subscription, adding it at the beginning, or the
+// end if skipBacklog is set, if it doesn't exist. f.mu must be held.
+func (f *Fake) subscription(name string, skipBacklog bool) *fakeSubscription {
+	if s, ok := f.subs[name]; ok {
+		return s
+	}
+	s := &fakeSubscription{
+		cursors: make([]int64, len(f.partitions)),
+		acked:   make([]map[int64]bool, len(f.partitions)),
+	}
+	for p := range f.partitions {
+		if skipBacklog {
+			s.cursors[p] = int64(len(f.partitions[p]))
+		}
+		s.acked[p] = make(map[int64]bool)
+	}
+	f.subs[name] = s
+	return s
+}
+
+// Committed returns the committed cursor of a subscription in a partition.
+func (f *Fake) Committed(subscription string, partition int) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.subscription(subscription, false).cursors[partition]
+}
+
+// commit sets the committed cursor of a subscription in a partition.
+// f.mu must be held.
+func (f *Fake) commit(s *fakeSubscription, partition int, offset int64) {
+	s.cursors[partition] = offset
+	s.acked[partition] = make(map[int64]bool)
+	f.notify()
+}
+
+// WaitCommitted waits until a subscription has committed every message of
+// the topic.
+func (f *Fake) WaitCommitted(ctx context.Context, subscription string) error {
+	for {
+		f.mu.Lock()
+		s := f.subscription(subscription, false)
+		done := true
+		for p, msgs := range f.partitions {
+			if s.cursors[p] < int64(len(msgs)) {
+				done = false
+			}
+		}
+		changed := f.changed
+		f.mu.Unlock()
+		if done {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Subscriber returns a subscriber client of a subscription, adding it if
+// it doesn't exist.
+func (f *Fake) Subscriber(subscription string) *FakeSubscriber {
+	f.AddSubscription(subscription)
+	return &FakeSubscriber{fake: f, subscription: subscription}
+}
+
+// FakeSubscriber receives the messages of a subscription of a Fake, like a
+// pscompat.SubscriberClient. Messages must be acked and nacked with its Ack
+// and Nack methods, rather than the methods of the message.
+type FakeSubscriber struct {
+	fake         *Fake
+	subscription string
+
+	mu     sync.Mutex
+	nacked bool
+	cancel context.CancelFunc
+	// outstanding counts the messages delivered but not acked or nacked.
+	outstanding sync.WaitGroup
+}
+
+// Receive calls fn with the messages of the subscription, from its
+// committed cursors, until ctx is done or a message is nacked. Like
+// pscompat.SubscriberClient.Receive, it calls fn concurrently for different
+// partitions, one message at a time per partition, and only returns once
+// every message it delivered has been acked or nacked.
+func (s *FakeSubscriber) Receive(ctx context.Context, fn func(context.Context, *pubsub.Message)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s.mu.Lock()
+	s.nacked = false
+	s.cancel = cancel
+	s.mu.Unlock()
+
+	f := s.fake
+	f.mu.Lock()
+	next := append([]int64(nil), f.subscription(s.subscription, false).cursors...)
+	f.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for p := range next {
+		wg.Add(1)
+		go func(p int, offset int64) {
+			defer wg.Done()
+			for {
+				f.mu.Lock()
+				msgs := f.partitions[p]
+				changed := f.changed
+				f.mu.Unlock()
+				if offset >= int64(len(msgs)) {
+					select {
+					case <-changed:
+						continue
+					case <-ctx.Done():
+						return
+					}
+				}
+				if ctx.Err() != nil {
+					return
+				}
+				m := msgs[offset]
+				s.outstanding.Add(1)
+				fn(ctx, &pubsub.Message{
+					ID:          (&pscompat.MessageMetadata{Partition: p, Offset: offset}).String(),
+					Data:        m.data,
+					Attributes:  m.attributes,
+					PublishTime: m.publishTime,
+				})
+				offset++
+			}
+		}(p, next[p])
+	}
+	wg.Wait()
+	s.outstanding.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.nacked {
+		return ErrNacked
+	}
+	return nil
+}
+
+// Ack acks a message, and advances the committed cursor of its partition
+// past all the messages acked in order.
+func (s *FakeSubscriber) Ack(msg *pubsub.Message) {
+	md, err := pscompat.ParseMessageMetadata(msg.ID)
+	if err != nil {
+		panic(err)
+	}
+	defer s.outstanding.Done()
+	f := s.fake
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sub := f.subscription(s.subscription, false)
+	if md.Offset < sub.cursors[md.Partition] {
+		// Already committed.
+		return
+	}
+	sub.acked[md.Partition][md.Offset] = true
+	for sub.acked[md.Partition][sub.cursors[md.Partition]] {
+		delete(sub.acked[md.Partition], sub.cursors[md.Partition])
+		sub.cursors[md.Partition]++
+	}
+	f.notify()
+}
+
+// Nack stops Receive, which returns ErrNacked. The message isn't committed,
+// so the next Receive delivers it again.
+func (s *FakeSubscriber) Nack(msg *pubsub.Message) {
+	defer s.outstanding.Done()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nacked = true
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
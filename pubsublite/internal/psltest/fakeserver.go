@@ -0,0 +1,269 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package psltest
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/longrunning/autogen/longrunningpb"
+	pb "cloud.google.com/go/pubsublite/apiv1/pubsublitepb"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Serve serves the admin, cursor and topic stats APIs of f on a local port
+// until the end of the test, and returns the options of clients that
+// connect to it, such as pubsublite.NewAdminClient.
+//
+// The admin API supports getting the topic and its subscriptions, creating
+// subscriptions and seeking them. Event times are publish times.
+func (f *Fake) Serve(t *testing.T) []option.ClientOption {
+	t.Helper()
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	pb.RegisterAdminServiceServer(s, &fakeAdmin{f: f})
+	pb.RegisterCursorServiceServer(s, &fakeCursors{f: f})
+	pb.RegisterTopicStatsServiceServer(s, &fakeTopicStats{f: f})
+	go s.Serve(l)
+	t.Cleanup(s.Stop)
+
+	return []option.ClientOption{
+		option.WithEndpoint(l.Addr().String()),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	}
+}
+
+// lookup returns a subscription, or a NotFound error. f.mu must be held.
+func (f *Fake) lookup(name string) (*fakeSubscription, error) {
+	s, ok := f.subs[name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "subscription %s not found", name)
+	}
+	return s, nil
+}
+
+// timeCursor returns the offset of the first message of a partition
+// published at or after t, or the head if there is none. f.mu must be held.
+func (f *Fake) timeCursor(partition int, t time.Time) int64 {
+	for i, m := range f.partitions[partition] {
+		if !m.publishTime.Before(t) {
+			return int64(i)
+		}
+	}
+	return int64(len(f.partitions[partition]))
+}
+
+type fakeAdmin struct {
+	pb.UnimplementedAdminServiceServer
+	f *Fake
+}
+
+func (a *fakeAdmin) subscriptionProto(name string) *pb.Subscription {
+	return &pb.Subscription{
+		Name:  name,
+		Topic: a.f.Topic,
+		DeliveryConfig: &pb.Subscription_DeliveryConfig{
+			DeliveryRequirement: pb.Subscription_DeliveryConfig_DELIVER_IMMEDIATELY,
+		},
+	}
+}
+
+func (a *fakeAdmin) GetTopicPartitions(ctx context.Context, req *pb.GetTopicPartitionsRequest) (*pb.TopicPartitions, error) {
+	if req.GetName() != a.f.Topic {
+		return nil, status.Errorf(codes.NotFound, "topic %s not found", req.GetName())
+	}
+	return &pb.TopicPartitions{PartitionCount: int64(a.f.Partitions())}, nil
+}
+
+func (a *fakeAdmin) GetSubscription(ctx context.Context, req *pb.GetSubscriptionRequest) (*pb.Subscription, error) {
+	a.f.mu.Lock()
+	defer a.f.mu.Unlock()
+	if _, err := a.f.lookup(req.GetName()); err != nil {
+		return nil, err
+	}
+	return a.subscriptionProto(req.GetName()), nil
+}
+
+func (a *fakeAdmin) CreateSubscription(ctx context.Context, req *pb.CreateSubscriptionRequest) (*pb.Subscription, error) {
+	if req.GetSubscription().GetTopic() != a.f.Topic {
+		return nil, status.Errorf(codes.NotFound, "topic %s not found", req.GetSubscription().GetTopic())
+	}
+	name := req.GetParent() + "/subscriptions/" + req.GetSubscriptionId()
+	a.f.mu.Lock()
+	defer a.f.mu.Unlock()
+	if _, ok := a.f.subs[name]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "subscription %s already exists", name)
+	}
+	a.f.subscription(name, req.GetSkipBacklog())
+	return a.subscriptionProto(name), nil
+}
+
+func (a *fakeAdmin) SeekSubscription(ctx context.Context, req *pb.SeekSubscriptionRequest) (*longrunningpb.Operation, error) {
+	a.f.mu.Lock()
+	defer a.f.mu.Unlock()
+	s, err := a.f.lookup(req.GetName())
+	if err != nil {
+		return nil, err
+	}
+	for p := range a.f.partitions {
+		var offset int64
+		switch {
+		case req.GetNamedTarget() == pb.SeekSubscriptionRequest_HEAD:
+			// The head is past the last message: pubsublite.End.
+			offset = int64(len(a.f.partitions[p]))
+		case req.GetNamedTarget() == pb.SeekSubscriptionRequest_TAIL:
+			offset = 0
+		case req.GetTimeTarget().GetPublishTime() != nil:
+			offset = a.f.timeCursor(p, req.GetTimeTarget().GetPublishTime().AsTime())
+		case req.GetTimeTarget().GetEventTime() != nil:
+			offset = a.f.timeCursor(p, req.GetTimeTarget().GetEventTime().AsTime())
+		default:
+			return nil, status.Error(codes.InvalidArgument, "no seek target")
+		}
+		a.f.commit(s, p, offset)
+	}
+
+	// The seek is done at once.
+	resp, err := anypb.New(&pb.SeekSubscriptionResponse{})
+	if err != nil {
+		return nil, err
+	}
+	md, err := anypb.New(&pb.OperationMetadata{Target: req.GetName(), Verb: "seek", CreateTime: timestamppb.Now(), EndTime: timestamppb.Now()})
+	if err != nil {
+		return nil, err
+	}
+	return &longrunningpb.Operation{
+		Name:     strings.Replace(req.GetName(), "/subscriptions/", "/operations/seek-", 1),
+		Done:     true,
+		Metadata: md,
+		Result:   &longrunningpb.Operation_Response{Response: resp},
+	}, nil
+}
+
+type fakeCursors struct {
+	pb.UnimplementedCursorServiceServer
+	f *Fake
+}
+
+func (c *fakeCursors) CommitCursor(ctx context.Context, req *pb.CommitCursorRequest) (*pb.CommitCursorResponse, error) {
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+	s, err := c.f.lookup(req.GetSubscription())
+	if err != nil {
+		return nil, err
+	}
+	if p := req.GetPartition(); p < 0 || p >= int64(len(c.f.partitions)) {
+		return nil, status.Errorf(codes.InvalidArgument, "no partition %d", p)
+	}
+	c.f.commit(s, int(req.GetPartition()), req.GetCursor().GetOffset())
+	return &pb.CommitCursorResponse{}, nil
+}
+
+func (c *fakeCursors) ListPartitionCursors(ctx context.Context, req *pb.ListPartitionCursorsRequest) (*pb.ListPartitionCursorsResponse, error) {
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+	s, err := c.f.lookup(req.GetParent())
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.ListPartitionCursorsResponse{}
+	for p, offset := range s.cursors {
+		resp.PartitionCursors = append(resp.PartitionCursors, &pb.PartitionCursor{
+			Partition: int64(p),
+			Cursor:    &pb.Cursor{Offset: offset},
+		})
+	}
+	return resp, nil
+}
+
+type fakeTopicStats struct {
+	pb.UnimplementedTopicStatsServiceServer
+	f *Fake
+}
+
+// partition checks the topic and partition of a request. f.mu must be
+// held.
+func (s *fakeTopicStats) partition(topic string, partition int64) ([]fakeMessage, error) {
+	if topic != s.f.Topic {
+		return nil, status.Errorf(codes.NotFound, "topic %s not found", topic)
+	}
+	if partition < 0 || partition >= int64(len(s.f.partitions)) {
+		return nil, status.Errorf(codes.InvalidArgument, "no partition %d", partition)
+	}
+	return s.f.partitions[partition], nil
+}
+
+func (s *fakeTopicStats) ComputeHeadCursor(ctx context.Context, req *pb.ComputeHeadCursorRequest) (*pb.ComputeHeadCursorResponse, error) {
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+	msgs, err := s.partition(req.GetTopic(), req.GetPartition())
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ComputeHeadCursorResponse{HeadCursor: &pb.Cursor{Offset: int64(len(msgs))}}, nil
+}
+
+func (s *fakeTopicStats) ComputeMessageStats(ctx context.Context, req *pb.ComputeMessageStatsRequest) (*pb.ComputeMessageStatsResponse, error) {
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+	msgs, err := s.partition(req.GetTopic(), req.GetPartition())
+	if err != nil {
+		return nil, err
+	}
+	start, end := req.GetStartCursor().GetOffset(), int64(len(msgs))
+	if req.GetEndCursor() != nil && req.GetEndCursor().GetOffset() < end {
+		end = req.GetEndCursor().GetOffset()
+	}
+	resp := &pb.ComputeMessageStatsResponse{}
+	for _, m := range msgs[min(start, end):end] {
+		resp.MessageCount++
+		resp.MessageBytes += int64(len(m.data))
+		if resp.MinimumPublishTime == nil || m.publishTime.Before(resp.MinimumPublishTime.AsTime()) {
+			resp.MinimumPublishTime = timestamppb.New(m.publishTime)
+		}
+	}
+	return resp, nil
+}
+
+func (s *fakeTopicStats) ComputeTimeCursor(ctx context.Context, req *pb.ComputeTimeCursorRequest) (*pb.ComputeTimeCursorResponse, error) {
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+	if _, err := s.partition(req.GetTopic(), req.GetPartition()); err != nil {
+		return nil, err
+	}
+	t := req.GetTarget().GetPublishTime()
+	if t == nil {
+		t = req.GetTarget().GetEventTime()
+	}
+	offset := s.f.timeCursor(int(req.GetPartition()), t.AsTime())
+	if offset == int64(len(s.f.partitions[req.GetPartition()])) {
+		// No message was published at or after t.
+		return &pb.ComputeTimeCursorResponse{}, nil
+	}
+	return &pb.ComputeTimeCursorResponse{Cursor: &pb.Cursor{Offset: offset}}, nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The optimizetours command plans the routes of vehicles delivering
+// shipments, from CSV or GeoJSON files.
+//
+//	optimizetours -project my-project -shipments shipments.csv -vehicles vehicles.csv
+//	optimizetours -project my-project -shipments shipments.geojson -vehicles vehicles.geojson -geojson routes.geojson
+//
+// It prints the itinerary of each vehicle and the shipments that were
+// skipped, and why. With -geojson, it also writes the routes and visits to
+// a GeoJSON file that map tools such as geojson.io can show. See package
+// tours for the columns of the files.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	routeoptimization "cloud.google.com/go/maps/routeoptimization/apiv1"
+	"github.com/GoogleCloudPlatform/golang-samples/routeoptimization/tours"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func main() {
+	projectID := flag.String("project", os.Getenv("GOOGLE_CLOUD_PROJECT"), "Google Cloud project ID.")
+	shipments := flag.String("shipments", "", "CSV or GeoJSON file of shipments.")
+	vehicles := flag.String("vehicles", "", "CSV or GeoJSON file of vehicles.")
+	geojson := flag.String("geojson", "", "GeoJSON file to write the routes to.")
+	timeout := flag.Duration("timeout", time.Minute, "Time limit of the optimization.")
+	flag.Parse()
+	if *projectID == "" {
+		log.Fatal("Error: -project or GOOGLE_CLOUD_PROJECT must be set")
+	}
+	if *shipments == "" || *vehicles == "" {
+		log.Fatal("Error: -shipments and -vehicles must be set")
+	}
+
+	model, err := tours.LoadModel(*shipments, *vehicles)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Leave the API time to return the best solution it found.
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout+30*time.Second)
+	defer cancel()
+	c, err := routeoptimization.NewClient(ctx)
+	if err != nil {
+		log.Fatalf("routeoptimization.NewClient: %v", err)
+	}
+	defer c.Close()
+
+	req := tours.Request(*projectID, model)
+	req.Timeout = durationpb.New(*timeout)
+	resp, err := c.OptimizeTours(ctx, req)
+	if err != nil {
+		log.Fatalf("OptimizeTours: %v", err)
+	}
+
+	if err := tours.WriteItinerary(os.Stdout, model, resp); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println()
+	if err := tours.WriteSkipped(os.Stdout, model, resp); err != nil {
+		log.Fatal(err)
+	}
+	if *geojson != "" {
+		b, err := tours.RoutesGeoJSON(model, resp)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(*geojson, b, 0o644); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("\nWrote routes to %s\n", *geojson)
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tours
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	rpb "cloud.google.com/go/maps/routeoptimization/apiv1/routeoptimizationpb"
+	"google.golang.org/genproto/googleapis/type/latlng"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// outFeature is a GeoJSON feature of the rendered solution.
+type outFeature struct {
+	Type       string         `json:"type"`
+	Geometry   outGeometry    `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+type outGeometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+// RoutesGeoJSON renders the routes of a solution of model as a GeoJSON
+// FeatureCollection: a LineString per vehicle route, following its
+// polyline if the response has one and joining its stops otherwise, and a
+// Point per visit.
+func RoutesGeoJSON(model *rpb.ShipmentModel, resp *rpb.OptimizeToursResponse) ([]byte, error) {
+	features := []outFeature{}
+	for _, route := range resp.GetRoutes() {
+		if len(route.GetVisits()) == 0 {
+			continue
+		}
+		label := vehicleLabel(model, route.GetVehicleIndex())
+		var line [][2]float64
+		if encoded := route.GetRoutePolyline().GetPoints(); encoded != "" {
+			points, err := DecodePolyline(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("RoutesGeoJSON: route of %s: %w", label, err)
+			}
+			for _, p := range points {
+				line = append(line, position(p))
+			}
+		} else {
+			for _, s := range stops(model, route) {
+				if s.location != nil {
+					line = append(line, position(s.location))
+				}
+			}
+		}
+		features = append(features, outFeature{
+			Type:     "Feature",
+			Geometry: outGeometry{Type: "LineString", Coordinates: line},
+			Properties: map[string]any{
+				"vehicle":         label,
+				"vehicle_index":   route.GetVehicleIndex(),
+				"shipments":       route.GetMetrics().GetPerformedShipmentCount(),
+				"distance_meters": route.GetMetrics().GetTravelDistanceMeters(),
+				"duration":        route.GetMetrics().GetTotalDuration().AsDuration().String(),
+				"cost":            route.GetRouteTotalCost(),
+			},
+		})
+		for i, visit := range route.GetVisits() {
+			loc := visitRequest(model, visit).GetArrivalLocation()
+			if loc == nil {
+				continue
+			}
+			features = append(features, outFeature{
+				Type:     "Feature",
+				Geometry: outGeometry{Type: "Point", Coordinates: position(loc)},
+				Properties: map[string]any{
+					"vehicle":  label,
+					"stop":     i + 1,
+					"shipment": shipmentLabel(model, visit.GetShipmentIndex()),
+					"type":     visitType(visit),
+					"arrival":  formatTime(visit.GetStartTime()),
+				},
+			})
+		}
+	}
+	return json.MarshalIndent(struct {
+		Type     string       `json:"type"`
+		Features []outFeature `json:"features"`
+	}{"FeatureCollection", features}, "", "  ")
+}
+
+// WriteItinerary writes a table of the stops of each vehicle of a solution
+// of model to w.
+func WriteItinerary(w io.Writer, model *rpb.ShipmentModel, resp *rpb.OptimizeToursResponse) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, route := range resp.GetRoutes() {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		label := vehicleLabel(model, route.GetVehicleIndex())
+		if len(route.GetVisits()) == 0 {
+			fmt.Fprintf(tw, "Vehicle %s: unused\n", label)
+			continue
+		}
+		m := route.GetMetrics()
+		fmt.Fprintf(tw, "Vehicle %s: %d shipments, %.1f km, %v, cost %.2f\n",
+			label, m.GetPerformedShipmentCount(), m.GetTravelDistanceMeters()/1000,
+			m.GetTotalDuration().AsDuration(), route.GetRouteTotalCost())
+		fmt.Fprintln(tw, "STOP\tTIME\tVISIT\tSHIPMENT\tLOCATION")
+		for j, s := range stops(model, route) {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", j, formatTime(s.time), s.kind, s.shipment, formatLocation(s.location))
+		}
+	}
+	return tw.Flush()
+}
+
+// WriteSkipped writes a table of the shipments a solution of model skips,
+// and why, to w.
+func WriteSkipped(w io.Writer, model *rpb.ShipmentModel, resp *rpb.OptimizeToursResponse) error {
+	if len(resp.GetSkippedShipments()) == 0 {
+		_, err := fmt.Fprintln(w, "No skipped shipments.")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SHIPMENT\tREASON\tEXAMPLE VEHICLE")
+	for _, s := range resp.GetSkippedShipments() {
+		label := s.GetLabel()
+		if label == "" {
+			label = shipmentLabel(model, s.GetIndex())
+		}
+		reasons := s.GetReasons()
+		if len(reasons) == 0 {
+			// The API sets no reason when it can't explain the skip.
+			fmt.Fprintf(tw, "%s\t%s\t\n", label, "unknown")
+			continue
+		}
+		for _, r := range reasons {
+			example := ""
+			if r.ExampleVehicleIndex != nil {
+				example = vehicleLabel(model, r.GetExampleVehicleIndex())
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", label, reason(r), example)
+		}
+	}
+	return tw.Flush()
+}
+
+var reasons = map[rpb.SkippedShipment_Reason_Code]string{
+	rpb.SkippedShipment_Reason_NO_VEHICLE:                                               "no vehicle",
+	rpb.SkippedShipment_Reason_DEMAND_EXCEEDS_VEHICLE_CAPACITY:                          "demand exceeds vehicle capacity",
+	rpb.SkippedShipment_Reason_CANNOT_BE_PERFORMED_WITHIN_VEHICLE_DISTANCE_LIMIT:        "beyond vehicle distance limit",
+	rpb.SkippedShipment_Reason_CANNOT_BE_PERFORMED_WITHIN_VEHICLE_DURATION_LIMIT:        "beyond vehicle duration limit",
+	rpb.SkippedShipment_Reason_CANNOT_BE_PERFORMED_WITHIN_VEHICLE_TRAVEL_DURATION_LIMIT: "beyond vehicle travel duration limit",
+	rpb.SkippedShipment_Reason_CANNOT_BE_PERFORMED_WITHIN_VEHICLE_TIME_WINDOWS:          "outside vehicle time windows",
+	rpb.SkippedShipment_Reason_VEHICLE_NOT_ALLOWED:                                      "vehicle not allowed",
+}
+
+func reason(r *rpb.SkippedShipment_Reason) string {
+	s, ok := reasons[r.GetCode()]
+	if !ok {
+		s = r.GetCode().String()
+	}
+	if t := r.GetExampleExceededCapacityType(); t != "" {
+		s += " (" + t + ")"
+	}
+	return s
+}
+
+// stop is a row of an itinerary.
+type stop struct {
+	time     *timestamppb.Timestamp
+	kind     string
+	shipment string
+	location *latlng.LatLng
+}
+
+// stops returns the start, visits and end of a route.
+func stops(model *rpb.ShipmentModel, route *rpb.ShipmentRoute) []stop {
+	var v *rpb.Vehicle
+	if i := int(route.GetVehicleIndex()); i < len(model.GetVehicles()) {
+		v = model.GetVehicles()[i]
+	}
+	s := []stop{{time: route.GetVehicleStartTime(), kind: "start", location: v.GetStartLocation()}}
+	for _, visit := range route.GetVisits() {
+		s = append(s, stop{
+			time:     visit.GetStartTime(),
+			kind:     visitType(visit),
+			shipment: shipmentLabel(model, visit.GetShipmentIndex()),
+			location: visitRequest(model, visit).GetArrivalLocation(),
+		})
+	}
+	return append(s, stop{time: route.GetVehicleEndTime(), kind: "end", location: v.GetEndLocation()})
+}
+
+func visitType(visit *rpb.ShipmentRoute_Visit) string {
+	if visit.GetIsPickup() {
+		return "pickup"
+	}
+	return "delivery"
+}
+
+// visitRequest returns the pickup or delivery request of visit, or nil if
+// it isn't in model.
+func visitRequest(model *rpb.ShipmentModel, visit *rpb.ShipmentRoute_Visit) *rpb.Shipment_VisitRequest {
+	i := int(visit.GetShipmentIndex())
+	if i >= len(model.GetShipments()) {
+		return nil
+	}
+	requests := model.GetShipments()[i].GetDeliveries()
+	if visit.GetIsPickup() {
+		requests = model.GetShipments()[i].GetPickups()
+	}
+	if j := int(visit.GetVisitRequestIndex()); j < len(requests) {
+		return requests[j]
+	}
+	return nil
+}
+
+// vehicleLabel returns the label of a vehicle, or its index if it has none.
+func vehicleLabel(model *rpb.ShipmentModel, i int32) string {
+	if int(i) < len(model.GetVehicles()) && model.GetVehicles()[i].GetLabel() != "" {
+		return model.GetVehicles()[i].GetLabel()
+	}
+	return fmt.Sprintf("#%d", i)
+}
+
+// shipmentLabel returns the label of a shipment, or its index if it has
+// none.
+func shipmentLabel(model *rpb.ShipmentModel, i int32) string {
+	if int(i) < len(model.GetShipments()) && model.GetShipments()[i].GetLabel() != "" {
+		return model.GetShipments()[i].GetLabel()
+	}
+	return fmt.Sprintf("#%d", i)
+}
+
+func formatTime(ts *timestamppb.Timestamp) string {
+	if ts == nil {
+		return "-"
+	}
+	return ts.AsTime().UTC().Format(time.RFC3339)
+}
+
+func formatLocation(ll *latlng.LatLng) string {
+	if ll == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.5f,%.5f", ll.GetLatitude(), ll.GetLongitude())
+}
+
+// position returns a GeoJSON position, longitude first.
+func position(ll *latlng.LatLng) [2]float64 {
+	return [2]float64{ll.GetLongitude(), ll.GetLatitude()}
+}
+
+// DecodePolyline decodes a polyline in the
+// [encoded polyline format] of route polylines.
+//
+// [encoded polyline format]: https://developers.google.com/maps/documentation/utilities/polylinealgorithm
+func DecodePolyline(encoded string) ([]*latlng.LatLng, error) {
+	var points []*latlng.LatLng
+	var lat, lng int64
+	for i := 0; i < len(encoded); {
+		var deltas [2]int64
+		for j := range deltas {
+			var result int64
+			var shift uint
+			for {
+				if i >= len(encoded) {
+					return nil, fmt.Errorf("DecodePolyline: truncated polyline")
+				}
+				b := int64(encoded[i]) - 63
+				i++
+				if b < 0 || b > 63 {
+					return nil, fmt.Errorf("DecodePolyline: invalid character %q", encoded[i-1])
+				}
+				result |= (b & 0x1f) << shift
+				shift += 5
+				if b < 0x20 {
+					break
+				}
+			}
+			if result&1 != 0 {
+				deltas[j] = ^(result >> 1)
+			} else {
+				deltas[j] = result >> 1
+			}
+		}
+		lat += deltas[0]
+		lng += deltas[1]
+		points = append(points, &latlng.LatLng{Latitude: float64(lat) / 1e5, Longitude: float64(lng) / 1e5})
+	}
+	return points, nil
+}
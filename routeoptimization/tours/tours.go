@@ -0,0 +1,553 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tours builds Route Optimization requests from shipments and
+// vehicles in CSV or GeoJSON files, and renders the solutions.
+//
+// In CSV files, each row is a shipment or a vehicle, with the columns
+// below. In GeoJSON files, each feature is a shipment or a vehicle, with
+// the same names as properties, except for the locations, which are the
+// geometry: a Point for the delivery of a shipment or the start and end of
+// a vehicle, or a MultiPoint for the pickup and delivery of a shipment or
+// the start and end of a vehicle. Loads and capacities are objects in the
+// "loads" and "capacities" properties.
+//
+// Shipment columns:
+//
+//	label
+//	pickup_lat, pickup_lng            pickup location, if any
+//	pickup_start, pickup_end          pickup time window, RFC 3339
+//	pickup_duration                   time spent at the pickup, such as 5m
+//	delivery_lat, delivery_lng        delivery location, if any
+//	delivery_start, delivery_end      delivery time window, RFC 3339
+//	delivery_duration                 time spent at the delivery
+//	penalty_cost                      cost of skipping the shipment
+//	load:TYPE                         amount of a load type, such as load:weight_kg
+//
+// Vehicle columns:
+//
+//	label
+//	start_lat, start_lng              start location, if fixed
+//	end_lat, end_lng                  end location, if fixed
+//	shift_start, shift_end            earliest start and latest end, RFC 3339
+//	cost_per_km, cost_per_hour, fixed_cost
+//	capacity:TYPE                     maximum load of a load type
+//
+// A shipment must have a pickup or a delivery location.
+package tours
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	rpb "cloud.google.com/go/maps/routeoptimization/apiv1/routeoptimizationpb"
+	"google.golang.org/genproto/googleapis/type/latlng"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Format is the format of a shipments or vehicles file.
+type Format int
+
+// Formats of shipments and vehicles files.
+const (
+	CSV Format = iota
+	GeoJSON
+)
+
+// FormatOf returns the format of a file from its extension: .csv, or
+// .geojson or .json.
+func FormatOf(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return CSV, nil
+	case ".geojson", ".json":
+		return GeoJSON, nil
+	}
+	return 0, fmt.Errorf("tours: unknown format of %s: want .csv, .geojson or .json", path)
+}
+
+// Columns of the shipments and vehicles files, other than loads and
+// capacities.
+var (
+	shipmentColumns = []string{
+		"label",
+		"pickup_lat", "pickup_lng", "pickup_start", "pickup_end", "pickup_duration",
+		"delivery_lat", "delivery_lng", "delivery_start", "delivery_end", "delivery_duration",
+		"penalty_cost",
+	}
+	vehicleColumns = []string{
+		"label",
+		"start_lat", "start_lng", "end_lat", "end_lng",
+		"shift_start", "shift_end",
+		"cost_per_km", "cost_per_hour", "fixed_cost",
+	}
+)
+
+// Prefixes of load and capacity columns.
+const (
+	loadPrefix     = "load:"
+	capacityPrefix = "capacity:"
+)
+
+// record is a row of a CSV file or the properties of a GeoJSON feature,
+// with the geometry as lat and lng columns.
+type record struct {
+	where  string // for errors, such as "on line 2"
+	fields map[string]string
+}
+
+// ReadShipments reads shipments from r.
+func ReadShipments(r io.Reader, f Format) ([]*rpb.Shipment, error) {
+	records, err := readRecords(r, f, []string{"pickup", "delivery"}, "loads", loadPrefix)
+	if err != nil {
+		return nil, err
+	}
+	var shipments []*rpb.Shipment
+	for _, rec := range records {
+		s, err := rec.shipment()
+		if err != nil {
+			return nil, fmt.Errorf("tours: shipment %s: %w", rec.where, err)
+		}
+		shipments = append(shipments, s)
+	}
+	return shipments, nil
+}
+
+// ReadVehicles reads vehicles from r.
+func ReadVehicles(r io.Reader, f Format) ([]*rpb.Vehicle, error) {
+	records, err := readRecords(r, f, []string{"start", "end"}, "capacities", capacityPrefix)
+	if err != nil {
+		return nil, err
+	}
+	var vehicles []*rpb.Vehicle
+	for _, rec := range records {
+		v, err := rec.vehicle()
+		if err != nil {
+			return nil, fmt.Errorf("tours: vehicle %s: %w", rec.where, err)
+		}
+		vehicles = append(vehicles, v)
+	}
+	return vehicles, nil
+}
+
+// LoadModel reads shipments and vehicles from files, in the formats of
+// their extensions, and returns a model of them.
+func LoadModel(shipmentsPath, vehiclesPath string) (*rpb.ShipmentModel, error) {
+	var shipments []*rpb.Shipment
+	var vehicles []*rpb.Vehicle
+	err := readFile(shipmentsPath, func(r io.Reader, f Format) (err error) {
+		shipments, err = ReadShipments(r, f)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = readFile(vehiclesPath, func(r io.Reader, f Format) (err error) {
+		vehicles, err = ReadVehicles(r, f)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewModel(shipments, vehicles), nil
+}
+
+func readFile(path string, read func(io.Reader, Format) error) error {
+	f, err := FormatOf(path)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := read(file, f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// NewModel returns a model of shipments and vehicles. Its global start and
+// end times span the days of all their time windows, as the API rejects
+// times outside them; without time windows, the API's defaults apply.
+func NewModel(shipments []*rpb.Shipment, vehicles []*rpb.Vehicle) *rpb.ShipmentModel {
+	m := &rpb.ShipmentModel{Shipments: shipments, Vehicles: vehicles}
+	var windows []*rpb.TimeWindow
+	for _, s := range shipments {
+		for _, vr := range append(append([]*rpb.Shipment_VisitRequest{}, s.Pickups...), s.Deliveries...) {
+			windows = append(windows, vr.TimeWindows...)
+		}
+	}
+	for _, v := range vehicles {
+		windows = append(windows, v.StartTimeWindows...)
+		windows = append(windows, v.EndTimeWindows...)
+	}
+	var start, end time.Time
+	for _, w := range windows {
+		for _, ts := range []*timestamppb.Timestamp{w.StartTime, w.EndTime} {
+			if ts == nil {
+				continue
+			}
+			t := ts.AsTime()
+			if start.IsZero() || t.Before(start) {
+				start = t
+			}
+			if end.IsZero() || t.After(end) {
+				end = t
+			}
+		}
+	}
+	if !start.IsZero() {
+		// Leave room for visits before the first and after the last time
+		// in the files, such as vehicles without shift times.
+		m.GlobalStartTime = timestamppb.New(start.Truncate(24 * time.Hour))
+		m.GlobalEndTime = timestamppb.New(end.Truncate(24 * time.Hour).Add(24 * time.Hour))
+	}
+	return m
+}
+
+// Request returns a request to optimize the tours of model, with
+// polylines of the routes.
+func Request(projectID string, model *rpb.ShipmentModel) *rpb.OptimizeToursRequest {
+	return &rpb.OptimizeToursRequest{
+		Parent:            "projects/" + projectID,
+		Model:             model,
+		PopulatePolylines: true,
+	}
+}
+
+func readRecords(r io.Reader, f Format, points []string, group, prefix string) ([]record, error) {
+	if f == GeoJSON {
+		return readGeoJSON(r, points, group, prefix)
+	}
+	return readCSV(r)
+}
+
+func readCSV(r io.Reader) ([]record, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("tours: no header row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tours: %w", err)
+	}
+	var records []record
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("tours: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		rec := record{where: fmt.Sprintf("on line %d", line), fields: make(map[string]string)}
+		for i, v := range row {
+			if v = strings.TrimSpace(v); v != "" {
+				rec.fields[strings.TrimSpace(header[i])] = v
+			}
+		}
+		records = append(records, rec)
+	}
+}
+
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+type feature struct {
+	Type       string         `json:"type"`
+	Geometry   geometry       `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+type geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// readGeoJSON reads the features of a FeatureCollection. points names the
+// locations of a MultiPoint; a Point is the second location of shipments
+// (the delivery) and both locations of vehicles.
+func readGeoJSON(r io.Reader, points []string, group, prefix string) ([]record, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var fc featureCollection
+	if err := dec.Decode(&fc); err != nil {
+		return nil, fmt.Errorf("tours: %w", err)
+	}
+	if fc.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("tours: got GeoJSON %q, want FeatureCollection", fc.Type)
+	}
+	var records []record
+	for i, f := range fc.Features {
+		rec := record{where: fmt.Sprintf("feature %d", i), fields: make(map[string]string)}
+		if err := rec.setGeometry(f.Geometry, points); err != nil {
+			return nil, fmt.Errorf("tours: %s: %w", rec.where, err)
+		}
+		for k, v := range f.Properties {
+			if k == group {
+				m, ok := v.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("tours: %s: %q is not an object", rec.where, k)
+				}
+				for typ, amount := range m {
+					rec.fields[prefix+typ] = fmt.Sprint(amount)
+				}
+				continue
+			}
+			if v != nil {
+				rec.fields[k] = fmt.Sprint(v)
+			}
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func (rec *record) setGeometry(g geometry, points []string) error {
+	var coords [][]float64
+	switch g.Type {
+	case "Point":
+		var c []float64
+		if err := json.Unmarshal(g.Coordinates, &c); err != nil {
+			return err
+		}
+		if points[0] == "pickup" {
+			// A shipment with only a delivery.
+			coords = [][]float64{nil, c}
+		} else {
+			coords = [][]float64{c, c}
+		}
+	case "MultiPoint":
+		if err := json.Unmarshal(g.Coordinates, &coords); err != nil {
+			return err
+		}
+		if len(coords) != 2 {
+			return fmt.Errorf("got a MultiPoint of %d points, want 2", len(coords))
+		}
+	case "":
+		return nil
+	default:
+		return fmt.Errorf("got a %s geometry, want Point or MultiPoint", g.Type)
+	}
+	for i, c := range coords {
+		if c == nil {
+			continue
+		}
+		if len(c) < 2 {
+			return fmt.Errorf("bad coordinates %v", c)
+		}
+		// GeoJSON positions are longitude first.
+		rec.fields[points[i]+"_lng"] = strconv.FormatFloat(c[0], 'f', -1, 64)
+		rec.fields[points[i]+"_lat"] = strconv.FormatFloat(c[1], 'f', -1, 64)
+	}
+	return nil
+}
+
+// check reports unknown fields, such as misspelled columns.
+func (rec *record) check(columns []string, prefix string) error {
+	known := make(map[string]bool)
+	for _, c := range columns {
+		known[c] = true
+	}
+	var unknown []string
+	for k := range rec.fields {
+		if !known[k] && !strings.HasPrefix(k, prefix) {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown fields %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func (rec *record) shipment() (*rpb.Shipment, error) {
+	if err := rec.check(shipmentColumns, loadPrefix); err != nil {
+		return nil, err
+	}
+	p := parser{rec: rec}
+	s := &rpb.Shipment{Label: rec.fields["label"]}
+	if pickup := p.visit("pickup"); pickup != nil {
+		s.Pickups = []*rpb.Shipment_VisitRequest{pickup}
+	}
+	if delivery := p.visit("delivery"); delivery != nil {
+		s.Deliveries = []*rpb.Shipment_VisitRequest{delivery}
+	}
+	if _, ok := rec.fields["penalty_cost"]; ok {
+		cost := p.float("penalty_cost")
+		s.PenaltyCost = &cost
+	}
+	for k := range rec.fields {
+		if typ, ok := strings.CutPrefix(k, loadPrefix); ok {
+			if s.LoadDemands == nil {
+				s.LoadDemands = make(map[string]*rpb.Shipment_Load)
+			}
+			s.LoadDemands[typ] = &rpb.Shipment_Load{Amount: p.int(k)}
+		}
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	if s.Pickups == nil && s.Deliveries == nil {
+		return nil, errors.New("no pickup or delivery location")
+	}
+	return s, nil
+}
+
+func (rec *record) vehicle() (*rpb.Vehicle, error) {
+	if err := rec.check(vehicleColumns, capacityPrefix); err != nil {
+		return nil, err
+	}
+	p := parser{rec: rec}
+	v := &rpb.Vehicle{
+		Label:            rec.fields["label"],
+		StartLocation:    p.location("start"),
+		EndLocation:      p.location("end"),
+		CostPerKilometer: p.float("cost_per_km"),
+		CostPerHour:      p.float("cost_per_hour"),
+		FixedCost:        p.float("fixed_cost"),
+	}
+	if t := p.time("shift_start"); t != nil {
+		v.StartTimeWindows = []*rpb.TimeWindow{{StartTime: t}}
+	}
+	if t := p.time("shift_end"); t != nil {
+		v.EndTimeWindows = []*rpb.TimeWindow{{EndTime: t}}
+	}
+	for k := range rec.fields {
+		if typ, ok := strings.CutPrefix(k, capacityPrefix); ok {
+			if v.LoadLimits == nil {
+				v.LoadLimits = make(map[string]*rpb.Vehicle_LoadLimit)
+			}
+			maxLoad := p.int(k)
+			v.LoadLimits[typ] = &rpb.Vehicle_LoadLimit{MaxLoad: &maxLoad}
+		}
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return v, nil
+}
+
+// parser parses the fields of a record, keeping the first error.
+type parser struct {
+	rec *record
+	err error
+}
+
+func (p *parser) fail(field, v string, err error) {
+	if p.err == nil {
+		p.err = fmt.Errorf("%s %q: %w", field, v, err)
+	}
+}
+
+func (p *parser) float(field string) float64 {
+	v, ok := p.rec.fields[field]
+	if !ok {
+		return 0
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		p.fail(field, v, err)
+	}
+	return f
+}
+
+func (p *parser) int(field string) int64 {
+	v, ok := p.rec.fields[field]
+	if !ok {
+		return 0
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		p.fail(field, v, err)
+	}
+	return n
+}
+
+func (p *parser) time(field string) *timestamppb.Timestamp {
+	v, ok := p.rec.fields[field]
+	if !ok {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		p.fail(field, v, err)
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func (p *parser) duration(field string) *durationpb.Duration {
+	v, ok := p.rec.fields[field]
+	if !ok {
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		p.fail(field, v, err)
+		return nil
+	}
+	return durationpb.New(d)
+}
+
+// location returns the location in the name_lat and name_lng fields, or
+// nil if they are unset.
+func (p *parser) location(name string) *latlng.LatLng {
+	_, hasLat := p.rec.fields[name+"_lat"]
+	_, hasLng := p.rec.fields[name+"_lng"]
+	if !hasLat && !hasLng {
+		return nil
+	}
+	if !hasLat || !hasLng {
+		p.fail(name+"_lat/"+name+"_lng", "", errors.New("both must be set"))
+		return nil
+	}
+	return &latlng.LatLng{Latitude: p.float(name + "_lat"), Longitude: p.float(name + "_lng")}
+}
+
+// visit returns the pickup or delivery visit request, or nil if it has no
+// location.
+func (p *parser) visit(name string) *rpb.Shipment_VisitRequest {
+	loc := p.location(name)
+	if loc == nil {
+		return nil
+	}
+	vr := &rpb.Shipment_VisitRequest{
+		ArrivalLocation: loc,
+		Duration:        p.duration(name + "_duration"),
+	}
+	start, end := p.time(name+"_start"), p.time(name+"_end")
+	if start != nil || end != nil {
+		vr.TimeWindows = []*rpb.TimeWindow{{StartTime: start, EndTime: end}}
+	}
+	return vr
+}
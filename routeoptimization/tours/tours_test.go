@@ -0,0 +1,329 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tours_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	routeoptimization "cloud.google.com/go/maps/routeoptimization/apiv1"
+	rpb "cloud.google.com/go/maps/routeoptimization/apiv1/routeoptimizationpb"
+	"github.com/GoogleCloudPlatform/golang-samples/routeoptimization/tours"
+	"google.golang.org/api/option"
+	"google.golang.org/genproto/googleapis/type/latlng"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var update = flag.Bool("update", false, "Update the golden files in testdata.")
+
+func TestLoadModel(t *testing.T) {
+	fromCSV, err := tours.LoadModel("testdata/shipments.csv", "testdata/vehicles.csv")
+	if err != nil {
+		t.Fatalf("LoadModel(CSV): %v", err)
+	}
+	fromGeoJSON, err := tours.LoadModel("testdata/shipments.geojson", "testdata/vehicles.geojson")
+	if err != nil {
+		t.Fatalf("LoadModel(GeoJSON): %v", err)
+	}
+	if !proto.Equal(fromCSV, fromGeoJSON) {
+		t.Errorf("CSV and GeoJSON models differ:\n%v\n%v", fromCSV, fromGeoJSON)
+	}
+
+	if got := len(fromCSV.GetShipments()); got != 3 {
+		t.Fatalf("got %d shipments, want 3", got)
+	}
+	bakery := fromCSV.GetShipments()[0]
+	if got, want := bakery.GetPickups()[0].GetArrivalLocation(), (&latlng.LatLng{Latitude: 48.86471, Longitude: 2.34901}); !proto.Equal(got, want) {
+		t.Errorf("bakery pickup: got %v, want %v", got, want)
+	}
+	if got, want := bakery.GetDeliveries()[0].GetDuration().AsDuration(), 10*time.Minute; got != want {
+		t.Errorf("bakery delivery duration: got %v, want %v", got, want)
+	}
+	if got := bakery.GetLoadDemands()["weight_kg"].GetAmount(); got != 20 {
+		t.Errorf("bakery load: got %d, want 20", got)
+	}
+	if got := bakery.GetPenaltyCost(); got != 100 {
+		t.Errorf("bakery penalty cost: got %v, want 100", got)
+	}
+	if florist := fromCSV.GetShipments()[1]; len(florist.GetPickups()) != 0 || len(florist.GetDeliveries()) != 1 {
+		t.Errorf("florist: got %d pickups and %d deliveries, want 0 and 1", len(florist.GetPickups()), len(florist.GetDeliveries()))
+	}
+	if piano := fromCSV.GetShipments()[2]; piano.PenaltyCost != nil {
+		t.Errorf("piano: got penalty cost %v, want none (mandatory)", piano.GetPenaltyCost())
+	}
+
+	van := fromCSV.GetVehicles()[0]
+	if got := van.GetLoadLimits()["weight_kg"].GetMaxLoad(); got != 100 {
+		t.Errorf("van capacity: got %d, want 100", got)
+	}
+	if van.GetCostPerKilometer() != 0.5 || van.GetCostPerHour() != 30 || van.GetFixedCost() != 10 {
+		t.Errorf("van costs: got %v/km, %v/h, %v fixed; want 0.5, 30, 10", van.GetCostPerKilometer(), van.GetCostPerHour(), van.GetFixedCost())
+	}
+	if got, want := van.GetEndTimeWindows()[0].GetEndTime().AsTime(), time.Date(2026, 1, 5, 18, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("van shift end: got %v, want %v", got, want)
+	}
+
+	day := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	if got := fromCSV.GetGlobalStartTime().AsTime(); !got.Equal(day) {
+		t.Errorf("global start time: got %v, want %v", got, day)
+	}
+	if got, want := fromCSV.GetGlobalEndTime().AsTime(), day.Add(24*time.Hour); !got.Equal(want) {
+		t.Errorf("global end time: got %v, want %v", got, want)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+		want string
+	}{
+		{"unknown column", "label,delivery_lat,delivery_lng,wieght\na,1,2,3\n", "unknown fields wieght"},
+		{"no location", "label,load:weight_kg\na,3\n", "no pickup or delivery location"},
+		{"half location", "label,delivery_lat\na,1\n", "both must be set"},
+		{"bad time", "delivery_lat,delivery_lng,delivery_start\n1,2,9am\n", `delivery_start "9am"`},
+		{"bad load", "delivery_lat,delivery_lng,load:weight_kg\n1,2,heavy\n", `load:weight_kg "heavy"`},
+	}
+	for _, tc := range tests {
+		_, err := tours.ReadShipments(strings.NewReader(tc.csv), tours.CSV)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: got error %v, want %q", tc.name, err, tc.want)
+		}
+	}
+	_, err := tours.ReadVehicles(strings.NewReader(`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"LineString","coordinates":[]}}]}`), tours.GeoJSON)
+	if err == nil || !strings.Contains(err.Error(), "LineString") {
+		t.Errorf("ReadVehicles with a LineString: got error %v", err)
+	}
+}
+
+// fakeServer is a RouteOptimization server that returns a canned
+// response.
+type fakeServer struct {
+	rpb.UnimplementedRouteOptimizationServer
+	req  *rpb.OptimizeToursRequest
+	resp *rpb.OptimizeToursResponse
+}
+
+func (s *fakeServer) OptimizeTours(ctx context.Context, req *rpb.OptimizeToursRequest) (*rpb.OptimizeToursResponse, error) {
+	s.req = req
+	return s.resp, nil
+}
+
+func newClient(t *testing.T, srv rpb.RouteOptimizationServer) *routeoptimization.Client {
+	t.Helper()
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := grpc.NewServer()
+	rpb.RegisterRouteOptimizationServer(gs, srv)
+	go gs.Serve(l)
+	t.Cleanup(gs.Stop)
+
+	c, err := routeoptimization.NewClient(context.Background(),
+		option.WithEndpoint(l.Addr().String()),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestOptimizeTours(t *testing.T) {
+	ctx := context.Background()
+	model, err := tours.LoadModel("testdata/shipments.csv", "testdata/vehicles.csv")
+	if err != nil {
+		t.Fatalf("LoadModel: %v", err)
+	}
+
+	at := func(hour, min int) *timestamppb.Timestamp {
+		return timestamppb.New(time.Date(2026, 1, 5, hour, min, 0, 0, time.UTC))
+	}
+	var van int32 // The index of the only vehicle.
+	srv := &fakeServer{resp: &rpb.OptimizeToursResponse{
+		Routes: []*rpb.ShipmentRoute{{
+			VehicleIndex:     0,
+			VehicleLabel:     "van",
+			VehicleStartTime: at(8, 0),
+			VehicleEndTime:   at(10, 15),
+			Visits: []*rpb.ShipmentRoute_Visit{
+				{ShipmentIndex: 0, IsPickup: true, StartTime: at(8, 10)},
+				{ShipmentIndex: 0, StartTime: at(9, 0)},
+				{ShipmentIndex: 1, StartTime: at(10, 0)},
+			},
+			RoutePolyline: &rpb.ShipmentRoute_EncodedPolyline{Points: encodePolyline(
+				[2]float64{48.86310, 2.34120}, [2]float64{48.86471, 2.34901}, [2]float64{48.88094, 2.32386},
+				[2]float64{48.84656, 2.37212}, [2]float64{48.86310, 2.34120},
+			)},
+			Metrics: &rpb.AggregatedMetrics{
+				PerformedShipmentCount: 2,
+				TravelDistanceMeters:   14250,
+				TotalDuration:          durationpb.New(2*time.Hour + 15*time.Minute),
+			},
+			RouteTotalCost: 84.63,
+		}},
+		SkippedShipments: []*rpb.SkippedShipment{{
+			Index: 2,
+			Label: "piano",
+			Reasons: []*rpb.SkippedShipment_Reason{{
+				Code:                        rpb.SkippedShipment_Reason_DEMAND_EXCEEDS_VEHICLE_CAPACITY,
+				ExampleVehicleIndex:         &van,
+				ExampleExceededCapacityType: "weight_kg",
+			}},
+		}},
+	}}
+	c := newClient(t, srv)
+
+	resp, err := c.OptimizeTours(ctx, tours.Request("my-project", model))
+	if err != nil {
+		t.Fatalf("OptimizeTours: %v", err)
+	}
+	if got := srv.req.GetParent(); got != "projects/my-project" {
+		t.Errorf("parent: got %q, want projects/my-project", got)
+	}
+	if !srv.req.GetPopulatePolylines() {
+		t.Errorf("request doesn't populate polylines")
+	}
+	if !proto.Equal(srv.req.GetModel(), model) {
+		t.Errorf("server got model\n%v\nwant\n%v", srv.req.GetModel(), model)
+	}
+
+	var itinerary, skipped bytes.Buffer
+	if err := tours.WriteItinerary(&itinerary, model, resp); err != nil {
+		t.Fatalf("WriteItinerary: %v", err)
+	}
+	if err := tours.WriteSkipped(&skipped, model, resp); err != nil {
+		t.Fatalf("WriteSkipped: %v", err)
+	}
+	routes, err := tours.RoutesGeoJSON(model, resp)
+	if err != nil {
+		t.Fatalf("RoutesGeoJSON: %v", err)
+	}
+	checkGolden(t, "itinerary.txt", itinerary.Bytes())
+	checkGolden(t, "skipped.txt", skipped.Bytes())
+	checkGolden(t, "routes.geojson", routes)
+}
+
+// checkGolden compares got to the golden file testdata/golden/name.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s: got\n%s\nwant\n%s", name, got, want)
+	}
+}
+
+func TestRoutesWithoutPolyline(t *testing.T) {
+	model, err := tours.LoadModel("testdata/shipments.geojson", "testdata/vehicles.geojson")
+	if err != nil {
+		t.Fatalf("LoadModel: %v", err)
+	}
+	resp := &rpb.OptimizeToursResponse{Routes: []*rpb.ShipmentRoute{
+		{VehicleIndex: 0, Visits: []*rpb.ShipmentRoute_Visit{{ShipmentIndex: 1}}},
+	}}
+	b, err := tours.RoutesGeoJSON(model, resp)
+	if err != nil {
+		t.Fatalf("RoutesGeoJSON: %v", err)
+	}
+	// Without a polyline, the route joins the start, the florist and the
+	// end of the van.
+	want := `"coordinates": [
+          [
+            2.3412,
+            48.8631
+          ],
+          [
+            2.37212,
+            48.84656
+          ],
+          [
+            2.3412,
+            48.8631
+          ]
+        ]`
+	if !strings.Contains(string(b), want) {
+		t.Errorf("RoutesGeoJSON = %s, want a LineString with %s", b, want)
+	}
+}
+
+func TestDecodePolyline(t *testing.T) {
+	// The example of the encoded polyline format documentation.
+	got, err := tours.DecodePolyline("_p~iF~ps|U_ulLnnqC_mqNvxq`@")
+	if err != nil {
+		t.Fatalf("DecodePolyline: %v", err)
+	}
+	want := [][2]float64{{38.5, -120.2}, {40.7, -120.95}, {43.252, -126.453}}
+	if len(got) != len(want) {
+		t.Fatalf("got %d points, want %d", len(got), len(want))
+	}
+	for i, p := range got {
+		if math.Abs(p.GetLatitude()-want[i][0]) > 1e-9 || math.Abs(p.GetLongitude()-want[i][1]) > 1e-9 {
+			t.Errorf("point %d: got %v, want %v", i, p, want[i])
+		}
+	}
+	if _, err := tours.DecodePolyline("_p~iF~ps|"); err == nil {
+		t.Errorf("DecodePolyline of a truncated polyline succeeded")
+	}
+}
+
+// encodePolyline encodes lat, lng points in the encoded polyline format.
+func encodePolyline(points ...[2]float64) string {
+	var b strings.Builder
+	var prev [2]int64
+	for _, p := range points {
+		for i, v := range p {
+			n := int64(math.Round(v * 1e5))
+			d := n - prev[i]
+			prev[i] = n
+			d <<= 1
+			if d < 0 {
+				d = ^d
+			}
+			for d >= 0x20 {
+				b.WriteByte(byte((0x20 | (d & 0x1f)) + 63))
+				d >>= 5
+			}
+			b.WriteByte(byte(d + 63))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,297 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secretmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/googleapis/gax-go/v2"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/api/option"
+)
+
+// ErrDataCorruption is returned when the CRC32C checksum of an accessed
+// payload does not match the checksum reported by Secret Manager.
+var ErrDataCorruption = errors.New("data corruption detected")
+
+// SecretAccessor is the subset of the Secret Manager client used by
+// SecretCache. *secretmanager.Client satisfies it.
+type SecretAccessor interface {
+	AccessSecretVersion(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error)
+}
+
+// invalidatingEvents are the Secret Manager notification event types after
+// which cached payloads for the secret may be stale.
+var invalidatingEvents = map[string]bool{
+	"SECRET_VERSION_ADD":     true,
+	"SECRET_ROTATE":          true,
+	"SECRET_VERSION_ENABLE":  true,
+	"SECRET_VERSION_DISABLE": true,
+	"SECRET_VERSION_DESTROY": true,
+	"SECRET_DELETE":          true,
+}
+
+// aliasEvent is the notification event type sent when a secret's metadata,
+// including its version aliases, is updated. Moving an alias changes the
+// payload of the cached "versions/<alias>" entries, but of no others.
+const aliasEvent = "SECRET_UPDATE"
+
+// fetchTimeout bounds an API call shared by the callers of Access.
+const fetchTimeout = time.Minute
+
+type cacheEntry struct {
+	data    []byte
+	version string
+	expires time.Time
+}
+
+// SecretCache serves secret payloads from memory so that hot paths do not
+// call Secret Manager on every request. Entries expire after the configured
+// TTL, concurrent misses for the same name share a single API call, and every
+// payload is checked against its CRC32C checksum before it is cached.
+//
+// Names may refer to global secrets
+// ("projects/p/secrets/s/versions/latest") or regional secrets
+// ("projects/p/locations/l/secrets/s/versions/my-alias"). A SecretCache is
+// safe for concurrent use.
+type SecretCache struct {
+	client SecretAccessor
+	ttl    time.Duration
+	now    func() time.Time
+
+	group singleflight.Group
+
+	mu      sync.RWMutex
+	entries map[string]cacheEntry
+	// generations counts the invalidations of each secret: fetches that
+	// started before an invalidation don't cache their result.
+	generations map[string]uint64
+	// inflight counts the fetches in progress for each name.
+	inflight map[string]int
+}
+
+// NewSecretCache returns a SecretCache that accesses secrets through client
+// and keeps each payload for ttl.
+func NewSecretCache(client SecretAccessor, ttl time.Duration) *SecretCache {
+	return &SecretCache{
+		client:      client,
+		ttl:         ttl,
+		now:         time.Now,
+		entries:     make(map[string]cacheEntry),
+		generations: make(map[string]uint64),
+		inflight:    make(map[string]int),
+	}
+}
+
+// NewRegionalClient creates a Secret Manager client that talks to the
+// regional endpoint for locationID. Regional secrets can only be accessed
+// through their regional endpoint.
+func NewRegionalClient(ctx context.Context, locationID string, opts ...option.ClientOption) (*secretmanager.Client, error) {
+	endpoint := fmt.Sprintf("secretmanager.%s.rep.googleapis.com:443", locationID)
+	opts = append([]option.ClientOption{option.WithEndpoint(endpoint)}, opts...)
+	client, err := secretmanager.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create regional secretmanager client: %w", err)
+	}
+	return client, nil
+}
+
+// Access returns the payload of the named secret version, e.g.
+// "projects/my-project/secrets/my-secret/versions/latest". Cached payloads are
+// returned without calling the API until they expire or are invalidated.
+//
+// Concurrent callers share one API call, which isn't canceled with the
+// context of any of them. Each caller still returns when its own context is
+// done.
+//
+// The returned slice is shared with the cache and must not be modified.
+func (c *SecretCache) Access(ctx context.Context, name string) ([]byte, error) {
+	if e, ok := c.lookup(name); ok {
+		return e.data, nil
+	}
+
+	ch := c.group.DoChan(name, func() (interface{}, error) {
+		// Another caller may have filled the entry while we were waiting.
+		if e, ok := c.lookup(name); ok {
+			return e, nil
+		}
+		// The fetch is shared by every caller waiting for name, so it
+		// doesn't stop when the first one gives up.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fetchTimeout)
+		defer cancel()
+		return c.fetch(ctx, name)
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(cacheEntry).data, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// ResolvedVersion returns the full resource name of the version that backs
+// the cached entry for name, for example the numbered version that "latest"
+// pointed to when it was fetched. It reports false if name is not cached.
+func (c *SecretCache) ResolvedVersion(name string) (string, bool) {
+	e, ok := c.lookup(name)
+	return e.version, ok
+}
+
+func (c *SecretCache) lookup(name string) (cacheEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	e, ok := c.entries[name]
+	if !ok || !c.now().Before(e.expires) {
+		return cacheEntry{}, false
+	}
+	return e, true
+}
+
+// fetch accesses name and caches its payload, unless the secret was
+// invalidated during the call.
+func (c *SecretCache) fetch(ctx context.Context, name string) (cacheEntry, error) {
+	secret := secretOf(name)
+	c.mu.Lock()
+	gen := c.generations[secret]
+	c.inflight[name]++
+	c.mu.Unlock()
+
+	e, err := c.access(ctx, name)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.inflight[name]--; c.inflight[name] == 0 {
+		delete(c.inflight, name)
+	}
+	if err != nil {
+		return cacheEntry{}, err
+	}
+	if c.generations[secret] == gen {
+		c.entries[name] = e
+	}
+	return e, nil
+}
+
+func (c *SecretCache) access(ctx context.Context, name string) (cacheEntry, error) {
+	result, err := c.client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{
+		Name: name,
+	})
+	if err != nil {
+		return cacheEntry{}, fmt.Errorf("failed to access secret version %q: %w", name, err)
+	}
+
+	// Verify the data checksum.
+	crc32c := crc32.MakeTable(crc32.Castagnoli)
+	checksum := int64(crc32.Checksum(result.GetPayload().GetData(), crc32c))
+	if result.GetPayload().DataCrc32C == nil || checksum != result.GetPayload().GetDataCrc32C() {
+		return cacheEntry{}, fmt.Errorf("%s: %w", name, ErrDataCorruption)
+	}
+
+	return cacheEntry{
+		data:    result.GetPayload().GetData(),
+		version: result.GetName(),
+		expires: c.now().Add(c.ttl),
+	}, nil
+}
+
+// Invalidate drops every cached version of the given secret, e.g.
+// "projects/my-project/secrets/my-secret". It returns the number of entries
+// that were removed. Fetches of the secret in progress are not cached, and
+// later calls to Access don't wait for them.
+func (c *SecretCache) Invalidate(secret string) int {
+	return c.invalidate(secret, func(string) bool { return true })
+}
+
+// invalidateAliases drops the cached versions of secret that are named by
+// an alias, rather than by number or as "latest".
+func (c *SecretCache) invalidateAliases(secret string) int {
+	return c.invalidate(secret, isAlias)
+}
+
+// invalidate drops the cached versions of secret whose version ID matches.
+func (c *SecretCache) invalidate(secret string, match func(version string) bool) int {
+	secret = strings.TrimSuffix(secret, "/")
+	prefix := secret + "/versions/"
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generations[secret]++
+	for name := range c.inflight {
+		if v, ok := strings.CutPrefix(name, prefix); ok && match(v) {
+			c.group.Forget(name)
+		}
+	}
+	n := 0
+	for name := range c.entries {
+		if v, ok := strings.CutPrefix(name, prefix); ok && match(v) {
+			delete(c.entries, name)
+			n++
+		}
+	}
+	return n
+}
+
+// secretOf returns the secret of a version name.
+func secretOf(name string) string {
+	if i := strings.LastIndex(name, "/versions/"); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// isAlias reports whether version is an alias, rather than a version number
+// or "latest".
+func isAlias(version string) bool {
+	if version == "latest" {
+		return false
+	}
+	_, err := strconv.ParseUint(version, 10, 64)
+	return err != nil
+}
+
+// HandleEventNotification invalidates cached payloads for the secret named in
+// a Secret Manager Pub/Sub notification. It can be used as the body of a
+// Cloud Run or Cloud Functions Pub/Sub handler, alongside
+// ConsumeEventNotification. SECRET_UPDATE, which is sent when version aliases
+// move, only invalidates the versions accessed by alias. Events that cannot
+// change a secret's payload are ignored.
+func (c *SecretCache) HandleEventNotification(ctx context.Context, m PubSubMessage) error {
+	if m.Attributes.SecretId == "" {
+		return errors.New("notification is missing the secretId attribute")
+	}
+	if m.Attributes.EventType == aliasEvent {
+		c.invalidateAliases(m.Attributes.SecretId)
+		return nil
+	}
+	if !invalidatingEvents[m.Attributes.EventType] {
+		return nil
+	}
+	c.Invalidate(m.Attributes.SecretId)
+	return nil
+}
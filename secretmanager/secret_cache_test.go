@@ -0,0 +1,309 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secretmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/googleapis/gax-go/v2"
+)
+
+// fakeAccessor serves payloads from a map and counts API calls.
+type fakeAccessor struct {
+	mu       sync.Mutex
+	payloads map[string]string
+	corrupt  bool
+	calls    int32
+	block    chan struct{}
+}
+
+func (f *fakeAccessor) set(name, payload string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.payloads[name] = payload
+}
+
+func (f *fakeAccessor) AccessSecretVersion(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error) {
+	f.mu.Lock()
+	data, ok := f.payloads[req.GetName()]
+	f.mu.Unlock()
+	atomic.AddInt32(&f.calls, 1)
+	if f.block != nil {
+		<-f.block
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("%s not found", req.GetName())
+	}
+	checksum := int64(crc32.Checksum([]byte(data), crc32.MakeTable(crc32.Castagnoli)))
+	if f.corrupt {
+		checksum++
+	}
+	return &secretmanagerpb.AccessSecretVersionResponse{
+		Name: req.GetName() + "-resolved",
+		Payload: &secretmanagerpb.SecretPayload{
+			Data:       []byte(data),
+			DataCrc32C: &checksum,
+		},
+	}, nil
+}
+
+func TestSecretCacheTTL(t *testing.T) {
+	const name = "projects/p/secrets/s/versions/latest"
+	fake := &fakeAccessor{payloads: map[string]string{name: "v1"}}
+	c := NewSecretCache(fake, time.Minute)
+	now := time.Unix(0, 0)
+	c.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		got, err := c.Access(ctx, name)
+		if err != nil {
+			t.Fatalf("Access: %v", err)
+		}
+		if string(got) != "v1" {
+			t.Errorf("Access got %q, want %q", got, "v1")
+		}
+	}
+	if fake.calls != 1 {
+		t.Errorf("API called %d times, want 1", fake.calls)
+	}
+	if got, ok := c.ResolvedVersion(name); !ok || got != name+"-resolved" {
+		t.Errorf("ResolvedVersion = %q, %v", got, ok)
+	}
+
+	fake.set(name, "v2")
+	now = now.Add(time.Minute)
+	got, err := c.Access(ctx, name)
+	if err != nil {
+		t.Fatalf("Access: %v", err)
+	}
+	if string(got) != "v2" {
+		t.Errorf("Access after expiry got %q, want %q", got, "v2")
+	}
+	if fake.calls != 2 {
+		t.Errorf("API called %d times, want 2", fake.calls)
+	}
+}
+
+func TestSecretCacheSingleflight(t *testing.T) {
+	const name = "projects/p/secrets/s/versions/latest"
+	fake := &fakeAccessor{
+		payloads: map[string]string{name: "v1"},
+		block:    make(chan struct{}),
+	}
+	c := NewSecretCache(fake, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Access(context.Background(), name); err != nil {
+				t.Errorf("Access: %v", err)
+			}
+		}()
+	}
+	// Wait for the first call to reach the fake before releasing it.
+	for atomic.LoadInt32(&fake.calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(fake.block)
+	wg.Wait()
+
+	if fake.calls != 1 {
+		t.Errorf("API called %d times, want 1", fake.calls)
+	}
+}
+
+func TestSecretCacheCanceledCaller(t *testing.T) {
+	const name = "projects/p/secrets/s/versions/latest"
+	fake := &fakeAccessor{
+		payloads: map[string]string{name: "v1"},
+		block:    make(chan struct{}),
+	}
+	c := NewSecretCache(fake, time.Minute)
+
+	// The first caller starts the fetch, and gives up.
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err := c.Access(ctx, name)
+		first <- err
+	}()
+	for atomic.LoadInt32(&fake.calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	second := make(chan error)
+	go func() {
+		got, err := c.Access(context.Background(), name)
+		if err == nil && string(got) != "v1" {
+			err = fmt.Errorf("got %q, want %q", got, "v1")
+		}
+		second <- err
+	}()
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("Access with a canceled context got err %v, want %v", err, context.Canceled)
+	}
+
+	// The fetch goes on for the other caller.
+	close(fake.block)
+	if err := <-second; err != nil {
+		t.Errorf("Access: %v", err)
+	}
+	if fake.calls != 1 {
+		t.Errorf("API called %d times, want 1", fake.calls)
+	}
+}
+
+func TestSecretCacheInvalidateDuringFetch(t *testing.T) {
+	const (
+		secret = "projects/p/secrets/s"
+		name   = secret + "/versions/latest"
+	)
+	fake := &fakeAccessor{
+		payloads: map[string]string{name: "v1"},
+		block:    make(chan struct{}),
+	}
+	c := NewSecretCache(fake, time.Minute)
+	ctx := context.Background()
+
+	access := func(want string) chan struct{} {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			got, err := c.Access(ctx, name)
+			if err != nil || string(got) != want {
+				t.Errorf("Access got %q, %v, want %q", got, err, want)
+			}
+		}()
+		return done
+	}
+	waitCalls := func(n int32) {
+		deadline := time.Now().Add(5 * time.Second)
+		for atomic.LoadInt32(&fake.calls) < n {
+			if time.Now().After(deadline) {
+				t.Fatalf("API called %d times, want %d", fake.calls, n)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// The first fetch reads v1, and the secret changes while it's blocked.
+	first := access("v1")
+	waitCalls(1)
+	fake.set(name, "v2")
+	c.Invalidate(secret)
+
+	// A later caller doesn't join the stale fetch.
+	second := access("v2")
+	waitCalls(2)
+	close(fake.block)
+	<-first
+	<-second
+
+	got, err := c.Access(ctx, name)
+	if err != nil || string(got) != "v2" {
+		t.Errorf("Access after the fetches got %q, %v, want %q", got, err, "v2")
+	}
+	if fake.calls != 2 {
+		t.Errorf("API called %d times, want 2", fake.calls)
+	}
+}
+
+func TestSecretCacheChecksum(t *testing.T) {
+	const name = "projects/p/secrets/s/versions/1"
+	fake := &fakeAccessor{payloads: map[string]string{name: "v1"}, corrupt: true}
+	c := NewSecretCache(fake, time.Minute)
+
+	if _, err := c.Access(context.Background(), name); !errors.Is(err, ErrDataCorruption) {
+		t.Fatalf("Access got err %v, want %v", err, ErrDataCorruption)
+	}
+	if _, ok := c.ResolvedVersion(name); ok {
+		t.Errorf("corrupt payload was cached")
+	}
+}
+
+func TestSecretCacheHandleEventNotification(t *testing.T) {
+	const (
+		global   = "projects/p/secrets/s"
+		regional = "projects/p/locations/us-east1/secrets/s"
+		other    = "projects/p/secrets/other"
+	)
+	fake := &fakeAccessor{payloads: map[string]string{
+		global + "/versions/latest":       "g",
+		global + "/versions/3":            "g3",
+		regional + "/versions/production": "r",
+		regional + "/versions/2":          "r2",
+		other + "/versions/latest":        "o",
+	}}
+	c := NewSecretCache(fake, time.Hour)
+	ctx := context.Background()
+	for name := range fake.payloads {
+		if _, err := c.Access(ctx, name); err != nil {
+			t.Fatalf("Access(%q): %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		secret    string
+		eventType string
+		evicted   []string
+		kept      []string
+	}{
+		{secret: global, eventType: "SECRET_UPDATE", kept: []string{global + "/versions/latest", global + "/versions/3"}},
+		{secret: regional, eventType: "SECRET_UPDATE", evicted: []string{regional + "/versions/production"}, kept: []string{regional + "/versions/2"}},
+		{secret: global, eventType: "SECRET_VERSION_ADD", evicted: []string{global + "/versions/latest", global + "/versions/3"}},
+		{secret: regional, eventType: "SECRET_ROTATE", evicted: []string{regional + "/versions/2"}},
+	}
+	for _, tt := range tests {
+		err := c.HandleEventNotification(ctx, PubSubMessage{
+			Attributes: PubSubAttributes{SecretId: tt.secret, EventType: tt.eventType},
+		})
+		if err != nil {
+			t.Fatalf("HandleEventNotification(%s, %s): %v", tt.secret, tt.eventType, err)
+		}
+		for _, name := range tt.evicted {
+			if _, ok := c.ResolvedVersion(name); ok {
+				t.Errorf("%s: %q still cached", tt.eventType, name)
+			}
+		}
+		for _, name := range tt.kept {
+			if _, ok := c.ResolvedVersion(name); !ok {
+				t.Errorf("%s: %q was invalidated", tt.eventType, name)
+			}
+		}
+	}
+	if _, ok := c.ResolvedVersion(other + "/versions/latest"); !ok {
+		t.Errorf("unrelated secret was invalidated")
+	}
+
+	if err := c.HandleEventNotification(ctx, PubSubMessage{}); err == nil {
+		t.Errorf("HandleEventNotification with no secretId: want error")
+	}
+}
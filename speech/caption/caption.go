@@ -13,7 +13,7 @@
 // limitations under the License.
 
 // Command caption sends audio data to the Google Speech API
-// and prints its transcript.
+// and writes SRT or WebVTT captions built from its word time offsets.
 package main
 
 import (
@@ -27,35 +27,94 @@ import (
 
 	speech "cloud.google.com/go/speech/apiv1"
 	"cloud.google.com/go/speech/apiv1/speechpb"
+	"github.com/GoogleCloudPlatform/golang-samples/speech/captions"
 )
 
-const usage = `Usage: caption <audiofile>
+const usage = `Usage: caption [flags] <audiofile>
 
 Audio file must be a 16-bit signed little-endian encoded
-with a sample rate of 16000.
+with a sample rate of 16000, unless -rate is set.
 
-The path to the audio file may be a GCS URI (gs://...).`
+The path to the audio file may be a GCS URI (gs://...).
+
+Flags:
+`
+
+var (
+	format     = flag.String("format", "srt", "caption format, srt or vtt")
+	output     = flag.String("o", "", "caption file to write, or stdout if empty; with -channels, one file per channel is written, e.g. out.ch1.srt")
+	speakers   = flag.Int("speakers", 0, "label up to this many speakers using diarization")
+	channels   = flag.Int("channels", 1, "number of audio channels, each captioned separately")
+	rate       = flag.Int("rate", 16000, "sample rate of the audio in hertz")
+	lang       = flag.String("lang", "en-US", "language of the audio")
+	transcript = flag.Bool("transcript", false, "print the transcript instead of captions")
+)
 
 func main() {
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
 	flag.Parse()
-	if len(os.Args) < 2 {
-		fmt.Fprintln(os.Stderr, usage)
+	if flag.NArg() < 1 {
+		flag.Usage()
 		os.Exit(2)
 	}
+	path := flag.Arg(0)
+
+	if *transcript {
+		var runFunc func(io.Writer, string) error
+		if strings.Contains(path, "://") {
+			runFunc = recognizeGCS
+		} else {
+			runFunc = recognize
+		}
+
+		// Perform the request.
+		if err := runFunc(os.Stdout, path); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	f, err := captions.ParseFormat(*format)
+	if err != nil {
+		log.Fatal(err)
+	}
+	config := captions.RecognitionConfig(int32(*rate), *lang, int32(*speakers), int32(*channels))
+	if err := caption(os.Stdout, path, config, f, *output); err != nil {
+		log.Fatal(err)
+	}
+}
 
-	var runFunc func(io.Writer, string) error
+// caption recognizes the audio at path, a local file or a GCS URI, and
+// writes its captions to output, or to w if output is empty.
+func caption(w io.Writer, path string, config *speechpb.RecognitionConfig, f captions.Format, output string) error {
+	ctx := context.Background()
+
+	client, err := speech.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("speech.NewClient: %w", err)
+	}
+	defer client.Close()
 
-	path := os.Args[1]
+	audio := &speechpb.RecognitionAudio{}
 	if strings.Contains(path, "://") {
-		runFunc = recognizeGCS
+		audio.AudioSource = &speechpb.RecognitionAudio_Uri{Uri: path}
 	} else {
-		runFunc = recognize
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("os.ReadFile: %w", err)
+		}
+		audio.AudioSource = &speechpb.RecognitionAudio_Content{Content: data}
 	}
 
-	// Perform the request.
-	if err := runFunc(os.Stdout, os.Args[1]); err != nil {
-		log.Fatal(err)
+	resp, err := client.Recognize(ctx, &speechpb.RecognizeRequest{Config: config, Audio: audio})
+	if err != nil {
+		return fmt.Errorf("Recognize: %w", err)
 	}
+	tracks := captions.Tracks(captions.WordsFromResults(resp.GetResults()), captions.Options{})
+	return captions.WriteFiles(w, output, f, tracks)
 }
 
 // [START speech_transcribe_sync_gcs]
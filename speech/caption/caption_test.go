@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"github.com/GoogleCloudPlatform/golang-samples/internal/testutil"
+	"github.com/GoogleCloudPlatform/golang-samples/speech/captions"
 )
 
 func TestRecognize(t *testing.T) {
@@ -55,3 +56,20 @@ func TestRecognizeGCS(t *testing.T) {
 		t.Errorf("Transcript: got %q; want %q", got, want)
 	}
 }
+
+func TestCaption(t *testing.T) {
+	testutil.SystemTest(t)
+
+	var buf bytes.Buffer
+	config := captions.RecognitionConfig(16000, "en-US", 0, 1)
+	if err := caption(&buf, "gs://python-docs-samples-tests/speech/audio.raw", config, captions.WebVTT, ""); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	if !strings.HasPrefix(got, "WEBVTT\n\n1\n00:00:") {
+		t.Errorf("got %q; want a WebVTT file", got)
+	}
+	if want := "Brooklyn Bridge"; !strings.Contains(got, want) {
+		t.Errorf("Captions: got %q; want %q", got, want)
+	}
+}
@@ -13,11 +13,13 @@
 // limitations under the License.
 
 // Command captionasync sends audio data to the Google Speech API
-// and prints its transcript.
+// as a long-running operation and writes SRT or WebVTT captions built from
+// its word time offsets.
 package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"io"
 	"log"
@@ -26,29 +28,40 @@ import (
 
 	speech "cloud.google.com/go/speech/apiv1"
 	"cloud.google.com/go/speech/apiv1/speechpb"
+	"github.com/GoogleCloudPlatform/golang-samples/speech/captions"
 )
 
-const usage = `Usage: captionasync <audiofile>
+const usage = `Usage: captionasync [flags] <audiofile>
 
 Audio file must be a 16-bit signed little-endian encoded
-with a sample rate of 16000.
+with a sample rate of 16000, unless -rate is set.
 
-The path to the audio file may be a GCS URI (gs://...).`
+The path to the audio file may be a GCS URI (gs://...).
 
-func main() {
-	if len(os.Args) < 2 {
-		fmt.Fprintln(os.Stderr, usage)
-		os.Exit(2)
-	}
+Flags:
+`
 
-	var sendFunc func(io.Writer, *speech.Client, string) error
+var (
+	format     = flag.String("format", "srt", "caption format, srt or vtt")
+	output     = flag.String("o", "", "caption file to write, or stdout if empty; with -channels, one file per channel is written, e.g. out.ch1.srt")
+	speakers   = flag.Int("speakers", 0, "label up to this many speakers using diarization")
+	channels   = flag.Int("channels", 1, "number of audio channels, each captioned separately")
+	rate       = flag.Int("rate", 16000, "sample rate of the audio in hertz")
+	lang       = flag.String("lang", "en-US", "language of the audio")
+	transcript = flag.Bool("transcript", false, "print the transcript instead of captions")
+)
 
-	path := os.Args[1]
-	if strings.Contains(path, "://") {
-		sendFunc = sendGCS
-	} else {
-		sendFunc = send
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
 	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	path := flag.Arg(0)
 
 	ctx := context.Background()
 	client, err := speech.NewClient(ctx)
@@ -57,11 +70,57 @@ func main() {
 	}
 	defer client.Close()
 
-	if err := sendFunc(os.Stdout, client, os.Args[1]); err != nil {
+	if *transcript {
+		var sendFunc func(io.Writer, *speech.Client, string) error
+		if strings.Contains(path, "://") {
+			sendFunc = sendGCS
+		} else {
+			sendFunc = send
+		}
+		if err := sendFunc(os.Stdout, client, path); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	f, err := captions.ParseFormat(*format)
+	if err != nil {
+		log.Fatal(err)
+	}
+	config := captions.RecognitionConfig(int32(*rate), *lang, int32(*speakers), int32(*channels))
+	if err := caption(os.Stdout, client, path, config, f, *output); err != nil {
 		log.Fatal(err)
 	}
 }
 
+// caption recognizes the audio at path, a local file or a GCS URI, and
+// writes its captions to output, or to w if output is empty.
+func caption(w io.Writer, client *speech.Client, path string, config *speechpb.RecognitionConfig, f captions.Format, output string) error {
+	ctx := context.Background()
+
+	audio := &speechpb.RecognitionAudio{}
+	if strings.Contains(path, "://") {
+		audio.AudioSource = &speechpb.RecognitionAudio_Uri{Uri: path}
+	} else {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("os.ReadFile: %w", err)
+		}
+		audio.AudioSource = &speechpb.RecognitionAudio_Content{Content: data}
+	}
+
+	op, err := client.LongRunningRecognize(ctx, &speechpb.LongRunningRecognizeRequest{Config: config, Audio: audio})
+	if err != nil {
+		return fmt.Errorf("LongRunningRecognize: %w", err)
+	}
+	resp, err := op.Wait(ctx)
+	if err != nil {
+		return fmt.Errorf("Wait: %w", err)
+	}
+	tracks := captions.Tracks(captions.WordsFromResults(resp.GetResults()), captions.Options{})
+	return captions.WriteFiles(w, output, f, tracks)
+}
+
 // [START speech_transcribe_async]
 
 func send(w io.Writer, client *speech.Client, filename string) error {
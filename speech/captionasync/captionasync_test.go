@@ -22,6 +22,7 @@ import (
 
 	speech "cloud.google.com/go/speech/apiv1"
 	"github.com/GoogleCloudPlatform/golang-samples/internal/testutil"
+	"github.com/GoogleCloudPlatform/golang-samples/speech/captions"
 )
 
 func TestRecognize(t *testing.T) {
@@ -67,3 +68,26 @@ func TestRecognizeGCS(t *testing.T) {
 		t.Errorf("Transcript: got %q; want %q", got, want)
 	}
 }
+
+func TestCaption(t *testing.T) {
+	testutil.SystemTest(t)
+
+	ctx := context.Background()
+	client, err := speech.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	config := captions.RecognitionConfig(16000, "en-US", 0, 1)
+	if err := caption(&buf, client, "../testdata/quit.raw", config, captions.SRT, ""); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	if !strings.HasPrefix(got, "1\n00:00:") {
+		t.Errorf("got %q; want an SRT file", got)
+	}
+	if want := "quit"; !strings.Contains(strings.ToLower(got), want) {
+		t.Errorf("Captions: got %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,316 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package captions builds SRT and WebVTT captions from the word time offsets
+// returned by the Speech-to-Text API.
+//
+// Recognition must be run with EnableWordTimeOffsets. Words are grouped into
+// cues that respect a maximum line length, number of lines and duration,
+// and a new cue is started at long pauses, at the end of a sentence, and
+// whenever the speaker changes. Audio recognized with
+// EnableSeparateRecognitionPerChannel produces one track per channel.
+package captions
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cloud.google.com/go/speech/apiv1/speechpb"
+)
+
+// Word is a recognized word and its position in the audio.
+type Word struct {
+	Text       string
+	Start, End time.Duration
+	// Speaker is the diarization speaker tag, or 0 if diarization is off.
+	Speaker int32
+	// Channel is the audio channel tag, or 0 for single channel audio.
+	Channel int32
+}
+
+// Cue is a caption shown from Start to End.
+type Cue struct {
+	Start, End time.Duration
+	Speaker    int32
+	Lines      []string
+}
+
+// Text returns the lines of the cue joined by newlines.
+func (c Cue) Text() string {
+	return strings.Join(c.Lines, "\n")
+}
+
+// Track holds the cues for one audio channel.
+type Track struct {
+	Channel int32
+	Cues    []Cue
+}
+
+// Options controls how words are grouped into cues. The zero value of each
+// field selects its default.
+type Options struct {
+	// MaxLineLength is the maximum number of characters per line. A single
+	// longer word is put on a line of its own. Default 42.
+	MaxLineLength int
+	// MaxLines is the maximum number of lines per cue. Default 2.
+	MaxLines int
+	// MaxDuration is the longest a cue may be displayed. Default 7s.
+	MaxDuration time.Duration
+	// MinDuration is the shortest a cue is displayed, if the next cue does
+	// not start first. Default 1s.
+	MinDuration time.Duration
+	// MaxGap is the longest pause allowed within a cue. Default 1s.
+	MaxGap time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxLineLength <= 0 {
+		o.MaxLineLength = 42
+	}
+	if o.MaxLines <= 0 {
+		o.MaxLines = 2
+	}
+	if o.MaxDuration <= 0 {
+		o.MaxDuration = 7 * time.Second
+	}
+	if o.MinDuration <= 0 {
+		o.MinDuration = time.Second
+	}
+	if o.MaxGap <= 0 {
+		o.MaxGap = time.Second
+	}
+	return o
+}
+
+// WordsFromResults returns the words of the top alternative of each result,
+// in order.
+//
+// With speaker diarization enabled, the service repeats every word of a
+// channel, now with its speaker tag, in that channel's final result. In
+// that case only the final result is used, so each word appears once.
+func WordsFromResults(results []*speechpb.SpeechRecognitionResult) []Word {
+	byChannel := make(map[int32][]*speechpb.SpeechRecognitionResult)
+	var channels []int32
+	for _, r := range results {
+		if len(r.GetAlternatives()) == 0 {
+			continue
+		}
+		ch := r.GetChannelTag()
+		if _, ok := byChannel[ch]; !ok {
+			channels = append(channels, ch)
+		}
+		byChannel[ch] = append(byChannel[ch], r)
+	}
+
+	var words []Word
+	for _, ch := range channels {
+		rs := byChannel[ch]
+		if last := rs[len(rs)-1]; hasSpeakerTags(last) {
+			rs = rs[len(rs)-1:]
+		}
+		for _, r := range rs {
+			words = append(words, wordsOf(r.GetAlternatives()[0].GetWords(), ch)...)
+		}
+	}
+	return words
+}
+
+// WordsFromStreamingResult returns the words of the top alternative of a
+// final streaming result. Interim results return nil, as their words may
+// still change.
+func WordsFromStreamingResult(r *speechpb.StreamingRecognitionResult) []Word {
+	if !r.GetIsFinal() || len(r.GetAlternatives()) == 0 {
+		return nil
+	}
+	return wordsOf(r.GetAlternatives()[0].GetWords(), r.GetChannelTag())
+}
+
+func hasSpeakerTags(r *speechpb.SpeechRecognitionResult) bool {
+	for _, w := range r.GetAlternatives()[0].GetWords() {
+		if w.GetSpeakerTag() != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func wordsOf(infos []*speechpb.WordInfo, channel int32) []Word {
+	words := make([]Word, 0, len(infos))
+	for _, wi := range infos {
+		words = append(words, Word{
+			Text:    wi.GetWord(),
+			Start:   wi.GetStartTime().AsDuration(),
+			End:     wi.GetEndTime().AsDuration(),
+			Speaker: wi.GetSpeakerTag(),
+			Channel: channel,
+		})
+	}
+	return words
+}
+
+// Tracks groups words into cues, with one track per channel in channel
+// order.
+func Tracks(words []Word, opts Options) []Track {
+	segs := make(map[int32]*Segmenter)
+	cues := make(map[int32][]Cue)
+	for _, w := range words {
+		s, ok := segs[w.Channel]
+		if !ok {
+			s = NewSegmenter(opts)
+			segs[w.Channel] = s
+		}
+		cues[w.Channel] = append(cues[w.Channel], s.Add(w)...)
+	}
+
+	var tracks []Track
+	for ch, s := range segs {
+		tracks = append(tracks, Track{Channel: ch, Cues: append(cues[ch], s.Flush()...)})
+	}
+	sort.Slice(tracks, func(i, j int) bool { return tracks[i].Channel < tracks[j].Channel })
+	return tracks
+}
+
+// Segmenter groups a stream of words from a single channel into cues. It
+// is used directly for streaming recognition, where words arrive as results
+// become final.
+type Segmenter struct {
+	opts  Options
+	words []Word
+}
+
+// NewSegmenter returns a Segmenter using opts.
+func NewSegmenter(opts Options) *Segmenter {
+	return &Segmenter{opts: opts.withDefaults()}
+}
+
+// Add adds words and returns the cues they completed.
+func (s *Segmenter) Add(words ...Word) []Cue {
+	var done []Cue
+	for _, w := range words {
+		if strings.TrimSpace(w.Text) == "" {
+			continue
+		}
+		if len(s.words) > 0 && s.breakBefore(w) {
+			done = append(done, s.cue(w.Start))
+		}
+		s.words = append(s.words, w)
+	}
+	return done
+}
+
+// Flush returns the cue being built, if any.
+func (s *Segmenter) Flush() []Cue {
+	if len(s.words) == 0 {
+		return nil
+	}
+	return []Cue{s.cue(-1)}
+}
+
+// breakBefore reports whether w must start a new cue.
+func (s *Segmenter) breakBefore(w Word) bool {
+	first, last := s.words[0], s.words[len(s.words)-1]
+	switch {
+	case w.Speaker != last.Speaker:
+		return true
+	case w.Start-last.End > s.opts.MaxGap:
+		return true
+	case w.End-first.Start > s.opts.MaxDuration:
+		return true
+	case endsSentence(last.Text) && last.End-first.Start >= s.opts.MinDuration:
+		return true
+	}
+	texts := make([]string, 0, len(s.words)+1)
+	for _, sw := range s.words {
+		texts = append(texts, sw.Text)
+	}
+	return len(wrap(append(texts, w.Text), s.opts.MaxLineLength)) > s.opts.MaxLines
+}
+
+// cue builds a cue from the pending words and resets them. next is the
+// start of the following cue, or -1 if there is none, and limits how far
+// a short cue is extended to meet MinDuration.
+func (s *Segmenter) cue(next time.Duration) Cue {
+	texts := make([]string, 0, len(s.words))
+	for _, w := range s.words {
+		texts = append(texts, w.Text)
+	}
+	c := Cue{
+		Start:   s.words[0].Start,
+		End:     s.words[len(s.words)-1].End,
+		Speaker: s.words[0].Speaker,
+		Lines:   wrap(texts, s.opts.MaxLineLength),
+	}
+	if minEnd := c.Start + s.opts.MinDuration; c.End < minEnd {
+		c.End = minEnd
+		if next >= 0 && c.End > next {
+			c.End = next
+		}
+	}
+	s.words = s.words[:0]
+	return c
+}
+
+func endsSentence(word string) bool {
+	return strings.HasSuffix(word, ".") || strings.HasSuffix(word, "?") || strings.HasSuffix(word, "!")
+}
+
+// wrap fills lines greedily with words, up to max characters each.
+func wrap(words []string, max int) []string {
+	var lines, line []string
+	n := 0
+	for _, w := range words {
+		l := utf8.RuneCountInString(w)
+		if n > 0 && n+1+l > max {
+			lines = append(lines, strings.Join(line, " "))
+			line, n = nil, 0
+		}
+		if n > 0 {
+			n++
+		}
+		line = append(line, w)
+		n += l
+	}
+	if len(line) > 0 {
+		lines = append(lines, strings.Join(line, " "))
+	}
+	return lines
+}
+
+// RecognitionConfig returns a config for 16-bit linear PCM audio that
+// returns what captions need: word time offsets and punctuation. If
+// speakers is positive, diarization labels up to that many speakers. If
+// channels is more than 1, each channel is recognized separately.
+func RecognitionConfig(sampleRate int32, languageCode string, speakers, channels int32) *speechpb.RecognitionConfig {
+	c := &speechpb.RecognitionConfig{
+		Encoding:                   speechpb.RecognitionConfig_LINEAR16,
+		SampleRateHertz:            sampleRate,
+		LanguageCode:               languageCode,
+		EnableWordTimeOffsets:      true,
+		EnableAutomaticPunctuation: true,
+	}
+	if speakers > 0 {
+		c.DiarizationConfig = &speechpb.SpeakerDiarizationConfig{
+			EnableSpeakerDiarization: true,
+			MinSpeakerCount:          1,
+			MaxSpeakerCount:          speakers,
+		}
+	}
+	if channels > 1 {
+		c.AudioChannelCount = channels
+		c.EnableSeparateRecognitionPerChannel = true
+	}
+	return c
+}
@@ -0,0 +1,299 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package captions
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"cloud.google.com/go/speech/apiv1/speechpb"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// loadResponse reads a RecognizeResponse recorded from the API in JSON.
+func loadResponse(t *testing.T, name string) *speechpb.RecognizeResponse {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := &speechpb.RecognizeResponse{}
+	if err := protojson.Unmarshal(b, resp); err != nil {
+		t.Fatalf("protojson.Unmarshal(%s): %v", name, err)
+	}
+	return resp
+}
+
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		channels []int32
+	}{
+		{"brooklyn_bridge", []int32{0}},
+		{"diarization", []int32{0}},
+		{"multichannel", []int32{1, 2}},
+	}
+	for _, tc := range tests {
+		resp := loadResponse(t, tc.fixture)
+		tracks := Tracks(WordsFromResults(resp.GetResults()), Options{})
+		var got []int32
+		for _, tr := range tracks {
+			got = append(got, tr.Channel)
+		}
+		if !reflect.DeepEqual(got, tc.channels) {
+			t.Fatalf("%s: got channels %v, want %v", tc.fixture, got, tc.channels)
+		}
+
+		for _, tr := range tracks {
+			for _, f := range []Format{SRT, WebVTT} {
+				path := filepath.Join("testdata", tc.fixture+f.Ext())
+				if tr.Channel != 0 {
+					path = TrackPath(path, tr.Channel)
+				}
+				want, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var buf bytes.Buffer
+				if err := WriteTrack(&buf, f, tr); err != nil {
+					t.Fatalf("WriteTrack: %v", err)
+				}
+				if buf.String() != string(want) {
+					t.Errorf("%s: got\n%s\nwant\n%s", path, buf.String(), want)
+				}
+			}
+		}
+	}
+}
+
+func TestDiarizationUsesFinalResult(t *testing.T) {
+	resp := loadResponse(t, "diarization")
+	words := WordsFromResults(resp.GetResults())
+	final := resp.GetResults()[len(resp.GetResults())-1].GetAlternatives()[0].GetWords()
+	if len(words) != len(final) {
+		t.Fatalf("got %d words, want the %d of the final result", len(words), len(final))
+	}
+	speakers := make(map[int32]bool)
+	for _, w := range words {
+		speakers[w.Speaker] = true
+	}
+	if !speakers[1] || !speakers[2] || speakers[0] {
+		t.Errorf("speakers = %v, want 1 and 2", speakers)
+	}
+}
+
+// evenWords returns n words of the given text, each lasting d with no gap
+// between them.
+func evenWords(text string, n int, d time.Duration) []Word {
+	var words []Word
+	for i := 0; i < n; i++ {
+		words = append(words, Word{Text: text, Start: time.Duration(i) * d, End: time.Duration(i+1) * d})
+	}
+	return words
+}
+
+func TestSegmentLimits(t *testing.T) {
+	opts := Options{MaxLineLength: 20, MaxLines: 2, MaxDuration: 5 * time.Second}
+	tracks := Tracks(evenWords("word", 50, 200*time.Millisecond), opts)
+	if len(tracks) != 1 {
+		t.Fatalf("got %d tracks, want 1", len(tracks))
+	}
+	var n int
+	for i, c := range tracks[0].Cues {
+		if len(c.Lines) > opts.MaxLines {
+			t.Errorf("cue %d has %d lines", i, len(c.Lines))
+		}
+		for _, l := range c.Lines {
+			if utf8.RuneCountInString(l) > opts.MaxLineLength {
+				t.Errorf("cue %d line %q is too long", i, l)
+			}
+			n += len(strings.Fields(l))
+		}
+		if c.End-c.Start > opts.MaxDuration {
+			t.Errorf("cue %d lasts %v", i, c.End-c.Start)
+		}
+	}
+	if n != 50 {
+		t.Errorf("cues hold %d words, want 50", n)
+	}
+
+	// Long single words get a line of their own rather than being dropped.
+	cues := Tracks([]Word{{Text: "supercalifragilisticexpialidocious", End: time.Second}}, opts)[0].Cues
+	if len(cues) != 1 || len(cues[0].Lines) != 1 {
+		t.Errorf("long word cues = %+v", cues)
+	}
+}
+
+func TestSegmentBreaks(t *testing.T) {
+	words := []Word{
+		{Text: "one", Start: 0, End: 300 * time.Millisecond, Speaker: 1},
+		{Text: "two", Start: 400 * time.Millisecond, End: 700 * time.Millisecond, Speaker: 1},
+		// A pause longer than MaxGap.
+		{Text: "three.", Start: 3 * time.Second, End: 4200 * time.Millisecond, Speaker: 1},
+		// A sentence ended, and the cue is longer than MinDuration.
+		{Text: "Four", Start: 4300 * time.Millisecond, End: 4500 * time.Millisecond, Speaker: 1},
+		// A speaker change.
+		{Text: "five", Start: 4600 * time.Millisecond, End: 4800 * time.Millisecond, Speaker: 2},
+	}
+
+	s := NewSegmenter(Options{})
+	got := s.Add(words...)
+	got = append(got, s.Flush()...)
+	var texts []string
+	for _, c := range got {
+		texts = append(texts, c.Text())
+	}
+	if want := []string{"one two", "three.", "Four", "five"}; !reflect.DeepEqual(texts, want) {
+		t.Fatalf("cues = %q, want %q", texts, want)
+	}
+
+	// Short cues are extended to MinDuration, but never into the next cue.
+	if got[0].End != time.Second {
+		t.Errorf("cue 0 ends at %v, want 1s", got[0].End)
+	}
+	if got[2].End != 4600*time.Millisecond {
+		t.Errorf("cue 2 ends at %v, want the start of cue 3", got[2].End)
+	}
+	if got[3].Speaker != 2 || got[3].End != 5600*time.Millisecond {
+		t.Errorf("last cue = %+v", got[3])
+	}
+}
+
+func TestStreamingMatchesBatch(t *testing.T) {
+	resp := loadResponse(t, "brooklyn_bridge")
+	batch := Tracks(WordsFromResults(resp.GetResults()), Options{})[0].Cues
+
+	s := NewSegmenter(Options{})
+	var streamed []Cue
+	for _, r := range resp.GetResults() {
+		// An interim result must be ignored.
+		interim := &speechpb.StreamingRecognitionResult{Alternatives: r.GetAlternatives()}
+		streamed = append(streamed, s.Add(WordsFromStreamingResult(interim)...)...)
+
+		final := &speechpb.StreamingRecognitionResult{Alternatives: r.GetAlternatives(), IsFinal: true}
+		streamed = append(streamed, s.Add(WordsFromStreamingResult(final)...)...)
+	}
+	streamed = append(streamed, s.Flush()...)
+	if !reflect.DeepEqual(streamed, batch) {
+		t.Errorf("streamed cues differ from batch cues:\n%+v\n%+v", streamed, batch)
+	}
+}
+
+func TestWriterFormatting(t *testing.T) {
+	c := Cue{Start: 3723004 * time.Millisecond, End: 3725500 * time.Millisecond, Speaker: 3, Lines: []string{"a <b> & c", "d"}}
+
+	var srt bytes.Buffer
+	w := NewWriter(&srt, SRT)
+	if err := w.WriteCue(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteCue(c); err != nil {
+		t.Fatal(err)
+	}
+	want := "1\n01:02:03,004 --> 01:02:05,500\n[Speaker 3] a <b> & c\nd\n\n2\n"
+	if !strings.HasPrefix(srt.String(), want) {
+		t.Errorf("SRT = %q, want prefix %q", srt.String(), want)
+	}
+
+	var vtt bytes.Buffer
+	if err := WriteTrack(&vtt, WebVTT, Track{Cues: []Cue{c}}); err != nil {
+		t.Fatal(err)
+	}
+	want = "WEBVTT\n\n1\n01:02:03.004 --> 01:02:05.500\n<v Speaker 3>a &lt;b&gt; &amp; c\nd\n\n"
+	if vtt.String() != want {
+		t.Errorf("WebVTT = %q, want %q", vtt.String(), want)
+	}
+
+	var empty bytes.Buffer
+	if err := WriteTrack(&empty, WebVTT, Track{}); err != nil || empty.String() != "WEBVTT\n\n" {
+		t.Errorf("empty WebVTT = %q, %v", empty.String(), err)
+	}
+}
+
+func TestFormatHelpers(t *testing.T) {
+	for in, want := range map[string]Format{"srt": SRT, "VTT": WebVTT, "webvtt": WebVTT} {
+		if got, err := ParseFormat(in); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %v, %v", in, got, err)
+		}
+	}
+	if _, err := ParseFormat("ass"); err == nil {
+		t.Errorf("ParseFormat(ass) succeeded")
+	}
+	if got := TrackPath("out/captions.srt", 2); got != "out/captions.ch2.srt" {
+		t.Errorf("TrackPath = %q", got)
+	}
+}
+
+func TestWordsFromResultsOffsets(t *testing.T) {
+	results := []*speechpb.SpeechRecognitionResult{
+		{Alternatives: []*speechpb.SpeechRecognitionAlternative{{
+			Words: []*speechpb.WordInfo{{
+				Word:      "hello",
+				StartTime: durationpb.New(1500 * time.Millisecond),
+				EndTime:   durationpb.New(2 * time.Second),
+			}},
+		}}},
+		// Results without alternatives are skipped.
+		{},
+	}
+	got := WordsFromResults(results)
+	want := []Word{{Text: "hello", Start: 1500 * time.Millisecond, End: 2 * time.Second}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WordsFromResults = %+v, want %+v", got, want)
+	}
+}
+
+func TestRecognitionConfig(t *testing.T) {
+	c := RecognitionConfig(16000, "en-US", 0, 1)
+	if !c.GetEnableWordTimeOffsets() || c.GetDiarizationConfig() != nil || c.GetEnableSeparateRecognitionPerChannel() {
+		t.Errorf("mono config = %v", c)
+	}
+	c = RecognitionConfig(8000, "en-US", 2, 2)
+	if c.GetDiarizationConfig().GetMaxSpeakerCount() != 2 || c.GetAudioChannelCount() != 2 || !c.GetEnableSeparateRecognitionPerChannel() {
+		t.Errorf("stereo config = %v", c)
+	}
+}
+
+func TestWriteFiles(t *testing.T) {
+	tracks := []Track{
+		{Channel: 1, Cues: []Cue{{End: time.Second, Lines: []string{"left"}}}},
+		{Channel: 2, Cues: []Cue{{End: time.Second, Lines: []string{"right"}}}},
+	}
+	var buf bytes.Buffer
+	if err := WriteFiles(&buf, "", SRT, tracks); err == nil {
+		t.Errorf("WriteFiles to stdout with 2 channels succeeded")
+	}
+
+	path := filepath.Join(t.TempDir(), "out.srt")
+	if err := WriteFiles(&buf, path, SRT, tracks); err != nil {
+		t.Fatalf("WriteFiles: %v", err)
+	}
+	for ch, want := range map[int32]string{1: "left", 2: "right"} {
+		b, err := os.ReadFile(TrackPath(path, ch))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(b), want) {
+			t.Errorf("channel %d = %q, want %q", ch, b, want)
+		}
+	}
+}
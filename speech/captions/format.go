@@ -0,0 +1,188 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package captions
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Format is a caption file format.
+type Format int
+
+const (
+	// SRT is the SubRip format.
+	SRT Format = iota
+	// WebVTT is the Web Video Text Tracks format.
+	WebVTT
+)
+
+// ParseFormat parses "srt", "vtt" or "webvtt".
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "srt":
+		return SRT, nil
+	case "vtt", "webvtt":
+		return WebVTT, nil
+	}
+	return 0, fmt.Errorf("unknown caption format %q, want srt or vtt", s)
+}
+
+// Ext returns the file extension of f, including the dot.
+func (f Format) Ext() string {
+	if f == WebVTT {
+		return ".vtt"
+	}
+	return ".srt"
+}
+
+// Writer writes cues in a caption format, numbering them from 1. Cues with
+// a speaker tag are labelled with the speaker.
+type Writer struct {
+	w       io.Writer
+	format  Format
+	n       int
+	started bool
+}
+
+// NewWriter returns a Writer that writes to w.
+func NewWriter(w io.Writer, f Format) *Writer {
+	return &Writer{w: w, format: f}
+}
+
+// WriteCue writes c.
+func (w *Writer) WriteCue(c Cue) error {
+	if err := w.header(); err != nil {
+		return err
+	}
+	w.n++
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d\n", w.n)
+	sep := ','
+	if w.format == WebVTT {
+		sep = '.'
+	}
+	fmt.Fprintf(&b, "%s --> %s\n", timestamp(c.Start, sep), timestamp(c.End, sep))
+	for i, line := range c.Lines {
+		if w.format == WebVTT {
+			line = vttEscaper.Replace(line)
+		}
+		if i == 0 && c.Speaker != 0 {
+			if w.format == WebVTT {
+				line = fmt.Sprintf("<v Speaker %d>%s", c.Speaker, line)
+			} else {
+				line = fmt.Sprintf("[Speaker %d] %s", c.Speaker, line)
+			}
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	_, err := io.WriteString(w.w, b.String())
+	return err
+}
+
+// Close finishes the file. A WebVTT file without cues still needs its
+// header.
+func (w *Writer) Close() error {
+	return w.header()
+}
+
+func (w *Writer) header() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	if w.format != WebVTT {
+		return nil
+	}
+	_, err := io.WriteString(w.w, "WEBVTT\n\n")
+	return err
+}
+
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// timestamp formats d as HH:MM:SS followed by sep and milliseconds.
+func timestamp(d time.Duration, sep rune) string {
+	if d < 0 {
+		d = 0
+	}
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// WriteTrack writes all the cues of t to w.
+func WriteTrack(w io.Writer, f Format, t Track) error {
+	cw := NewWriter(w, f)
+	for _, c := range t.Cues {
+		if err := cw.WriteCue(c); err != nil {
+			return err
+		}
+	}
+	return cw.Close()
+}
+
+// TrackPath returns the file name for the track of channel, given the
+// file name for single channel audio: "captions.srt" becomes
+// "captions.ch2.srt" for channel 2.
+func TrackPath(path string, channel int32) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s.ch%d%s", strings.TrimSuffix(path, ext), channel, ext)
+}
+
+// WriteFiles writes tracks to the file at path, or to w if path is empty.
+// When there is more than one track, each is written to its own file named
+// by TrackPath, so path is required.
+func WriteFiles(w io.Writer, path string, f Format, tracks []Track) error {
+	if len(tracks) > 1 && path == "" {
+		return fmt.Errorf("captions: %d channels need an output file", len(tracks))
+	}
+	if path == "" {
+		if len(tracks) == 0 {
+			return WriteTrack(w, f, Track{})
+		}
+		return WriteTrack(w, f, tracks[0])
+	}
+	if len(tracks) == 0 {
+		tracks = []Track{{}}
+	}
+	for _, t := range tracks {
+		p := path
+		if len(tracks) > 1 {
+			p = TrackPath(path, t.Channel)
+		}
+		if err := writeFile(p, f, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(path string, f Format, t Track) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("os.Create: %w", err)
+	}
+	if err := WriteTrack(out, f, t); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
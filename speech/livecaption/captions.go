@@ -0,0 +1,163 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"cloud.google.com/go/speech/apiv1/speechpb"
+	"github.com/GoogleCloudPlatform/golang-samples/speech/captions"
+)
+
+var (
+	format   = flag.String("format", "srt", "caption format, srt or vtt")
+	output   = flag.String("o", "", "caption file to write, or stdout if empty; with -channels, one file per channel is written, e.g. out.ch1.srt")
+	speakers = flag.Int("speakers", 0, "label up to this many speakers using diarization")
+	channels = flag.Int("channels", 1, "number of audio channels, each captioned separately")
+	rate     = flag.Int("rate", 16000, "sample rate of the audio in hertz")
+	lang     = flag.String("lang", "en-US", "language of the audio")
+)
+
+func main() {
+	flag.Parse()
+
+	f, err := captions.ParseFormat(*format)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cw, err := newCaptionWriter(os.Stdout, *output, f, *channels)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Captions need word time offsets, which the config enables.
+	config := captions.RecognitionConfig(int32(*rate), *lang, int32(*speakers), int32(*channels))
+	if err := streamRecognize(context.Background(), config, cw.add); err != nil {
+		log.Fatal(err)
+	}
+	if err := cw.close(); err != nil {
+		log.Fatalf("Could not write captions: %v", err)
+	}
+}
+
+// captionWriter writes captions as streaming results become final, with
+// one file per channel when channels are recognized separately.
+type captionWriter struct {
+	format captions.Format
+	// open returns the destination for a channel's captions.
+	open   func(channel int32) (io.WriteCloser, error)
+	tracks map[int32]*track
+}
+
+type track struct {
+	seg *captions.Segmenter
+	w   *captions.Writer
+	out io.WriteCloser
+	// end is the end of the last word added, so that words repeated by a
+	// later result are only captioned once.
+	end time.Duration
+}
+
+// newCaptionWriter returns a captionWriter that writes to output, or to w if
+// output is empty. Output is required if channels is more than 1.
+func newCaptionWriter(w io.Writer, output string, f captions.Format, channels int) (*captionWriter, error) {
+	if output == "" && channels > 1 {
+		return nil, fmt.Errorf("%d channels need an output file", channels)
+	}
+	open := func(channel int32) (io.WriteCloser, error) {
+		if output == "" {
+			return nopCloser{w}, nil
+		}
+		path := output
+		if channels > 1 {
+			path = captions.TrackPath(output, channel)
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("os.Create: %w", err)
+		}
+		return f, nil
+	}
+	return &captionWriter{format: f, open: open, tracks: make(map[int32]*track)}, nil
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// add captions the words of a final result. Interim results are ignored.
+func (cw *captionWriter) add(r *speechpb.StreamingRecognitionResult) error {
+	words := captions.WordsFromStreamingResult(r)
+	if len(words) == 0 {
+		return nil
+	}
+	t, err := cw.track(r.GetChannelTag())
+	if err != nil {
+		return err
+	}
+	for _, word := range words {
+		if word.Start < t.end {
+			continue
+		}
+		t.end = word.End
+		for _, c := range t.seg.Add(word) {
+			if err := t.w.WriteCue(c); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (cw *captionWriter) track(channel int32) (*track, error) {
+	if t, ok := cw.tracks[channel]; ok {
+		return t, nil
+	}
+	out, err := cw.open(channel)
+	if err != nil {
+		return nil, err
+	}
+	t := &track{
+		seg: captions.NewSegmenter(captions.Options{}),
+		w:   captions.NewWriter(out, cw.format),
+		out: out,
+	}
+	cw.tracks[channel] = t
+	return t, nil
+}
+
+// close writes the last cue of each channel.
+func (cw *captionWriter) close() error {
+	var firstErr error
+	for _, t := range cw.tracks {
+		for _, c := range t.seg.Flush() {
+			if err := t.w.WriteCue(c); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		if err := t.w.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if err := t.out.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,66 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/speech/apiv1/speechpb"
+	"github.com/GoogleCloudPlatform/golang-samples/speech/captions"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func result(final bool, words ...string) *speechpb.StreamingRecognitionResult {
+	alt := &speechpb.SpeechRecognitionAlternative{}
+	for i, w := range words {
+		alt.Words = append(alt.Words, &speechpb.WordInfo{
+			Word:      w,
+			StartTime: durationpb.New(time.Duration(i) * 500 * time.Millisecond),
+			EndTime:   durationpb.New(time.Duration(i+1) * 500 * time.Millisecond),
+		})
+	}
+	return &speechpb.StreamingRecognitionResult{Alternatives: []*speechpb.SpeechRecognitionAlternative{alt}, IsFinal: final}
+}
+
+func TestCaptionWriter(t *testing.T) {
+	var buf bytes.Buffer
+	cw, err := newCaptionWriter(&buf, "", captions.SRT, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []*speechpb.StreamingRecognitionResult{
+		result(false, "hello"),
+		result(true, "hello", "world."),
+		// A later result repeating earlier words only adds the new one.
+		result(true, "hello", "world.", "Again"),
+	} {
+		if err := cw.add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cw.close(); err != nil {
+		t.Fatal(err)
+	}
+	want := "1\n00:00:00,000 --> 00:00:01,000\nhello world.\n\n2\n00:00:01,000 --> 00:00:02,000\nAgain\n\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if _, err := newCaptionWriter(&buf, "", captions.SRT, 2); err == nil {
+		t.Errorf("newCaptionWriter to stdout with 2 channels succeeded")
+	}
+}
@@ -13,7 +13,8 @@
 // limitations under the License.
 
 // Command livecaption pipes the stdin audio data to
-// Google Speech API and outputs the transcript.
+// Google Speech API and writes SRT or WebVTT captions as results become
+// final.
 //
 // As an example, gst-launch can be used to capture the mic input:
 //
@@ -32,30 +33,27 @@ import (
 	"cloud.google.com/go/speech/apiv1/speechpb"
 )
 
-func main() {
-	ctx := context.Background()
-
+// streamRecognize pipes the stdin audio data to the Speech API, recognized
+// with config, and calls handle with each result.
+func streamRecognize(ctx context.Context, config *speechpb.RecognitionConfig, handle func(*speechpb.StreamingRecognitionResult) error) error {
 	client, err := speech.NewClient(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("speech.NewClient: %w", err)
 	}
+	defer client.Close()
 	stream, err := client.StreamingRecognize(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("StreamingRecognize: %w", err)
 	}
 	// Send the initial configuration message.
 	if err := stream.Send(&speechpb.StreamingRecognizeRequest{
 		StreamingRequest: &speechpb.StreamingRecognizeRequest_StreamingConfig{
 			StreamingConfig: &speechpb.StreamingRecognitionConfig{
-				Config: &speechpb.RecognitionConfig{
-					Encoding:        speechpb.RecognitionConfig_LINEAR16,
-					SampleRateHertz: 16000,
-					LanguageCode:    "en-US",
-				},
+				Config: config,
 			},
 		},
 	}); err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("Send: %w", err)
 	}
 
 	go func() {
@@ -89,20 +87,22 @@ func main() {
 	for {
 		resp, err := stream.Recv()
 		if err == io.EOF {
-			break
+			return nil
 		}
 		if err != nil {
-			log.Fatalf("Cannot stream results: %v", err)
+			return fmt.Errorf("cannot stream results: %w", err)
 		}
 		if err := resp.Error; err != nil {
 			// Workaround while the API doesn't give a more informative error.
 			if err.Code == 3 || err.Code == 11 {
 				log.Print("WARNING: Speech recognition request exceeded limit of 60 seconds.")
 			}
-			log.Fatalf("Could not recognize: %v", err)
+			return fmt.Errorf("could not recognize: %v", err)
 		}
 		for _, result := range resp.Results {
-			fmt.Printf("Result: %+v\n", result)
+			if err := handle(result); err != nil {
+				return err
+			}
 		}
 	}
 }
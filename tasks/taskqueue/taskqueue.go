@@ -0,0 +1,313 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package taskqueue enqueues Cloud Tasks HTTP tasks and handles them.
+//
+// Queue adds tasks with OIDC tokens, schedule times and deduplicating
+// names. Handler parses the X-CloudTasks-* headers of task requests. The
+// taskqueuetest package emulates a queue in process, for testing both
+// together.
+package taskqueue
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	taskspb "cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
+	"github.com/googleapis/gax-go/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ErrDuplicate is returned by Enqueue when a task with the same name was
+// created recently. Cloud Tasks remembers names for up to about nine days
+// after a task completes or is deleted.
+var ErrDuplicate = errors.New("taskqueue: duplicate task name")
+
+// Creator creates tasks. It is implemented by *cloudtasks.Client and by
+// taskqueuetest.Emulator.
+type Creator interface {
+	CreateTask(ctx context.Context, req *taskspb.CreateTaskRequest, opts ...gax.CallOption) (*taskspb.Task, error)
+}
+
+// Path returns the resource name of a queue.
+func Path(projectID, locationID, queueID string) string {
+	return fmt.Sprintf("projects/%s/locations/%s/queues/%s", projectID, locationID, queueID)
+}
+
+// Queue adds HTTP tasks to a Cloud Tasks queue.
+type Queue struct {
+	Client Creator
+	// Name is the queue's resource name; see Path.
+	Name string
+	// ServiceAccount, if set, is the email of the service account whose
+	// OIDC token is sent with each task, as Cloud Run and Cloud Functions
+	// targets that require authentication expect. The account that
+	// enqueues tasks needs the iam.serviceAccounts.actAs permission on it.
+	ServiceAccount string
+	// Audience is the audience of the OIDC token. Default: the task URL.
+	Audience string
+}
+
+// Option customizes a task.
+type Option func(*taskspb.Task)
+
+// At schedules the task to run at t rather than immediately. Cloud Tasks
+// accepts schedule times up to 30 days in the future.
+func At(t time.Time) Option {
+	return func(task *taskspb.Task) { task.ScheduleTime = timestamppb.New(t) }
+}
+
+// After schedules the task to run after d.
+func After(d time.Duration) Option {
+	return At(time.Now().Add(d))
+}
+
+// Deadline sets how long Cloud Tasks waits for the handler to respond
+// before the attempt fails, between 15 seconds and 30 minutes.
+// Default: 10 minutes.
+func Deadline(d time.Duration) Option {
+	return func(task *taskspb.Task) { task.DispatchDeadline = durationpb.New(d) }
+}
+
+// Header adds a header to the task request.
+func Header(key, value string) Option {
+	return func(task *taskspb.Task) {
+		r := task.GetHttpRequest()
+		if r.Headers == nil {
+			r.Headers = make(map[string]string)
+		}
+		r.Headers[key] = value
+	}
+}
+
+// Method sets the HTTP method of the task request. Default: POST.
+func Method(m taskspb.HttpMethod) Option {
+	return func(task *taskspb.Task) { task.GetHttpRequest().HttpMethod = m }
+}
+
+// DedupKey names the task after a hash of key, so enqueueing the same key
+// twice creates only one task, and the second Enqueue returns
+// ErrDuplicate. For example, a key of the order ID and "confirmation"
+// sends one confirmation email per order however often the enqueueing
+// request is retried.
+//
+// Hashing also spreads the names out: names with sequential prefixes,
+// such as timestamps, increase task latency.
+func DedupKey(key ...string) Option {
+	sum := sha256.Sum256([]byte(strings.Join(key, "\x00")))
+	return TaskID(hex.EncodeToString(sum[:16]))
+}
+
+// TaskID names the task id, within the queue. IDs may contain letters,
+// numbers, hyphens and underscores. Like DedupKey, it makes Enqueue return
+// ErrDuplicate for a name that was used recently.
+func TaskID(id string) Option {
+	return func(task *taskspb.Task) { task.Name = id }
+}
+
+// Enqueue adds a task that sends body to url.
+func (q *Queue) Enqueue(ctx context.Context, url string, body []byte, opts ...Option) (*taskspb.Task, error) {
+	r := &taskspb.HttpRequest{
+		HttpMethod: taskspb.HttpMethod_POST,
+		Url:        url,
+		Body:       body,
+	}
+	if q.ServiceAccount != "" {
+		r.AuthorizationHeader = &taskspb.HttpRequest_OidcToken{
+			OidcToken: &taskspb.OidcToken{
+				ServiceAccountEmail: q.ServiceAccount,
+				Audience:            q.Audience,
+			},
+		}
+	}
+	task := &taskspb.Task{MessageType: &taskspb.Task_HttpRequest{HttpRequest: r}}
+	for _, opt := range opts {
+		opt(task)
+	}
+	if task.Name != "" {
+		task.Name = q.Name + "/tasks/" + task.Name
+	}
+
+	created, err := q.Client.CreateTask(ctx, &taskspb.CreateTaskRequest{Parent: q.Name, Task: task})
+	if status.Code(err) == codes.AlreadyExists {
+		return nil, fmt.Errorf("%w: %s", ErrDuplicate, task.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("CreateTask: %w", err)
+	}
+	return created, nil
+}
+
+// EnqueueJSON adds a task that sends v, encoded as JSON, to url.
+func (q *Queue) EnqueueJSON(ctx context.Context, url string, v any, opts ...Option) (*taskspb.Task, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal: %w", err)
+	}
+	opts = append([]Option{Header("Content-Type", "application/json")}, opts...)
+	return q.Enqueue(ctx, url, body, opts...)
+}
+
+// Headers set by Cloud Tasks on HTTP task requests.
+const (
+	HeaderQueueName        = "X-CloudTasks-QueueName"
+	HeaderTaskName         = "X-CloudTasks-TaskName"
+	HeaderRetryCount       = "X-CloudTasks-TaskRetryCount"
+	HeaderExecutionCount   = "X-CloudTasks-TaskExecutionCount"
+	HeaderETA              = "X-CloudTasks-TaskETA"
+	HeaderPreviousResponse = "X-CloudTasks-TaskPreviousResponse"
+	HeaderRetryReason      = "X-CloudTasks-TaskRetryReason"
+)
+
+// Info describes a task request.
+type Info struct {
+	// QueueName and TaskName are the short IDs of the queue and task.
+	QueueName string
+	TaskName  string
+	// RetryCount is the number of earlier attempts, including those that
+	// never reached the handler.
+	RetryCount int
+	// ExecutionCount is the number of earlier attempts the handler
+	// responded to, not counting 503 responses.
+	ExecutionCount int
+	// ETA is the time the task was scheduled to run.
+	ETA time.Time
+	// PreviousResponse is the status code of the previous attempt, or 0.
+	PreviousResponse int
+	// RetryReason says why the previous attempt failed.
+	RetryReason string
+}
+
+// ParseInfo parses the Cloud Tasks headers of r. It fails if r has no
+// task name, as requests that don't come from Cloud Tasks don't.
+func ParseInfo(r *http.Request) (*Info, error) {
+	info := &Info{
+		QueueName:   r.Header.Get(HeaderQueueName),
+		TaskName:    r.Header.Get(HeaderTaskName),
+		RetryReason: r.Header.Get(HeaderRetryReason),
+	}
+	if info.TaskName == "" {
+		return nil, fmt.Errorf("taskqueue: missing %s header", HeaderTaskName)
+	}
+	for h, p := range map[string]*int{
+		HeaderRetryCount:       &info.RetryCount,
+		HeaderExecutionCount:   &info.ExecutionCount,
+		HeaderPreviousResponse: &info.PreviousResponse,
+	} {
+		v := r.Header.Get(h)
+		if v == "" {
+			continue
+		}
+		if _, err := fmt.Sscan(v, p); err != nil {
+			return nil, fmt.Errorf("taskqueue: bad %s header %q", h, v)
+		}
+	}
+	if v := r.Header.Get(HeaderETA); v != "" {
+		eta, err := parseETA(v)
+		if err != nil {
+			return nil, fmt.Errorf("taskqueue: bad %s header %q", HeaderETA, v)
+		}
+		info.ETA = eta
+	}
+	return info, nil
+}
+
+// parseETA parses seconds since the epoch with an optional fraction, such
+// as "1700000000.123456", without the rounding of a float64.
+func parseETA(v string) (time.Time, error) {
+	secs, frac, _ := strings.Cut(v, ".")
+	var s, ns int64
+	if _, err := fmt.Sscan(secs, &s); err != nil {
+		return time.Time{}, err
+	}
+	if frac != "" {
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		if _, err := fmt.Sscan(frac+strings.Repeat("0", 9-len(frac)), &ns); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return time.Unix(s, ns), nil
+}
+
+// FormatETA formats t as Cloud Tasks does in the X-CloudTasks-TaskETA
+// header.
+func FormatETA(t time.Time) string {
+	return fmt.Sprintf("%d.%06d", t.Unix(), t.Nanosecond()/1e3)
+}
+
+// Handler returns a handler for task requests that calls fn with the
+// task's Info and body. It replies 200 OK if fn succeeds, so the task is
+// deleted, and 500 Internal Server Error if fn fails, so the task is
+// retried according to the queue's retry configuration. Requests without
+// Cloud Tasks headers get 400 Bad Request.
+func Handler(fn func(ctx context.Context, info *Info, body []byte) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info, err := ParseInfo(r)
+		if err != nil {
+			http.Error(w, "Bad Request - Invalid Task", http.StatusBadRequest)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Internal Error", http.StatusInternalServerError)
+			return
+		}
+		if err := fn(r.Context(), info, body); err != nil {
+			log.Printf("Task %s failed: %v", info.TaskName, err)
+			code := http.StatusInternalServerError
+			var bad *badRequestError
+			if errors.As(err, &bad) {
+				code = http.StatusBadRequest
+			}
+			http.Error(w, http.StatusText(code), code)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// HandlerJSON is like Handler, but decodes the body, as sent by
+// EnqueueJSON, into a T. A body that can't be decoded gets 400 Bad
+// Request; Cloud Tasks still retries it, until the queue's retry limits.
+func HandlerJSON[T any](fn func(ctx context.Context, info *Info, v *T) error) http.Handler {
+	return Handler(func(ctx context.Context, info *Info, body []byte) error {
+		v := new(T)
+		if err := json.Unmarshal(body, v); err != nil {
+			return &badRequestError{fmt.Errorf("json.Unmarshal: %w", err)}
+		}
+		return fn(ctx, info, v)
+	})
+}
+
+// badRequestError is an error in the task request itself.
+type badRequestError struct {
+	err error
+}
+
+func (e *badRequestError) Error() string { return e.err.Error() }
+func (e *badRequestError) Unwrap() error { return e.err }
@@ -0,0 +1,239 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taskqueue_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	taskspb "cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
+	"github.com/GoogleCloudPlatform/golang-samples/tasks/taskqueue"
+	"github.com/GoogleCloudPlatform/golang-samples/tasks/taskqueue/taskqueuetest"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var queuePath = taskqueue.Path("my-project", "us-central1", "my-queue")
+
+type order struct {
+	ID string `json:"id"`
+}
+
+func TestEnqueue(t *testing.T) {
+	ctx := context.Background()
+	em := taskqueuetest.NewEmulator(http.NotFoundHandler())
+	q := &taskqueue.Queue{Client: em, Name: queuePath, ServiceAccount: "tasks@my-project.iam.gserviceaccount.com"}
+
+	eta := em.Now().Add(time.Hour)
+	task, err := q.EnqueueJSON(ctx, "https://example.com/confirm", order{ID: "42"}, taskqueue.At(eta), taskqueue.DedupKey("42", "confirm"))
+	if err != nil {
+		t.Fatalf("EnqueueJSON: %v", err)
+	}
+	if !strings.HasPrefix(task.GetName(), queuePath+"/tasks/") {
+		t.Errorf("task name %q is not in queue %q", task.GetName(), queuePath)
+	}
+	if got := task.GetScheduleTime().AsTime(); !got.Equal(eta) {
+		t.Errorf("schedule time: got %v, want %v", got, eta)
+	}
+	hr := task.GetHttpRequest()
+	if got, want := hr.GetOidcToken().GetServiceAccountEmail(), q.ServiceAccount; got != want {
+		t.Errorf("OIDC service account: got %q, want %q", got, want)
+	}
+	if got, want := string(hr.GetBody()), `{"id":"42"}`; got != want {
+		t.Errorf("body: got %s, want %s", got, want)
+	}
+	if got, want := hr.GetHeaders()["Content-Type"], "application/json"; got != want {
+		t.Errorf("Content-Type: got %q, want %q", got, want)
+	}
+
+	_, err = q.EnqueueJSON(ctx, "https://example.com/confirm", order{ID: "42"}, taskqueue.DedupKey("42", "confirm"))
+	if !errors.Is(err, taskqueue.ErrDuplicate) {
+		t.Errorf("EnqueueJSON with the same DedupKey: got error %v, want ErrDuplicate", err)
+	}
+	if _, err := q.EnqueueJSON(ctx, "https://example.com/confirm", order{ID: "43"}, taskqueue.DedupKey("43", "confirm")); err != nil {
+		t.Errorf("EnqueueJSON with another DedupKey: %v", err)
+	}
+	if _, err := q.Enqueue(ctx, "https://example.com/confirm", nil, taskqueue.TaskID("not/valid")); err == nil {
+		t.Errorf("Enqueue with an invalid TaskID succeeded")
+	}
+	if got := len(em.Pending()); got != 2 {
+		t.Errorf("got %d pending tasks, want 2", got)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	ctx := context.Background()
+	var infos []taskqueue.Info
+	h := taskqueue.HandlerJSON(func(ctx context.Context, info *taskqueue.Info, o *order) error {
+		infos = append(infos, *info)
+		if o.ID != "42" {
+			t.Errorf("got order %q, want 42", o.ID)
+		}
+		if len(infos) < 3 {
+			return errors.New("flaky backend")
+		}
+		return nil
+	})
+	em := taskqueuetest.NewEmulator(h)
+	start := em.Now()
+	q := &taskqueue.Queue{Client: em, Name: queuePath}
+	if _, err := q.EnqueueJSON(ctx, "https://example.com/confirm", order{ID: "42"}, taskqueue.TaskID("confirm-42")); err != nil {
+		t.Fatalf("EnqueueJSON: %v", err)
+	}
+
+	results, err := em.Drain(ctx)
+	if err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	if len(results) != 1 || !results[0].OK || results[0].Attempts != 3 {
+		t.Fatalf("got results %+v, want one OK result after 3 attempts", results)
+	}
+	// The default backoff starts at 100ms and doubles.
+	if got, want := results[0].Done.Sub(start), 300*time.Millisecond; got != want {
+		t.Errorf("task done after %v, want %v", got, want)
+	}
+	for i, info := range infos {
+		if info.TaskName != "confirm-42" || info.QueueName != "my-queue" {
+			t.Errorf("attempt %d: got task %q in queue %q, want confirm-42 in my-queue", i, info.TaskName, info.QueueName)
+		}
+		if info.RetryCount != i || info.ExecutionCount != i {
+			t.Errorf("attempt %d: got retry count %d, execution count %d; want %d, %d", i, info.RetryCount, info.ExecutionCount, i, i)
+		}
+		wantPrevious := 0
+		if i > 0 {
+			wantPrevious = http.StatusInternalServerError
+		}
+		if info.PreviousResponse != wantPrevious {
+			t.Errorf("attempt %d: got previous response %d, want %d", i, info.PreviousResponse, wantPrevious)
+		}
+	}
+}
+
+func TestGiveUp(t *testing.T) {
+	ctx := context.Background()
+	attempts := 0
+	h := taskqueue.Handler(func(context.Context, *taskqueue.Info, []byte) error {
+		attempts++
+		return errors.New("always fails")
+	})
+	em := taskqueuetest.NewEmulator(h)
+	em.Retry = &taskspb.RetryConfig{MaxAttempts: 4, MinBackoff: durationpb.New(time.Second)}
+	q := &taskqueue.Queue{Client: em, Name: queuePath}
+	if _, err := q.Enqueue(ctx, "https://example.com/fail", []byte("x")); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	results, err := em.Drain(ctx)
+	if err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	if len(results) != 1 || results[0].OK || results[0].Status != http.StatusInternalServerError {
+		t.Fatalf("got results %+v, want one failed result", results)
+	}
+	if attempts != 4 {
+		t.Errorf("got %d attempts, want 4", attempts)
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	ctx := context.Background()
+	var etas []time.Time
+	h := taskqueue.Handler(func(ctx context.Context, info *taskqueue.Info, body []byte) error {
+		etas = append(etas, info.ETA)
+		return nil
+	})
+	em := taskqueuetest.NewEmulator(h)
+	eta := em.Now().Add(time.Hour)
+	q := &taskqueue.Queue{Client: em, Name: queuePath}
+	if _, err := q.Enqueue(ctx, "https://example.com/later", nil, taskqueue.At(eta)); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	if results, err := em.Run(ctx, 30*time.Minute); err != nil || len(results) != 0 {
+		t.Fatalf("Run(30m) = %+v, %v; want no results", results, err)
+	}
+	if results, err := em.Run(ctx, 30*time.Minute); err != nil || len(results) != 1 {
+		t.Fatalf("Run(30m) = %+v, %v; want one result", results, err)
+	}
+	if len(etas) != 1 || !etas[0].Equal(eta.Truncate(time.Microsecond)) {
+		t.Errorf("got ETAs %v, want [%v]", etas, eta)
+	}
+}
+
+func TestHandlerStatus(t *testing.T) {
+	h := taskqueue.HandlerJSON(func(context.Context, *taskqueue.Info, *order) error { return nil })
+	tests := []struct {
+		name    string
+		headers map[string]string
+		body    string
+		want    int
+	}{
+		{"ok", map[string]string{taskqueue.HeaderTaskName: "t"}, `{"id":"1"}`, http.StatusOK},
+		{"not a task", nil, `{"id":"1"}`, http.StatusBadRequest},
+		{"bad retry count", map[string]string{taskqueue.HeaderTaskName: "t", taskqueue.HeaderRetryCount: "x"}, `{}`, http.StatusBadRequest},
+		{"bad body", map[string]string{taskqueue.HeaderTaskName: "t"}, `{`, http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+		for k, v := range tc.headers {
+			req.Header.Set(k, v)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if rr.Code != tc.want {
+			t.Errorf("%s: got status %d, want %d", tc.name, rr.Code, tc.want)
+		}
+	}
+}
+
+func TestParseInfo(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set(taskqueue.HeaderTaskName, "t")
+	req.Header.Set(taskqueue.HeaderETA, "1700000000.250000")
+	req.Header.Set(taskqueue.HeaderPreviousResponse, "503")
+	info, err := taskqueue.ParseInfo(req)
+	if err != nil {
+		t.Fatalf("ParseInfo: %v", err)
+	}
+	if want := time.Unix(1700000000, 250e6); !info.ETA.Equal(want) {
+		t.Errorf("ETA: got %v, want %v", info.ETA, want)
+	}
+	if info.PreviousResponse != 503 {
+		t.Errorf("PreviousResponse: got %d, want 503", info.PreviousResponse)
+	}
+	if got := taskqueue.FormatETA(info.ETA); got != "1700000000.250000" {
+		t.Errorf("FormatETA(%v) = %q, want 1700000000.250000", info.ETA, got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cfg := &taskspb.RetryConfig{
+		MinBackoff:   durationpb.New(10 * time.Second),
+		MaxBackoff:   durationpb.New(300 * time.Second),
+		MaxDoublings: 3,
+	}
+	want := []time.Duration{10, 20, 40, 80, 160, 240, 300, 300}
+	for i, w := range want {
+		if got := taskqueuetest.Backoff(cfg, i+1); got != w*time.Second {
+			t.Errorf("Backoff(attempt %d) = %v, want %v", i+1, got, w*time.Second)
+		}
+	}
+	if got := taskqueuetest.Backoff(nil, 1000); got != taskqueuetest.DefaultMaxBackoff {
+		t.Errorf("Backoff(nil, 1000) = %v, want %v", got, taskqueuetest.DefaultMaxBackoff)
+	}
+}
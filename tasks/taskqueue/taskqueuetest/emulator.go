@@ -0,0 +1,339 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package taskqueuetest emulates a Cloud Tasks queue in process, for
+// testing task flows offline.
+package taskqueuetest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	taskspb "cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
+	"github.com/GoogleCloudPlatform/golang-samples/tasks/taskqueue"
+	"github.com/googleapis/gax-go/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Defaults of a queue's retry configuration.
+const (
+	DefaultMaxAttempts  = 100
+	DefaultMinBackoff   = 100 * time.Millisecond
+	DefaultMaxBackoff   = time.Hour
+	DefaultMaxDoublings = 16
+)
+
+// maxScheduleAhead is how far in the future a task may be scheduled.
+const maxScheduleAhead = 30 * 24 * time.Hour
+
+var taskIDRE = regexp.MustCompile(`^[A-Za-z0-9_-]{1,500}$`)
+
+// Emulator is an in-process Cloud Tasks queue. It implements
+// taskqueue.Creator, and dispatches HTTP tasks to Handler whatever their
+// URL, retrying failed tasks with the backoff of a real queue.
+//
+// Time is virtual: Run and Drain move the emulator's clock forward to
+// each task's schedule time, so a test of retries spread over hours runs
+// instantly. The clock starts at the real time when the Emulator is
+// created. Dispatch deadlines are not enforced.
+type Emulator struct {
+	// Handler handles task requests.
+	Handler http.Handler
+	// Retry is the queue's retry configuration. Unset fields have the
+	// defaults of a real queue; MaxAttempts -1 means unlimited attempts.
+	Retry *taskspb.RetryConfig
+
+	mu      sync.Mutex
+	now     time.Time
+	seq     int
+	names   map[string]bool
+	pending []*entry
+}
+
+type entry struct {
+	task       *taskspb.Task
+	seq        int
+	eta        time.Time
+	first      time.Time
+	attempts   int
+	executions int
+	previous   int
+}
+
+// Result is the outcome of a task that left the queue.
+type Result struct {
+	Task *taskspb.Task
+	// OK is true if the handler succeeded, and false if the queue gave up
+	// on the task.
+	OK bool
+	// Attempts is the number of times the task was dispatched.
+	Attempts int
+	// Status is the status code of the last attempt.
+	Status int
+	// Done is the emulator time the task left the queue.
+	Done time.Time
+}
+
+// NewEmulator returns an Emulator that dispatches tasks to h.
+func NewEmulator(h http.Handler) *Emulator {
+	return &Emulator{Handler: h, now: time.Now(), names: make(map[string]bool)}
+}
+
+// Now returns the emulator's clock.
+func (e *Emulator) Now() time.Time {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.now
+}
+
+// CreateTask adds a task to the queue, as the Cloud Tasks API does. It
+// fails with codes.AlreadyExists if a task with the same name was ever
+// created on the Emulator.
+func (e *Emulator) CreateTask(ctx context.Context, req *taskspb.CreateTaskRequest, opts ...gax.CallOption) (*taskspb.Task, error) {
+	if req.GetParent() == "" {
+		return nil, status.Error(codes.InvalidArgument, "parent is required")
+	}
+	if req.GetTask().GetHttpRequest() == nil {
+		return nil, status.Error(codes.InvalidArgument, "task must have an HTTP request")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	task := proto.Clone(req.GetTask()).(*taskspb.Task)
+	prefix := req.GetParent() + "/tasks/"
+	if task.Name == "" {
+		e.seq++
+		task.Name = fmt.Sprintf("%s%d", prefix, 1000000+e.seq)
+	} else if id, ok := strings.CutPrefix(task.Name, prefix); !ok || !taskIDRE.MatchString(id) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid task name %q", task.Name)
+	}
+	if e.names[task.Name] {
+		return nil, status.Errorf(codes.AlreadyExists, "task %s already exists", task.Name)
+	}
+
+	eta := e.now
+	if st := task.GetScheduleTime(); st != nil {
+		if t := st.AsTime(); t.After(eta) {
+			eta = t
+		}
+	}
+	if eta.Sub(e.now) > maxScheduleAhead {
+		return nil, status.Errorf(codes.InvalidArgument, "schedule time %v is more than 30 days ahead", eta)
+	}
+	task.ScheduleTime = timestamppb.New(eta)
+	task.CreateTime = timestamppb.New(e.now)
+	e.names[task.Name] = true
+	e.seq++
+	e.pending = append(e.pending, &entry{task: task, seq: e.seq, eta: eta})
+	return proto.Clone(task).(*taskspb.Task), nil
+}
+
+// Pending returns the tasks in the queue, in dispatch order.
+func (e *Emulator) Pending() []*taskspb.Task {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sort()
+	var tasks []*taskspb.Task
+	for _, en := range e.pending {
+		tasks = append(tasks, proto.Clone(en.task).(*taskspb.Task))
+	}
+	return tasks
+}
+
+// Run dispatches the tasks that are due in the next d of emulator time,
+// including tasks the handler adds and retries, and then moves the clock
+// forward by d. It returns the tasks that left the queue.
+func (e *Emulator) Run(ctx context.Context, d time.Duration) ([]Result, error) {
+	until := e.Now().Add(d)
+	results, err := e.run(ctx, &until)
+	if err != nil {
+		return results, err
+	}
+	e.mu.Lock()
+	e.now = until
+	e.mu.Unlock()
+	return results, nil
+}
+
+// Drain dispatches tasks until the queue is empty, and returns them. A
+// handler that always fails on a queue with unlimited attempts keeps
+// Drain running until ctx is done.
+func (e *Emulator) Drain(ctx context.Context) ([]Result, error) {
+	return e.run(ctx, nil)
+}
+
+func (e *Emulator) run(ctx context.Context, until *time.Time) ([]Result, error) {
+	var results []Result
+	for {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		e.mu.Lock()
+		e.sort()
+		if len(e.pending) == 0 || (until != nil && e.pending[0].eta.After(*until)) {
+			e.mu.Unlock()
+			return results, nil
+		}
+		en := e.pending[0]
+		e.pending = e.pending[1:]
+		if en.eta.After(e.now) {
+			e.now = en.eta
+		}
+		if en.attempts == 0 {
+			en.first = e.now
+		}
+		queueID := path.Base(path.Dir(path.Dir(en.task.Name)))
+		e.mu.Unlock()
+
+		// The handler may create tasks, so it runs without the lock.
+		code := e.dispatch(ctx, queueID, en)
+
+		e.mu.Lock()
+		en.attempts++
+		if code != http.StatusServiceUnavailable {
+			en.executions++
+		}
+		en.previous = code
+		en.task.DispatchCount = int32(en.attempts)
+		en.task.ResponseCount = int32(en.attempts)
+		switch {
+		case code >= 200 && code < 300:
+			results = append(results, Result{Task: en.task, OK: true, Attempts: en.attempts, Status: code, Done: e.now})
+		case e.giveUp(en):
+			results = append(results, Result{Task: en.task, OK: false, Attempts: en.attempts, Status: code, Done: e.now})
+		default:
+			en.eta = e.now.Add(Backoff(e.Retry, en.attempts))
+			en.task.ScheduleTime = timestamppb.New(en.eta)
+			e.pending = append(e.pending, en)
+		}
+		e.mu.Unlock()
+	}
+}
+
+// sort orders pending tasks by schedule time, then creation order.
+func (e *Emulator) sort() {
+	sort.SliceStable(e.pending, func(i, j int) bool {
+		a, b := e.pending[i], e.pending[j]
+		if !a.eta.Equal(b.eta) {
+			return a.eta.Before(b.eta)
+		}
+		return a.seq < b.seq
+	})
+}
+
+// dispatch sends the task request to the handler and returns the status
+// code.
+func (e *Emulator) dispatch(ctx context.Context, queueID string, en *entry) int {
+	hr := en.task.GetHttpRequest()
+	method := strings.TrimPrefix(hr.GetHttpMethod().String(), "HTTP_METHOD_")
+	if hr.GetHttpMethod() == taskspb.HttpMethod_HTTP_METHOD_UNSPECIFIED {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequestWithContext(ctx, method, hr.GetUrl(), bytes.NewReader(hr.GetBody()))
+	if err != nil {
+		return http.StatusBadRequest
+	}
+	for k, v := range hr.GetHeaders() {
+		req.Header.Set(k, v)
+	}
+	if len(hr.GetBody()) > 0 && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	req.Header.Set("User-Agent", "Google-Cloud-Tasks")
+	if hr.GetOidcToken() != nil || hr.GetOauthToken() != nil {
+		// A placeholder; tests shouldn't verify tokens.
+		req.Header.Set("Authorization", "Bearer taskqueuetest-token")
+	}
+	req.Header.Set(taskqueue.HeaderQueueName, queueID)
+	req.Header.Set(taskqueue.HeaderTaskName, path.Base(en.task.Name))
+	req.Header.Set(taskqueue.HeaderRetryCount, strconv.Itoa(en.attempts))
+	req.Header.Set(taskqueue.HeaderExecutionCount, strconv.Itoa(en.executions))
+	req.Header.Set(taskqueue.HeaderETA, taskqueue.FormatETA(en.eta))
+	if en.previous != 0 {
+		req.Header.Set(taskqueue.HeaderPreviousResponse, strconv.Itoa(en.previous))
+	}
+
+	rr := httptest.NewRecorder()
+	e.Handler.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+// giveUp reports whether a failed task has reached the retry limits. A
+// task is retried until both the attempt limit and the retry duration,
+// when set, are reached.
+func (e *Emulator) giveUp(en *entry) bool {
+	maxAttempts := int(e.Retry.GetMaxAttempts())
+	if maxAttempts == 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	attemptsDone := maxAttempts > 0 && en.attempts >= maxAttempts
+	maxDuration := e.Retry.GetMaxRetryDuration().AsDuration()
+	durationDone := maxDuration > 0 && e.now.Sub(en.first) >= maxDuration
+	switch {
+	case maxAttempts > 0 && maxDuration > 0:
+		return attemptsDone && durationDone
+	case maxAttempts > 0:
+		return attemptsDone
+	default:
+		return durationDone
+	}
+}
+
+// Backoff returns the delay after the given number of failed attempts
+// under cfg, which may be nil for the defaults. The delay starts at
+// MinBackoff, doubles MaxDoublings times, then grows linearly by the last
+// doubled delay, up to MaxBackoff.
+//
+// For example, with MinBackoff 10s, MaxBackoff 300s and MaxDoublings 3,
+// the delays are 10s, 20s, 40s, 80s, 160s, 240s, 300s, 300s...
+func Backoff(cfg *taskspb.RetryConfig, attempts int) time.Duration {
+	minBackoff, maxBackoff := DefaultMinBackoff, DefaultMaxBackoff
+	if cfg.GetMinBackoff() != nil {
+		minBackoff = cfg.GetMinBackoff().AsDuration()
+	}
+	if cfg.GetMaxBackoff() != nil {
+		maxBackoff = cfg.GetMaxBackoff().AsDuration()
+	}
+	doublings := DefaultMaxDoublings
+	if cfg != nil && cfg.MaxDoublings != 0 {
+		doublings = int(cfg.MaxDoublings)
+	}
+
+	n := attempts - 1
+	d := minBackoff
+	for i := 0; i < min(n, doublings) && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if n > doublings {
+		k := time.Duration(n - doublings + 1)
+		if d > maxBackoff/k {
+			return maxBackoff
+		}
+		d *= k
+	}
+	return min(d, maxBackoff)
+}
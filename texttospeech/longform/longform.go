@@ -0,0 +1,149 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package longform synthesizes text and SSML that is too long for a single
+// Text-to-Speech request.
+//
+// The input is split into chunks at sentence and paragraph boundaries,
+// the chunks are synthesized concurrently, and the audio is stitched back
+// together in order. The start time of each chunk in the result is returned
+// as a chapter, which can be written out as a JSON manifest.
+package longform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/googleapis/gax-go/v2"
+	"golang.org/x/sync/errgroup"
+)
+
+// Synthesizer is implemented by *texttospeech.Client.
+type Synthesizer interface {
+	SynthesizeSpeech(context.Context, *texttospeechpb.SynthesizeSpeechRequest, ...gax.CallOption) (*texttospeechpb.SynthesizeSpeechResponse, error)
+}
+
+// Options configures long-form synthesis.
+type Options struct {
+	// Voice selects the voice. It is required.
+	Voice *texttospeechpb.VoiceSelectionParams
+	// AudioConfig must use the LINEAR16 or MP3 encoding.
+	AudioConfig *texttospeechpb.AudioConfig
+	// MaxBytes is the largest chunk sent in one request. Default
+	// MaxInputBytes.
+	MaxBytes int
+	// Concurrency is the number of requests made at once. Default 4.
+	Concurrency int
+}
+
+// Chapter is the position of one chunk of the input in the audio.
+type Chapter struct {
+	Index      int
+	Input      string
+	Start, End time.Duration
+}
+
+// Result is the synthesized audio.
+type Result struct {
+	Audio    []byte
+	Chapters []Chapter
+}
+
+// SynthesizeText synthesizes plain text of any length.
+func SynthesizeText(ctx context.Context, s Synthesizer, text string, opts Options) (*Result, error) {
+	chunks := SplitText(text, opts.MaxBytes)
+	return synthesize(ctx, s, chunks, opts, func(c string) *texttospeechpb.SynthesisInput {
+		return &texttospeechpb.SynthesisInput{InputSource: &texttospeechpb.SynthesisInput_Text{Text: c}}
+	})
+}
+
+// SynthesizeSSML synthesizes an SSML document of any length.
+func SynthesizeSSML(ctx context.Context, s Synthesizer, ssml string, opts Options) (*Result, error) {
+	chunks, err := SplitSSML(ssml, opts.MaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	return synthesize(ctx, s, chunks, opts, func(c string) *texttospeechpb.SynthesisInput {
+		return &texttospeechpb.SynthesisInput{InputSource: &texttospeechpb.SynthesisInput_Ssml{Ssml: c}}
+	})
+}
+
+func synthesize(ctx context.Context, s Synthesizer, chunks []string, opts Options, input func(string) *texttospeechpb.SynthesisInput) (*Result, error) {
+	encoding := opts.AudioConfig.GetAudioEncoding()
+	if encoding != texttospeechpb.AudioEncoding_LINEAR16 && encoding != texttospeechpb.AudioEncoding_MP3 {
+		return nil, fmt.Errorf("longform: cannot stitch %v audio, use LINEAR16 or MP3", encoding)
+	}
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("longform: no input to synthesize")
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	audio := make([][]byte, len(chunks))
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i, c := range chunks {
+		i, c := i, c
+		g.Go(func() error {
+			resp, err := s.SynthesizeSpeech(ctx, &texttospeechpb.SynthesizeSpeechRequest{
+				Input:       input(c),
+				Voice:       opts.Voice,
+				AudioConfig: opts.AudioConfig,
+			})
+			if err != nil {
+				return fmt.Errorf("SynthesizeSpeech(chunk %d): %w", i, err)
+			}
+			audio[i] = resp.GetAudioContent()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	out, durations, err := Stitch(encoding, audio)
+	if err != nil {
+		return nil, err
+	}
+	r := &Result{Audio: out}
+	var start time.Duration
+	for i, c := range chunks {
+		r.Chapters = append(r.Chapters, Chapter{Index: i, Input: c, Start: start, End: start + durations[i]})
+		start += durations[i]
+	}
+	return r, nil
+}
+
+// WriteManifest writes chapters as a JSON array, with times in seconds.
+func WriteManifest(w io.Writer, chapters []Chapter) error {
+	type entry struct {
+		Index int     `json:"index"`
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+		Input string  `json:"input"`
+	}
+	entries := make([]entry, 0, len(chapters))
+	for _, c := range chapters {
+		entries = append(entries, entry{Index: c.Index, Start: c.Start.Seconds(), End: c.End.Seconds(), Input: c.Input})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
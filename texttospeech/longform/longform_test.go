@@ -0,0 +1,164 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package longform
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/googleapis/gax-go/v2"
+)
+
+// fakeSynthesizer returns one 16 kHz sample per byte of input, with the
+// value of the first byte, after a delay that makes later chunks finish
+// first.
+type fakeSynthesizer struct {
+	mu       sync.Mutex
+	inputs   []string
+	active   int32
+	peak     int32
+	failWith string
+}
+
+func (f *fakeSynthesizer) SynthesizeSpeech(ctx context.Context, req *texttospeechpb.SynthesizeSpeechRequest, _ ...gax.CallOption) (*texttospeechpb.SynthesizeSpeechResponse, error) {
+	n := atomic.AddInt32(&f.active, 1)
+	defer atomic.AddInt32(&f.active, -1)
+	f.mu.Lock()
+	if n > f.peak {
+		f.peak = n
+	}
+	in := req.GetInput().GetText() + req.GetInput().GetSsml()
+	f.inputs = append(f.inputs, in)
+	delay := time.Duration(10-len(f.inputs)) * time.Millisecond
+	f.mu.Unlock()
+
+	if f.failWith != "" && strings.Contains(in, f.failWith) {
+		return nil, errors.New("synthesis failed")
+	}
+	time.Sleep(delay)
+	samples := make([]int16, len(in))
+	for i := range samples {
+		samples[i] = int16(in[0])
+	}
+	return &texttospeechpb.SynthesizeSpeechResponse{AudioContent: wav(16000, samples)}, nil
+}
+
+var linear16 = Options{
+	Voice:       &texttospeechpb.VoiceSelectionParams{LanguageCode: "en-US"},
+	AudioConfig: &texttospeechpb.AudioConfig{AudioEncoding: texttospeechpb.AudioEncoding_LINEAR16},
+	MaxBytes:    20,
+	Concurrency: 3,
+}
+
+func TestSynthesizeText(t *testing.T) {
+	ctx := context.Background()
+	s := &fakeSynthesizer{}
+	text := "Alpha one. Bravo two. Charlie three. Delta four. Echo five. Foxtrot six."
+	r, err := SynthesizeText(ctx, s, text, linear16)
+	if err != nil {
+		t.Fatalf("SynthesizeText: %v", err)
+	}
+	if len(r.Chapters) != 6 {
+		t.Fatalf("got %d chapters, want 6", len(r.Chapters))
+	}
+	if s.peak > 3 {
+		t.Errorf("%d requests were made at once, want at most 3", s.peak)
+	}
+
+	_, data, err := parseWAV(r.Audio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var start time.Duration
+	for i, c := range r.Chapters {
+		if c.Index != i || c.Start != start {
+			t.Errorf("chapter %d = %+v, want start %v", i, c, start)
+		}
+		if want := time.Duration(len(c.Input)) * time.Second / 16000; c.End-c.Start != want {
+			t.Errorf("chapter %d lasts %v, want %v", i, c.End-c.Start, want)
+		}
+		// The audio of each chunk is in input order.
+		off := int(c.Start*16000/time.Second) * 2
+		if got := int16(binary.LittleEndian.Uint16(data[off:])); got != int16(c.Input[0]) {
+			t.Errorf("chapter %d audio starts with %c, want %c", i, got, c.Input[0])
+		}
+		start = c.End
+	}
+
+	var buf bytes.Buffer
+	if err := WriteManifest(&buf, r.Chapters); err != nil {
+		t.Fatal(err)
+	}
+	var manifest []struct {
+		Index      int
+		Start, End float64
+		Input      string
+	}
+	if err := json.Unmarshal(buf.Bytes(), &manifest); err != nil {
+		t.Fatalf("manifest %s: %v", buf.String(), err)
+	}
+	if len(manifest) != 6 || manifest[1].Input != "Bravo two." || manifest[1].Start != r.Chapters[0].End.Seconds() {
+		t.Errorf("manifest = %+v", manifest)
+	}
+}
+
+func TestSynthesizeErrors(t *testing.T) {
+	ctx := context.Background()
+	text := "Alpha one. Bravo two. Charlie three."
+	if _, err := SynthesizeText(ctx, &fakeSynthesizer{failWith: "Bravo"}, text, linear16); err == nil {
+		t.Errorf("SynthesizeText succeeded when a chunk failed")
+	}
+
+	opts := linear16
+	opts.AudioConfig = &texttospeechpb.AudioConfig{AudioEncoding: texttospeechpb.AudioEncoding_OGG_OPUS}
+	s := &fakeSynthesizer{}
+	if _, err := SynthesizeText(ctx, s, text, opts); err == nil {
+		t.Errorf("SynthesizeText with OGG_OPUS succeeded")
+	}
+	if len(s.inputs) != 0 {
+		t.Errorf("made %d requests before failing, want none", len(s.inputs))
+	}
+
+	if _, err := SynthesizeSSML(ctx, s, "<speak><p>Unclosed.</speak>", linear16); err == nil {
+		t.Errorf("SynthesizeSSML of malformed SSML succeeded")
+	}
+}
+
+func TestSynthesizeSSML(t *testing.T) {
+	s := &fakeSynthesizer{}
+	opts := linear16
+	opts.MaxBytes = 40
+	r, err := SynthesizeSSML(context.Background(), s, "<speak><p>Alpha one. Bravo two.</p><p>Charlie three.</p></speak>", opts)
+	if err != nil {
+		t.Fatalf("SynthesizeSSML: %v", err)
+	}
+	if len(r.Chapters) != 3 {
+		t.Fatalf("got %d chapters, want 3", len(r.Chapters))
+	}
+	for _, in := range s.inputs {
+		if !strings.HasPrefix(in, "<speak><p>") || !strings.HasSuffix(in, "</p></speak>") {
+			t.Errorf("request input %q is not a complete document", in)
+		}
+	}
+}
@@ -0,0 +1,468 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package longform
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxInputBytes is the largest input accepted by a single SynthesizeSpeech
+// request.
+const MaxInputBytes = 5000
+
+// SplitText splits plain text into chunks of at most maxBytes bytes. Chunks
+// end at paragraph or sentence boundaries; a sentence longer than maxBytes
+// is split between words. Whitespace within a paragraph is collapsed.
+func SplitText(text string, maxBytes int) []string {
+	if maxBytes <= 0 {
+		maxBytes = MaxInputBytes
+	}
+	var chunks []string
+	var b strings.Builder
+	for _, para := range paragraphs(text) {
+		for i, s := range sentences(para) {
+			sep := " "
+			if i == 0 {
+				sep = "\n\n"
+			}
+			s = strings.Join(strings.Fields(s), " ")
+			if s == "" {
+				continue
+			}
+			if b.Len() > 0 && b.Len()+len(sep)+len(s) <= maxBytes {
+				b.WriteString(sep)
+				b.WriteString(s)
+				continue
+			}
+			if b.Len() > 0 {
+				chunks = append(chunks, b.String())
+				b.Reset()
+			}
+			if len(s) <= maxBytes {
+				b.WriteString(s)
+				continue
+			}
+			words := splitWords(s, maxBytes)
+			chunks = append(chunks, words[:len(words)-1]...)
+			b.WriteString(words[len(words)-1])
+		}
+	}
+	if b.Len() > 0 {
+		chunks = append(chunks, b.String())
+	}
+	return chunks
+}
+
+// paragraphs splits text at blank lines.
+func paragraphs(text string) []string {
+	var paras []string
+	var cur []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			if len(cur) > 0 {
+				paras = append(paras, strings.Join(cur, "\n"))
+				cur = nil
+			}
+			continue
+		}
+		cur = append(cur, line)
+	}
+	if len(cur) > 0 {
+		paras = append(paras, strings.Join(cur, "\n"))
+	}
+	return paras
+}
+
+// sentences splits text after each sentence terminator that is followed by
+// whitespace or the end of the text. The pieces joined together are text.
+func sentences(text string) []string {
+	var out []string
+	start := 0
+	for i := 0; i < len(text); {
+		r, n := utf8.DecodeRuneInString(text[i:])
+		i += n
+		if !isTerminator(r) {
+			continue
+		}
+		// Include repeated terminators and closing quotes or brackets.
+		for i < len(text) {
+			r, n := utf8.DecodeRuneInString(text[i:])
+			if !isTerminator(r) && !strings.ContainsRune(`"')]”’»`, r) {
+				break
+			}
+			i += n
+		}
+		next, _ := utf8.DecodeRuneInString(text[i:])
+		if i == len(text) || unicode.IsSpace(next) || isFullWidthTerminator(r) {
+			out = append(out, text[start:i])
+			start = i
+		}
+	}
+	if start < len(text) {
+		out = append(out, text[start:])
+	}
+	return out
+}
+
+func isTerminator(r rune) bool {
+	return r == '.' || r == '!' || r == '?' || r == '…' || isFullWidthTerminator(r)
+}
+
+func isFullWidthTerminator(r rune) bool {
+	return r == '。' || r == '！' || r == '？'
+}
+
+// endsSentence reports whether text, ignoring trailing whitespace, ends a
+// sentence.
+func endsSentence(text string) bool {
+	text = strings.TrimRightFunc(text, unicode.IsSpace)
+	text = strings.TrimRight(text, `"')]”’»`)
+	r, _ := utf8.DecodeLastRuneInString(text)
+	return isTerminator(r)
+}
+
+// splitWords splits s into pieces of at most maxBytes bytes between words,
+// or between runes for a word longer than maxBytes.
+func splitWords(s string, maxBytes int) []string {
+	var out []string
+	var b strings.Builder
+	for _, w := range strings.Fields(s) {
+		if b.Len() > 0 && b.Len()+1+len(w) <= maxBytes {
+			b.WriteByte(' ')
+			b.WriteString(w)
+			continue
+		}
+		if b.Len() > 0 {
+			out = append(out, b.String())
+			b.Reset()
+		}
+		for len(w) > maxBytes {
+			cut := maxBytes
+			for cut > 0 && !utf8.RuneStart(w[cut]) {
+				cut--
+			}
+			out = append(out, w[:cut])
+			w = w[cut:]
+		}
+		b.WriteString(w)
+	}
+	if b.Len() > 0 {
+		out = append(out, b.String())
+	}
+	return out
+}
+
+// splittable holds the SSML elements that may be closed at the end of one
+// chunk and reopened at the start of the next. Splitting any other element,
+// such as <say-as> or <s>, would change how it is spoken.
+var splittable = map[string]bool{
+	"p":         true,
+	"paragraph": true,
+	"prosody":   true,
+	"voice":     true,
+	"lang":      true,
+	"emphasis":  true,
+}
+
+type openTag struct {
+	name, raw string
+}
+
+// ssmlUnit is a run of SSML that ends at a point where the document may be
+// split.
+type ssmlUnit struct {
+	raw strings.Builder
+	// before and after are the elements open at the start and end of the
+	// unit.
+	before, after []openTag
+	content       bool
+}
+
+// SplitSSML splits an SSML document into documents of at most maxBytes
+// bytes. Splits are made after sentences, paragraphs and <break> elements.
+// Elements such as <p> and <prosody> that are open at a split are closed at
+// the end of the chunk and reopened, with their attributes, at the start of
+// the next, so every chunk is well-formed and is spoken the same way.
+//
+// An error is returned if the document is malformed, or if a part of it that
+// cannot be split, such as a single sentence, is longer than maxBytes.
+func SplitSSML(ssml string, maxBytes int) ([]string, error) {
+	if maxBytes <= 0 {
+		maxBytes = MaxInputBytes
+	}
+	tokens, err := tokenize(ssml)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the <speak> root element.
+	var speak string
+	for len(tokens) > 0 && speak == "" {
+		t := tokens[0]
+		tokens = tokens[1:]
+		switch {
+		case t.kind == tokOpen && t.name == "speak":
+			speak = t.raw
+		case t.kind == tokOther, t.kind == tokText && strings.TrimSpace(t.raw) == "":
+		default:
+			return nil, fmt.Errorf("longform: SSML must have a <speak> root element")
+		}
+	}
+	end := len(tokens) - 1
+	for end >= 0 && (tokens[end].kind == tokOther || tokens[end].kind == tokText && strings.TrimSpace(tokens[end].raw) == "") {
+		end--
+	}
+	if speak == "" || end < 0 || tokens[end].kind != tokClose || tokens[end].name != "speak" {
+		return nil, fmt.Errorf("longform: SSML must have a <speak> root element")
+	}
+	units, err := ssmlUnits(tokens[:end])
+	if err != nil {
+		return nil, err
+	}
+
+	chunk := func(us []*ssmlUnit) string {
+		var b strings.Builder
+		b.WriteString(speak)
+		for _, t := range us[0].before {
+			b.WriteString(t.raw)
+		}
+		for _, u := range us {
+			b.WriteString(u.raw.String())
+		}
+		after := us[len(us)-1].after
+		for i := len(after) - 1; i >= 0; i-- {
+			b.WriteString("</" + after[i].name + ">")
+		}
+		b.WriteString("</speak>")
+		return b.String()
+	}
+
+	var chunks []string
+	for i := 0; i < len(units); {
+		j := i + 1
+		c := chunk(units[i:j])
+		if len(c) > maxBytes {
+			return nil, fmt.Errorf("longform: SSML %q is %d bytes, which is more than the limit of %d and cannot be split", abbreviate(units[i].raw.String()), len(c), maxBytes)
+		}
+		for j < len(units) {
+			next := chunk(units[i : j+1])
+			if len(next) > maxBytes {
+				break
+			}
+			c = next
+			j++
+		}
+		chunks = append(chunks, c)
+		i = j
+	}
+	return chunks, nil
+}
+
+// ssmlUnits groups the tokens inside the <speak> element into units.
+func ssmlUnits(tokens []token) ([]*ssmlUnit, error) {
+	var units []*ssmlUnit
+	var stack []openTag
+	cur := &ssmlUnit{}
+	canSplit := func() bool {
+		for _, t := range stack {
+			if !splittable[t.name] {
+				return false
+			}
+		}
+		return true
+	}
+	endUnit := func() {
+		if !cur.content {
+			return
+		}
+		cur.after = append([]openTag(nil), stack...)
+		units = append(units, cur)
+		cur = &ssmlUnit{before: append([]openTag(nil), stack...)}
+	}
+	// add appends raw. Closing tags and whitespace that follow the end of a
+	// unit are kept with it, rather than starting a unit with no content.
+	add := func(raw string, content bool) {
+		if !cur.content && !content && len(units) > 0 && cur.raw.Len() == 0 {
+			last := units[len(units)-1]
+			last.raw.WriteString(raw)
+			last.after = append([]openTag(nil), stack...)
+			cur.before = last.after
+			return
+		}
+		cur.raw.WriteString(raw)
+		cur.content = cur.content || content
+	}
+
+	for _, t := range tokens {
+		switch t.kind {
+		case tokText:
+			for _, s := range sentences(t.raw) {
+				add(s, strings.TrimSpace(s) != "")
+				if endsSentence(s) && canSplit() {
+					endUnit()
+				}
+			}
+		case tokOpen:
+			add(t.raw, true)
+			stack = append(stack, openTag{name: t.name, raw: t.raw})
+		case tokClose:
+			if len(stack) == 0 || stack[len(stack)-1].name != t.name {
+				return nil, fmt.Errorf("longform: unexpected %s in SSML", t.raw)
+			}
+			stack = stack[:len(stack)-1]
+			add(t.raw, false)
+			if (t.name == "p" || t.name == "paragraph" || t.name == "s" || t.name == "sentence") && canSplit() {
+				endUnit()
+			}
+		case tokSelfClose:
+			add(t.raw, true)
+			if t.name == "break" && canSplit() {
+				endUnit()
+			}
+		default:
+			add(t.raw, false)
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("longform: unclosed <%s> in SSML", stack[len(stack)-1].name)
+	}
+	if cur.content {
+		endUnit()
+	} else if cur.raw.Len() > 0 && len(units) > 0 {
+		units[len(units)-1].raw.WriteString(cur.raw.String())
+	}
+	if len(units) == 0 {
+		return nil, fmt.Errorf("longform: SSML has no content")
+	}
+	return units, nil
+}
+
+func abbreviate(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) <= 40 {
+		return s
+	}
+	cut := 40
+	for !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "..."
+}
+
+type tokenKind int
+
+const (
+	tokText tokenKind = iota
+	tokOpen
+	tokClose
+	tokSelfClose
+	// tokOther is a comment, processing instruction or declaration.
+	tokOther
+)
+
+type token struct {
+	kind tokenKind
+	raw  string
+	name string
+}
+
+// tokenize splits SSML into text and markup. Entities in text are left
+// as they are.
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	for len(s) > 0 {
+		lt := strings.IndexByte(s, '<')
+		if lt != 0 {
+			if lt < 0 {
+				lt = len(s)
+			}
+			tokens = append(tokens, token{kind: tokText, raw: s[:lt]})
+			s = s[lt:]
+			continue
+		}
+
+		var end int
+		switch {
+		case strings.HasPrefix(s, "<!--"):
+			end = indexEnd(s, "-->")
+		case strings.HasPrefix(s, "<![CDATA["):
+			return nil, fmt.Errorf("longform: CDATA sections are not supported in SSML")
+		case strings.HasPrefix(s, "<?"):
+			end = indexEnd(s, "?>")
+		default:
+			end = tagEnd(s)
+		}
+		if end <= 0 {
+			return nil, fmt.Errorf("longform: unterminated markup %q in SSML", abbreviate(s))
+		}
+		raw := s[:end]
+		s = s[end:]
+
+		t := token{kind: tokOther, raw: raw}
+		switch {
+		case strings.HasPrefix(raw, "<!"), strings.HasPrefix(raw, "<?"):
+		case strings.HasPrefix(raw, "</"):
+			t.kind = tokClose
+			t.name = strings.TrimSpace(raw[2 : len(raw)-1])
+		case strings.HasSuffix(raw, "/>"):
+			t.kind = tokSelfClose
+			t.name = tagName(raw[1 : len(raw)-2])
+		default:
+			t.kind = tokOpen
+			t.name = tagName(raw[1 : len(raw)-1])
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, nil
+}
+
+// indexEnd returns the index after the first delim in s, or 0 if s doesn't
+// contain delim.
+func indexEnd(s, delim string) int {
+	i := strings.Index(s, delim)
+	if i < 0 {
+		return 0
+	}
+	return i + len(delim)
+}
+
+// tagEnd returns the length of the tag at the start of s, skipping '>' in
+// quoted attribute values, or 0 if the tag is not terminated.
+func tagEnd(s string) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func tagName(s string) string {
+	if i := strings.IndexFunc(s, unicode.IsSpace); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
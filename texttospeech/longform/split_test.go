@@ -0,0 +1,156 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package longform
+
+import (
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSentences(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"One. Two! Three?", []string{"One.", " Two!", " Three?"}},
+		{"Mr.Smith went to 3.5 places. End", []string{"Mr.Smith went to 3.5 places.", " End"}},
+		{`He said "stop." Then left...`, []string{`He said "stop."`, " Then left..."}},
+		{"一つ。二つ。", []string{"一つ。", "二つ。"}},
+	}
+	for _, tc := range tests {
+		if got := sentences(tc.in); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("sentences(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	text := "First sentence here. Second one.\n\nNew   paragraph\nwraps lines. Last."
+	tests := []struct {
+		max  int
+		want []string
+	}{
+		{100, []string{"First sentence here. Second one.\n\nNew paragraph wraps lines. Last."}},
+		{40, []string{"First sentence here. Second one.", "New paragraph wraps lines. Last."}},
+		{26, []string{"First sentence here.", "Second one.", "New paragraph wraps lines.", "Last."}},
+		// A sentence longer than the limit is split between words.
+		{12, []string{"First", "sentence", "here.", "Second one.", "New", "paragraph", "wraps lines.", "Last."}},
+	}
+	for _, tc := range tests {
+		got := SplitText(text, tc.max)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("SplitText(%d) = %q, want %q", tc.max, got, tc.want)
+		}
+		for _, c := range got {
+			if len(c) > tc.max {
+				t.Errorf("SplitText(%d) chunk %q is too long", tc.max, c)
+			}
+		}
+	}
+
+	// Words longer than the limit are split between runes.
+	got := SplitText("ééééé", 4)
+	if want := []string{"éé", "éé", "é"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitText(long word) = %q, want %q", got, want)
+	}
+}
+
+// wellFormed reports whether s is a well-formed XML document.
+func wellFormed(s string) error {
+	d := xml.NewDecoder(strings.NewReader(s))
+	for {
+		if _, err := d.Token(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func TestSplitSSML(t *testing.T) {
+	ssml := `<?xml version="1.0"?>
+<speak xml:lang="en-US">
+  <p>First sentence. Second <say-as interpret-as="characters">SSML</say-as> sentence.</p>
+  <prosody rate="slow" pitch="-2st">
+    <p>Slow one. Slow two.</p>
+    <p>Slow three.</p>
+  </prosody>
+  <break time="1s"/>
+  <s>A single sentence. With two parts.</s>
+</speak>`
+
+	for _, max := range []int{110, 150, 200, 1000} {
+		chunks, err := SplitSSML(ssml, max)
+		if err != nil {
+			t.Fatalf("SplitSSML(%d): %v", max, err)
+		}
+		var text strings.Builder
+		for _, c := range chunks {
+			if len(c) > max {
+				t.Errorf("SplitSSML(%d): chunk %q is too long", max, c)
+			}
+			if err := wellFormed(c); err != nil {
+				t.Errorf("SplitSSML(%d): chunk %q: %v", max, c, err)
+			}
+			if !strings.HasPrefix(c, `<speak xml:lang="en-US">`) {
+				t.Errorf("SplitSSML(%d): chunk %q lost the <speak> attributes", max, c)
+			}
+			if strings.Contains(c, "Slow") && !strings.Contains(c, `<prosody rate="slow" pitch="-2st">`) {
+				t.Errorf("SplitSSML(%d): chunk %q lost its <prosody>", max, c)
+			}
+			d := xml.NewDecoder(strings.NewReader(c))
+			for tok, err := d.Token(); err == nil; tok, err = d.Token() {
+				if cd, ok := tok.(xml.CharData); ok {
+					text.WriteString(strings.Join(strings.Fields(string(cd)), " ") + " ")
+				}
+			}
+		}
+		want := "First sentence. Second SSML sentence. Slow one. Slow two. Slow three. A single sentence. With two parts."
+		if got := strings.Join(strings.Fields(text.String()), " "); got != want {
+			t.Errorf("SplitSSML(%d) text = %q, want %q", max, got, want)
+		}
+		if max == 1000 && len(chunks) != 1 {
+			t.Errorf("SplitSSML(1000) = %d chunks, want 1", len(chunks))
+		}
+	}
+
+	// The <s> element is never split, so a limit below its size fails.
+	if _, err := SplitSSML("<speak><s>One. Two. Three.</s></speak>", 30); err == nil {
+		t.Errorf("SplitSSML(<s>) succeeded, want an error")
+	}
+	if chunks, err := SplitSSML("<speak><p>One. Two. Three.</p></speak>", 30); err != nil || len(chunks) != 3 {
+		t.Errorf("SplitSSML(<p>) = %q, %v; want 3 chunks", chunks, err)
+	}
+}
+
+func TestSplitSSMLErrors(t *testing.T) {
+	for _, ssml := range []string{
+		"Hello.",
+		"<speak>Hello.",
+		"<speak><p>Hello.</speak>",
+		"<speak><p>Hello.</s></p></speak>",
+		`<speak><break time="1s"</speak>`,
+		"<speak> </speak>",
+		"<speak><?xml hi</speak>",
+		"<speak><!-- unterminated</speak>",
+	} {
+		if _, err := SplitSSML(ssml, 100); err == nil {
+			t.Errorf("SplitSSML(%q) succeeded, want an error", ssml)
+		}
+	}
+}
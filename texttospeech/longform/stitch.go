@@ -0,0 +1,259 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package longform
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+)
+
+// Stitch joins the audio returned for each chunk, in order, into a single
+// file. It also returns the duration of each chunk.
+//
+// LINEAR16 audio is returned by the API as WAV files, which are combined
+// under a single header. MP3 audio is joined frame by frame, dropping ID3
+// tags and the Xing or Info frame at the start of each chunk, as their
+// lengths would be wrong for the combined file.
+func Stitch(encoding texttospeechpb.AudioEncoding, chunks [][]byte) ([]byte, []time.Duration, error) {
+	switch encoding {
+	case texttospeechpb.AudioEncoding_LINEAR16:
+		return stitchWAV(chunks)
+	case texttospeechpb.AudioEncoding_MP3:
+		return stitchMP3(chunks)
+	}
+	return nil, nil, fmt.Errorf("longform: cannot stitch %v audio, use LINEAR16 or MP3", encoding)
+}
+
+// wavFormat is the PCM format from the "fmt " chunk of a WAV file.
+type wavFormat struct {
+	AudioFormat   uint16
+	Channels      uint16
+	SampleRate    uint32
+	ByteRate      uint32
+	BlockAlign    uint16
+	BitsPerSample uint16
+}
+
+// parseWAV returns the format and samples of a WAV file.
+func parseWAV(b []byte) (wavFormat, []byte, error) {
+	var f wavFormat
+	if len(b) < 12 || string(b[:4]) != "RIFF" || string(b[8:12]) != "WAVE" {
+		return f, nil, fmt.Errorf("not a WAV file")
+	}
+	var data []byte
+	var haveFormat bool
+	for p := 12; p+8 <= len(b); {
+		id := string(b[p : p+4])
+		size := int(binary.LittleEndian.Uint32(b[p+4 : p+8]))
+		p += 8
+		// Streamed WAV files may have a size larger than the file.
+		if size > len(b)-p || size < 0 {
+			size = len(b) - p
+		}
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return f, nil, fmt.Errorf("short fmt chunk")
+			}
+			if err := binary.Read(bytes.NewReader(b[p:p+16]), binary.LittleEndian, &f); err != nil {
+				return f, nil, err
+			}
+			haveFormat = true
+		case "data":
+			data = b[p : p+size]
+		}
+		p += size + size%2
+	}
+	if !haveFormat || data == nil {
+		return f, nil, fmt.Errorf("WAV file has no fmt or data chunk")
+	}
+	if f.BlockAlign == 0 || f.SampleRate == 0 {
+		return f, nil, fmt.Errorf("invalid WAV format %+v", f)
+	}
+	return f, data, nil
+}
+
+func stitchWAV(chunks [][]byte) ([]byte, []time.Duration, error) {
+	var format wavFormat
+	var data []byte
+	durations := make([]time.Duration, len(chunks))
+	for i, c := range chunks {
+		f, d, err := parseWAV(c)
+		if err != nil {
+			return nil, nil, fmt.Errorf("longform: chunk %d: %w", i, err)
+		}
+		if i == 0 {
+			format = f
+		} else if f != format {
+			return nil, nil, fmt.Errorf("longform: chunk %d has format %+v, want %+v", i, f, format)
+		}
+		data = append(data, d...)
+		frames := len(d) / int(f.BlockAlign)
+		durations[i] = time.Duration(frames) * time.Second / time.Duration(f.SampleRate)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(36+len(data)))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(16))
+	binary.Write(&b, binary.LittleEndian, format)
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(len(data)))
+	b.Write(data)
+	return b.Bytes(), durations, nil
+}
+
+var (
+	mp3Bitrates = [2][16]int{
+		// MPEG-1 Layer III.
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+		// MPEG-2 and MPEG-2.5 Layer III.
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+	}
+	// mp3SampleRates is indexed by the version bits of the frame header.
+	mp3SampleRates = [4][3]int{
+		{11025, 12000, 8000},  // MPEG-2.5
+		{},                    // reserved
+		{22050, 24000, 16000}, // MPEG-2
+		{44100, 48000, 32000}, // MPEG-1
+	}
+)
+
+// mp3Frame is the header of an MPEG audio Layer III frame.
+type mp3Frame struct {
+	size, samples, sampleRate int
+	// sideInfo is the size of the side information following the header,
+	// where a Xing or Info tag is found.
+	sideInfo int
+}
+
+func parseMP3Frame(b []byte) (mp3Frame, error) {
+	var f mp3Frame
+	if len(b) < 4 || b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+		return f, fmt.Errorf("no frame sync")
+	}
+	version := (b[1] >> 3) & 3
+	if version == 1 || (b[1]>>1)&3 != 1 {
+		return f, fmt.Errorf("not an MPEG Layer III frame")
+	}
+	mpeg1 := version == 3
+	table := 1
+	if mpeg1 {
+		table = 0
+	}
+	bitrate := mp3Bitrates[table][b[2]>>4] * 1000
+	srIndex := (b[2] >> 2) & 3
+	if bitrate == 0 || srIndex == 3 {
+		return f, fmt.Errorf("unsupported bitrate or sample rate")
+	}
+	f.sampleRate = mp3SampleRates[version][srIndex]
+	padding := int(b[2]>>1) & 1
+	mono := b[3]>>6 == 3
+	if mpeg1 {
+		f.samples = 1152
+		f.size = 144*bitrate/f.sampleRate + padding
+		f.sideInfo = 32
+		if mono {
+			f.sideInfo = 17
+		}
+	} else {
+		f.samples = 576
+		f.size = 72*bitrate/f.sampleRate + padding
+		f.sideInfo = 17
+		if mono {
+			f.sideInfo = 9
+		}
+	}
+	return f, nil
+}
+
+// mp3Frames returns the audio frames of an MP3 file, without tags, along
+// with their total number of samples and sample rate.
+func mp3Frames(b []byte) (frames []byte, samples, sampleRate int, err error) {
+	// Skip an ID3v2 tag.
+	if len(b) >= 10 && string(b[:3]) == "ID3" {
+		size := int(b[6])<<21 | int(b[7])<<14 | int(b[8])<<7 | int(b[9])
+		size += 10
+		if b[5]&0x10 != 0 {
+			size += 10
+		}
+		if size > len(b) {
+			return nil, 0, 0, fmt.Errorf("truncated ID3 tag")
+		}
+		b = b[size:]
+	}
+	// Drop an ID3v1 tag.
+	if len(b) >= 128 && string(b[len(b)-128:len(b)-125]) == "TAG" {
+		b = b[:len(b)-128]
+	}
+
+	for p, first := 0, true; p < len(b); first = false {
+		f, err := parseMP3Frame(b[p:])
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("MP3 frame at offset %d: %w", p, err)
+		}
+		if p+f.size > len(b) {
+			return nil, 0, 0, fmt.Errorf("truncated MP3 frame at offset %d", p)
+		}
+		if sampleRate != 0 && f.sampleRate != sampleRate {
+			return nil, 0, 0, fmt.Errorf("MP3 sample rate changes from %d to %d", sampleRate, f.sampleRate)
+		}
+		sampleRate = f.sampleRate
+		frame := b[p : p+f.size]
+		p += f.size
+		if first && isXingFrame(frame, f) {
+			continue
+		}
+		frames = append(frames, frame...)
+		samples += f.samples
+	}
+	return frames, samples, sampleRate, nil
+}
+
+func isXingFrame(frame []byte, f mp3Frame) bool {
+	off := 4 + f.sideInfo
+	if len(frame) < off+4 {
+		return false
+	}
+	tag := string(frame[off : off+4])
+	return tag == "Xing" || tag == "Info"
+}
+
+func stitchMP3(chunks [][]byte) ([]byte, []time.Duration, error) {
+	var out []byte
+	var rate int
+	durations := make([]time.Duration, len(chunks))
+	for i, c := range chunks {
+		frames, samples, sampleRate, err := mp3Frames(c)
+		if err != nil {
+			return nil, nil, fmt.Errorf("longform: chunk %d: %w", i, err)
+		}
+		if samples == 0 {
+			continue
+		}
+		if rate != 0 && sampleRate != rate {
+			return nil, nil, fmt.Errorf("longform: chunk %d has sample rate %d, want %d", i, sampleRate, rate)
+		}
+		rate = sampleRate
+		out = append(out, frames...)
+		durations[i] = time.Duration(samples) * time.Second / time.Duration(sampleRate)
+	}
+	return out, durations, nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package longform
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+)
+
+// wav returns a 16-bit mono WAV file holding samples, with an extra LIST
+// chunk before the data as the API sometimes includes.
+func wav(sampleRate uint32, samples []int16) []byte {
+	var data bytes.Buffer
+	binary.Write(&data, binary.LittleEndian, samples)
+
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(4+24+12+8+data.Len()))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(16))
+	binary.Write(&b, binary.LittleEndian, wavFormat{
+		AudioFormat:   1,
+		Channels:      1,
+		SampleRate:    sampleRate,
+		ByteRate:      sampleRate * 2,
+		BlockAlign:    2,
+		BitsPerSample: 16,
+	})
+	b.WriteString("LIST")
+	binary.Write(&b, binary.LittleEndian, uint32(3))
+	b.WriteString("abc\x00")
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(data.Len()))
+	b.Write(data.Bytes())
+	return b.Bytes()
+}
+
+func TestStitchWAV(t *testing.T) {
+	a := make([]int16, 24000)
+	b := make([]int16, 12000)
+	for i := range b {
+		b[i] = 1
+	}
+	out, durations, err := Stitch(texttospeechpb.AudioEncoding_LINEAR16, [][]byte{wav(24000, a), wav(24000, b)})
+	if err != nil {
+		t.Fatalf("Stitch: %v", err)
+	}
+	if want := []time.Duration{time.Second, 500 * time.Millisecond}; !reflect.DeepEqual(durations, want) {
+		t.Errorf("durations = %v, want %v", durations, want)
+	}
+
+	f, data, err := parseWAV(out)
+	if err != nil {
+		t.Fatalf("parseWAV: %v", err)
+	}
+	if f.SampleRate != 24000 || f.BitsPerSample != 16 {
+		t.Errorf("format = %+v", f)
+	}
+	if len(data) != 2*(len(a)+len(b)) {
+		t.Errorf("got %d bytes of samples, want %d", len(data), 2*(len(a)+len(b)))
+	}
+	if got := binary.LittleEndian.Uint32(out[4:8]); int(got) != len(out)-8 {
+		t.Errorf("RIFF size = %d, want %d", got, len(out)-8)
+	}
+	if data[2*len(a)-1] != 0 || data[2*len(a)] != 1 {
+		t.Errorf("samples are not in chunk order")
+	}
+
+	if _, _, err := Stitch(texttospeechpb.AudioEncoding_LINEAR16, [][]byte{wav(24000, a), wav(16000, a)}); err == nil {
+		t.Errorf("Stitch with different sample rates succeeded")
+	}
+	if _, _, err := Stitch(texttospeechpb.AudioEncoding_LINEAR16, [][]byte{[]byte("not a wav")}); err == nil {
+		t.Errorf("Stitch of a non-WAV chunk succeeded")
+	}
+	if _, _, err := Stitch(texttospeechpb.AudioEncoding_OGG_OPUS, nil); err == nil {
+		t.Errorf("Stitch of OGG_OPUS succeeded")
+	}
+}
+
+// frame returns a mono Layer III frame filled with fill: MPEG-2 at 32 kbps
+// and 24 kHz, or MPEG-1 at 64 kbps and 44.1 kHz if mpeg1 is set.
+func frame(mpeg1 bool, fill byte) []byte {
+	h := []byte{0xFF, 0xF3, 0x44, 0xC0} // MPEG-2, 32 kbps, 24 kHz, mono.
+	size := 72 * 32000 / 24000
+	if mpeg1 {
+		h = []byte{0xFF, 0xFB, 0x50, 0xC0} // MPEG-1, 64 kbps, 44.1 kHz, mono.
+		size = 144 * 64000 / 44100
+	}
+	f := bytes.Repeat([]byte{fill}, size)
+	copy(f, h)
+	return f
+}
+
+// mp3 returns an MP3 file of n frames with an ID3v2 tag and an Info frame.
+func mp3(n int, fill byte) []byte {
+	b := []byte("ID3\x04\x00\x00\x00\x00\x00\x05hello")
+	info := frame(false, 0)
+	copy(info[4+9:], "Info")
+	b = append(b, info...)
+	for i := 0; i < n; i++ {
+		b = append(b, frame(false, fill)...)
+	}
+	return b
+}
+
+func TestStitchMP3(t *testing.T) {
+	out, durations, err := Stitch(texttospeechpb.AudioEncoding_MP3, [][]byte{mp3(100, 1), mp3(50, 2)})
+	if err != nil {
+		t.Fatalf("Stitch: %v", err)
+	}
+	// Each frame holds 576 samples at 24 kHz, or 24ms.
+	if want := []time.Duration{2400 * time.Millisecond, 1200 * time.Millisecond}; !reflect.DeepEqual(durations, want) {
+		t.Errorf("durations = %v, want %v", durations, want)
+	}
+	size := len(frame(false, 0))
+	if len(out) != 150*size {
+		t.Fatalf("got %d bytes, want the %d bytes of 150 frames", len(out), 150*size)
+	}
+	if out[4] != 1 || out[100*size+4] != 2 {
+		t.Errorf("frames are not in chunk order")
+	}
+
+	if _, _, err := Stitch(texttospeechpb.AudioEncoding_MP3, [][]byte{mp3(1, 1), frame(true, 1)}); err == nil {
+		t.Errorf("Stitch with different sample rates succeeded")
+	}
+	if _, _, err := Stitch(texttospeechpb.AudioEncoding_MP3, [][]byte{mp3(2, 1)[:100]}); err == nil {
+		t.Errorf("Stitch of a truncated frame succeeded")
+	}
+}
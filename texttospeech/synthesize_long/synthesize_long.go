@@ -0,0 +1,126 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The synthesize_long command converts a plain text or SSML file of any
+// length to an audio file, with a manifest of the start time of each part.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	texttospeech "cloud.google.com/go/texttospeech/apiv1"
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/GoogleCloudPlatform/golang-samples/texttospeech/longform"
+)
+
+// SynthesizeLongFile synthesizes the contents of inputFile, which is SSML if
+// ssml is set, and saves the output to outputFile. The encoding is LINEAR16
+// or MP3. If manifestFile is not empty, the chapters are written to it as
+// JSON.
+func SynthesizeLongFile(w io.Writer, inputFile string, ssml bool, encoding texttospeechpb.AudioEncoding, outputFile, manifestFile string) error {
+	ctx := context.Background()
+
+	input, err := os.ReadFile(inputFile)
+	if err != nil {
+		return err
+	}
+
+	client, err := texttospeech.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	opts := longform.Options{
+		Voice: &texttospeechpb.VoiceSelectionParams{
+			LanguageCode: "en-US",
+			SsmlGender:   texttospeechpb.SsmlVoiceGender_FEMALE,
+		},
+		AudioConfig: &texttospeechpb.AudioConfig{
+			AudioEncoding: encoding,
+		},
+	}
+	var r *longform.Result
+	if ssml {
+		r, err = longform.SynthesizeSSML(ctx, client, string(input), opts)
+	} else {
+		r, err = longform.SynthesizeText(ctx, client, string(input), opts)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(outputFile, r.Audio, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Audio content of %d chunks written to file: %v\n", len(r.Chapters), outputFile)
+
+	if manifestFile == "" {
+		return nil
+	}
+	f, err := os.Create(manifestFile)
+	if err != nil {
+		return err
+	}
+	if err := longform.WriteManifest(f, r.Chapters); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Chapter manifest written to file: %v\n", manifestFile)
+	return nil
+}
+
+func main() {
+	textFile := flag.String("text", "",
+		"The text file from which to synthesize speech.")
+	ssmlFile := flag.String("ssml", "",
+		"The ssml file from which to synthesize speech.")
+	encoding := flag.String("encoding", "linear16",
+		"The audio encoding, linear16 (WAV) or mp3.")
+	outputFile := flag.String("output-file", "output.wav",
+		"The name of the output file.")
+	manifestFile := flag.String("manifest-file", "",
+		"The name of the chapter manifest file, if any.")
+	flag.Parse()
+
+	enc, ok := texttospeechpb.AudioEncoding_value[strings.ToUpper(*encoding)]
+	if !ok {
+		log.Fatalf("Error: unknown encoding %q", *encoding)
+	}
+
+	var err error
+	if *textFile != "" {
+		err = SynthesizeLongFile(os.Stdout, *textFile, false, texttospeechpb.AudioEncoding(enc), *outputFile, *manifestFile)
+	} else if *ssmlFile != "" {
+		err = SynthesizeLongFile(os.Stdout, *ssmlFile, true, texttospeechpb.AudioEncoding(enc), *outputFile, *manifestFile)
+	} else {
+		log.Fatal(`Error: please supply a --text or --ssml file.
+
+Examples:
+  go run synthesize_long.go --text ../resources/long.txt --manifest-file chapters.json
+  go run synthesize_long.go --ssml ../resources/long.ssml --encoding mp3 --output-file output.mp3`)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
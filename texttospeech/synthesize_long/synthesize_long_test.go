@@ -0,0 +1,84 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/GoogleCloudPlatform/golang-samples/internal/testutil"
+)
+
+const (
+	textFile = "../resources/long.txt"
+	ssmlFile = "../resources/long.ssml"
+)
+
+func TestSynthesizeLongFile(t *testing.T) {
+	testutil.SystemTest(t)
+
+	tests := []struct {
+		input    string
+		ssml     bool
+		encoding texttospeechpb.AudioEncoding
+		output   string
+	}{
+		{textFile, false, texttospeechpb.AudioEncoding_LINEAR16, "output.wav"},
+		{ssmlFile, true, texttospeechpb.AudioEncoding_MP3, "output.mp3"},
+	}
+	for _, tc := range tests {
+		dir := t.TempDir()
+		outputFile := filepath.Join(dir, tc.output)
+		manifestFile := filepath.Join(dir, "chapters.json")
+
+		var buf bytes.Buffer
+		if err := SynthesizeLongFile(&buf, tc.input, tc.ssml, tc.encoding, outputFile, manifestFile); err != nil {
+			t.Fatalf("SynthesizeLongFile(%s): %v", tc.input, err)
+		}
+		if got := buf.String(); !strings.Contains(got, "Audio content of") {
+			t.Errorf("SynthesizeLongFile(%s): got %q", tc.input, got)
+		}
+
+		stat, err := os.Stat(outputFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stat.Size() == 0 {
+			t.Error("Empty output file")
+		}
+
+		b, err := os.ReadFile(manifestFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var chapters []struct{ Start, End float64 }
+		if err := json.Unmarshal(b, &chapters); err != nil {
+			t.Fatal(err)
+		}
+		if len(chapters) < 2 {
+			t.Errorf("got %d chapters, want the input split into at least 2", len(chapters))
+		}
+		for i := 1; i < len(chapters); i++ {
+			if chapters[i].Start != chapters[i-1].End || chapters[i].End <= chapters[i].Start {
+				t.Errorf("chapter %d = %+v follows %+v", i, chapters[i], chapters[i-1])
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localize
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Cache holds the translations of segments, keyed by a hash of their
+// content, so that only new or changed segments are sent to the API.
+//
+// A Cache is stored as a JSON file. Save writes only the entries used since
+// the cache was opened, so translations of segments that have been removed
+// from the source files are dropped.
+type Cache struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]string
+	used    map[string]bool
+}
+
+// OpenCache reads the cache file at path. The file does not need to exist.
+func OpenCache(path string) (*Cache, error) {
+	c := &Cache{path: path, entries: make(map[string]string), used: make(map[string]bool)}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+	if err := json.Unmarshal(b, &c.entries); err != nil {
+		return nil, fmt.Errorf("localize: reading cache %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// cacheKey identifies the translation of a segment. The glossary and the
+// version of its contents are part of the key, as changing either changes
+// the translation.
+func cacheKey(source, target, glossary, glossaryVersion, segment string) string {
+	h := sha256.New()
+	for _, s := range []string{source, target, glossary, glossaryVersion, segment} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *Cache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.entries[key]
+	if ok {
+		c.used[key] = true
+	}
+	return t, ok
+}
+
+func (c *Cache) put(key, translation string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = translation
+	c.used[key] = true
+}
+
+// Save writes the entries used since the cache was opened to its file.
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	used := make(map[string]string, len(c.used))
+	for k := range c.used {
+		used[k] = c.entries[k]
+	}
+	c.mu.Unlock()
+
+	b, err := json.MarshalIndent(used, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("os.MkdirAll: %w", err)
+	}
+	// Write to a temporary file first, so an interrupted run does not
+	// leave a truncated cache.
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("os.WriteFile: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("os.Rename: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The translate command translates a directory of Markdown, HTML, PO and
+// JSON files, only sending segments that changed since the last run.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"cloud.google.com/go/storage"
+	translate "cloud.google.com/go/translate/apiv3"
+	"github.com/GoogleCloudPlatform/golang-samples/translate/localize"
+)
+
+func main() {
+	projectID := flag.String("project", os.Getenv("GOOGLE_CLOUD_PROJECT"), "Google Cloud project ID.")
+	location := flag.String("location", "us-central1", "Location of the glossary, if any.")
+	source := flag.String("source", "en", "Language code of the input files.")
+	target := flag.String("target", "", "Language code to translate to.")
+	in := flag.String("in", ".", "Directory of files to translate.")
+	out := flag.String("out", "", "Directory to write translations to. Default: <in>/<target>.")
+	cachePath := flag.String("cache", "", "Translation cache file. Default: <out>/.translate-cache.json.")
+	glossaryID := flag.String("glossary", "", "ID of a glossary to apply.")
+	glossaryCSV := flag.String("glossary-csv", "", "CSV file to sync the glossary from before translating.")
+	glossaryBucket := flag.String("glossary-bucket", "", "Cloud Storage bucket to upload the CSV to when creating the glossary.")
+	deleteGlossary := flag.Bool("delete-glossary", false, "Delete the glossary and exit.")
+	flag.Parse()
+
+	if *projectID == "" {
+		log.Fatal("Error: -project or GOOGLE_CLOUD_PROJECT must be set")
+	}
+	if *target == "" && !*deleteGlossary {
+		log.Fatal(`Error: please supply a -target language.
+
+Examples:
+  go run . -in docs -target ja
+  go run . -in docs -target ja -glossary docs-ja -glossary-csv glossary.csv -glossary-bucket my-bucket`)
+	}
+	if *out == "" {
+		*out = filepath.Join(*in, *target)
+	}
+	if *cachePath == "" {
+		*cachePath = filepath.Join(*out, ".translate-cache.json")
+	}
+
+	ctx := context.Background()
+	client, err := translate.NewTranslationClient(ctx)
+	if err != nil {
+		log.Fatalf("NewTranslationClient: %v", err)
+	}
+	defer client.Close()
+
+	parent := fmt.Sprintf("projects/%s/locations/%s", *projectID, *location)
+	var glossary string
+	if *glossaryID != "" {
+		glossary = fmt.Sprintf("%s/glossaries/%s", parent, *glossaryID)
+	}
+
+	if *deleteGlossary {
+		if glossary == "" {
+			log.Fatal("Error: -delete-glossary needs -glossary")
+		}
+		if err := localize.DeleteGlossary(ctx, client, glossary); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Deleted glossary %s\n", glossary)
+		return
+	}
+
+	if *glossaryCSV != "" {
+		if glossary == "" {
+			log.Fatal("Error: -glossary-csv needs -glossary")
+		}
+		if err := syncGlossary(ctx, client, glossary, *glossaryCSV, *glossaryBucket); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var glossaryVersion string
+	if glossary != "" {
+		if glossaryVersion, err = localize.GlossaryVersion(ctx, client, glossary); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	cache, err := localize.OpenCache(*cachePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	stats, err := localize.TranslateDir(ctx, client, *in, *out, localize.Options{
+		Parent:          parent,
+		SourceLanguage:  *source,
+		TargetLanguage:  *target,
+		Glossary:        glossary,
+		GlossaryVersion: glossaryVersion,
+		Cache:           cache,
+	})
+	// Keep the translations made before any error.
+	if err := cache.Save(); err != nil {
+		log.Printf("Saving cache: %v", err)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Translated %d files to %s: %d segments, %d from the cache, %d translated\n",
+		stats.Files, *out, stats.Segments, stats.Cached, stats.Translated)
+}
+
+func syncGlossary(ctx context.Context, client *translate.TranslationClient, glossary, csvPath, bucket string) error {
+	f, err := os.Open(csvPath)
+	if err != nil {
+		return fmt.Errorf("os.Open: %w", err)
+	}
+	defer f.Close()
+	g, err := localize.ReadGlossaryCSV(f)
+	if err != nil {
+		return err
+	}
+
+	gcs, err := storage.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("storage.NewClient: %w", err)
+	}
+	defer gcs.Close()
+
+	res, err := localize.SyncGlossary(ctx, client, gcs, glossary, g, bucket)
+	if err != nil {
+		return err
+	}
+	if res.Created {
+		fmt.Printf("Created glossary %s with %d terms\n", glossary, res.Added)
+	} else {
+		fmt.Printf("Synced glossary %s: %d added, %d updated, %d deleted\n", glossary, res.Added, res.Updated, res.Deleted)
+	}
+	return nil
+}
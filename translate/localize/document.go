@@ -0,0 +1,190 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localize
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Format is a kind of file that can be translated.
+type Format int
+
+const (
+	// Markdown files have the .md or .markdown extension.
+	Markdown Format = iota + 1
+	// HTML files have the .html or .htm extension.
+	HTML
+	// PO files are gettext catalogs with the .po or .pot extension. The
+	// msgid of each entry is translated into its msgstr.
+	PO
+	// JSON files have the .json extension. Every string value is
+	// translated; keys are not.
+	JSON
+)
+
+// FormatOf returns the format of a file from its extension, or 0 if it is
+// not supported.
+func FormatOf(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return Markdown
+	case ".html", ".htm":
+		return HTML
+	case ".po", ".pot":
+		return PO
+	case ".json":
+		return JSON
+	}
+	return 0
+}
+
+func (f Format) String() string {
+	switch f {
+	case Markdown:
+		return "Markdown"
+	case HTML:
+		return "HTML"
+	case PO:
+		return "PO"
+	case JSON:
+		return "JSON"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// part is a piece of a document. A literal part is copied to the output as
+// it is. A segment is translated, and the output of render is written in
+// its place.
+type part struct {
+	text    string
+	segment bool
+	// html is set for segments that hold HTML markup, not plain text.
+	html bool
+	// patterns match text in the segment that is not translated, in
+	// addition to placeholders.
+	patterns []*regexp.Regexp
+	render   func(translation string) string
+	// raw, if set, is written in place of a segment with nothing to
+	// translate.
+	raw string
+}
+
+// document is a file split into literal parts and segments.
+type document struct {
+	parts []part
+}
+
+func (d *document) literal(s string) {
+	if s == "" {
+		return
+	}
+	if n := len(d.parts); n > 0 && !d.parts[n-1].segment {
+		d.parts[n-1].text += s
+		return
+	}
+	d.parts = append(d.parts, part{text: s})
+}
+
+// segment adds a segment. Leading and trailing whitespace, and segments with
+// nothing to translate, are kept as literals.
+func (d *document) segment(p part) {
+	trimmed := strings.TrimFunc(p.text, unicode.IsSpace)
+	if !hasWords(trimmed, p) {
+		if p.raw != "" {
+			d.literal(p.raw)
+		} else if p.render != nil {
+			d.literal(p.render(p.text))
+		} else {
+			d.literal(p.text)
+		}
+		return
+	}
+	start := strings.Index(p.text, trimmed)
+	lead, trail := p.text[:start], p.text[start+len(trimmed):]
+	if p.render == nil {
+		d.literal(lead)
+	}
+	p.text = trimmed
+	p.segment = true
+	if p.render != nil {
+		render := p.render
+		p.render = func(t string) string { return render(lead + t + trail) }
+	}
+	d.parts = append(d.parts, p)
+	if p.render == nil {
+		d.literal(trail)
+	}
+}
+
+// hasWords reports whether text has any letters outside of markup and
+// placeholders.
+func hasWords(text string, p part) bool {
+	if p.html {
+		text = htmlTag.ReplaceAllString(text, "")
+	}
+	for _, re := range append(append([]*regexp.Regexp(nil), p.patterns...), placeholders...) {
+		text = re.ReplaceAllString(text, "")
+	}
+	return strings.IndexFunc(text, unicode.IsLetter) >= 0
+}
+
+// segments returns the text of each segment.
+func (d *document) segments() []part {
+	var segs []part
+	for _, p := range d.parts {
+		if p.segment {
+			segs = append(segs, p)
+		}
+	}
+	return segs
+}
+
+// render returns the document with segment i replaced by translations[i].
+func (d *document) render(translations []string) []byte {
+	var b strings.Builder
+	i := 0
+	for _, p := range d.parts {
+		if !p.segment {
+			b.WriteString(p.text)
+			continue
+		}
+		t := translations[i]
+		i++
+		if p.render != nil {
+			t = p.render(t)
+		}
+		b.WriteString(t)
+	}
+	return []byte(b.String())
+}
+
+// parse splits src into parts according to its format.
+func parse(f Format, src []byte) (*document, error) {
+	switch f {
+	case Markdown:
+		return parseMarkdown(string(src)), nil
+	case HTML:
+		return parseHTML(string(src))
+	case PO:
+		return parsePO(string(src))
+	case JSON:
+		return parseJSON(src)
+	}
+	return nil, fmt.Errorf("localize: unsupported format %v", f)
+}
@@ -0,0 +1,319 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localize
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	translate "cloud.google.com/go/translate/apiv3"
+	"cloud.google.com/go/translate/apiv3/translatepb"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Glossary is a glossary read from a CSV file. The header row holds
+// language codes. With two languages, each row maps a term in the first
+// language to its translation in the second. With more, each row is a set
+// of equivalent terms.
+type Glossary struct {
+	Languages []string
+	Rows      [][]string
+}
+
+// ReadGlossaryCSV reads a glossary from CSV.
+func ReadGlossaryCSV(r io.Reader) (*Glossary, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("localize: reading glossary: %w", err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("localize: glossary needs a header row of language codes and at least one term")
+	}
+	g := &Glossary{}
+	for _, l := range records[0] {
+		g.Languages = append(g.Languages, strings.TrimSpace(l))
+	}
+	if len(g.Languages) < 2 {
+		return nil, fmt.Errorf("localize: glossary needs at least two languages, got %q", g.Languages)
+	}
+	seen := make(map[string]int)
+	for i, rec := range records[1:] {
+		if rec[0] == "" || len(rec) == 2 && rec[1] == "" {
+			return nil, fmt.Errorf("localize: glossary row %d is missing a term", i+2)
+		}
+		if prev, ok := seen[rec[0]]; ok {
+			return nil, fmt.Errorf("localize: glossary rows %d and %d both define %q", prev, i+2, rec[0])
+		}
+		seen[rec[0]] = i + 2
+		g.Rows = append(g.Rows, rec)
+	}
+	return g, nil
+}
+
+// entries returns the glossary as API entries.
+func (g *Glossary) entries() []*translatepb.GlossaryEntry {
+	var entries []*translatepb.GlossaryEntry
+	for _, row := range g.Rows {
+		e := &translatepb.GlossaryEntry{}
+		if len(g.Languages) == 2 {
+			e.Data = &translatepb.GlossaryEntry_TermsPair{TermsPair: &translatepb.GlossaryEntry_GlossaryTermsPair{
+				SourceTerm: &translatepb.GlossaryTerm{LanguageCode: g.Languages[0], Text: row[0]},
+				TargetTerm: &translatepb.GlossaryTerm{LanguageCode: g.Languages[1], Text: row[1]},
+			}}
+		} else {
+			set := &translatepb.GlossaryEntry_GlossaryTermsSet{}
+			for i, term := range row {
+				if term != "" {
+					set.Terms = append(set.Terms, &translatepb.GlossaryTerm{LanguageCode: g.Languages[i], Text: term})
+				}
+			}
+			e.Data = &translatepb.GlossaryEntry_TermsSet{TermsSet: set}
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// csv returns the glossary in the CSV format used to create it. Glossaries
+// of language pairs have no header row.
+func (g *Glossary) csv() []byte {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	if len(g.Languages) > 2 {
+		w.Write(g.Languages)
+	}
+	w.WriteAll(g.Rows)
+	return b.Bytes()
+}
+
+// entryKey identifies an entry by its first term.
+func entryKey(e *translatepb.GlossaryEntry) string {
+	if p := e.GetTermsPair(); p != nil {
+		return p.GetSourceTerm().GetText()
+	}
+	if terms := e.GetTermsSet().GetTerms(); len(terms) > 0 {
+		return terms[0].GetText()
+	}
+	return ""
+}
+
+// diffEntries returns the entries to create, update and delete to change
+// have into want. Entries to update carry the name of the existing entry.
+func diffEntries(want, have []*translatepb.GlossaryEntry) (create, update, del []*translatepb.GlossaryEntry) {
+	existing := make(map[string]*translatepb.GlossaryEntry)
+	for _, e := range have {
+		existing[entryKey(e)] = e
+	}
+	for _, w := range want {
+		h, ok := existing[entryKey(w)]
+		switch {
+		case !ok:
+			create = append(create, w)
+		case !sameTerms(w, h):
+			u := proto.Clone(w).(*translatepb.GlossaryEntry)
+			u.Name = h.GetName()
+			update = append(update, u)
+		}
+		delete(existing, entryKey(w))
+	}
+	for _, h := range have {
+		if _, ok := existing[entryKey(h)]; ok {
+			del = append(del, h)
+		}
+	}
+	return create, update, del
+}
+
+func sameTerms(a, b *translatepb.GlossaryEntry) bool {
+	a = proto.Clone(a).(*translatepb.GlossaryEntry)
+	b = proto.Clone(b).(*translatepb.GlossaryEntry)
+	a.Name, b.Name = "", ""
+	a.Description, b.Description = "", ""
+	return proto.Equal(a, b)
+}
+
+// GlossarySync reports the changes made by SyncGlossary.
+type GlossarySync struct {
+	Created                 bool
+	Added, Updated, Deleted int
+}
+
+// SyncGlossary makes the glossary called name, such as
+// "projects/my-project/locations/us-central1/glossaries/docs", match g.
+//
+// A new glossary is created from a CSV file, which is uploaded to bucket.
+// An existing glossary is updated entry by entry: terms that are new in g
+// are added, changed terms are updated and terms missing from g are
+// deleted.
+func SyncGlossary(ctx context.Context, client *translate.TranslationClient, gcs *storage.Client, name string, g *Glossary, bucket string) (GlossarySync, error) {
+	var res GlossarySync
+	_, err := client.GetGlossary(ctx, &translatepb.GetGlossaryRequest{Name: name})
+	if status.Code(err) == codes.NotFound {
+		return GlossarySync{Created: true, Added: len(g.Rows)}, createGlossary(ctx, client, gcs, name, g, bucket)
+	}
+	if err != nil {
+		return res, fmt.Errorf("GetGlossary: %w", err)
+	}
+
+	have, err := listEntries(ctx, client, name)
+	if err != nil {
+		return res, err
+	}
+
+	create, update, del := diffEntries(g.entries(), have)
+	for _, e := range create {
+		if _, err := client.CreateGlossaryEntry(ctx, &translatepb.CreateGlossaryEntryRequest{Parent: name, GlossaryEntry: e}); err != nil {
+			return res, fmt.Errorf("CreateGlossaryEntry(%q): %w", entryKey(e), err)
+		}
+		res.Added++
+	}
+	for _, e := range update {
+		if _, err := client.UpdateGlossaryEntry(ctx, &translatepb.UpdateGlossaryEntryRequest{GlossaryEntry: e}); err != nil {
+			return res, fmt.Errorf("UpdateGlossaryEntry(%q): %w", entryKey(e), err)
+		}
+		res.Updated++
+	}
+	for _, e := range del {
+		if err := client.DeleteGlossaryEntry(ctx, &translatepb.DeleteGlossaryEntryRequest{Name: e.GetName()}); err != nil {
+			return res, fmt.Errorf("DeleteGlossaryEntry(%q): %w", entryKey(e), err)
+		}
+		res.Deleted++
+	}
+	return res, nil
+}
+
+// GlossaryVersion returns a hash of the entries of the glossary called
+// name, to use as Options.GlossaryVersion. It changes whenever a term is
+// added, changed or deleted.
+func GlossaryVersion(ctx context.Context, client *translate.TranslationClient, name string) (string, error) {
+	entries, err := listEntries(ctx, client, name)
+	if err != nil {
+		return "", err
+	}
+	return entriesVersion(entries), nil
+}
+
+func listEntries(ctx context.Context, client *translate.TranslationClient, name string) ([]*translatepb.GlossaryEntry, error) {
+	var entries []*translatepb.GlossaryEntry
+	it := client.ListGlossaryEntries(ctx, &translatepb.ListGlossaryEntriesRequest{Parent: name})
+	for {
+		e, err := it.Next()
+		if err == iterator.Done {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ListGlossaryEntries: %w", err)
+		}
+		entries = append(entries, e)
+	}
+}
+
+// entriesVersion hashes the terms of entries, in any order.
+func entriesVersion(entries []*translatepb.GlossaryEntry) string {
+	var terms []string
+	for _, e := range entries {
+		var t []string
+		if p := e.GetTermsPair(); p != nil {
+			t = append(t, p.GetSourceTerm().GetLanguageCode(), p.GetSourceTerm().GetText(),
+				p.GetTargetTerm().GetLanguageCode(), p.GetTargetTerm().GetText())
+		}
+		for _, term := range e.GetTermsSet().GetTerms() {
+			t = append(t, term.GetLanguageCode(), term.GetText())
+		}
+		terms = append(terms, strings.Join(t, "\x00"))
+	}
+	sort.Strings(terms)
+	h := sha256.New()
+	for _, t := range terms {
+		h.Write([]byte(t))
+		h.Write([]byte{1})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func createGlossary(ctx context.Context, client *translate.TranslationClient, gcs *storage.Client, name string, g *Glossary, bucket string) error {
+	i := strings.Index(name, "/glossaries/")
+	if i < 0 {
+		return fmt.Errorf("localize: invalid glossary name %q", name)
+	}
+	if bucket == "" {
+		return fmt.Errorf("localize: a bucket is needed to create glossary %s", name)
+	}
+	object := "glossaries/" + path.Base(name) + ".csv"
+	w := gcs.Bucket(bucket).Object(object).NewWriter(ctx)
+	w.ContentType = "text/csv"
+	if _, err := w.Write(g.csv()); err != nil {
+		w.Close()
+		return fmt.Errorf("Writer.Write: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("Writer.Close: %w", err)
+	}
+
+	glossary := &translatepb.Glossary{
+		Name: name,
+		InputConfig: &translatepb.GlossaryInputConfig{
+			Source: &translatepb.GlossaryInputConfig_GcsSource{
+				GcsSource: &translatepb.GcsSource{InputUri: fmt.Sprintf("gs://%s/%s", bucket, object)},
+			},
+		},
+	}
+	if len(g.Languages) == 2 {
+		glossary.Languages = &translatepb.Glossary_LanguagePair{LanguagePair: &translatepb.Glossary_LanguageCodePair{
+			SourceLanguageCode: g.Languages[0],
+			TargetLanguageCode: g.Languages[1],
+		}}
+	} else {
+		glossary.Languages = &translatepb.Glossary_LanguageCodesSet_{LanguageCodesSet: &translatepb.Glossary_LanguageCodesSet{
+			LanguageCodes: g.Languages,
+		}}
+	}
+
+	op, err := client.CreateGlossary(ctx, &translatepb.CreateGlossaryRequest{
+		Parent:   name[:i],
+		Glossary: glossary,
+	})
+	if err != nil {
+		return fmt.Errorf("CreateGlossary: %w", err)
+	}
+	if _, err := op.Wait(ctx); err != nil {
+		return fmt.Errorf("Wait: %w", err)
+	}
+	return nil
+}
+
+// DeleteGlossary deletes the glossary called name.
+func DeleteGlossary(ctx context.Context, client *translate.TranslationClient, name string) error {
+	op, err := client.DeleteGlossary(ctx, &translatepb.DeleteGlossaryRequest{Name: name})
+	if err != nil {
+		return fmt.Errorf("DeleteGlossary: %w", err)
+	}
+	if _, err := op.Wait(ctx); err != nil {
+		return fmt.Errorf("Wait: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localize
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// htmlPatterns match inline HTML that is not translated.
+var htmlPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?s)<code\b[^>]*>.*?</code>`),
+	regexp.MustCompile(`(?s)<kbd\b[^>]*>.*?</kbd>`),
+}
+
+// htmlBlocks are the elements that separate segments. Inline elements such
+// as <a> and <em> are translated as part of the surrounding text.
+var htmlBlocks = map[string]bool{
+	"html": true, "head": true, "body": true, "title": true, "meta": true, "link": true,
+	"p": true, "div": true, "section": true, "article": true, "header": true, "footer": true,
+	"nav": true, "aside": true, "main": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "ul": true, "ol": true, "li": true, "dl": true, "dt": true,
+	"dd": true, "table": true, "thead": true, "tbody": true, "tfoot": true, "tr": true,
+	"td": true, "th": true, "caption": true, "blockquote": true, "figure": true,
+	"figcaption": true, "hr": true, "form": true, "fieldset": true, "legend": true,
+	"option": true, "details": true, "summary": true,
+}
+
+// htmlSkip are the elements whose content is never translated.
+var htmlSkip = map[string]bool{
+	"script": true, "style": true, "pre": true, "textarea": true, "svg": true, "math": true,
+}
+
+// parseHTML splits HTML into segments, one for the content of each block
+// element. Segments are sent to the API as HTML, so inline markup is kept.
+func parseHTML(src string) (*document, error) {
+	d := &document{}
+	var run strings.Builder
+	flush := func() {
+		d.segment(part{text: run.String(), html: true, patterns: htmlPatterns})
+		run.Reset()
+	}
+
+	z := html.NewTokenizer(strings.NewReader(src))
+	skip := ""
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("localize: parsing HTML: %w", z.Err())
+		}
+		raw := string(z.Raw())
+
+		var name string
+		if tt == html.StartTagToken || tt == html.EndTagToken || tt == html.SelfClosingTagToken {
+			n, _ := z.TagName()
+			name = string(n)
+		}
+		switch {
+		case skip != "":
+			d.literal(raw)
+			if tt == html.EndTagToken && name == skip {
+				skip = ""
+			}
+		case tt == html.TextToken:
+			run.WriteString(raw)
+		case tt == html.StartTagToken && htmlSkip[name]:
+			flush()
+			d.literal(raw)
+			skip = name
+		case name != "" && !htmlBlocks[name]:
+			run.WriteString(raw)
+		default:
+			// Block elements, comments and the doctype.
+			flush()
+			d.literal(raw)
+		}
+	}
+	flush()
+	return d, nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localize
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// parseJSON splits a JSON file into segments, one for each string value.
+// Keys, the order of members and the layout of the file are kept as they
+// are.
+func parseJSON(src []byte) (*document, error) {
+	if !json.Valid(src) {
+		return nil, fmt.Errorf("localize: invalid JSON")
+	}
+	d := &document{}
+	// inObject records, for each open container, whether it is an object.
+	var inObject []bool
+	expectKey := false
+	start := 0
+	for i := 0; i < len(src); i++ {
+		switch src[i] {
+		case '{':
+			inObject = append(inObject, true)
+			expectKey = true
+		case '[':
+			inObject = append(inObject, false)
+		case '}', ']':
+			inObject = inObject[:len(inObject)-1]
+		case ',':
+			expectKey = len(inObject) > 0 && inObject[len(inObject)-1]
+		case ':':
+			expectKey = false
+		case '"':
+			end := stringEnd(src, i)
+			if expectKey {
+				i = end - 1
+				continue
+			}
+			var s string
+			if err := json.Unmarshal(src[i:end], &s); err != nil {
+				return nil, fmt.Errorf("localize: JSON string at offset %d: %w", i, err)
+			}
+			d.literal(string(src[start:i]))
+			d.segment(part{text: s, raw: string(src[i:end]), render: jsonQuote})
+			start = end
+			i = end - 1
+		}
+	}
+	d.literal(string(src[start:]))
+	return d, nil
+}
+
+// stringEnd returns the offset just past the JSON string starting at
+// src[start].
+func stringEnd(src []byte, start int) int {
+	for i := start + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(src)
+}
+
+func jsonQuote(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,264 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package localize translates directories of Markdown, HTML, gettext PO and
+// JSON files with the Cloud Translation API v3.
+//
+// Each file is split into segments, such as paragraphs or message strings,
+// and only the segments are translated, so markup, code and file structure
+// are preserved. Placeholders such as {name}, {{name}} and %s are protected
+// from translation, and a translation that loses one is rejected.
+// Translations are stored in a Cache keyed by the hash of each segment, so
+// running again after editing the source files only translates the segments
+// that changed.
+package localize
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cloud.google.com/go/translate/apiv3/translatepb"
+	"github.com/googleapis/gax-go/v2"
+)
+
+// Translator is implemented by *translate.TranslationClient.
+type Translator interface {
+	TranslateText(context.Context, *translatepb.TranslateTextRequest, ...gax.CallOption) (*translatepb.TranslateTextResponse, error)
+}
+
+// Options configures translation.
+type Options struct {
+	// Parent is the project and location to use, for example
+	// "projects/my-project/locations/us-central1". A glossary must be in
+	// the same location.
+	Parent string
+	// SourceLanguage and TargetLanguage are language codes such as "en".
+	SourceLanguage, TargetLanguage string
+	// Glossary is the resource name of a glossary to apply, if any.
+	Glossary string
+	// GlossaryVersion identifies the contents of Glossary, such as the
+	// result of GlossaryVersion. Translations cached with another version
+	// are translated again.
+	GlossaryVersion string
+	// Cache holds translations from earlier runs. If nil, every segment is
+	// translated.
+	Cache *Cache
+}
+
+// Stats counts the work done.
+type Stats struct {
+	Files    int
+	Segments int
+	// Cached segments were found in the cache. The rest were translated.
+	Cached     int
+	Translated int
+}
+
+func (s *Stats) add(o Stats) {
+	s.Files += o.Files
+	s.Segments += o.Segments
+	s.Cached += o.Cached
+	s.Translated += o.Translated
+}
+
+// The limits of a TranslateText request are 1024 strings and 30,000
+// characters; requests are kept well below them.
+const (
+	maxBatchSegments = 128
+	maxBatchBytes    = 20000
+)
+
+// TranslateFile translates the contents of a file of format f.
+func TranslateFile(ctx context.Context, t Translator, f Format, src []byte, opts Options) ([]byte, Stats, error) {
+	d, err := parse(f, src)
+	if err != nil {
+		return nil, Stats{}, err
+	}
+	translations, stats, err := translateSegments(ctx, t, d.segments(), opts)
+	if err != nil {
+		return nil, stats, err
+	}
+	stats.Files = 1
+	return d.render(translations), stats, nil
+}
+
+// request is a segment prepared for the API.
+type request struct {
+	html string
+	kept []string
+	key  string
+	// indexes are the segments with this content.
+	indexes []int
+}
+
+func translateSegments(ctx context.Context, t Translator, segs []part, opts Options) ([]string, Stats, error) {
+	stats := Stats{Segments: len(segs)}
+	out := make([]string, len(segs))
+	var pending []*request
+	byKey := make(map[string]*request)
+	for i, s := range segs {
+		html, kept := protect(s.text, s.html, s.patterns)
+		key := cacheKey(opts.SourceLanguage, opts.TargetLanguage, opts.Glossary, opts.GlossaryVersion, html)
+		if opts.Cache != nil {
+			if tr, ok := opts.Cache.get(key); ok {
+				out[i] = tr
+				stats.Cached++
+				continue
+			}
+		}
+		if r, ok := byKey[key]; ok {
+			r.indexes = append(r.indexes, i)
+			continue
+		}
+		r := &request{html: html, kept: kept, key: key, indexes: []int{i}}
+		byKey[key] = r
+		pending = append(pending, r)
+	}
+
+	for len(pending) > 0 {
+		n, size := 0, 0
+		for n < len(pending) && n < maxBatchSegments && (n == 0 || size+len(pending[n].html) <= maxBatchBytes) {
+			size += len(pending[n].html)
+			n++
+		}
+		batch := pending[:n]
+		pending = pending[n:]
+		if err := translateBatch(ctx, t, batch, segs, opts); err != nil {
+			return nil, stats, err
+		}
+		for _, r := range batch {
+			for _, i := range r.indexes {
+				out[i] = r.html
+			}
+			stats.Translated += len(r.indexes)
+		}
+	}
+	return out, stats, nil
+}
+
+// translateBatch translates the requests in batch, replacing their html
+// with the restored translation.
+func translateBatch(ctx context.Context, t Translator, batch []*request, segs []part, opts Options) error {
+	req := &translatepb.TranslateTextRequest{
+		Parent:             opts.Parent,
+		SourceLanguageCode: opts.SourceLanguage,
+		TargetLanguageCode: opts.TargetLanguage,
+		MimeType:           "text/html",
+	}
+	for _, r := range batch {
+		req.Contents = append(req.Contents, r.html)
+	}
+	if opts.Glossary != "" {
+		req.GlossaryConfig = &translatepb.TranslateTextGlossaryConfig{Glossary: opts.Glossary}
+	}
+	resp, err := t.TranslateText(ctx, req)
+	if err != nil {
+		return fmt.Errorf("TranslateText: %w", err)
+	}
+	translations := resp.GetTranslations()
+	if opts.Glossary != "" {
+		translations = resp.GetGlossaryTranslations()
+	}
+	if len(translations) != len(batch) {
+		return fmt.Errorf("localize: got %d translations for %d segments", len(translations), len(batch))
+	}
+	for i, r := range batch {
+		tr, err := restore(translations[i].GetTranslatedText(), segs[r.indexes[0]].html, r.kept)
+		if err != nil {
+			return fmt.Errorf("localize: %w", err)
+		}
+		r.html = tr
+		if opts.Cache != nil {
+			opts.Cache.put(r.key, tr)
+		}
+	}
+	return nil
+}
+
+// OutputPath returns the path of the translation of a file: .pot templates
+// become .po catalogs, and other files keep their name.
+func OutputPath(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".pot") {
+		return strings.TrimSuffix(path, filepath.Ext(path)) + ".po"
+	}
+	return path
+}
+
+// TranslateDir translates every supported file in srcDir, and its
+// subdirectories, into the same place under dstDir. Hidden files and
+// directories, and dstDir if it is inside srcDir, are skipped. Files whose
+// translation has not changed are not rewritten.
+func TranslateDir(ctx context.Context, t Translator, srcDir, dstDir string, opts Options) (Stats, error) {
+	var stats Stats
+	absDst, err := filepath.Abs(dstDir)
+	if err != nil {
+		return stats, err
+	}
+	err = filepath.WalkDir(srcDir, func(path string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != srcDir && strings.HasPrefix(e.Name(), ".") {
+			if e.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if e.IsDir() {
+			if abs, err := filepath.Abs(path); err == nil && abs == absDst && path != srcDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		f := FormatOf(path)
+		if f == 0 {
+			return nil
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("os.ReadFile: %w", err)
+		}
+		out, s, err := TranslateFile(ctx, t, f, src, opts)
+		stats.add(s)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(dstDir, OutputPath(rel))
+		if old, err := os.ReadFile(dst); err == nil && bytes.Equal(old, out) {
+			return nil
+		} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("os.ReadFile: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return fmt.Errorf("os.MkdirAll: %w", err)
+		}
+		if err := os.WriteFile(dst, out, 0644); err != nil {
+			return fmt.Errorf("os.WriteFile: %w", err)
+		}
+		return nil
+	})
+	return stats, err
+}
@@ -0,0 +1,443 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localize
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/translate/apiv3/translatepb"
+	"github.com/googleapis/gax-go/v2"
+)
+
+// untranslated matches what fakeTranslator leaves alone, as the API does:
+// protected spans, tags and entities.
+var untranslated = regexp.MustCompile(`(?s)<span translate="no">.*?</span>|<[^>]*>|&[#A-Za-z0-9]+;`)
+
+// fakeTranslator "translates" text by upper-casing it.
+type fakeTranslator struct {
+	requests []*translatepb.TranslateTextRequest
+	// edit, if set, changes each translation.
+	edit func(string) string
+}
+
+func (f *fakeTranslator) TranslateText(_ context.Context, req *translatepb.TranslateTextRequest, _ ...gax.CallOption) (*translatepb.TranslateTextResponse, error) {
+	f.requests = append(f.requests, req)
+	resp := &translatepb.TranslateTextResponse{}
+	for _, c := range req.GetContents() {
+		var b strings.Builder
+		pos := 0
+		for _, m := range untranslated.FindAllStringIndex(c, -1) {
+			b.WriteString(strings.ToUpper(c[pos:m[0]]))
+			b.WriteString(c[m[0]:m[1]])
+			pos = m[1]
+		}
+		b.WriteString(strings.ToUpper(c[pos:]))
+		t := b.String()
+		if f.edit != nil {
+			t = f.edit(t)
+		}
+		tr := &translatepb.Translation{TranslatedText: t}
+		if req.GetGlossaryConfig() != nil {
+			resp.GlossaryTranslations = append(resp.GlossaryTranslations, tr)
+		} else {
+			resp.Translations = append(resp.Translations, tr)
+		}
+	}
+	return resp, nil
+}
+
+var testOptions = Options{
+	Parent:         "projects/p/locations/us-central1",
+	SourceLanguage: "en",
+	TargetLanguage: "ja",
+}
+
+func translateString(t *testing.T, f Format, src string) string {
+	t.Helper()
+	out, _, err := TranslateFile(context.Background(), &fakeTranslator{}, f, []byte(src), testOptions)
+	if err != nil {
+		t.Fatalf("TranslateFile(%v): %v", f, err)
+	}
+	return string(out)
+}
+
+func TestMarkdown(t *testing.T) {
+	src := "---\ntitle: Guide\n---\n" +
+		"# Getting started\n\n" +
+		"Hello {name}, run `go build` and\nsee [the docs](https://example.com/docs) & more.\n\n" +
+		"- First item with %s\n" +
+		"  continued here\n" +
+		"1. Numbered\n\n" +
+		"> Quoted text\n\n" +
+		"```go\nfmt.Println(\"hello\")\n```\n\n" +
+		"    indented code\n\n" +
+		"| Name | Value |\n| --- | --- |\n| size | 10 |\n\n" +
+		"<img src=\"a.png\" alt=\"x\">\n" +
+		"[ref]: https://example.com\n"
+	want := "---\ntitle: Guide\n---\n" +
+		"# GETTING STARTED\n\n" +
+		"HELLO {name}, RUN `go build` AND SEE [THE DOCS](https://example.com/docs) & MORE.\n\n" +
+		"- FIRST ITEM WITH %s CONTINUED HERE\n" +
+		"1. NUMBERED\n\n" +
+		"> QUOTED TEXT\n\n" +
+		"```go\nfmt.Println(\"hello\")\n```\n\n" +
+		"    indented code\n\n" +
+		"| NAME | VALUE |\n| --- | --- |\n| SIZE | 10 |\n\n" +
+		"<img src=\"a.png\" alt=\"x\">\n" +
+		"[ref]: https://example.com\n"
+	if got := translateString(t, Markdown, src); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	src := `<!DOCTYPE html>
+<html><head><title>Welcome</title>
+<script>var greeting = "hello";</script></head>
+<body>
+<h1>Hello, <em>{name}</em>!</h1>
+<p>Click <a href="/x?id={id}" title="t">here</a> to run <code>go test</code>.</p>
+<pre>keep this</pre>
+<!-- a comment -->
+<ul><li>One &amp; two</li><li>%d items</li></ul>
+</body></html>
+`
+	want := `<!DOCTYPE html>
+<html><head><title>WELCOME</title>
+<script>var greeting = "hello";</script></head>
+<body>
+<h1>HELLO, <em>{name}</em>!</h1>
+<p>CLICK <a href="/x?id={id}" title="t">HERE</a> TO RUN <code>go test</code>.</p>
+<pre>keep this</pre>
+<!-- a comment -->
+<ul><li>ONE &amp; TWO</li><li>%d ITEMS</li></ul>
+</body></html>
+`
+	if got := translateString(t, HTML, src); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPO(t *testing.T) {
+	src := `# Translation template.
+msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+
+#: main.go:10
+msgid "Hello, %s!"
+msgstr ""
+
+msgctxt "menu"
+msgid ""
+"First line\n"
+"second line"
+msgstr ""
+
+msgid "One file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+
+msgid "%s"
+msgstr ""
+
+#~ msgid "Old"
+#~ msgstr ""
+`
+	want := `# Translation template.
+msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+
+#: main.go:10
+msgid "Hello, %s!"
+msgstr "HELLO, %s!"
+
+msgctxt "menu"
+msgid ""
+"First line\n"
+"second line"
+msgstr ""
+"FIRST LINE\n"
+"SECOND LINE"
+
+msgid "One file"
+msgid_plural "%d files"
+msgstr[0] "ONE FILE"
+msgstr[1] "%d FILES"
+
+msgid "%s"
+msgstr ""
+
+#~ msgid "Old"
+#~ msgstr ""
+`
+	if got := translateString(t, PO, src); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	src := `{
+  "title": "Settings",
+  "nested": {"save": "Save \"{name}\"", "count": 3, "empty": ""},
+  "list": ["a & b", "<b>bold</b>", "http://example.com"],
+  "flag": true
+}
+`
+	want := `{
+  "title": "SETTINGS",
+  "nested": {"save": "SAVE \"{name}\"", "count": 3, "empty": ""},
+  "list": ["A & B", "<B>BOLD</B>", "HTTP://EXAMPLE.COM"],
+  "flag": true
+}
+`
+	if got := translateString(t, JSON, src); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if _, _, err := TranslateFile(context.Background(), &fakeTranslator{}, JSON, []byte(`{"a": `), testOptions); err == nil {
+		t.Errorf("TranslateFile of invalid JSON succeeded")
+	}
+}
+
+func TestPlaceholders(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		kept []string
+	}{
+		{"Hi {name}", []string{"{name}"}},
+		{"Hi {{ user.name }} and ${x}", []string{"{{ user.name }}", "${x}"}},
+		{"%s of %d, %1$s, %(count)d, %.2f and 100%%", []string{"%s", "%d", "%1$s", "%(count)d", "%.2f", "%%"}},
+		{"a < b & c", nil},
+	} {
+		html, kept := protect(tc.in, false, nil)
+		if strings.Join(kept, "|") != strings.Join(tc.kept, "|") {
+			t.Errorf("protect(%q) kept %q, want %q", tc.in, kept, tc.kept)
+		}
+		back, err := restore(html, false, kept)
+		if err != nil || back != tc.in {
+			t.Errorf("restore(protect(%q)) = %q, %v", tc.in, back, err)
+		}
+	}
+
+	// A translation that loses a placeholder is rejected.
+	f := &fakeTranslator{edit: func(s string) string { return noTranslateSpan.ReplaceAllString(s, "") }}
+	if _, _, err := TranslateFile(context.Background(), f, Markdown, []byte("Hello {name}\n"), testOptions); err == nil {
+		t.Errorf("TranslateFile succeeded after losing a placeholder")
+	}
+}
+
+func TestGlossaryRequests(t *testing.T) {
+	f := &fakeTranslator{}
+	opts := testOptions
+	opts.Glossary = "projects/p/locations/us-central1/glossaries/docs"
+	out, _, err := TranslateFile(context.Background(), f, Markdown, []byte("Hello\n"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "HELLO\n" {
+		t.Errorf("got %q", out)
+	}
+	if got := f.requests[0].GetGlossaryConfig().GetGlossary(); got != opts.Glossary {
+		t.Errorf("glossary = %q, want %q", got, opts.Glossary)
+	}
+}
+
+func TestCacheGlossaryVersion(t *testing.T) {
+	cache, err := OpenCache(filepath.Join(t.TempDir(), "cache.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := testOptions
+	opts.Glossary = "projects/p/locations/us-central1/glossaries/docs"
+	opts.Cache = cache
+	for _, tc := range []struct {
+		version    string
+		translated int
+	}{
+		{"v1", 1},
+		{"v1", 0},
+		// The glossary changed: cached translations are not used.
+		{"v2", 1},
+	} {
+		opts.GlossaryVersion = tc.version
+		_, stats, err := TranslateFile(context.Background(), &fakeTranslator{}, Markdown, []byte("Hello\n"), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Translated != tc.translated {
+			t.Errorf("glossary version %s: translated %d segments, want %d", tc.version, stats.Translated, tc.translated)
+		}
+	}
+}
+
+func TestTranslateDir(t *testing.T) {
+	ctx := context.Background()
+	src := t.TempDir()
+	dst := filepath.Join(src, "ja")
+	write := func(name, content string) {
+		t.Helper()
+		p := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("index.md", "# Title\n\nFirst paragraph.\n\nSecond paragraph.\n")
+	write("docs/page.html", "<p>Page</p>\n")
+	write("locale/messages.pot", "msgid \"Save\"\nmsgstr \"\"\n")
+	write("locale/en.json", `{"save": "Save", "title": "Title"}`)
+	write("notes.txt", "Not translated")
+	write(".hidden/skip.md", "Skipped")
+
+	cachePath := filepath.Join(dst, ".translate-cache.json")
+	run := func() (Stats, *fakeTranslator) {
+		t.Helper()
+		cache, err := OpenCache(cachePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := &fakeTranslator{}
+		opts := testOptions
+		opts.Cache = cache
+		stats, err := TranslateDir(ctx, f, src, dst, opts)
+		if err != nil {
+			t.Fatalf("TranslateDir: %v", err)
+		}
+		if err := cache.Save(); err != nil {
+			t.Fatal(err)
+		}
+		return stats, f
+	}
+
+	stats, f := run()
+	// "Title" and "Save" appear in two files but are only translated once.
+	if stats.Files != 4 || stats.Segments != 7 || stats.Translated != 5 || stats.Cached != 2 {
+		t.Errorf("first run stats = %+v", stats)
+	}
+	var sent int
+	for _, r := range f.requests {
+		sent += len(r.GetContents())
+	}
+	if sent != 5 {
+		t.Errorf("sent %d segments, want 5", sent)
+	}
+	for name, want := range map[string]string{
+		"index.md":           "# TITLE\n\nFIRST PARAGRAPH.\n\nSECOND PARAGRAPH.\n",
+		"docs/page.html":     "<p>PAGE</p>\n",
+		"locale/messages.po": "msgid \"Save\"\nmsgstr \"SAVE\"\n",
+		"locale/en.json":     `{"save": "SAVE", "title": "TITLE"}`,
+	} {
+		got, err := os.ReadFile(filepath.Join(dst, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	for _, name := range []string{"notes.txt", ".hidden/skip.md", "ja/index.md"} {
+		if _, err := os.Stat(filepath.Join(dst, name)); err == nil {
+			t.Errorf("%s was written", name)
+		}
+	}
+
+	// Nothing changed, so nothing is translated.
+	stats, f = run()
+	if len(f.requests) != 0 || stats.Cached != 7 {
+		t.Errorf("second run made %d requests, stats %+v", len(f.requests), stats)
+	}
+
+	// Only the changed paragraph is translated.
+	write("index.md", "# Title\n\nFirst paragraph.\n\nEdited paragraph.\n")
+	stats, f = run()
+	if len(f.requests) != 1 || len(f.requests[0].GetContents()) != 1 || stats.Translated != 1 {
+		t.Errorf("after an edit, requests = %v, stats %+v", f.requests, stats)
+	}
+	cache, err := OpenCache(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cache.entries) != 5 {
+		t.Errorf("cache has %d entries, want 5 after dropping the old paragraph", len(cache.entries))
+	}
+}
+
+func TestGlossary(t *testing.T) {
+	g, err := ReadGlossaryCSV(strings.NewReader("en,ja\nCloud Run,Cloud Run\nbucket,バケット\nproject,プロジェクト\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(g.csv()) != "Cloud Run,Cloud Run\nbucket,バケット\nproject,プロジェクト\n" {
+		t.Errorf("csv() = %q", g.csv())
+	}
+
+	pair := func(name, src, dst string) *translatepb.GlossaryEntry {
+		return &translatepb.GlossaryEntry{Name: name, Data: &translatepb.GlossaryEntry_TermsPair{TermsPair: &translatepb.GlossaryEntry_GlossaryTermsPair{
+			SourceTerm: &translatepb.GlossaryTerm{LanguageCode: "en", Text: src},
+			TargetTerm: &translatepb.GlossaryTerm{LanguageCode: "ja", Text: dst},
+		}}}
+	}
+	have := []*translatepb.GlossaryEntry{
+		pair("e1", "Cloud Run", "Cloud Run"),
+		pair("e2", "bucket", "バケツ"),
+		pair("e3", "instance", "インスタンス"),
+	}
+	create, update, del := diffEntries(g.entries(), have)
+	if len(create) != 1 || entryKey(create[0]) != "project" {
+		t.Errorf("create = %v", create)
+	}
+	if len(update) != 1 || update[0].GetName() != "e2" || update[0].GetTermsPair().GetTargetTerm().GetText() != "バケット" {
+		t.Errorf("update = %v", update)
+	}
+	if len(del) != 1 || del[0].GetName() != "e3" {
+		t.Errorf("delete = %v", del)
+	}
+
+	// The version of the entries ignores their names and order, but not
+	// their terms.
+	reordered := []*translatepb.GlossaryEntry{have[2], pair("x", "Cloud Run", "Cloud Run"), have[1]}
+	if entriesVersion(have) != entriesVersion(reordered) {
+		t.Errorf("entriesVersion changed with the order and names of entries")
+	}
+	if entriesVersion(have) == entriesVersion(g.entries()) {
+		t.Errorf("entriesVersion did not change with the terms")
+	}
+
+	sets, err := ReadGlossaryCSV(strings.NewReader("en,ja,fr\nbucket,バケット,bucket\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if terms := sets.entries()[0].GetTermsSet().GetTerms(); len(terms) != 3 {
+		t.Errorf("terms = %v", terms)
+	}
+	if string(sets.csv()) != "en,ja,fr\nbucket,バケット,bucket\n" {
+		t.Errorf("csv() = %q", sets.csv())
+	}
+
+	for _, bad := range []string{"en,ja\n", "en\nbucket\n", "en,ja\nbucket,\n", "en,ja\na,b\na,c\n"} {
+		if _, err := ReadGlossaryCSV(strings.NewReader(bad)); err == nil {
+			t.Errorf("ReadGlossaryCSV(%q) succeeded", bad)
+		}
+	}
+}
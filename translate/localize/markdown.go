@@ -0,0 +1,162 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localize
+
+import (
+	"regexp"
+	"strings"
+)
+
+// markdownPatterns match inline Markdown that is not translated: code
+// spans, link destinations and references, URLs and inline HTML tags.
+var markdownPatterns = []*regexp.Regexp{
+	regexp.MustCompile("`+[^`]+`+"),
+	regexp.MustCompile(`\]\([^)]*\)`),
+	regexp.MustCompile(`\]\[[^\]]*\]`),
+	regexp.MustCompile(`<[a-z]+://[^>]*>`),
+	regexp.MustCompile(`https?://[^\s)>\]]+`),
+	regexp.MustCompile(`</?[A-Za-z][^>]*>`),
+}
+
+var (
+	mdFence        = regexp.MustCompile("^\\s{0,3}(```+|~~~+)")
+	mdHeading      = regexp.MustCompile(`^\s{0,3}#{1,6}\s+`)
+	mdListItem     = regexp.MustCompile(`^\s*([-*+]|\d+[.)])\s+(\[[ xX]\]\s+)?`)
+	mdQuote        = regexp.MustCompile(`^\s{0,3}(>\s?)+`)
+	mdRule         = regexp.MustCompile(`^\s{0,3}((-\s*){3,}|(\*\s*){3,}|(_\s*){3,}|(=\s*){3,})$`)
+	mdLinkDef      = regexp.MustCompile(`^\s{0,3}\[[^\]]+\]:\s`)
+	mdHTMLBlock    = regexp.MustCompile(`^\s{0,3}<[A-Za-z!/]`)
+	mdTableDivider = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+)
+
+// parseMarkdown splits Markdown into segments. Each paragraph, heading,
+// list item, quoted line and table cell is a segment. Front matter, code
+// blocks and link definitions are not translated, and paragraphs are joined
+// onto a single line.
+func parseMarkdown(src string) *document {
+	d := &document{}
+	lines := strings.SplitAfter(src, "\n")
+
+	// Front matter.
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) == "---" {
+		for i := 1; i < len(lines); i++ {
+			if strings.TrimSpace(lines[i]) == "---" {
+				d.literal(strings.Join(lines[:i+1], ""))
+				lines = lines[i+1:]
+				break
+			}
+		}
+	}
+
+	var para []string
+	var paraEnd string
+	flush := func() {
+		if len(para) == 0 {
+			return
+		}
+		d.segment(part{text: strings.Join(para, " "), patterns: markdownPatterns})
+		d.literal(paraEnd)
+		para = nil
+	}
+	// line adds a segment for a single line of content.
+	line := func(prefix, rest string) {
+		d.literal(prefix)
+		body := strings.TrimRight(rest, "\r\n")
+		d.segment(part{text: body, patterns: markdownPatterns})
+		d.literal(rest[len(body):])
+	}
+
+	var fence string
+	prevBlank, inCode, inTable := true, false, false
+	for _, l := range lines {
+		if l == "" {
+			continue
+		}
+		content := strings.TrimRight(l, "\r\n")
+		blank := strings.TrimSpace(content) == ""
+
+		switch {
+		case fence != "":
+			d.literal(l)
+			if strings.HasPrefix(strings.TrimSpace(content), fence) {
+				fence = ""
+			}
+		case blank:
+			flush()
+			d.literal(l)
+			inTable = false
+		case mdFence.MatchString(content):
+			flush()
+			fence = mdFence.FindStringSubmatch(content)[1]
+			d.literal(l)
+		case len(para) == 0 && (prevBlank || inCode) && (strings.HasPrefix(l, "    ") || strings.HasPrefix(l, "\t")):
+			// Indented code block.
+			d.literal(l)
+			inCode = true
+			prevBlank = false
+			continue
+		case mdRule.MatchString(content), mdLinkDef.MatchString(content):
+			flush()
+			d.literal(l)
+		case mdHeading.MatchString(content):
+			flush()
+			prefix := mdHeading.FindString(content)
+			line(prefix, l[len(prefix):])
+		case mdQuote.MatchString(content):
+			flush()
+			prefix := mdQuote.FindString(content)
+			line(prefix, l[len(prefix):])
+		case mdListItem.MatchString(content):
+			flush()
+			prefix := mdListItem.FindString(content)
+			d.literal(prefix)
+			para = []string{strings.TrimSpace(content[len(prefix):])}
+			paraEnd = l[len(content):]
+		case mdHTMLBlock.MatchString(content):
+			flush()
+			d.segment(part{text: content, html: true})
+			d.literal(l[len(content):])
+		case strings.Contains(content, "|") && (inTable || strings.HasPrefix(strings.TrimSpace(content), "|")):
+			flush()
+			inTable = true
+			if mdTableDivider.MatchString(content) {
+				d.literal(l)
+				break
+			}
+			tableRow(d, content)
+			d.literal(l[len(content):])
+		default:
+			para = append(para, strings.TrimSpace(content))
+			paraEnd = l[len(content):]
+		}
+		prevBlank = blank
+		inCode = false
+	}
+	flush()
+	return d
+}
+
+// tableRow adds a segment for each cell of a table row.
+func tableRow(d *document, row string) {
+	start := 0
+	for i := 0; i < len(row); i++ {
+		if row[i] == '|' && (i == 0 || row[i-1] != '\\') {
+			d.segment(part{text: row[start:i], patterns: markdownPatterns})
+			d.literal("|")
+			start = i + 1
+		}
+	}
+	d.segment(part{text: row[start:], patterns: markdownPatterns})
+}
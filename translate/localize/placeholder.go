@@ -0,0 +1,132 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localize
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// placeholders match text that must be copied to the translation unchanged:
+// {name}, {{name}}, ${name}, and printf verbs such as %s, %1$d and %(name)s.
+var placeholders = []*regexp.Regexp{
+	regexp.MustCompile(`\{\{[^{}]*\}\}`),
+	regexp.MustCompile(`\$?\{[A-Za-z0-9_.]*\}`),
+	regexp.MustCompile(`%(\d+\$|\([A-Za-z_][A-Za-z0-9_]*\))?[-+ #0]*\d*(\.\d+)?[sdifgeEuxXoqcvt%]`),
+}
+
+const (
+	noTranslateOpen  = `<span translate="no">`
+	noTranslateClose = `</span>`
+)
+
+var noTranslateSpan = regexp.MustCompile(`(?s)<span translate="no">(.*?)</span>`)
+
+// htmlTag matches a tag in an HTML segment, inside which placeholders are
+// not protected.
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// protect converts a segment to the HTML sent to the API. Text matching
+// placeholders, or one of the format's own patterns, is wrapped in a
+// translate="no" span. Plain text is escaped. It returns the protected
+// strings, which restore checks are present in the translation.
+func protect(text string, isHTML bool, patterns []*regexp.Regexp) (string, []string) {
+	type span struct{ start, end int }
+	var tags []span
+	if isHTML {
+		for _, m := range htmlTag.FindAllStringIndex(text, -1) {
+			tags = append(tags, span{m[0], m[1]})
+		}
+	}
+	inTag := func(start, end int) bool {
+		for _, t := range tags {
+			if start < t.end && end > t.start && !(start <= t.start && end >= t.end) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var spans []span
+	for _, re := range append(append([]*regexp.Regexp(nil), patterns...), placeholders...) {
+		for _, m := range re.FindAllStringIndex(text, -1) {
+			if m[0] == m[1] || inTag(m[0], m[1]) {
+				continue
+			}
+			spans = append(spans, span{m[0], m[1]})
+		}
+	}
+	// Keep the leftmost, longest matches that do not overlap.
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end > spans[j].end
+	})
+
+	escape := html.EscapeString
+	if isHTML {
+		escape = func(s string) string { return s }
+	}
+	var b strings.Builder
+	var kept []string
+	pos := 0
+	for _, s := range spans {
+		if s.start < pos {
+			continue
+		}
+		b.WriteString(escape(text[pos:s.start]))
+		b.WriteString(noTranslateOpen)
+		b.WriteString(escape(text[s.start:s.end]))
+		b.WriteString(noTranslateClose)
+		kept = append(kept, text[s.start:s.end])
+		pos = s.end
+	}
+	b.WriteString(escape(text[pos:]))
+	return b.String(), kept
+}
+
+// restore converts a translation returned by the API back to the form of
+// the segment, removing the spans added by protect. It returns an error if
+// a protected string was lost or changed.
+func restore(translated string, isHTML bool, want []string) (string, error) {
+	unescape := html.UnescapeString
+	if isHTML {
+		unescape = func(s string) string { return s }
+	}
+	var b strings.Builder
+	var got []string
+	pos := 0
+	for _, m := range noTranslateSpan.FindAllStringSubmatchIndex(translated, -1) {
+		b.WriteString(unescape(translated[pos:m[0]]))
+		inner := unescape(translated[m[2]:m[3]])
+		b.WriteString(inner)
+		got = append(got, inner)
+		pos = m[1]
+	}
+	b.WriteString(unescape(translated[pos:]))
+
+	g := append([]string(nil), got...)
+	w := append([]string(nil), want...)
+	sort.Strings(g)
+	sort.Strings(w)
+	if strings.Join(g, "\x00") != strings.Join(w, "\x00") || len(g) != len(w) {
+		return "", fmt.Errorf("translation %q changed placeholders %q to %q", translated, want, got)
+	}
+	return b.String(), nil
+}
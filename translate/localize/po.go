@@ -0,0 +1,140 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localize
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// poField is a keyword of a PO entry, such as msgid, and its string.
+type poField struct {
+	keyword string
+	value   string
+	// raw holds the lines of the field.
+	raw string
+}
+
+// parsePO splits a gettext catalog into segments, one for each msgstr. The
+// msgstr of an entry, or msgstr[0] of a plural entry, is the translation of
+// its msgid; the other plural forms are translations of its msgid_plural.
+// The header entry and obsolete entries are copied as they are.
+func parsePO(src string) (*document, error) {
+	d := &document{}
+	lines := strings.SplitAfter(src, "\n")
+	for i := 0; i < len(lines); {
+		l := strings.TrimSpace(lines[i])
+		if l == "" || strings.HasPrefix(l, "#") {
+			d.literal(lines[i])
+			i++
+			continue
+		}
+
+		// Read the fields of an entry, up to the next comment or blank line.
+		var fields []poField
+		for i < len(lines) {
+			l := strings.TrimSpace(lines[i])
+			if l == "" || strings.HasPrefix(l, "#") {
+				break
+			}
+			f, n, err := readPOField(lines[i:])
+			if err != nil {
+				return nil, fmt.Errorf("localize: PO line %d: %w", i+1, err)
+			}
+			fields = append(fields, f)
+			i += n
+		}
+
+		var msgid, plural string
+		for _, f := range fields {
+			switch f.keyword {
+			case "msgid":
+				msgid = f.value
+			case "msgid_plural":
+				plural = f.value
+			}
+		}
+		for _, f := range fields {
+			if !strings.HasPrefix(f.keyword, "msgstr") || msgid == "" {
+				d.literal(f.raw)
+				continue
+			}
+			source := msgid
+			if f.keyword != "msgstr" && f.keyword != "msgstr[0]" {
+				source = plural
+			}
+			keyword, eol := f.keyword, f.raw[len(strings.TrimRight(f.raw, "\r\n")):]
+			d.segment(part{
+				text: source,
+				raw:  f.raw,
+				render: func(t string) string {
+					return keyword + " " + poQuote(t) + eol
+				},
+			})
+		}
+	}
+	return d, nil
+}
+
+// readPOField reads a field starting at lines[0] and returns it and the
+// number of lines it spans.
+func readPOField(lines []string) (poField, int, error) {
+	l := strings.TrimSpace(lines[0])
+	sp := strings.IndexByte(l, ' ')
+	if sp < 0 {
+		return poField{}, 0, fmt.Errorf("malformed line %q", l)
+	}
+	f := poField{keyword: l[:sp], raw: lines[0]}
+	s, err := strconv.Unquote(strings.TrimSpace(l[sp:]))
+	if err != nil {
+		return poField{}, 0, fmt.Errorf("malformed string in %q", l)
+	}
+	n := 1
+	for ; n < len(lines); n++ {
+		cont := strings.TrimSpace(lines[n])
+		if !strings.HasPrefix(cont, `"`) {
+			break
+		}
+		more, err := strconv.Unquote(cont)
+		if err != nil {
+			return poField{}, 0, fmt.Errorf("malformed string %q", cont)
+		}
+		s += more
+		f.raw += lines[n]
+	}
+	f.value = s
+	return f, n, nil
+}
+
+// poQuote quotes s as a PO string, split after each newline.
+func poQuote(s string) string {
+	quote := func(s string) string {
+		r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+		return `"` + r.Replace(s) + `"`
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if len(lines) == 1 || len(lines) == 2 && lines[1] == "" {
+		return quote(s)
+	}
+	var b strings.Builder
+	b.WriteString(`""`)
+	for _, l := range lines {
+		if l != "" {
+			b.WriteString("\n" + quote(l))
+		}
+	}
+	return b.String()
+}